@@ -6,35 +6,50 @@ import (
 	"log"
 	"net"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/ethan-stone/go-key-store/internal/configuration"
-	"github.com/ethan-stone/go-key-store/internal/gossip"
+	"github.com/ethan-stone/go-key-store/internal/engine"
+	"github.com/ethan-stone/go-key-store/internal/hash"
 	"github.com/ethan-stone/go-key-store/internal/http_server"
+	"github.com/ethan-stone/go-key-store/internal/raft"
 	"github.com/ethan-stone/go-key-store/internal/rpc"
 	"github.com/ethan-stone/go-key-store/internal/store"
+	"github.com/ethan-stone/go-key-store/internal/wal"
 )
 
-// 1. Read config file. This contains info about this node, and seed node to get info of other nodes.
-// 2. Initialize RpcClient with seed node.
-// 3. Gossip with seed node to get rest of the cluster config.
-// 4. Update cluster config.
-// 5. Initialize rest of rpc clients.
+// 1. Load this node's ID from the data directory, or generate one the first time.
+// 2. Initialize the rpc client manager and this node's cluster config.
+// 3. Open the storage engine and recover it from the latest snapshot or checkpoint and the WAL.
+// 4. Join the cluster config raft group, which applies the last config this node knows was committed.
+// 5. Start HTTP server for client requests.
 // 6. Start gRPC server for inter-node communications.
-// 7. Start HTTP server for client requests.
 func main() {
 	log.Default().SetFlags(log.Ldate | log.Ltime | log.Lmsgprefix)
 
-	nodeID := configuration.GenerateNodeID()
-
-	log.SetPrefix(nodeID + " ")
-
 	var (
-		httpPort string
-		grpcPort string
+		httpPort         string
+		grpcPort         string
+		dataDir          string
+		storageEngine    string
+		snapshotInterval time.Duration
+		walSegmentSize   int64
+		walDurability    string
+		walBatchDelay    time.Duration
+		hashFunctionName string
 	)
 
 	flag.StringVar(&httpPort, "http-port", "8080", "")
 	flag.StringVar(&grpcPort, "grpc-port", "8081", "")
+	flag.StringVar(&dataDir, "data-dir", "", "Directory the node ID, WAL, snapshots and raft state are kept in. Defaults to data/<grpc-port>.")
+	flag.StringVar(&storageEngine, "storage-engine", engine.Memory, "Where this node keeps its data. One of "+strings.Join(engine.Names, ", ")+".")
+	flag.Int64Var(&walSegmentSize, "wal-segment-size", wal.DefaultMaxSegmentSize, "Size in bytes a WAL segment can grow to before a new one is started.")
+	flag.StringVar(&walDurability, "wal-durability", "always", "When a write is durable. One of always (fsync every write), batched (group commit) or none (leave it to the OS).")
+	flag.DurationVar(&walBatchDelay, "wal-batch-delay", wal.DefaultMaxBatchDelay, "Longest a batch waits for more writes to share its fsync with the batched durability policy. 0 only groups writes that arrive during the previous fsync.")
+	flag.StringVar(&hashFunctionName, "hash-function", string(hash.DefaultFunction), "Function keys are hashed into slots with. Every node in a cluster has to use the same one. One of crc16, xxhash or crc32. Clusters created before this could be picked use crc32.")
+	flag.DurationVar(&snapshotInterval, "snapshot-interval", time.Minute*5, "How often to snapshot or checkpoint the store and delete old WAL files.")
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: %s [options]\n", os.Args[0])
@@ -44,6 +59,30 @@ func main() {
 
 	flag.Parse()
 
+	hashFunction, err := hash.ParseFunction(hashFunctionName)
+
+	if err != nil {
+		log.Fatalf("invalid --hash-function %v", err)
+	}
+
+	if dataDir == "" {
+		dataDir = filepath.Join("data", grpcPort)
+	}
+
+	err = os.MkdirAll(dataDir, 0755)
+
+	if err != nil {
+		log.Fatalf("failed to create data directory %v", err)
+	}
+
+	nodeID, err := configuration.LoadNodeID(dataDir)
+
+	if err != nil {
+		log.Fatalf("failed to load node ID %v", err)
+	}
+
+	log.SetPrefix(nodeID + " ")
+
 	thisNodeConfig := &configuration.NodeConfig{
 		ID:        nodeID,
 		Address:   "localhost:" + grpcPort,
@@ -57,32 +96,50 @@ func main() {
 	otherNodes := []*configuration.NodeConfig{}
 
 	clusterConfig = &configuration.ClusterConfig{
-		ThisNode:   thisNodeConfig,
-		OtherNodes: otherNodes,
+		ThisNode:     thisNodeConfig,
+		OtherNodes:   otherNodes,
+		HashFunction: hashFunction,
 	}
 
 	configurationManager := configuration.NewBaseConfigurationManager(clusterConfig)
 
-	// initialize rpc clients
-	for i := range clusterConfig.OtherNodes {
-		// skip over current node
-		if clusterConfig.OtherNodes[i].Address == clusterConfig.ThisNode.Address {
-			continue
-		}
+	durability, err := wal.ParseDurabilityPolicy(walDurability)
 
-		grpcClientManager.GetOrCreateRpcClient(&rpc.RpcClientConfig{
-			Address: clusterConfig.OtherNodes[i].Address,
-		})
+	if err != nil {
+		log.Fatalf("invalid --wal-durability %v", err)
 	}
 
-	gossiper := gossip.NewGossipClient(&gossip.GossipClientConfig{
-		RpcClientManager: grpcClientManager,
+	localStore, err := store.InitializeDurableLocalKeyValueStore(&store.DurableLocalKeyValueStoreConfig{
+		DataDir:           dataDir,
+		Engine:            storageEngine,
+		MaxWalSegmentSize: walSegmentSize,
+		Wal: &wal.WalWriterConfig{
+			Durability:    durability,
+			MaxBatchDelay: walBatchDelay,
+		},
+	})
+
+	if err != nil {
+		log.Fatalf("failed to recover store from WAL %v", err)
+	}
+
+	localStore.StartSnapshotting(snapshotInterval)
+	localStore.StartExpiring(store.DefaultExpiryInterval)
+	configGroup, err := raft.OpenConfigGroup(&raft.ConfigGroupConfig{
+		DataDir:          dataDir,
 		ConfigManager:    configurationManager,
+		RpcClientManager: grpcClientManager,
 	})
 
-	gossiper.Gossip()
+	if err != nil {
+		log.Fatalf("failed to open the cluster config raft group %v", err)
+	}
+
+	raftHost := raft.NewHost(configGroup)
 
-	localStore := store.InitializeLocalKeyValueStore()
+	localStore.StartReplicating(configurationManager, grpcClientManager)
+	localStore.StartFailover(configurationManager, grpcClientManager, configGroup)
+	localStore.StartRaftShards(configurationManager, grpcClientManager, configGroup, raftHost)
 
 	httpServer := http_server.NewHttpServer(
 		&http_server.HttpServerConfig{
@@ -108,7 +165,7 @@ func main() {
 
 	log.Printf("GRPC server runnnig on port %s", grpcPort)
 
-	grpcServer := rpc.NewRpcServer(localStore, configurationManager, grpcClientManager)
+	grpcServer := rpc.NewRpcServer(localStore, configurationManager, grpcClientManager, raftHost)
 
 	if err := grpcServer.Serve(list); err != nil {
 		log.Fatalf("failed to start grpc server %v", err)
@@ -3,6 +3,7 @@ package add_node
 import (
 	"fmt"
 
+	"github.com/ethan-stone/go-key-store/internal/hash"
 	"github.com/ethan-stone/go-key-store/internal/rpc"
 	"github.com/spf13/cobra"
 )
@@ -21,69 +22,86 @@ var AddNodeCommand = &cobra.Command{
 			return err
 		}
 
-		clusterNodeClusterConfig, err := clusterNodeClient.GetClusterConfig(&rpc.GetClusterConfigRequest{})
+		newNodeClient, err := rpcClientManager.GetOrCreateRpcClient(&rpc.RpcClientConfig{
+			Address: newNodeAddress,
+		})
 
 		if err != nil {
 			return err
 		}
 
-		clusterContainsNewNode := false
+		return addNode(clusterNodeClient, newNodeClient)
+	},
+}
 
-		for _, node := range clusterNodeClusterConfig.OtherNodes {
-			if node.Address == newNodeAddress {
-				clusterContainsNewNode = true
-				break
-			}
-		}
+// addNode commits a config with every node already in the cluster and the new
+// node, which doesn't have any hash slots yet.
+func addNode(clusterNodeClient rpc.RpcClient, newNodeClient rpc.RpcClient) error {
+	clusterNodeClusterConfig, err := clusterNodeClient.GetClusterConfig(&rpc.GetClusterConfigRequest{})
 
-		if clusterContainsNewNode {
-			return fmt.Errorf("new node %s is already a part of the cluster", newNodeAddress)
-		}
+	if err != nil {
+		return err
+	}
 
-		newNodeClient, err := rpcClientManager.GetOrCreateRpcClient(&rpc.RpcClientConfig{
-			Address: newNodeAddress,
-		})
+	newNodeAddress := newNodeClient.GetAddress()
+	clusterContainsNewNode := false
 
-		if err != nil {
-			return err
+	for _, node := range clusterNodeClusterConfig.OtherNodes {
+		if node.Address == newNodeAddress {
+			clusterContainsNewNode = true
+			break
 		}
+	}
 
-		newNodeClusterConfig, err := newNodeClient.GetClusterConfig(&rpc.GetClusterConfigRequest{})
+	if clusterContainsNewNode {
+		return fmt.Errorf("new node %s is already a part of the cluster", newNodeAddress)
+	}
 
-		if err != nil {
-			return err
-		}
+	newNodeClusterConfig, err := newNodeClient.GetClusterConfig(&rpc.GetClusterConfigRequest{})
 
-		if len(newNodeClusterConfig.OtherNodes) > 0 {
-			return fmt.Errorf("new node %s is already a part of a cluster", newNodeAddress)
-		}
+	if err != nil {
+		return err
+	}
 
-		allNodes := []*rpc.NodeConfig{}
+	if len(newNodeClusterConfig.OtherNodes) > 0 {
+		return fmt.Errorf("new node %s is already a part of a cluster", newNodeAddress)
+	}
 
-		allNodes = append(allNodes, clusterNodeClusterConfig.ThisNode)
+	hashFunction := rpc.RemoteHashFunction(clusterNodeClusterConfig.GetHashFunction())
+	newNodeHashFunction := rpc.RemoteHashFunction(newNodeClusterConfig.GetHashFunction())
 
-		allNodes = append(allNodes, clusterNodeClusterConfig.OtherNodes...)
+	if newNodeHashFunction != hashFunction {
+		return fmt.Errorf("new node %s uses the %s hash function but the cluster uses %s", newNodeAddress, newNodeHashFunction, hashFunction)
+	}
 
-		for _, node := range allNodes {
-			nodeClient, err := rpcClientManager.GetOrCreateRpcClient(&rpc.RpcClientConfig{
-				Address: node.Address,
-			})
+	allNodes := []*rpc.NodeConfig{}
 
-			if err != nil {
-				return err
-			}
+	allNodes = append(allNodes, clusterNodeClusterConfig.ThisNode)
 
-			nodeClient.SetClusterConfig(&rpc.SetClusterConfigRequest{
-				ThisNode: &rpc.SetNodeConfigOptions{
-					HashSlotsStart: node.HashSlotsStart,
-					HashSlotsEnd:   node.HashSlotsEnd,
-				},
-				OtherNodes: allNodes,
-			})
-		}
+	allNodes = append(allNodes, clusterNodeClusterConfig.OtherNodes...)
 
-		return nil
-	},
+	// the range starts after it ends, so no hash slots are routed to the new
+	// node until the cluster is resharded.
+	allNodes = append(allNodes, &rpc.NodeConfig{
+		NodeId:         newNodeClusterConfig.ThisNode.GetNodeId(),
+		Address:        newNodeClusterConfig.ThisNode.GetAddress(),
+		HashSlotsStart: hash.NumHashSlots,
+		HashSlotsEnd:   hash.NumHashSlots - 1,
+	})
+
+	// the cluster config raft group commits the config to every node,
+	// including the new one, unless it has changed since it was read.
+	_, err = clusterNodeClient.SetClusterConfig(&rpc.SetClusterConfigRequest{
+		OtherNodes:   allNodes,
+		HashFunction: string(hashFunction),
+		Epoch:        clusterNodeClusterConfig.GetEpoch(),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return nil
 }
 
 var newNodeAddress string     // address of the node to add to the cluster
@@ -0,0 +1,92 @@
+package add_node
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ethan-stone/go-key-store/internal/hash"
+	"github.com/ethan-stone/go-key-store/internal/rpc"
+)
+
+// fakeNodeClient commits the config it is sent straight away, the way the
+// cluster config raft group would if nothing changed since it was read.
+type fakeNodeClient struct {
+	rpc.RpcClient
+	config *rpc.GetClusterConfigResponse
+}
+
+func (client *fakeNodeClient) GetAddress() string {
+	return client.config.ThisNode.Address
+}
+
+func (client *fakeNodeClient) GetClusterConfig(req *rpc.GetClusterConfigRequest) (*rpc.GetClusterConfigResponse, error) {
+	return client.config, nil
+}
+
+func (client *fakeNodeClient) SetClusterConfig(req *rpc.SetClusterConfigRequest) (*rpc.SetClusterConfigResponse, error) {
+	if req.GetEpoch() != client.config.Epoch {
+		return nil, fmt.Errorf("the cluster config changed since epoch %d", req.GetEpoch())
+	}
+
+	config := &rpc.GetClusterConfigResponse{Ok: true, HashFunction: req.GetHashFunction(), Epoch: client.config.Epoch + 1}
+
+	for _, node := range req.GetOtherNodes() {
+		if node.NodeId == client.config.ThisNode.NodeId {
+			config.ThisNode = node
+		} else {
+			config.OtherNodes = append(config.OtherNodes, node)
+		}
+	}
+
+	client.config = config
+
+	return &rpc.SetClusterConfigResponse{Ok: true}, nil
+}
+
+func TestAddedNodeShouldBeInTheCommittedConfig(t *testing.T) {
+	clusterNode := &fakeNodeClient{config: &rpc.GetClusterConfigResponse{
+		ThisNode:     &rpc.NodeConfig{NodeId: "n0", Address: "localhost:9200", HashSlotsStart: 0, HashSlotsEnd: 8191},
+		OtherNodes:   []*rpc.NodeConfig{{NodeId: "n1", Address: "localhost:9201", HashSlotsStart: 8192, HashSlotsEnd: 16383}},
+		HashFunction: string(hash.CRC16),
+		Epoch:        4,
+	}}
+
+	newNode := &fakeNodeClient{config: &rpc.GetClusterConfigResponse{
+		ThisNode:     &rpc.NodeConfig{NodeId: "n2", Address: "localhost:9202", HashSlotsStart: 0, HashSlotsEnd: 16383},
+		HashFunction: string(hash.CRC16),
+	}}
+
+	err := addNode(clusterNode, newNode)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when adding a node %v", err)
+	}
+
+	committed := clusterNode.config
+
+	if committed.Epoch != 5 || len(committed.OtherNodes) != 2 {
+		t.Fatalf("Expected the config to be committed with both other nodes, got %v", committed)
+	}
+
+	added := committed.OtherNodes[1]
+
+	if added.NodeId != "n2" || added.Address != "localhost:9202" {
+		t.Fatalf("Expected the new node to be in the committed config, got %v", added)
+	}
+
+	// an empty range, so the slots the new node started with aren't taken
+	// from the nodes that own them.
+	if added.HashSlotsStart <= added.HashSlotsEnd {
+		t.Errorf("Did not expect the new node to have hash slots, got %d to %d", added.HashSlotsStart, added.HashSlotsEnd)
+	}
+
+	if committed.ThisNode.HashSlotsEnd != 8191 || committed.OtherNodes[0].HashSlotsStart != 8192 {
+		t.Errorf("Expected the other nodes to keep their hash slots, got %v", committed)
+	}
+
+	err = addNode(clusterNode, newNode)
+
+	if err == nil {
+		t.Errorf("Expected a node that is already in the committed config not to be added again")
+	}
+}
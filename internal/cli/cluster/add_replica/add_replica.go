@@ -0,0 +1,118 @@
+package add_replica
+
+import (
+	"fmt"
+
+	"github.com/ethan-stone/go-key-store/internal/configuration"
+	"github.com/ethan-stone/go-key-store/internal/rpc"
+	"github.com/spf13/cobra"
+)
+
+var AddReplicaCommand = &cobra.Command{
+	Use:   "add_replica",
+	Short: "Add a node to a cluster as a replica of a primary. The replica gets a copy of the primary's data and keeps following its writes.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rpcClientManager := rpc.NewGrpcClientManager(rpc.NewRpcClient)
+
+		primaryClient, err := rpcClientManager.GetOrCreateRpcClient(&rpc.RpcClientConfig{
+			Address: primaryAddress,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		primaryClusterConfig, err := primaryClient.GetClusterConfig(&rpc.GetClusterConfigRequest{})
+
+		if err != nil {
+			return err
+		}
+
+		// with quorum replication every node already keeps copies of other
+		// nodes' keys, so there is nothing for a replica to do.
+		if configuration.ReplicationMode(primaryClusterConfig.GetReplicationMode()) == configuration.QuorumReplication {
+			return fmt.Errorf("the cluster uses quorum replication, which doesn't have replicas")
+		}
+
+		primary := primaryClusterConfig.ThisNode
+
+		if primary.ReplicaOf != "" {
+			return fmt.Errorf("node %s is itself a replica of %s", primaryAddress, primary.ReplicaOf)
+		}
+
+		for _, node := range primaryClusterConfig.OtherNodes {
+			if node.Address == replicaAddress {
+				return fmt.Errorf("replica %s is already a part of the cluster", replicaAddress)
+			}
+		}
+
+		replicaClient, err := rpcClientManager.GetOrCreateRpcClient(&rpc.RpcClientConfig{
+			Address: replicaAddress,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		replicaClusterConfig, err := replicaClient.GetClusterConfig(&rpc.GetClusterConfigRequest{})
+
+		if err != nil {
+			return err
+		}
+
+		if len(replicaClusterConfig.OtherNodes) > 0 {
+			return fmt.Errorf("replica %s is already a part of a cluster", replicaAddress)
+		}
+
+		hashFunction := rpc.RemoteHashFunction(primaryClusterConfig.GetHashFunction())
+		replicaHashFunction := rpc.RemoteHashFunction(replicaClusterConfig.GetHashFunction())
+
+		if replicaHashFunction != hashFunction {
+			return fmt.Errorf("replica %s uses the %s hash function but the cluster uses %s", replicaAddress, replicaHashFunction, hashFunction)
+		}
+
+		// the replica has its primary's hash slots, but keys are only routed
+		// to the primary.
+		replica := &rpc.NodeConfig{
+			NodeId:         replicaClusterConfig.ThisNode.NodeId,
+			Address:        replicaClusterConfig.ThisNode.Address,
+			HashSlotsStart: primary.HashSlotsStart,
+			HashSlotsEnd:   primary.HashSlotsEnd,
+			ReplicaOf:      primary.NodeId,
+		}
+
+		allNodes := []*rpc.NodeConfig{}
+
+		allNodes = append(allNodes, primary)
+
+		allNodes = append(allNodes, primaryClusterConfig.OtherNodes...)
+
+		allNodes = append(allNodes, replica)
+
+		// the cluster config raft group commits the config to every node,
+		// the replica included.
+		_, err = primaryClient.SetClusterConfig(&rpc.SetClusterConfigRequest{
+			OtherNodes:   allNodes,
+			HashFunction: string(hashFunction),
+			Epoch:        primaryClusterConfig.GetEpoch(),
+		})
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s is now a replica of %s (slots %d to %d)\n", replicaAddress, primaryAddress, primary.HashSlotsStart, primary.HashSlotsEnd)
+
+		return nil
+	},
+}
+
+var replicaAddress string // address of the node to add as a replica
+var primaryAddress string // address of the primary it should replicate
+
+func init() {
+	AddReplicaCommand.Flags().StringVar(&replicaAddress, "replica-address", "", "Address of the node to add as a replica")
+	AddReplicaCommand.Flags().StringVar(&primaryAddress, "primary-address", "", "Address of the primary to replicate")
+	AddReplicaCommand.MarkFlagRequired("replica-address")
+	AddReplicaCommand.MarkFlagRequired("primary-address")
+}
@@ -1,6 +1,8 @@
 package cluster
 
 import (
+	add_node "github.com/ethan-stone/go-key-store/internal/cli/cluster/add_node"
+	add_replica "github.com/ethan-stone/go-key-store/internal/cli/cluster/add_replica"
 	create_cluster "github.com/ethan-stone/go-key-store/internal/cli/cluster/create"
 	verify_cluster "github.com/ethan-stone/go-key-store/internal/cli/cluster/verify"
 	"github.com/spf13/cobra"
@@ -14,4 +16,6 @@ var ClusterCommand = &cobra.Command{
 func init() {
 	ClusterCommand.AddCommand(create_cluster.CreateClusterCommand)
 	ClusterCommand.AddCommand(verify_cluster.VerifyClusterCommand)
+	ClusterCommand.AddCommand(add_replica.AddReplicaCommand)
+	ClusterCommand.AddCommand(add_node.AddNodeCommand)
 }
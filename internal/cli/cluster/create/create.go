@@ -6,6 +6,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/ethan-stone/go-key-store/internal/configuration"
 	"github.com/ethan-stone/go-key-store/internal/hash"
 	"github.com/ethan-stone/go-key-store/internal/rpc"
 	"github.com/spf13/cobra"
@@ -15,6 +16,22 @@ var CreateClusterCommand = &cobra.Command{
 	Use:   "create",
 	Short: "Configure a set of nodes to be in a cluster.",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		mode, err := configuration.ParseReplicationMode(replicationMode)
+
+		if err != nil {
+			return err
+		}
+
+		quorum := configuration.QuorumConfig{N: quorumN, R: quorumR, W: quorumW}
+
+		if mode == configuration.QuorumReplication {
+			err = quorum.Validate()
+
+			if err != nil {
+				return err
+			}
+		}
+
 		rpcClientManager := rpc.NewGrpcClientManager(rpc.NewRpcClient)
 
 		hashSlotRanges := hash.CalculateHashSlotRanges(len(nodeAddresses), 16384)
@@ -34,6 +51,7 @@ var CreateClusterCommand = &cobra.Command{
 		}
 
 		nodes := []*rpc.NodeConfig{}
+		hashFunction := hash.Function("")
 
 		for i := range nodeAddresses {
 			address := nodeAddresses[i]
@@ -56,6 +74,16 @@ var CreateClusterCommand = &cobra.Command{
 				return fmt.Errorf("node %s is already a part of a cluster", address)
 			}
 
+			// every node has to hash keys the same way, or they'd disagree on
+			// which node a key lives on.
+			nodeHashFunction := rpc.RemoteHashFunction(getClusterConfigResponse.GetHashFunction())
+
+			if hashFunction != "" && nodeHashFunction != hashFunction {
+				return fmt.Errorf("node %s uses the %s hash function but the other nodes use %s", address, nodeHashFunction, hashFunction)
+			}
+
+			hashFunction = nodeHashFunction
+
 			hashSlotRange := hashSlotRanges[i+1]
 
 			// when creating a cluster it is assumed all the nodes are independently running
@@ -67,6 +95,17 @@ var CreateClusterCommand = &cobra.Command{
 			})
 		}
 
+		fmt.Printf("Keys will be hashed with %s\n", hashFunction)
+		fmt.Printf("Replicas will be kept up to date with %s replication\n", mode)
+
+		if mode == configuration.QuorumReplication {
+			fmt.Printf("Each key will be kept on %d nodes, reads wait for %d and writes wait for %d\n", min(quorum.N, len(nodes)), quorum.R, quorum.W)
+
+			if quorum.R+quorum.W <= quorum.N {
+				fmt.Println("Reads may not see the latest write, since r + w is not more than n")
+			}
+		}
+
 		for i := range nodes {
 			node := nodes[i]
 
@@ -80,24 +119,31 @@ var CreateClusterCommand = &cobra.Command{
 			return nil
 		}
 
-		for i := range nodes {
-			node := nodes[i]
+		// the first node starts the cluster config raft group, adds the rest
+		// to it and commits the config to them.
+		client, err := rpcClientManager.GetOrCreateRpcClient(&rpc.RpcClientConfig{
+			Address: nodes[0].Address,
+		})
 
-			client, err := rpcClientManager.GetOrCreateRpcClient(&rpc.RpcClientConfig{
-				Address: node.Address,
-			})
+		if err != nil {
+			return err
+		}
 
-			if err != nil {
-				return err
-			}
+		req := &rpc.SetClusterConfigRequest{
+			OtherNodes:      nodes,
+			HashFunction:    string(hashFunction),
+			ReplicationMode: string(mode),
+			Bootstrap:       true,
+		}
 
-			client.SetClusterConfig(&rpc.SetClusterConfigRequest{
-				ThisNode: &rpc.SetNodeConfigOptions{
-					HashSlotsStart: node.HashSlotsStart,
-					HashSlotsEnd:   node.HashSlotsEnd,
-				},
-				OtherNodes: nodes,
-			})
+		if mode == configuration.QuorumReplication {
+			req.Quorum = &rpc.QuorumConfig{N: uint32(quorum.N), R: uint32(quorum.R), W: uint32(quorum.W)}
+		}
+
+		_, err = client.SetClusterConfig(req)
+
+		if err != nil {
+			return err
 		}
 
 		return nil
@@ -105,10 +151,16 @@ var CreateClusterCommand = &cobra.Command{
 }
 
 var nodeAddresses []string
+var replicationMode string
+var quorumN, quorumR, quorumW int
 
 func init() {
 	CreateClusterCommand.Flags().StringSliceVar(&nodeAddresses, "addresses", []string{}, "A list of node addresses, separated by commas (e.g., --addresses=localhost:8080,localhost:8081)")
 	CreateClusterCommand.MarkFlagRequired("addresses")
+	CreateClusterCommand.Flags().StringVar(&replicationMode, "replication-mode", string(configuration.SingleOwner), fmt.Sprintf("How primaries keep their replicas up to date, one of %v", configuration.ReplicationModes))
+	CreateClusterCommand.Flags().IntVar(&quorumN, "quorum-n", configuration.DefaultQuorumConfig.N, "How many nodes keep a copy of each key with quorum replication")
+	CreateClusterCommand.Flags().IntVar(&quorumR, "quorum-r", configuration.DefaultQuorumConfig.R, "How many copies a read waits for with quorum replication")
+	CreateClusterCommand.Flags().IntVar(&quorumW, "quorum-w", configuration.DefaultQuorumConfig.W, "How many copies a write waits for with quorum replication")
 }
 
 func confirm(s string) bool {
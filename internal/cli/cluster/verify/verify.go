@@ -3,6 +3,7 @@ package verify_cluster
 import (
 	"fmt"
 
+	"github.com/ethan-stone/go-key-store/internal/configuration"
 	"github.com/ethan-stone/go-key-store/internal/hash"
 	"github.com/ethan-stone/go-key-store/internal/rpc"
 	"github.com/spf13/cobra"
@@ -28,9 +29,17 @@ var VerifyClusterCommand = &cobra.Command{
 			return err
 		}
 
-		totalHashSlotsCovered := clusterConfig.ThisNode.HashSlotsEnd - clusterConfig.ThisNode.HashSlotsStart + 1 // +1 because the range is inclusive
+		allNodes := append([]*rpc.NodeConfig{clusterConfig.ThisNode}, clusterConfig.OtherNodes...)
+		nodes := []*rpc.NodeConfig{} // the primaries, which are the nodes keys are routed to
+		totalHashSlotsCovered := uint32(0)
 
-		for _, node := range clusterConfig.OtherNodes {
+		for _, node := range allNodes {
+			// a replica has its primary's hash slots, so it would count them twice.
+			if node.ReplicaOf != "" {
+				continue
+			}
+
+			nodes = append(nodes, node)
 			totalHashSlotsCovered += (node.HashSlotsEnd - node.HashSlotsStart + 1) // +1 because the range is inclusive
 		}
 
@@ -38,15 +47,54 @@ var VerifyClusterCommand = &cobra.Command{
 			return fmt.Errorf("total hash slots covered (%d) does not match expected (%d)", totalHashSlotsCovered, hash.NumHashSlots)
 		}
 
-		fmt.Println("Cluster is valid")
+		fmt.Printf("Cluster is valid at epoch %d, keys are hashed with %s\n", clusterConfig.GetEpoch(), rpc.RemoteHashFunction(clusterConfig.GetHashFunction()))
+		fmt.Printf("Replicas are kept up to date with %s replication\n", configuration.ReplicationMode(clusterConfig.GetReplicationMode()).Resolved())
+
+		if clusterConfig.GetQuorum() != nil {
+			quorum := clusterConfig.GetQuorum()
+			fmt.Printf("Each key is kept on %d nodes, reads wait for %d and writes wait for %d\n", quorum.GetN(), quorum.GetR(), quorum.GetW())
+		}
+
+		for _, node := range allNodes {
+			if node.ReplicaOf != "" {
+				fmt.Printf("%s is a replica of %s\n", node.Address, node.ReplicaOf)
+			}
+		}
+
+		hashFunction := rpc.RemoteHashFunction(clusterConfig.GetHashFunction())
+
+		for _, key := range keys {
+			err := printKeyOwner(key, hashFunction, nodes)
+
+			if err != nil {
+				return err
+			}
+		}
 
 		return nil
 	},
 }
 
+// printKeyOwner shows which slot and node a key is routed to, using the same
+// hash function and hash tag rule as the nodes themselves.
+func printKeyOwner(key string, hashFunction hash.Function, nodes []*rpc.NodeConfig) error {
+	hashSlot := hashFunction.HashSlot(key)
+
+	for _, node := range nodes {
+		if hashSlot >= node.HashSlotsStart && hashSlot <= node.HashSlotsEnd {
+			fmt.Printf("%s is in hash slot %d (hash tag %q) on %s\n", key, hashSlot, hash.HashTag(key), node.Address)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s is in hash slot %d, which no node covers", key, hashSlot)
+}
+
 var nodeAddress string
+var keys []string
 
 func init() {
 	VerifyClusterCommand.Flags().StringVar(&nodeAddress, "address", "", "The address of any node in the cluster (e.g., --address=localhost:8081)")
+	VerifyClusterCommand.Flags().StringSliceVar(&keys, "keys", nil, "Keys to show the hash slot and node of (e.g., --keys={user:42}:profile,{user:42}:settings)")
 	VerifyClusterCommand.MarkFlagRequired("address")
 }
@@ -4,6 +4,7 @@ import (
 	"log"
 
 	"github.com/ethan-stone/go-key-store/internal/cli/cluster"
+	"github.com/ethan-stone/go-key-store/internal/cli/wal"
 	"github.com/spf13/cobra"
 )
 
@@ -28,4 +29,5 @@ func Execute() error {
 func init() {
 	rootCmd.AddCommand(versionCommand)
 	rootCmd.AddCommand(cluster.ClusterCommand)
+	rootCmd.AddCommand(wal.WalCommand)
 }
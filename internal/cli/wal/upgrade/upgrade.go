@@ -0,0 +1,54 @@
+package upgrade_wal
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ethan-stone/go-key-store/internal/wal"
+	"github.com/spf13/cobra"
+)
+
+var UpgradeWalCommand = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Rewrite a v1 WAL file or WAL directory in the current format.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		info, err := os.Stat(walPath)
+
+		if err != nil {
+			return err
+		}
+
+		// opening a WAL directory upgrades every v1 segment in it in order, so
+		// the LSNs carry on from one segment to the next.
+		if info.IsDir() {
+			segmentedWal, err := wal.OpenSegmentedWal(&wal.SegmentedWalConfig{Dir: walPath})
+
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Upgraded %d segments in %s to WAL format v%d\n", len(segmentedWal.Segments()), walPath, wal.CurrentFormat)
+
+			return segmentedWal.Close()
+		}
+
+		nextLSN, err := wal.Upgrade(walPath, firstLSN)
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Upgraded %s to WAL format v%d, the next LSN is %d\n", walPath, wal.CurrentFormat, nextLSN)
+
+		return nil
+	},
+}
+
+var walPath string
+var firstLSN uint64
+
+func init() {
+	UpgradeWalCommand.Flags().StringVar(&walPath, "path", "", "A WAL file, or a WAL directory to upgrade every segment in (e.g., --path=data/8081/wal)")
+	UpgradeWalCommand.Flags().Uint64Var(&firstLSN, "first-lsn", 1, "LSN to give the first entry when --path is a single file")
+	UpgradeWalCommand.MarkFlagRequired("path")
+}
@@ -0,0 +1,88 @@
+package verify_wal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ethan-stone/go-key-store/internal/wal"
+	"github.com/spf13/cobra"
+)
+
+var VerifyWalCommand = &cobra.Command{
+	Use:   "verify",
+	Short: "Scan a WAL file or WAL directory and report every corrupt range.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		info, err := os.Stat(walPath)
+
+		if err != nil {
+			return err
+		}
+
+		fileNames := []string{walPath}
+
+		if info.IsDir() {
+			// segment names are zero padded, so sorting by name sorts by segment.
+			fileNames, err = filepath.Glob(filepath.Join(walPath, "*.wal"))
+
+			if err != nil {
+				return err
+			}
+
+			if salvageTo != "" {
+				err = os.MkdirAll(salvageTo, 0755)
+
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		corruptFiles := 0
+
+		for _, fileName := range fileNames {
+			salvagePath := salvageTo
+
+			if info.IsDir() && salvageTo != "" {
+				salvagePath = filepath.Join(salvageTo, filepath.Base(fileName))
+			}
+
+			report, err := wal.Verify(fileName, salvagePath)
+
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("%s: %d bytes, %d valid entries, %d corrupt ranges\n", fileName, report.Size, report.ValidEntries, len(report.CorruptRanges))
+
+			for _, corruptRange := range report.CorruptRanges {
+				fmt.Printf("  bytes %d to %d: %v\n", corruptRange.Start, corruptRange.End, corruptRange.Err)
+			}
+
+			if len(report.CorruptRanges) > 0 {
+				corruptFiles++
+			}
+
+			if salvagePath != "" {
+				fmt.Printf("  salvaged %d entries to %s\n", report.ValidEntries, salvagePath)
+			}
+		}
+
+		if corruptFiles > 0 {
+			return fmt.Errorf("%d of %d WAL files are corrupt", corruptFiles, len(fileNames))
+		}
+
+		fmt.Println("WAL is valid")
+
+		return nil
+	},
+}
+
+var walPath string
+var salvageTo string
+
+func init() {
+	VerifyWalCommand.Flags().StringVar(&walPath, "path", "", "A WAL file, or a WAL directory to verify every segment in (e.g., --path=data/8081/wal)")
+	VerifyWalCommand.Flags().StringVar(&salvageTo, "salvage-to", "", "Copy every valid entry into a new file, or a new directory when --path is a directory")
+	VerifyWalCommand.MarkFlagRequired("path")
+}
@@ -0,0 +1,17 @@
+package wal
+
+import (
+	upgrade_wal "github.com/ethan-stone/go-key-store/internal/cli/wal/upgrade"
+	verify_wal "github.com/ethan-stone/go-key-store/internal/cli/wal/verify"
+	"github.com/spf13/cobra"
+)
+
+var WalCommand = &cobra.Command{
+	Use:   "wal",
+	Short: "Inspect and repair WAL files.",
+}
+
+func init() {
+	WalCommand.AddCommand(verify_wal.VerifyWalCommand)
+	WalCommand.AddCommand(upgrade_wal.UpgradeWalCommand)
+}
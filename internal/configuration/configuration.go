@@ -2,10 +2,16 @@ package configuration
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
+	"github.com/ethan-stone/go-key-store/internal/hash"
 	"github.com/google/uuid"
 )
 
@@ -17,20 +23,235 @@ type NodeBootstrapConfig struct {
 }
 
 type ClusterConfig struct {
-	ThisNode   *NodeConfig
-	OtherNodes []*NodeConfig
+	ThisNode     *NodeConfig
+	OtherNodes   []*NodeConfig
+	HashFunction hash.Function // every node in the cluster has to use the same one
+	// ReplicationMode is how every primary keeps its replicas up to date.
+	ReplicationMode ReplicationMode
+	// Quorum is only used with QuorumReplication.
+	Quorum QuorumConfig
+	// Epoch is the index of the config in the cluster config raft log, so it
+	// goes up with every change and nodes can tell which of two configs is
+	// newer. It is 0 until the node joins a cluster.
+	Epoch uint64
+}
+
+// ReplicationMode is chosen for the whole cluster when it is created.
+type ReplicationMode string
+
+const (
+	// SingleOwner has the primary take every write and stream its WAL to its
+	// replicas. A write is acknowledged once it is on the primary, and a
+	// replica takes over if the primary fails.
+	SingleOwner ReplicationMode = "single"
+	// RaftReplication makes a primary and its replicas a raft group, whose
+	// leader is the primary. A write is only acknowledged once a majority of
+	// the group have it, and reads only see acknowledged writes.
+	RaftReplication ReplicationMode = "raft"
+	// QuorumReplication has no primaries or replicas. Every key is kept on
+	// the nodes on its preference list, and any node can read or write it by
+	// asking enough of them. The newest write to a key wins.
+	QuorumReplication ReplicationMode = "quorum"
+)
+
+var ReplicationModes = []ReplicationMode{SingleOwner, RaftReplication, QuorumReplication}
+
+// ParseReplicationMode returns the mode with the name, or the default for an
+// empty name.
+func ParseReplicationMode(name string) (ReplicationMode, error) {
+	if name == "" {
+		return SingleOwner, nil
+	}
+
+	for _, mode := range ReplicationModes {
+		if string(mode) == name {
+			return mode, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown replication mode %q, expected one of %v", name, ReplicationModes)
+}
+
+// Resolved is the mode itself, or the default for the zero value.
+func (mode ReplicationMode) Resolved() ReplicationMode {
+	if mode == "" {
+		return SingleOwner
+	}
+
+	return mode
+}
+
+// QuorumConfig is how many nodes keep a copy of each key with quorum
+// replication, and how many of them a read or write waits for. Reads see the
+// latest acknowledged write as long as R + W > N.
+type QuorumConfig struct {
+	N int `json:"n"`
+	R int `json:"r"`
+	W int `json:"w"`
+}
+
+var DefaultQuorumConfig = QuorumConfig{N: 3, R: 2, W: 2}
+
+// Resolved is the config itself, or the default for the zero value.
+func (quorum QuorumConfig) Resolved() QuorumConfig {
+	if quorum == (QuorumConfig{}) {
+		return DefaultQuorumConfig
+	}
+
+	return quorum
+}
+
+func (quorum QuorumConfig) Validate() error {
+	if quorum.N < 1 || quorum.R < 1 || quorum.W < 1 || quorum.R > quorum.N || quorum.W > quorum.N {
+		return fmt.Errorf("invalid quorum n=%d r=%d w=%d, r and w have to be from 1 to n", quorum.N, quorum.R, quorum.W)
+	}
+
+	return nil
 }
 
 type NodeConfig struct {
 	ID        string `json:"id"`
 	Address   string `json:"address"`
 	HashSlots []int  `json:"hashSlots"` // First element is the start of the range, second element is the end of the range. Both sides are inclusive.
+	// ReplicaOf is the ID of the node this node is a replica of, empty for a
+	// primary. A replica has the same hash slots as its primary, but keys in
+	// them are never routed to it.
+	ReplicaOf string `json:"replicaOf,omitempty"`
+}
+
+func (node *NodeConfig) IsReplica() bool {
+	return node.ReplicaOf != ""
+}
+
+// HasHashSlots is false for a node that was added to the cluster but hasn't
+// been given any hash slots yet. It has an empty range, which starts after it
+// ends, so no hash slot is ever routed to it.
+func (node *NodeConfig) HasHashSlots() bool {
+	return node.HashSlots[0] <= node.HashSlots[1]
+}
+
+// Primaries returns every node that owns its hash slots, this node first if
+// it is one.
+func (config *ClusterConfig) Primaries() []*NodeConfig {
+	primaries := []*NodeConfig{}
+
+	for _, node := range append([]*NodeConfig{config.ThisNode}, config.OtherNodes...) {
+		if !node.IsReplica() && node.HasHashSlots() {
+			primaries = append(primaries, node)
+		}
+	}
+
+	return primaries
+}
+
+// PreferenceList returns the nodes that keep a copy of keys in the hash slot
+// with quorum replication. It starts with the primary that owns the slot and
+// carries on through the primaries in order of their hash slots, wrapping
+// around, until it has N of them or every primary.
+func (config *ClusterConfig) PreferenceList(hashSlot uint32) []*NodeConfig {
+	primaries := config.Primaries()
+
+	slices.SortFunc(primaries, func(a, b *NodeConfig) int {
+		return a.HashSlots[0] - b.HashSlots[0]
+	})
+
+	owner := slices.IndexFunc(primaries, func(node *NodeConfig) bool {
+		return hashSlot >= uint32(node.HashSlots[0]) && hashSlot <= uint32(node.HashSlots[1])
+	})
+
+	if owner == -1 {
+		return nil
+	}
+
+	n := min(config.Quorum.Resolved().N, len(primaries))
+	preferenceList := make([]*NodeConfig, n)
+
+	for i := range n {
+		preferenceList[i] = primaries[(owner+i)%len(primaries)]
+	}
+
+	return preferenceList
+}
+
+// Promote returns a copy of the config at the next epoch where the replica
+// owns its primary's hash slots. The old primary and its other replicas become
+// replicas of the new primary.
+func (config *ClusterConfig) Promote(replicaID string) *ClusterConfig {
+	primaryID := config.FindNode(replicaID).ReplicaOf
+
+	promote := func(node *NodeConfig) *NodeConfig {
+		promoted := *node
+		promoted.HashSlots = append([]int{}, node.HashSlots...)
+
+		if promoted.ID == replicaID {
+			promoted.ReplicaOf = ""
+		} else if promoted.ID == primaryID || promoted.ReplicaOf == primaryID {
+			promoted.ReplicaOf = replicaID
+		}
+
+		return &promoted
+	}
+
+	otherNodes := []*NodeConfig{}
+
+	for _, node := range config.OtherNodes {
+		otherNodes = append(otherNodes, promote(node))
+	}
+
+	return &ClusterConfig{
+		ThisNode:        promote(config.ThisNode),
+		OtherNodes:      otherNodes,
+		HashFunction:    config.HashFunction,
+		ReplicationMode: config.ReplicationMode,
+		Quorum:          config.Quorum,
+		Epoch:           config.Epoch + 1,
+	}
+}
+
+// FindNode returns the node with the ID, or nil if there isn't one.
+func (config *ClusterConfig) FindNode(id string) *NodeConfig {
+	for _, node := range append([]*NodeConfig{config.ThisNode}, config.OtherNodes...) {
+		if node.ID == id {
+			return node
+		}
+	}
+
+	return nil
 }
 
 func GenerateNodeID() string {
 	return uuid.New().String()
 }
 
+const nodeIDFileName = "NODE_ID"
+
+// LoadNodeID returns the ID kept in the data directory, generating one the
+// first time. A node keeps its ID across restarts so it stays the same member
+// of the cluster's raft groups.
+func LoadNodeID(dataDir string) (string, error) {
+	path := filepath.Join(dataDir, nodeIDFileName)
+
+	contents, err := os.ReadFile(path)
+
+	if err == nil {
+		return strings.TrimSpace(string(contents)), nil
+	}
+
+	if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	nodeID := GenerateNodeID()
+
+	err = os.WriteFile(path, []byte(nodeID+"\n"), 0644)
+
+	if err != nil {
+		return "", err
+	}
+
+	return nodeID, nil
+}
+
 type ConfigurationManager interface {
 	SetClusterConfig(config *ClusterConfig)
 	GetClusterConfig() *ClusterConfig
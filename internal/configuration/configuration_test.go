@@ -122,3 +122,88 @@ func TestBaseConfigurationManager_GetClusterConfig(t *testing.T) {
 		}
 	})
 }
+
+func TestPromoteShouldHandTheReplicaItsPrimarysSlots(t *testing.T) {
+	config := &ClusterConfig{
+		ThisNode: &NodeConfig{ID: "replica1", Address: "addr2", HashSlots: []int{1, 2}, ReplicaOf: "primary"},
+		OtherNodes: []*NodeConfig{
+			{ID: "primary", Address: "addr1", HashSlots: []int{1, 2}},
+			{ID: "replica2", Address: "addr3", HashSlots: []int{1, 2}, ReplicaOf: "primary"},
+			{ID: "other", Address: "addr4", HashSlots: []int{3, 4}},
+		},
+		Epoch: 3,
+	}
+
+	promoted := config.Promote("replica1")
+
+	if promoted.Epoch != 4 {
+		t.Errorf("Expected epoch 4, got %d", promoted.Epoch)
+	}
+
+	expected := map[string]string{"replica1": "", "primary": "replica1", "replica2": "replica1", "other": ""}
+
+	for id, replicaOf := range expected {
+		if node := promoted.FindNode(id); node.ReplicaOf != replicaOf {
+			t.Errorf("Expected %s to be a replica of %q, got %q", id, replicaOf, node.ReplicaOf)
+		}
+	}
+
+	if config.ThisNode.ReplicaOf != "primary" || config.Epoch != 3 {
+		t.Errorf("Did not expect promoting to change the old config")
+	}
+}
+
+func TestPreferenceListShouldStartAtTheOwnerAndWrapAround(t *testing.T) {
+	config := &ClusterConfig{
+		ThisNode: &NodeConfig{ID: "c", Address: "addr3", HashSlots: []int{200, 299}},
+		OtherNodes: []*NodeConfig{
+			{ID: "a", Address: "addr1", HashSlots: []int{0, 99}},
+			{ID: "b", Address: "addr2", HashSlots: []int{100, 199}},
+			{ID: "d", Address: "addr4", HashSlots: []int{300, 399}},
+			{ID: "replica", Address: "addr5", HashSlots: []int{0, 99}, ReplicaOf: "a"},
+			// added, but not given any hash slots yet.
+			{ID: "new", Address: "addr6", HashSlots: []int{16384, 16383}},
+		},
+		Quorum: QuorumConfig{N: 3, R: 2, W: 2},
+	}
+
+	ids := func(nodes []*NodeConfig) []string {
+		ids := []string{}
+
+		for _, node := range nodes {
+			ids = append(ids, node.ID)
+		}
+
+		return ids
+	}
+
+	if got := ids(config.PreferenceList(150)); !reflect.DeepEqual(got, []string{"b", "c", "d"}) {
+		t.Errorf("Expected the preference list for slot 150 to be [b c d], got %v", got)
+	}
+
+	if got := ids(config.PreferenceList(350)); !reflect.DeepEqual(got, []string{"d", "a", "b"}) {
+		t.Errorf("Expected the preference list for slot 350 to wrap around to [d a b], got %v", got)
+	}
+
+	config.Quorum = QuorumConfig{N: 5, R: 1, W: 1}
+
+	if got := ids(config.PreferenceList(0)); !reflect.DeepEqual(got, []string{"a", "b", "c", "d"}) {
+		t.Errorf("Expected the preference list to stop at every primary, got %v", got)
+	}
+
+	if config.PreferenceList(400) != nil {
+		t.Errorf("Did not expect a preference list for a slot no node owns")
+	}
+}
+
+func TestQuorumConfigShouldNeedRAndWWithinN(t *testing.T) {
+	if err := DefaultQuorumConfig.Validate(); err != nil {
+		t.Errorf("Did not expect an error when validating the default quorum %v", err)
+	}
+
+	for _, quorum := range []QuorumConfig{{N: 3, R: 4, W: 2}, {N: 3, R: 2, W: 0}, {N: 0, R: 0, W: 0}} {
+		if quorum.Validate() == nil {
+			t.Errorf("Expected quorum %v to be invalid", quorum)
+		}
+	}
+}
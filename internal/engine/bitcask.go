@@ -0,0 +1,902 @@
+package engine
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/ethan-stone/go-key-store/internal/wal"
+)
+
+const (
+	DefaultBitcaskFileSize = 64 * 1024 * 1024
+	DefaultMergeThreshold  = 0.5
+
+	bitcaskDataSuffix = ".data"
+	bitcaskHintSuffix = ".hint"
+	mergeSuffix       = ".merge"
+	hintValueSize     = 17
+)
+
+type BitcaskConfig struct {
+	MaxFileSize    int64   // a new data file is started once the active one reaches this size
+	MergeThreshold float64 // fraction of the data files that has to be dead before a merge starts
+}
+
+// keydirEntry is where the newest value for a key is.
+type keydirEntry struct {
+	file   uint64
+	offset int64
+	size   int64
+}
+
+// hint is an entry in a hint file. Hint files list the keys in a data file
+// and where they are without the values, so start up can build the keydir
+// without reading every value.
+type hint struct {
+	key     string
+	offset  int64
+	size    int64
+	deleted bool
+}
+
+// BitcaskEngine keeps every key in memory in the keydir, but values only on
+// disk in append only data files. A read is one lookup in the keydir and one
+// read from disk.
+//
+// Data files and hint files are both in the WAL format. A data file entry is
+// a put or delete with the value. A hint file entry is always a put, with a
+// 17 byte value holding the offset and size of the entry in its data file and
+// a flags byte that is 1 for a delete.
+type BitcaskEngine struct {
+	sync.RWMutex
+	dir         string
+	config      BitcaskConfig
+	keydir      map[string]keydirEntry
+	keys        *keyList // the keys in the keydir, in order
+	files       map[uint64]*wal.WalReader
+	active      *wal.WalWriter
+	activeID    uint64
+	activeHints []hint         // everything written to the active file, for its hint file
+	totalBytes  int64          // size of every entry in every data file
+	liveBytes   int64          // size of the entries the keydir points at
+	mergeLock   sync.RWMutex   // held by a merge, and for reading by every open snapshot
+	merging     sync.WaitGroup // background merges
+}
+
+// OpenBitcaskEngine opens the engine in dir, creating it if needed. config can
+// be nil to use the defaults. The keydir is rebuilt from the hint files, and
+// from the data files that don't have one.
+func OpenBitcaskEngine(dir string, config *BitcaskConfig) (*BitcaskEngine, error) {
+	err := os.MkdirAll(dir, 0755)
+
+	if err != nil {
+		return nil, err
+	}
+
+	engine := &BitcaskEngine{
+		dir:    dir,
+		keydir: make(map[string]keydirEntry),
+		keys:   newKeyList(),
+		files:  make(map[uint64]*wal.WalReader),
+	}
+
+	if config != nil {
+		engine.config = *config
+	}
+
+	if engine.config.MaxFileSize <= 0 {
+		engine.config.MaxFileSize = DefaultBitcaskFileSize
+	}
+
+	if engine.config.MergeThreshold <= 0 {
+		engine.config.MergeThreshold = DefaultMergeThreshold
+	}
+
+	err = engine.load()
+
+	if err != nil {
+		engine.closeFiles()
+		return nil, err
+	}
+
+	return engine, nil
+}
+
+func (engine *BitcaskEngine) fileName(id uint64, suffix string) string {
+	return filepath.Join(engine.dir, fmt.Sprintf("%012d%s", id, suffix))
+}
+
+func (engine *BitcaskEngine) load() error {
+	// a merge that didn't finish swapping its files in leaves some behind.
+	// Every data file it did swap in already reflects the newest values.
+	leftovers, err := filepath.Glob(filepath.Join(engine.dir, "*"+mergeSuffix))
+
+	if err != nil {
+		return err
+	}
+
+	for _, leftover := range leftovers {
+		err = os.Remove(leftover)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	matches, err := filepath.Glob(filepath.Join(engine.dir, "*"+bitcaskDataSuffix))
+
+	if err != nil {
+		return err
+	}
+
+	ids := []uint64{}
+
+	for _, match := range matches {
+		var id uint64
+
+		_, err := fmt.Sscanf(filepath.Base(match), "%012d"+bitcaskDataSuffix, &id)
+
+		if err == nil {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for i, id := range ids {
+		last := i == len(ids)-1
+
+		hints, err := engine.readFileHints(id, last)
+
+		if err != nil {
+			return err
+		}
+
+		for _, h := range hints {
+			engine.apply(id, h)
+		}
+
+		if last {
+			engine.activeHints = hints
+		}
+	}
+
+	engine.activeID = 1
+
+	if len(ids) > 0 {
+		engine.activeID = ids[len(ids)-1]
+	}
+
+	return engine.openActive()
+}
+
+// readFileHints opens a data file and lists its entries, from its hint file if
+// it has a valid one. The last file has no hint file yet, and is truncated
+// after its last valid entry since it may have been cut short by a crash.
+func (engine *BitcaskEngine) readFileHints(id uint64, last bool) ([]hint, error) {
+	reader, err := wal.NewWalReader(engine.fileName(id, bitcaskDataSuffix))
+
+	if err != nil {
+		return nil, err
+	}
+
+	engine.files[id] = reader
+
+	if !last {
+		hints, err := readHintFile(engine.fileName(id, bitcaskHintSuffix))
+
+		if err == nil {
+			return hints, nil
+		}
+
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("Rebuilding hints for Bitcask data file %d: %v", id, err)
+		}
+	}
+
+	hints := []hint{}
+	offset := reader.Start()
+
+	for {
+		entryRead, err := reader.Read(offset)
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil && last && !errors.Is(err, wal.ErrIO) {
+			log.Printf("Truncating Bitcask data file %d at offset %d: %v", id, offset, err)
+
+			err = wal.Truncate(engine.fileName(id, bitcaskDataSuffix), offset)
+
+			if err != nil {
+				return nil, err
+			}
+
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("Bitcask data file %d: %w", id, err)
+		}
+
+		entry := entryRead.Entry()
+
+		hints = append(hints, hint{
+			key:     string(entry.KeyBytes),
+			offset:  offset,
+			size:    entryRead.Size(),
+			deleted: entry.OpType == wal.Del,
+		})
+
+		offset += entryRead.Size()
+	}
+
+	return hints, nil
+}
+
+func readHintFile(fileName string) ([]hint, error) {
+	reader, err := wal.NewWalReader(fileName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer reader.Close()
+
+	hints := []hint{}
+	offset := reader.Start()
+
+	for {
+		entryRead, err := reader.Read(offset)
+
+		if err == io.EOF {
+			return hints, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		entry := entryRead.Entry()
+
+		if entry.OpType != wal.Put || len(*entry.ValueBytes) != hintValueSize {
+			return nil, fmt.Errorf("hint file %s has a malformed entry at offset %d", fileName, offset)
+		}
+
+		value := *entry.ValueBytes
+
+		hints = append(hints, hint{
+			key:     string(entry.KeyBytes),
+			offset:  int64(binary.LittleEndian.Uint64(value[:8])),
+			size:    int64(binary.LittleEndian.Uint64(value[8:16])),
+			deleted: value[16]&tombstoneFlag != 0,
+		})
+
+		offset += entryRead.Size()
+	}
+}
+
+// writeHintFile writes hints to a temporary file and renames it to fileName.
+func writeHintFile(fileName string, tmpPath string, hints []hint) error {
+	os.Remove(tmpPath)
+
+	writer, err := wal.NewWalWriterWithConfig(tmpPath, &wal.WalWriterConfig{Durability: wal.SyncNone})
+
+	if err != nil {
+		return err
+	}
+
+	for _, h := range hints {
+		value := binary.LittleEndian.AppendUint64(nil, uint64(h.offset))
+		value = binary.LittleEndian.AppendUint64(value, uint64(h.size))
+		value = append(value, 0)
+
+		if h.deleted {
+			value[16] = tombstoneFlag
+		}
+
+		err = writer.Write(&wal.WalEntryWrite{
+			OpType:      wal.Put,
+			KeyLength:   int32(len(h.key)),
+			ValueLength: int32(len(value)),
+			KeyBytes:    []byte(h.key),
+			ValueBytes:  &value,
+		})
+
+		if err != nil {
+			writer.Close()
+			return err
+		}
+	}
+
+	err = writer.Close()
+
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, fileName)
+}
+
+// apply points the keydir at an entry. It must be called with the lock held.
+func (engine *BitcaskEngine) apply(file uint64, h hint) {
+	old, ok := engine.keydir[h.key]
+
+	if ok {
+		engine.liveBytes -= old.size
+	}
+
+	engine.totalBytes += h.size
+
+	if h.deleted {
+		delete(engine.keydir, h.key)
+		engine.keys.remove(h.key)
+
+		return
+	}
+
+	if !ok {
+		engine.keys.add(h.key)
+	}
+
+	engine.keydir[h.key] = keydirEntry{file: file, offset: h.offset, size: h.size}
+	engine.liveBytes += h.size
+}
+
+func (engine *BitcaskEngine) openActive() error {
+	active, err := wal.NewWalWriterWithConfig(engine.fileName(engine.activeID, bitcaskDataSuffix), &wal.WalWriterConfig{Durability: wal.SyncNone})
+
+	if err != nil {
+		return err
+	}
+
+	engine.active = active
+
+	if _, ok := engine.files[engine.activeID]; ok {
+		return nil
+	}
+
+	reader, err := wal.NewWalReader(engine.fileName(engine.activeID, bitcaskDataSuffix))
+
+	if err != nil {
+		return err
+	}
+
+	engine.files[engine.activeID] = reader
+
+	return nil
+}
+
+// readValue reads the value an entry points at. It must be called with at
+// least the read lock held.
+func (engine *BitcaskEngine) readValue(loc keydirEntry) (string, error) {
+	reader, ok := engine.files[loc.file]
+
+	if !ok {
+		return "", fmt.Errorf("Bitcask data file %d is not open", loc.file)
+	}
+
+	entryRead, err := reader.Read(loc.offset)
+
+	if err != nil {
+		return "", fmt.Errorf("Bitcask data file %d: %w", loc.file, err)
+	}
+
+	entry := entryRead.Entry()
+
+	if entry.OpType != wal.Put {
+		return "", fmt.Errorf("Bitcask data file %d has op type %d at offset %d, expected a put", loc.file, entry.OpType, loc.offset)
+	}
+
+	return string(*entry.ValueBytes), nil
+}
+
+func (engine *BitcaskEngine) Get(key string) (string, bool, error) {
+	engine.RLock()
+	defer engine.RUnlock()
+
+	loc, ok := engine.keydir[key]
+
+	if !ok {
+		return "", false, nil
+	}
+
+	val, err := engine.readValue(loc)
+
+	if err != nil {
+		return "", false, err
+	}
+
+	return val, true, nil
+}
+
+func (engine *BitcaskEngine) Put(key string, val string) error {
+	valueBytes := []byte(val)
+
+	return engine.write(&wal.WalEntryWrite{
+		OpType:      wal.Put,
+		KeyLength:   int32(len(key)),
+		ValueLength: int32(len(valueBytes)),
+		KeyBytes:    []byte(key),
+		ValueBytes:  &valueBytes,
+	})
+}
+
+// Delete appends a tombstone, so a restart doesn't bring back the value from
+// an older data file.
+func (engine *BitcaskEngine) Delete(key string) error {
+	engine.RLock()
+	_, ok := engine.keydir[key]
+	engine.RUnlock()
+
+	if !ok {
+		return nil
+	}
+
+	return engine.write(&wal.WalEntryWrite{
+		OpType:    wal.Del,
+		KeyLength: int32(len(key)),
+		KeyBytes:  []byte(key),
+	})
+}
+
+func (engine *BitcaskEngine) write(entry *wal.WalEntryWrite) error {
+	engine.Lock()
+	defer engine.Unlock()
+
+	if engine.active.Size() >= engine.config.MaxFileSize {
+		err := engine.rotate()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	pending, err := engine.active.Enqueue(entry)
+
+	if err == nil {
+		err = pending.Wait()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	h := hint{
+		key:     string(entry.KeyBytes),
+		offset:  pending.Offset,
+		size:    engine.active.Size() - pending.Offset,
+		deleted: entry.OpType == wal.Del,
+	}
+
+	engine.apply(engine.activeID, h)
+	engine.activeHints = append(engine.activeHints, h)
+
+	return nil
+}
+
+// rotate writes the hint file for the active data file and starts a new one.
+// It must be called with the lock held.
+func (engine *BitcaskEngine) rotate() error {
+	err := writeHintFile(engine.fileName(engine.activeID, bitcaskHintSuffix), engine.fileName(engine.activeID, bitcaskHintSuffix+".tmp"), engine.activeHints)
+
+	if err != nil {
+		return err
+	}
+
+	err = engine.active.Close()
+
+	if err != nil {
+		return err
+	}
+
+	engine.activeID++
+	engine.activeHints = nil
+
+	err = engine.openActive()
+
+	if err != nil {
+		return err
+	}
+
+	if engine.needsMerge() {
+		engine.merging.Add(1)
+
+		go func() {
+			defer engine.merging.Done()
+
+			err := engine.Merge()
+
+			if err != nil {
+				log.Printf("Bitcask merge failed %v", err)
+			}
+		}()
+	}
+
+	return nil
+}
+
+// needsMerge reports whether enough of the data files is overwritten or
+// deleted values to be worth rewriting. It must be called with the lock held.
+func (engine *BitcaskEngine) needsMerge() bool {
+	if engine.totalBytes == 0 {
+		return false
+	}
+
+	return float64(engine.totalBytes-engine.liveBytes)/float64(engine.totalBytes) >= engine.config.MergeThreshold
+}
+
+// Merge rewrites the data files other than the active one with only the
+// values the keydir still points at. The new files take the place of the old
+// ones in order, so a crash part way through swapping them in leaves a mix of
+// new files and old files where every key still resolves to its newest value.
+// Merges are skipped while a snapshot is open.
+func (engine *BitcaskEngine) Merge() error {
+	if !engine.mergeLock.TryLock() {
+		return nil
+	}
+
+	defer engine.mergeLock.Unlock()
+
+	engine.RLock()
+
+	inputs := []uint64{}
+
+	for id := range engine.files {
+		if id != engine.activeID {
+			inputs = append(inputs, id)
+		}
+	}
+
+	type liveEntry struct {
+		key string
+		loc keydirEntry
+	}
+
+	live := []liveEntry{}
+
+	for key, loc := range engine.keydir {
+		if loc.file != engine.activeID {
+			live = append(live, liveEntry{key: key, loc: loc})
+		}
+	}
+
+	engine.RUnlock()
+
+	if len(inputs) == 0 {
+		return nil
+	}
+
+	sort.Slice(inputs, func(i, j int) bool { return inputs[i] < inputs[j] })
+
+	sort.Slice(live, func(i, j int) bool {
+		if live[i].loc.file != live[j].loc.file {
+			return live[i].loc.file < live[j].loc.file
+		}
+
+		return live[i].loc.offset < live[j].loc.offset
+	})
+
+	outputs := 0
+	outputHints := [][]hint{}
+	moved := make(map[string][2]keydirEntry) // key to its old and new location
+
+	var writer *wal.WalWriter
+
+	finishOutput := func() error {
+		err := writer.Close()
+
+		writer = nil
+
+		if err != nil {
+			return err
+		}
+
+		id := inputs[outputs-1]
+
+		return writeHintFile(engine.fileName(id, bitcaskHintSuffix+mergeSuffix), engine.fileName(id, bitcaskHintSuffix+".tmp"), outputHints[outputs-1])
+	}
+
+	for _, entry := range live {
+		// the merged files reuse the input file numbers, so there can't be
+		// more of them. The last one grows past the maximum size if it has to.
+		if writer == nil || (writer.Size() >= engine.config.MaxFileSize && outputs < len(inputs)) {
+			if writer != nil {
+				err := finishOutput()
+
+				if err != nil {
+					return err
+				}
+			}
+
+			var err error
+
+			writer, err = wal.NewWalWriterWithConfig(engine.fileName(inputs[outputs], bitcaskDataSuffix+mergeSuffix), &wal.WalWriterConfig{Durability: wal.SyncNone})
+
+			if err != nil {
+				return err
+			}
+
+			outputs++
+			outputHints = append(outputHints, []hint{})
+		}
+
+		engine.RLock()
+		val, err := engine.readValue(entry.loc)
+		engine.RUnlock()
+
+		if err != nil {
+			writer.Close()
+			return err
+		}
+
+		valueBytes := []byte(val)
+		offset := writer.Size()
+
+		err = writer.Write(&wal.WalEntryWrite{
+			OpType:      wal.Put,
+			KeyLength:   int32(len(entry.key)),
+			ValueLength: int32(len(valueBytes)),
+			KeyBytes:    []byte(entry.key),
+			ValueBytes:  &valueBytes,
+		})
+
+		if err != nil {
+			writer.Close()
+			return err
+		}
+
+		h := hint{key: entry.key, offset: offset, size: writer.Size() - offset}
+
+		outputHints[outputs-1] = append(outputHints[outputs-1], h)
+		moved[entry.key] = [2]keydirEntry{entry.loc, {file: inputs[outputs-1], offset: h.offset, size: h.size}}
+	}
+
+	if writer != nil {
+		err := finishOutput()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return engine.swapMerged(inputs, outputs, moved)
+}
+
+// swapMerged replaces the first outputs input files with the merged files,
+// deletes the rest of the inputs, and points the keydir at the new files.
+func (engine *BitcaskEngine) swapMerged(inputs []uint64, outputs int, moved map[string][2]keydirEntry) error {
+	engine.Lock()
+	defer engine.Unlock()
+
+	for i, id := range inputs {
+		engine.files[id].Close()
+		delete(engine.files, id)
+
+		// the old hint file goes first, so it is never paired with the new data file.
+		err := os.Remove(engine.fileName(id, bitcaskHintSuffix))
+
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		if i >= outputs {
+			err = os.Remove(engine.fileName(id, bitcaskDataSuffix))
+
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		err = os.Rename(engine.fileName(id, bitcaskDataSuffix+mergeSuffix), engine.fileName(id, bitcaskDataSuffix))
+
+		if err == nil {
+			err = os.Rename(engine.fileName(id, bitcaskHintSuffix+mergeSuffix), engine.fileName(id, bitcaskHintSuffix))
+		}
+
+		if err != nil {
+			return err
+		}
+
+		reader, err := wal.NewWalReader(engine.fileName(id, bitcaskDataSuffix))
+
+		if err != nil {
+			return err
+		}
+
+		engine.files[id] = reader
+	}
+
+	totalBytes := int64(0)
+
+	for key, locs := range moved {
+		totalBytes += locs[1].size
+
+		// the key may have been written again while the merge was running.
+		if engine.keydir[key] == locs[0] {
+			engine.keydir[key] = locs[1]
+			engine.liveBytes += locs[1].size - locs[0].size
+		}
+	}
+
+	activeBytes := int64(0)
+
+	for _, h := range engine.activeHints {
+		activeBytes += h.size
+	}
+
+	engine.totalBytes = totalBytes + activeBytes
+
+	log.Printf("Merged %d Bitcask data files into %d", len(inputs), outputs)
+
+	return syncFile(engine.dir)
+}
+
+func (engine *BitcaskEngine) Iterate(fn func(key string, val string) bool) error {
+	return engine.IterateFrom("", fn)
+}
+
+// IterateFrom reads a batch of keys and their values at a time, and only
+// holds the engine's lock while it does, so fn is free to write to the
+// engine.
+func (engine *BitcaskEngine) IterateFrom(start string, fn func(key string, val string) bool) error {
+	for {
+		keys, vals, err := engine.readBatch(start)
+
+		if err != nil {
+			return err
+		}
+
+		for i, key := range keys {
+			if !fn(key, vals[i]) {
+				return nil
+			}
+		}
+
+		if len(keys) < iterateBatchSize {
+			return nil
+		}
+
+		start = after(keys[len(keys)-1])
+	}
+}
+
+// readBatch reads the values of the first keys that aren't before start.
+func (engine *BitcaskEngine) readBatch(start string) ([]string, []string, error) {
+	engine.RLock()
+	defer engine.RUnlock()
+
+	keys := engine.keys.from(start, iterateBatchSize)
+	vals := make([]string, len(keys))
+
+	for i, key := range keys {
+		val, err := engine.readValue(engine.keydir[key])
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		vals[i] = val
+	}
+
+	return keys, vals, nil
+}
+
+// Snapshot copies the keydir. Values are read from the data files when they
+// are asked for, which is safe since data files are only ever appended to
+// while no merge is running, and merges wait for every snapshot to close.
+func (engine *BitcaskEngine) Snapshot() (Snapshot, error) {
+	engine.mergeLock.RLock()
+
+	engine.RLock()
+	defer engine.RUnlock()
+
+	keydir := make(map[string]keydirEntry, len(engine.keydir))
+
+	for key, loc := range engine.keydir {
+		keydir[key] = loc
+	}
+
+	return &bitcaskSnapshot{engine: engine, keydir: keydir, keys: engine.keys.all()}, nil
+}
+
+// Sync fsyncs the active data file. The other data files were synced when
+// they were rotated out.
+func (engine *BitcaskEngine) Sync() error {
+	engine.Lock()
+	defer engine.Unlock()
+
+	err := engine.active.Sync()
+
+	if err != nil {
+		return err
+	}
+
+	return syncFile(engine.dir)
+}
+
+func (engine *BitcaskEngine) Close() error {
+	engine.merging.Wait()
+
+	engine.Lock()
+	defer engine.Unlock()
+
+	err := engine.active.Close()
+
+	engine.closeFiles()
+
+	return err
+}
+
+func (engine *BitcaskEngine) closeFiles() {
+	for id, reader := range engine.files {
+		reader.Close()
+		delete(engine.files, id)
+	}
+}
+
+type bitcaskSnapshot struct {
+	engine *BitcaskEngine
+	keydir map[string]keydirEntry
+	keys   []string // the keys in keydir, in order
+	closed bool
+}
+
+func (snapshot *bitcaskSnapshot) Get(key string) (string, bool, error) {
+	loc, ok := snapshot.keydir[key]
+
+	if !ok {
+		return "", false, nil
+	}
+
+	snapshot.engine.RLock()
+	defer snapshot.engine.RUnlock()
+
+	val, err := snapshot.engine.readValue(loc)
+
+	if err != nil {
+		return "", false, err
+	}
+
+	return val, true, nil
+}
+
+func (snapshot *bitcaskSnapshot) Iterate(fn func(key string, val string) bool) error {
+	return snapshot.IterateFrom("", fn)
+}
+
+// IterateFrom only reads the values of keys it hands to fn.
+func (snapshot *bitcaskSnapshot) IterateFrom(start string, fn func(key string, val string) bool) error {
+	for _, key := range searchKeys(snapshot.keys, start) {
+		val, _, err := snapshot.Get(key)
+
+		if err != nil {
+			return err
+		}
+
+		if !fn(key, val) {
+			break
+		}
+	}
+
+	return nil
+}
+
+func (snapshot *bitcaskSnapshot) Close() error {
+	if !snapshot.closed {
+		snapshot.closed = true
+		snapshot.engine.mergeLock.RUnlock()
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var smallBitcaskConfig = &BitcaskConfig{
+	MaxFileSize: 1024,
+	// merges are run by hand in these tests.
+	MergeThreshold: 2,
+}
+
+func TestBitcaskEngineShouldRebuildKeydirFromHintFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	engine, err := OpenBitcaskEngine(dir, smallBitcaskConfig)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening %v", err)
+	}
+
+	expected := make(map[string]string)
+
+	for i := range 100 {
+		key := fmt.Sprintf("key-%03d", i)
+
+		engine.Put(key, fmt.Sprintf("value-%d", i))
+		expected[key] = fmt.Sprintf("value-%d", i)
+
+		if i%10 == 0 {
+			engine.Delete(key)
+			delete(expected, key)
+		}
+	}
+
+	engine.Close()
+
+	hints, _ := filepath.Glob(filepath.Join(dir, "*"+bitcaskHintSuffix))
+
+	if len(hints) == 0 {
+		t.Errorf("Expected rotated data files to have hint files")
+	}
+
+	reopened, err := OpenBitcaskEngine(dir, smallBitcaskConfig)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when reopening %v", err)
+	}
+
+	defer reopened.Close()
+
+	checkEngine(t, reopened, expected)
+}
+
+func TestBitcaskMergeShouldReclaimSpace(t *testing.T) {
+	dir := t.TempDir()
+
+	engine, err := OpenBitcaskEngine(dir, smallBitcaskConfig)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening %v", err)
+	}
+
+	expected := make(map[string]string)
+
+	for round := range 10 {
+		for i := range 20 {
+			key := fmt.Sprintf("key-%02d", i)
+			val := fmt.Sprintf("value-%d-%d", round, i)
+
+			engine.Put(key, val)
+			expected[key] = val
+		}
+	}
+
+	engine.Delete("key-00")
+	delete(expected, "key-00")
+
+	before, _ := filepath.Glob(filepath.Join(dir, "*"+bitcaskDataSuffix))
+
+	err = engine.Merge()
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when merging %v", err)
+	}
+
+	after, _ := filepath.Glob(filepath.Join(dir, "*"+bitcaskDataSuffix))
+
+	if len(after) >= len(before) {
+		t.Errorf("Expected merge to remove data files, had %d and now have %d", len(before), len(after))
+	}
+
+	checkEngine(t, engine, expected)
+
+	engine.Close()
+
+	reopened, err := OpenBitcaskEngine(dir, smallBitcaskConfig)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when reopening %v", err)
+	}
+
+	defer reopened.Close()
+
+	checkEngine(t, reopened, expected)
+}
+
+func TestBitcaskEngineShouldTruncateTornTail(t *testing.T) {
+	dir := t.TempDir()
+
+	engine, err := OpenBitcaskEngine(dir, nil)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening %v", err)
+	}
+
+	engine.Put("a", "1")
+	engine.Close()
+
+	file, _ := os.OpenFile(filepath.Join(dir, "000000000001"+bitcaskDataSuffix), os.O_APPEND|os.O_WRONLY, 0666)
+	file.Write([]byte{1, 5, 0})
+	file.Close()
+
+	reopened, err := OpenBitcaskEngine(dir, nil)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when reopening %v", err)
+	}
+
+	defer reopened.Close()
+
+	reopened.Put("b", "2")
+
+	checkEngine(t, reopened, map[string]string{"a": "1", "b": "2"})
+}
@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+)
+
+const bloomBitsPerKey = 10 // about a 1% false positive rate
+
+// bloomFilter answers whether a key might be in an SSTable without reading
+// any of its blocks. It uses double hashing on a single 64 bit FNV hash to set
+// k bits per key.
+type bloomFilter struct {
+	bits []byte
+	k    uint32
+}
+
+func newBloomFilter(keyCount int) *bloomFilter {
+	bitCount := max(keyCount*bloomBitsPerKey, 64)
+
+	return &bloomFilter{
+		bits: make([]byte, (bitCount+7)/8),
+		k:    7, // bloomBitsPerKey * ln 2, rounded
+	}
+}
+
+func bloomHash(key string) (uint32, uint32) {
+	hasher := fnv.New64a()
+	hasher.Write([]byte(key))
+	h := hasher.Sum64()
+
+	return uint32(h), uint32(h>>32) | 1
+}
+
+func (filter *bloomFilter) add(key string) {
+	h1, h2 := bloomHash(key)
+	bitCount := uint32(len(filter.bits) * 8)
+
+	for i := range filter.k {
+		bit := (h1 + i*h2) % bitCount
+		filter.bits[bit/8] |= 1 << (bit % 8)
+	}
+}
+
+func (filter *bloomFilter) mayContain(key string) bool {
+	h1, h2 := bloomHash(key)
+	bitCount := uint32(len(filter.bits) * 8)
+
+	for i := range filter.k {
+		bit := (h1 + i*h2) % bitCount
+
+		if filter.bits[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// encode lays the filter out as a 4 byte k followed by the bits.
+func (filter *bloomFilter) encode() []byte {
+	buf := binary.LittleEndian.AppendUint32(nil, filter.k)
+
+	return append(buf, filter.bits...)
+}
+
+func decodeBloomFilter(buf []byte) (*bloomFilter, error) {
+	if len(buf) < 5 {
+		return nil, errors.New("bloom filter is too short")
+	}
+
+	return &bloomFilter{
+		k:    binary.LittleEndian.Uint32(buf[:4]),
+		bits: buf[4:],
+	}, nil
+}
@@ -0,0 +1,314 @@
+package engine
+
+import (
+	"log"
+)
+
+// background flushes frozen memtables and runs compactions until the engine
+// is closed. It is the only goroutine that changes the SSTable levels, so it
+// can read them without the lock while it works, and only takes the lock to
+// swap its results in.
+func (engine *LSMEngine) background() {
+	defer close(engine.done)
+
+	for {
+		select {
+		case <-engine.closed:
+			return
+		case <-engine.work:
+		}
+
+		err := engine.flush()
+
+		for err == nil {
+			var compacted bool
+
+			compacted, err = engine.compact()
+
+			if !compacted {
+				break
+			}
+		}
+
+		if err != nil {
+			log.Printf("LSM engine background work failed %v", err)
+
+			engine.Lock()
+			engine.err = err
+			engine.changed.Broadcast()
+			engine.Unlock()
+
+			return
+		}
+	}
+}
+
+// flush writes the frozen memtable to a new level 0 table.
+func (engine *LSMEngine) flush() error {
+	engine.RLock()
+	imm := engine.imm
+	engine.RUnlock()
+
+	if imm == nil {
+		return nil
+	}
+
+	var table *sstable
+
+	if imm.count > 0 {
+		tables, err := engine.writeTables(imm.iterator(), false, 0)
+
+		if err != nil {
+			return err
+		}
+
+		table = tables[0]
+	}
+
+	engine.Lock()
+	defer engine.Unlock()
+
+	if table != nil {
+		engine.levels[0] = append([]*sstable{table}, engine.levels[0]...)
+
+		err := engine.writeManifest()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	engine.imm = nil
+	engine.changed.Broadcast()
+
+	return nil
+}
+
+type compaction struct {
+	level  int        // the output goes to level + 1
+	inputs []*sstable // newest first
+}
+
+// pickCompaction finds the most pressing compaction, if there is one. Level 0
+// is compacted once it has too many tables, since every one of them has to
+// be checked on a read. Any other level is compacted once it is too big, one
+// table at a time.
+func (engine *LSMEngine) pickCompaction() *compaction {
+	engine.RLock()
+	defer engine.RUnlock()
+
+	var c *compaction
+
+	if len(engine.levels[0]) >= engine.config.L0CompactionTrigger {
+		c = &compaction{level: 0, inputs: append([]*sstable{}, engine.levels[0]...)}
+	}
+
+	for level := 1; c == nil && level < lsmLevels-1; level++ {
+		if engine.levelSize(level) > engine.maxLevelSize(level) {
+			c = &compaction{level: level, inputs: []*sstable{engine.levels[level][0]}}
+		}
+	}
+
+	if c == nil {
+		return nil
+	}
+
+	smallest, largest := keyRange(c.inputs)
+
+	for _, table := range engine.levels[c.level+1] {
+		if table.meta.overlaps(smallest, largest) {
+			c.inputs = append(c.inputs, table)
+		}
+	}
+
+	return c
+}
+
+func keyRange(tables []*sstable) (string, string) {
+	smallest := string(tables[0].meta.Smallest)
+	largest := string(tables[0].meta.Largest)
+
+	for _, table := range tables[1:] {
+		smallest = min(smallest, string(table.meta.Smallest))
+		largest = max(largest, string(table.meta.Largest))
+	}
+
+	return smallest, largest
+}
+
+// compact runs one compaction, and reports whether there was one to run.
+func (engine *LSMEngine) compact() (bool, error) {
+	c := engine.pickCompaction()
+
+	if c == nil {
+		return false, nil
+	}
+
+	output := c.level + 1
+
+	// tombstones only need to be kept while there might be an older value
+	// for them to hide in a deeper level.
+	engine.RLock()
+
+	dropTombstones := true
+
+	for level := output + 1; level < lsmLevels; level++ {
+		if len(engine.levels[level]) > 0 {
+			dropTombstones = false
+		}
+	}
+
+	engine.RUnlock()
+
+	iterators := []recordIterator{}
+
+	for _, table := range c.inputs {
+		iterators = append(iterators, table.iterator())
+	}
+
+	tables, err := engine.writeTables(newMergeIterator(iterators), dropTombstones, engine.config.TableSize)
+
+	if err != nil {
+		return true, err
+	}
+
+	engine.Lock()
+
+	replaced := make(map[*sstable]bool)
+
+	for _, table := range c.inputs {
+		replaced[table] = true
+	}
+
+	for _, level := range []int{c.level, output} {
+		kept := []*sstable{}
+
+		for _, table := range engine.levels[level] {
+			if !replaced[table] {
+				kept = append(kept, table)
+			}
+		}
+
+		engine.levels[level] = kept
+	}
+
+	engine.levels[output] = append(engine.levels[output], tables...)
+	sortLevel(engine.levels[output])
+
+	err = engine.writeManifest()
+
+	engine.changed.Broadcast()
+	engine.Unlock()
+
+	if err != nil {
+		return true, err
+	}
+
+	for _, table := range c.inputs {
+		table.obsolete.Store(true)
+		table.unref()
+	}
+
+	log.Printf("Compacted %d SSTables from level %d into %d tables in level %d", len(c.inputs), c.level, len(tables), output)
+
+	return true, nil
+}
+
+// writeTables writes records to new SSTables, starting a new one whenever the
+// current one reaches tableSize. A tableSize of 0 puts everything in one
+// table.
+func (engine *LSMEngine) writeTables(records recordIterator, dropTombstones bool, tableSize int64) ([]*sstable, error) {
+	tables := []*sstable{}
+	var writer *sstableWriter
+
+	abort := func() {
+		if writer != nil {
+			writer.abort()
+		}
+
+		for _, table := range tables {
+			table.obsolete.Store(true)
+			table.unref()
+		}
+	}
+
+	finish := func() error {
+		meta, err := writer.finish()
+
+		writer = nil
+
+		if err != nil {
+			return err
+		}
+
+		table, err := openSSTable(engine.tableFileName(meta.Number), *meta)
+
+		if err != nil {
+			return err
+		}
+
+		tables = append(tables, table)
+
+		return nil
+	}
+
+	for {
+		r, ok, err := records.next()
+
+		if err != nil {
+			abort()
+			return nil, err
+		}
+
+		if !ok {
+			break
+		}
+
+		if r.deleted && dropTombstones {
+			continue
+		}
+
+		if writer == nil {
+			number := engine.allocateFile()
+
+			writer, err = createSSTable(engine.tableFileName(number), number)
+
+			if err != nil {
+				abort()
+				return nil, err
+			}
+		}
+
+		err = writer.add(r)
+
+		if err == nil && tableSize > 0 && writer.size() >= tableSize {
+			err = finish()
+		}
+
+		if err != nil {
+			abort()
+			return nil, err
+		}
+	}
+
+	if writer != nil {
+		err := finish()
+
+		if err != nil {
+			abort()
+			return nil, err
+		}
+	}
+
+	return tables, nil
+}
+
+func (engine *LSMEngine) allocateFile() uint64 {
+	engine.Lock()
+	defer engine.Unlock()
+
+	number := engine.nextFile
+	engine.nextFile++
+
+	return number
+}
@@ -0,0 +1,221 @@
+package engine
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	tmpSuffix = ".tmp"
+
+	// MaxDiskKeyLength keeps the hex encoded file name under the 255 byte
+	// limit most file systems have.
+	MaxDiskKeyLength = 127
+)
+
+// DiskEngine keeps one file per key in a directory. File names are the hex
+// encoded key, which sorts the same way the keys do, so iterating is just
+// reading the directory.
+//
+// Writes replace the file with a rename but are not synced. The store's WAL
+// covers anything written since the last Sync.
+type DiskEngine struct {
+	sync.RWMutex
+	dir   string
+	dirty map[string]bool // files written since the last Sync
+}
+
+// OpenDiskEngine opens the engine in dir, creating it if needed. Temporary
+// files left behind by a crash part way through a write are removed.
+func OpenDiskEngine(dir string) (*DiskEngine, error) {
+	err := os.MkdirAll(dir, 0755)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tmpFiles, err := filepath.Glob(filepath.Join(dir, "*"+tmpSuffix))
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tmpFile := range tmpFiles {
+		err = os.Remove(tmpFile)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &DiskEngine{
+		dir:   dir,
+		dirty: make(map[string]bool),
+	}, nil
+}
+
+func (engine *DiskEngine) fileName(key string) string {
+	return filepath.Join(engine.dir, hex.EncodeToString([]byte(key)))
+}
+
+func (engine *DiskEngine) Get(key string) (string, bool, error) {
+	engine.RLock()
+	defer engine.RUnlock()
+
+	contents, err := os.ReadFile(engine.fileName(key))
+
+	if errors.Is(err, os.ErrNotExist) {
+		return "", false, nil
+	}
+
+	if err != nil {
+		return "", false, err
+	}
+
+	return string(contents), true, nil
+}
+
+func (engine *DiskEngine) Put(key string, val string) error {
+	if len(key) > MaxDiskKeyLength {
+		return fmt.Errorf("key is %d bytes, the disk engine only supports keys up to %d bytes", len(key), MaxDiskKeyLength)
+	}
+
+	engine.Lock()
+	defer engine.Unlock()
+
+	fileName := engine.fileName(key)
+	tmpPath := fileName + tmpSuffix
+
+	err := os.WriteFile(tmpPath, []byte(val), 0666)
+
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmpPath, fileName)
+
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	engine.dirty[fileName] = true
+
+	return nil
+}
+
+func (engine *DiskEngine) Delete(key string) error {
+	engine.Lock()
+	defer engine.Unlock()
+
+	fileName := engine.fileName(key)
+
+	err := os.Remove(fileName)
+
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	delete(engine.dirty, fileName)
+
+	return nil
+}
+
+func (engine *DiskEngine) Iterate(fn func(key string, val string) bool) error {
+	return engine.IterateFrom("", fn)
+}
+
+func (engine *DiskEngine) IterateFrom(start string, fn func(key string, val string) bool) error {
+	snapshot, err := engine.Snapshot()
+
+	if err != nil {
+		return err
+	}
+
+	defer snapshot.Close()
+
+	return snapshot.IterateFrom(start, fn)
+}
+
+// Snapshot reads every key into memory. That is fine for the small data sets
+// this engine is meant for, but it is not cheap.
+func (engine *DiskEngine) Snapshot() (Snapshot, error) {
+	engine.RLock()
+	defer engine.RUnlock()
+
+	entries, err := os.ReadDir(engine.dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	data := make(map[string]string, len(entries))
+
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), tmpSuffix) {
+			continue
+		}
+
+		key, err := hex.DecodeString(entry.Name())
+
+		if err != nil {
+			continue
+		}
+
+		contents, err := os.ReadFile(filepath.Join(engine.dir, entry.Name()))
+
+		if err != nil {
+			return nil, err
+		}
+
+		data[string(key)] = string(contents)
+	}
+
+	return &memorySnapshot{data: data}, nil
+}
+
+// Sync fsyncs every file written since the last Sync, then the directory so
+// the renames and deletes are durable too.
+func (engine *DiskEngine) Sync() error {
+	engine.Lock()
+	defer engine.Unlock()
+
+	for fileName := range engine.dirty {
+		err := syncFile(fileName)
+
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	err := syncFile(engine.dir)
+
+	if err != nil {
+		return err
+	}
+
+	engine.dirty = make(map[string]bool)
+
+	return nil
+}
+
+func (engine *DiskEngine) Close() error {
+	return engine.Sync()
+}
+
+func syncFile(fileName string) error {
+	file, err := os.Open(fileName)
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	return file.Sync()
+}
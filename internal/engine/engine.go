@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"fmt"
+)
+
+// Engine is where the local store keeps its data. The store logs every change
+// to its WAL before handing it to the engine, so an engine that keeps its data
+// in memory can still be rebuilt after a restart.
+//
+// Engines are safe for concurrent use.
+type Engine interface {
+	// Get returns the value for key, and false if there isn't one.
+	Get(key string) (string, bool, error)
+	Put(key string, val string) error
+	Delete(key string) error
+	// Iterate calls fn for every key in ascending order until fn returns false.
+	Iterate(fn func(key string, val string) bool) error
+	// IterateFrom is Iterate starting at the first key that isn't before start.
+	IterateFrom(start string, fn func(key string, val string) bool) error
+	// Snapshot returns a read only view of the engine as it is now. Later
+	// writes are not visible through it.
+	Snapshot() (Snapshot, error)
+	Close() error
+}
+
+// Snapshot is a point in time view of an engine. It has to be closed once it
+// is no longer needed so the engine can free whatever it is holding on to.
+type Snapshot interface {
+	Get(key string) (string, bool, error)
+	Iterate(fn func(key string, val string) bool) error
+	IterateFrom(start string, fn func(key string, val string) bool) error
+	Close() error
+}
+
+// Persistent is implemented by engines that keep their data on disk. Once Sync
+// returns, every write made before it was called survives a restart, so the
+// store only needs to replay the WAL from there instead of keeping snapshots.
+type Persistent interface {
+	Sync() error
+}
+
+const (
+	Memory  = "memory"
+	Disk    = "disk"
+	LSM     = "lsm"
+	Bitcask = "bitcask"
+)
+
+// Names lists every engine Open knows about.
+var Names = []string{Memory, Disk, LSM, Bitcask}
+
+type Config struct {
+	Name    string         // one of Names, defaults to Memory
+	Dir     string         // where a persistent engine keeps its files
+	LSM     *LSMConfig     // only used by the LSM engine, nil for the defaults
+	Bitcask *BitcaskConfig // only used by the Bitcask engine, nil for the defaults
+}
+
+// Open creates the engine named in config.
+func Open(config *Config) (Engine, error) {
+	switch config.Name {
+	case "", Memory:
+		return NewMemoryEngine(), nil
+	case Disk:
+		return OpenDiskEngine(config.Dir)
+	case LSM:
+		return OpenLSMEngine(config.Dir, config.LSM)
+	case Bitcask:
+		return OpenBitcaskEngine(config.Dir, config.Bitcask)
+	default:
+		return nil, fmt.Errorf("unknown storage engine %q, expected one of %v", config.Name, Names)
+	}
+}
@@ -0,0 +1,215 @@
+package engine
+
+import (
+	"fmt"
+	"testing"
+)
+
+func openEngines(t *testing.T) map[string]Engine {
+	engines := make(map[string]Engine)
+
+	for _, name := range Names {
+		engine, err := Open(&Config{Name: name, Dir: t.TempDir()})
+
+		if err != nil {
+			t.Fatalf("Did not expect an error when opening %s engine %v", name, err)
+		}
+
+		t.Cleanup(func() {
+			engine.Close()
+		})
+
+		engines[name] = engine
+	}
+
+	return engines
+}
+
+func TestEnginesShouldPutGetAndDelete(t *testing.T) {
+	for name, engine := range openEngines(t) {
+		err := engine.Put("a", "1")
+
+		if err != nil {
+			t.Fatalf("%s: Did not expect an error when putting %v", name, err)
+		}
+
+		val, ok, err := engine.Get("a")
+
+		if err != nil || !ok || val != "1" {
+			t.Errorf("%s: Expected a to be 1, got %s %t %v", name, val, ok, err)
+		}
+
+		err = engine.Delete("a")
+
+		if err != nil {
+			t.Fatalf("%s: Did not expect an error when deleting %v", name, err)
+		}
+
+		_, ok, err = engine.Get("a")
+
+		if err != nil || ok {
+			t.Errorf("%s: Did not expect to find key a, got %t %v", name, ok, err)
+		}
+
+		err = engine.Delete("missing")
+
+		if err != nil {
+			t.Errorf("%s: Did not expect an error when deleting a missing key %v", name, err)
+		}
+	}
+}
+
+func TestEnginesShouldIterateInOrderAndIsolateSnapshots(t *testing.T) {
+	for name, engine := range openEngines(t) {
+		for _, key := range []string{"c", "a", "b"} {
+			engine.Put(key, key+"-value")
+		}
+
+		snapshot, err := engine.Snapshot()
+
+		if err != nil {
+			t.Fatalf("%s: Did not expect an error when taking a snapshot %v", name, err)
+		}
+
+		engine.Put("d", "d-value")
+		engine.Delete("a")
+
+		keys := []string{}
+
+		err = snapshot.Iterate(func(key string, val string) bool {
+			if val != key+"-value" {
+				t.Errorf("%s: Expected %s to be %s-value, got %s", name, key, key, val)
+			}
+
+			keys = append(keys, key)
+
+			return true
+		})
+
+		snapshot.Close()
+
+		if err != nil {
+			t.Fatalf("%s: Did not expect an error when iterating %v", name, err)
+		}
+
+		if len(keys) != 3 || keys[0] != "a" || keys[1] != "b" || keys[2] != "c" {
+			t.Errorf("%s: Expected the snapshot to have a, b, c in order, got %v", name, keys)
+		}
+
+		keys = []string{}
+
+		engine.Iterate(func(key string, val string) bool {
+			keys = append(keys, key)
+
+			return len(keys) < 2
+		})
+
+		if len(keys) != 2 || keys[0] != "b" || keys[1] != "c" {
+			t.Errorf("%s: Expected iteration to stop after b, c, got %v", name, keys)
+		}
+	}
+}
+
+func TestEnginesShouldIterateFromAKey(t *testing.T) {
+	for name, engine := range openEngines(t) {
+		for _, key := range []string{"a", "ba", "bb", "c"} {
+			engine.Put(key, key+"-value")
+		}
+
+		keys := []string{}
+
+		err := engine.IterateFrom("b", func(key string, val string) bool {
+			keys = append(keys, key)
+
+			return true
+		})
+
+		if err != nil {
+			t.Fatalf("%s: Did not expect an error when iterating %v", name, err)
+		}
+
+		if len(keys) != 3 || keys[0] != "ba" || keys[1] != "bb" || keys[2] != "c" {
+			t.Errorf("%s: Expected iteration from b to see ba, bb, c, got %v", name, keys)
+		}
+	}
+}
+
+func TestDiskEngineShouldKeepDataAcrossReopen(t *testing.T) {
+	dir := t.TempDir()
+
+	engine, err := OpenDiskEngine(dir)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening %v", err)
+	}
+
+	engine.Put("a", "1")
+	engine.Put("b", "2")
+	engine.Delete("b")
+
+	err = engine.Close()
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when closing %v", err)
+	}
+
+	reopened, err := OpenDiskEngine(dir)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when reopening %v", err)
+	}
+
+	defer reopened.Close()
+
+	val, ok, _ := reopened.Get("a")
+
+	if !ok || val != "1" {
+		t.Errorf("Expected a to be 1, got %s %t", val, ok)
+	}
+
+	if _, ok, _ := reopened.Get("b"); ok {
+		t.Errorf("Did not expect to find key b")
+	}
+}
+
+func TestOpenShouldRejectUnknownEngine(t *testing.T) {
+	_, err := Open(&Config{Name: "unknown"})
+
+	if err == nil {
+		t.Errorf("Expected an error when opening an unknown engine")
+	}
+}
+
+func TestEnginesShouldIterateFromAKeyWhileItIsWrittenTo(t *testing.T) {
+	for name, engine := range openEngines(t) {
+		for i := range 3 * iterateBatchSize {
+			engine.Put(fmt.Sprintf("key-%04d", i), "value")
+		}
+
+		start := iterateBatchSize / 2
+		seen := 0
+
+		err := engine.IterateFrom(fmt.Sprintf("key-%04d", start), func(key string, val string) bool {
+			if expected := fmt.Sprintf("key-%04d", start+seen); key != expected {
+				t.Errorf("%s: Expected %s, got %s", name, expected, key)
+				return false
+			}
+
+			// fn can write to the engine without blocking.
+			engine.Put("a-"+key, "value")
+			engine.Delete(key)
+
+			seen++
+
+			return true
+		})
+
+		if err != nil {
+			t.Fatalf("%s: Did not expect an error when iterating %v", name, err)
+		}
+
+		if seen != 3*iterateBatchSize-start {
+			t.Errorf("%s: Expected to see %d keys, got %d", name, 3*iterateBatchSize-start, seen)
+		}
+	}
+}
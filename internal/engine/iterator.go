@@ -0,0 +1,138 @@
+package engine
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// recordIterator walks records in ascending key order. next returns false
+// once there are none left.
+type recordIterator interface {
+	next() (record, bool, error)
+}
+
+type sliceIterator struct {
+	records []record
+}
+
+func (iterator *sliceIterator) next() (record, bool, error) {
+	if len(iterator.records) == 0 {
+		return record{}, false, nil
+	}
+
+	r := iterator.records[0]
+	iterator.records = iterator.records[1:]
+
+	return r, true, nil
+}
+
+// searchRecords finds key in records sorted by key.
+func searchRecords(records []record, key string) (record, bool) {
+	i := sort.Search(len(records), func(i int) bool { return records[i].key >= key })
+
+	if i < len(records) && records[i].key == key {
+		return records[i], true
+	}
+
+	return record{}, false
+}
+
+type mergeSource struct {
+	current  record
+	priority int // lower wins when two sources have the same key
+	iterator recordIterator
+}
+
+type mergeHeap []*mergeSource
+
+func (h mergeHeap) Len() int { return len(h) }
+
+func (h mergeHeap) Less(i, j int) bool {
+	if h[i].current.key != h[j].current.key {
+		return h[i].current.key < h[j].current.key
+	}
+
+	return h[i].priority < h[j].priority
+}
+
+func (h mergeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *mergeHeap) Push(x any) { *h = append(*h, x.(*mergeSource)) }
+
+func (h *mergeHeap) Pop() any {
+	old := *h
+	source := old[len(old)-1]
+	*h = old[:len(old)-1]
+
+	return source
+}
+
+// mergeIterator merges iterators that are ordered newest first, so when a key
+// is in more than one of them only the newest record is returned. Tombstones
+// are returned too, it is up to the caller whether to skip them.
+type mergeIterator struct {
+	sources mergeHeap
+	started bool
+	pending []recordIterator
+}
+
+func newMergeIterator(iterators []recordIterator) *mergeIterator {
+	return &mergeIterator{pending: iterators}
+}
+
+func (iterator *mergeIterator) advance(source *mergeSource) error {
+	r, ok, err := source.iterator.next()
+
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		source.current = r
+		heap.Push(&iterator.sources, source)
+	}
+
+	return nil
+}
+
+func (iterator *mergeIterator) next() (record, bool, error) {
+	if !iterator.started {
+		iterator.started = true
+
+		for priority, child := range iterator.pending {
+			err := iterator.advance(&mergeSource{priority: priority, iterator: child})
+
+			if err != nil {
+				return record{}, false, err
+			}
+		}
+
+		iterator.pending = nil
+	}
+
+	if len(iterator.sources) == 0 {
+		return record{}, false, nil
+	}
+
+	newest := heap.Pop(&iterator.sources).(*mergeSource)
+	r := newest.current
+
+	err := iterator.advance(newest)
+
+	if err != nil {
+		return record{}, false, err
+	}
+
+	// drop the older records for the same key.
+	for len(iterator.sources) > 0 && iterator.sources[0].current.key == r.key {
+		older := heap.Pop(&iterator.sources).(*mergeSource)
+
+		err = iterator.advance(older)
+
+		if err != nil {
+			return record{}, false, err
+		}
+	}
+
+	return r, true, nil
+}
@@ -0,0 +1,124 @@
+package engine
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// iterateBatchSize is how many keys an engine reads under its lock at a time
+// while iterating, so fn can be called without holding it.
+const iterateBatchSize = 128
+
+type keyListNode struct {
+	key  string
+	next []*keyListNode
+}
+
+// keyList keeps the keys of an engine that stores them in a map in order, in
+// a skip list like the memtable's, so iterating from a key doesn't have to
+// sort every key first. It is not safe for concurrent use.
+type keyList struct {
+	head   *keyListNode
+	height int
+	rand   *rand.Rand
+}
+
+func newKeyList() *keyList {
+	return &keyList{
+		head:   &keyListNode{next: make([]*keyListNode, maxSkipListHeight)},
+		height: 1,
+		rand:   rand.New(rand.NewSource(rand.Int63())),
+	}
+}
+
+// findPath returns the last node before key at every level.
+func (list *keyList) findPath(key string) [maxSkipListHeight]*keyListNode {
+	var path [maxSkipListHeight]*keyListNode
+
+	node := list.head
+
+	for level := list.height - 1; level >= 0; level-- {
+		for node.next[level] != nil && node.next[level].key < key {
+			node = node.next[level]
+		}
+
+		path[level] = node
+	}
+
+	return path
+}
+
+// add does nothing if key is already in the list.
+func (list *keyList) add(key string) {
+	path := list.findPath(key)
+
+	if existing := path[0].next[0]; existing != nil && existing.key == key {
+		return
+	}
+
+	height := 1
+
+	for height < maxSkipListHeight && list.rand.Intn(4) == 0 {
+		height++
+	}
+
+	for level := list.height; level < height; level++ {
+		path[level] = list.head
+	}
+
+	list.height = max(list.height, height)
+
+	node := &keyListNode{key: key, next: make([]*keyListNode, height)}
+
+	for level := range height {
+		node.next[level] = path[level].next[level]
+		path[level].next[level] = node
+	}
+}
+
+func (list *keyList) remove(key string) {
+	path := list.findPath(key)
+	node := path[0].next[0]
+
+	if node == nil || node.key != key {
+		return
+	}
+
+	for level := range node.next {
+		path[level].next[level] = node.next[level]
+	}
+}
+
+// from returns up to n keys in order, starting at the first one that isn't
+// before start.
+func (list *keyList) from(start string, n int) []string {
+	keys := []string{}
+
+	for node := list.findPath(start)[0].next[0]; node != nil && len(keys) < n; node = node.next[0] {
+		keys = append(keys, node.key)
+	}
+
+	return keys
+}
+
+// all returns every key in order.
+func (list *keyList) all() []string {
+	keys := []string{}
+
+	for node := list.head.next[0]; node != nil; node = node.next[0] {
+		keys = append(keys, node.key)
+	}
+
+	return keys
+}
+
+// after is the first key that sorts after key, to carry on iterating from
+// once key has been seen.
+func after(key string) string {
+	return key + "\x00"
+}
+
+// searchKeys returns the keys in sorted keys that aren't before start.
+func searchKeys(keys []string, start string) []string {
+	return keys[sort.SearchStrings(keys, start):]
+}
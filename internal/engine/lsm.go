@@ -0,0 +1,533 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+const (
+	DefaultMemtableSize        = 4 * 1024 * 1024
+	DefaultTableSize           = 2 * 1024 * 1024
+	DefaultL0CompactionTrigger = 4
+	DefaultLevelSizeBase       = 10 * 1024 * 1024
+
+	lsmLevels           = 7
+	levelSizeMultiplier = 10
+	lsmManifestFileName = "MANIFEST"
+	sstableSuffix       = ".sst"
+)
+
+type LSMConfig struct {
+	MemtableSize        int64 // the memtable is flushed to an SSTable once it reaches this size
+	TableSize           int64 // compaction starts a new SSTable once the one it is writing reaches this size
+	L0CompactionTrigger int   // how many SSTables level 0 can have before they are compacted into level 1
+	LevelSizeBase       int64 // how big level 1 can get, every level after it can be 10 times bigger
+}
+
+// lsmManifest lists the live SSTables in each level. Level 0 tables come
+// straight from memtable flushes and can overlap, newest first. Every other
+// level is sorted by key with no overlap.
+type lsmManifest struct {
+	NextFile uint64        `json:"nextFile"`
+	Levels   [][]tableMeta `json:"levels"`
+}
+
+// LSMEngine is a log structured merge tree. Writes go to an in memory
+// memtable, which is covered by the store's WAL, and is flushed to an
+// immutable SSTable once it is big enough. A background goroutine flushes
+// memtables and compacts SSTables into deeper levels so reads only have to
+// check a few files.
+type LSMEngine struct {
+	sync.RWMutex
+	changed  *sync.Cond // broadcast after every flush and compaction
+	dir      string
+	config   LSMConfig
+	mem      *memtable
+	imm      *memtable // frozen and waiting to be flushed, if not nil
+	levels   [lsmLevels][]*sstable
+	nextFile uint64
+	err      error // set when a background flush or compaction fails
+	work     chan struct{}
+	closed   chan struct{}
+	done     chan struct{}
+}
+
+// OpenLSMEngine opens the engine in dir, creating it if needed. config can be
+// nil to use the defaults. SSTables that are not in the manifest are left over
+// from a flush or compaction that didn't finish and are deleted.
+func OpenLSMEngine(dir string, config *LSMConfig) (*LSMEngine, error) {
+	err := os.MkdirAll(dir, 0755)
+
+	if err != nil {
+		return nil, err
+	}
+
+	engine := &LSMEngine{
+		dir:    dir,
+		mem:    newMemtable(),
+		work:   make(chan struct{}, 1),
+		closed: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	engine.changed = sync.NewCond(engine)
+
+	if config != nil {
+		engine.config = *config
+	}
+
+	if engine.config.MemtableSize <= 0 {
+		engine.config.MemtableSize = DefaultMemtableSize
+	}
+
+	if engine.config.TableSize <= 0 {
+		engine.config.TableSize = DefaultTableSize
+	}
+
+	if engine.config.L0CompactionTrigger <= 0 {
+		engine.config.L0CompactionTrigger = DefaultL0CompactionTrigger
+	}
+
+	if engine.config.LevelSizeBase <= 0 {
+		engine.config.LevelSizeBase = DefaultLevelSizeBase
+	}
+
+	err = engine.load()
+
+	if err != nil {
+		engine.closeTables()
+		return nil, err
+	}
+
+	go engine.background()
+
+	// the last run may have stopped with compaction work left to do.
+	engine.schedule()
+
+	return engine, nil
+}
+
+func (engine *LSMEngine) tableFileName(number uint64) string {
+	return filepath.Join(engine.dir, fmt.Sprintf("%012d%s", number, sstableSuffix))
+}
+
+func (engine *LSMEngine) load() error {
+	contents, err := os.ReadFile(filepath.Join(engine.dir, lsmManifestFileName))
+
+	m := lsmManifest{NextFile: 1}
+
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if err == nil {
+		err = json.Unmarshal(contents, &m)
+
+		if err != nil {
+			return fmt.Errorf("invalid LSM manifest: %w", err)
+		}
+	}
+
+	engine.nextFile = m.NextFile
+	live := make(map[string]bool)
+
+	for level, metas := range m.Levels {
+		if level >= lsmLevels {
+			return fmt.Errorf("LSM manifest has %d levels, expected at most %d", len(m.Levels), lsmLevels)
+		}
+
+		for _, meta := range metas {
+			path := engine.tableFileName(meta.Number)
+
+			table, err := openSSTable(path, meta)
+
+			if err != nil {
+				return err
+			}
+
+			engine.levels[level] = append(engine.levels[level], table)
+			live[path] = true
+		}
+	}
+
+	matches, err := filepath.Glob(filepath.Join(engine.dir, "*"+sstableSuffix))
+
+	if err != nil {
+		return err
+	}
+
+	for _, match := range matches {
+		if live[match] {
+			continue
+		}
+
+		log.Printf("Removing SSTable %s that is not in the LSM manifest", match)
+
+		err = os.Remove(match)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeManifest must be called with the lock held.
+func (engine *LSMEngine) writeManifest() error {
+	m := lsmManifest{NextFile: engine.nextFile, Levels: make([][]tableMeta, lsmLevels)}
+
+	for level, tables := range engine.levels {
+		m.Levels[level] = []tableMeta{}
+
+		for _, table := range tables {
+			m.Levels[level] = append(m.Levels[level], table.meta)
+		}
+	}
+
+	contents, err := json.Marshal(&m)
+
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(engine.dir, lsmManifestFileName)
+	tmpPath := path + ".tmp"
+
+	err = os.WriteFile(tmpPath, contents, 0666)
+
+	if err == nil {
+		err = syncFile(tmpPath)
+	}
+
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return syncFile(engine.dir)
+}
+
+func (engine *LSMEngine) Get(key string) (string, bool, error) {
+	engine.RLock()
+
+	for _, mem := range []*memtable{engine.mem, engine.imm} {
+		if mem == nil {
+			continue
+		}
+
+		if r, ok := mem.get(key); ok {
+			engine.RUnlock()
+
+			return r.val, !r.deleted, nil
+		}
+	}
+
+	tables := engine.tablesFor(key)
+
+	engine.RUnlock()
+
+	defer func() {
+		for _, table := range tables {
+			table.unref()
+		}
+	}()
+
+	for _, table := range tables {
+		r, ok, err := table.get(key)
+
+		if err != nil {
+			return "", false, err
+		}
+
+		if ok {
+			return r.val, !r.deleted, nil
+		}
+	}
+
+	return "", false, nil
+}
+
+// tablesFor returns every table that might have key, newest first, with a
+// reference held on each. It must be called with the lock held.
+func (engine *LSMEngine) tablesFor(key string) []*sstable {
+	tables := []*sstable{}
+
+	for level, levelTables := range engine.levels {
+		for _, table := range levelTables {
+			if !table.meta.overlaps(key, key) {
+				continue
+			}
+
+			table.ref()
+			tables = append(tables, table)
+
+			// tables below level 0 don't overlap, so only one can have the key.
+			if level > 0 {
+				break
+			}
+		}
+	}
+
+	return tables
+}
+
+func (engine *LSMEngine) Put(key string, val string) error {
+	return engine.write(record{key: key, val: val})
+}
+
+func (engine *LSMEngine) Delete(key string) error {
+	return engine.write(record{key: key, deleted: true})
+}
+
+func (engine *LSMEngine) write(r record) error {
+	engine.Lock()
+	defer engine.Unlock()
+
+	if engine.err != nil {
+		return engine.err
+	}
+
+	engine.mem.put(r)
+
+	if engine.mem.size >= engine.config.MemtableSize {
+		return engine.freeze()
+	}
+
+	return nil
+}
+
+// freeze hands the memtable to the background goroutine to flush. If the
+// previous memtable is still being flushed, writes stall until it is done.
+// It must be called with the lock held.
+func (engine *LSMEngine) freeze() error {
+	for engine.imm != nil && engine.err == nil {
+		engine.changed.Wait()
+	}
+
+	if engine.err != nil {
+		return engine.err
+	}
+
+	engine.imm = engine.mem
+	engine.mem = newMemtable()
+
+	engine.schedule()
+
+	return nil
+}
+
+func (engine *LSMEngine) schedule() {
+	select {
+	case engine.work <- struct{}{}:
+	default:
+	}
+}
+
+func (engine *LSMEngine) Iterate(fn func(key string, val string) bool) error {
+	return engine.IterateFrom("", fn)
+}
+
+// IterateFrom works on a snapshot that only copies the part of the memtable
+// from start, so fn is free to write to the engine.
+func (engine *LSMEngine) IterateFrom(start string, fn func(key string, val string) bool) error {
+	snapshot := engine.snapshotFrom(start)
+
+	defer snapshot.Close()
+
+	return snapshot.IterateFrom(start, fn)
+}
+
+// Snapshot copies the memtable and holds a reference on every SSTable, so
+// compaction can't delete them while the snapshot is open.
+func (engine *LSMEngine) Snapshot() (Snapshot, error) {
+	return engine.snapshotFrom(""), nil
+}
+
+// snapshotFrom is a snapshot that can only be read from start on.
+func (engine *LSMEngine) snapshotFrom(start string) *lsmSnapshot {
+	engine.RLock()
+	defer engine.RUnlock()
+
+	snapshot := &lsmSnapshot{
+		mem: engine.mem.recordsFrom(start),
+		imm: engine.imm,
+	}
+
+	for _, levelTables := range engine.levels {
+		for _, table := range levelTables {
+			table.ref()
+			snapshot.tables = append(snapshot.tables, table)
+		}
+	}
+
+	return snapshot
+}
+
+// Sync flushes the memtable and waits until it is on disk.
+func (engine *LSMEngine) Sync() error {
+	engine.Lock()
+	defer engine.Unlock()
+
+	if engine.mem.count > 0 {
+		err := engine.freeze()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for engine.imm != nil && engine.err == nil {
+		engine.changed.Wait()
+	}
+
+	return engine.err
+}
+
+// Close flushes the memtable and stops background work.
+func (engine *LSMEngine) Close() error {
+	err := engine.Sync()
+
+	close(engine.closed)
+	<-engine.done
+
+	engine.closeTables()
+
+	return err
+}
+
+func (engine *LSMEngine) closeTables() {
+	for level, tables := range engine.levels {
+		for _, table := range tables {
+			table.unref()
+		}
+
+		engine.levels[level] = nil
+	}
+}
+
+// levelSize is the total size of the tables in a level. It must be called
+// with the lock held.
+func (engine *LSMEngine) levelSize(level int) int64 {
+	size := int64(0)
+
+	for _, table := range engine.levels[level] {
+		size += table.meta.Size
+	}
+
+	return size
+}
+
+func (engine *LSMEngine) maxLevelSize(level int) int64 {
+	size := engine.config.LevelSizeBase
+
+	for range level - 1 {
+		size *= levelSizeMultiplier
+	}
+
+	return size
+}
+
+// sortLevel keeps a level below 0 in key order.
+func sortLevel(tables []*sstable) {
+	sort.Slice(tables, func(i, j int) bool {
+		return string(tables[i].meta.Smallest) < string(tables[j].meta.Smallest)
+	})
+}
+
+type lsmSnapshot struct {
+	mem    []record
+	imm    *memtable
+	tables []*sstable // newest first
+}
+
+func (snapshot *lsmSnapshot) Get(key string) (string, bool, error) {
+	if r, ok := searchRecords(snapshot.mem, key); ok {
+		return r.val, !r.deleted, nil
+	}
+
+	if snapshot.imm != nil {
+		if r, ok := snapshot.imm.get(key); ok {
+			return r.val, !r.deleted, nil
+		}
+	}
+
+	for _, table := range snapshot.tables {
+		r, ok, err := table.get(key)
+
+		if err != nil {
+			return "", false, err
+		}
+
+		if ok {
+			return r.val, !r.deleted, nil
+		}
+	}
+
+	return "", false, nil
+}
+
+func (snapshot *lsmSnapshot) Iterate(fn func(key string, val string) bool) error {
+	return snapshot.IterateFrom("", fn)
+}
+
+// IterateFrom seeks every table to the block start is in with its index, and
+// skips the tables that end before it.
+func (snapshot *lsmSnapshot) IterateFrom(start string, fn func(key string, val string) bool) error {
+	mem := snapshot.mem[sort.Search(len(snapshot.mem), func(i int) bool { return snapshot.mem[i].key >= start }):]
+	iterators := []recordIterator{&sliceIterator{records: mem}}
+
+	if snapshot.imm != nil {
+		iterators = append(iterators, snapshot.imm.iteratorFrom(start))
+	}
+
+	for _, table := range snapshot.tables {
+		if string(table.meta.Largest) < start {
+			continue
+		}
+
+		iterators = append(iterators, table.iteratorFrom(start))
+	}
+
+	merged := newMergeIterator(iterators)
+
+	for {
+		r, ok, err := merged.next()
+
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return nil
+		}
+
+		if r.deleted {
+			continue
+		}
+
+		if !fn(r.key, r.val) {
+			return nil
+		}
+	}
+}
+
+func (snapshot *lsmSnapshot) Close() error {
+	for _, table := range snapshot.tables {
+		table.unref()
+	}
+
+	snapshot.tables = nil
+
+	return nil
+}
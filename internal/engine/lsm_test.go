@@ -0,0 +1,286 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var smallLSMConfig = &LSMConfig{
+	MemtableSize:        1024,
+	TableSize:           2048,
+	L0CompactionTrigger: 2,
+	LevelSizeBase:       4096,
+}
+
+func checkEngine(t *testing.T, engine Engine, expected map[string]string) {
+	for key, val := range expected {
+		actual, ok, err := engine.Get(key)
+
+		if err != nil {
+			t.Fatalf("Did not expect an error when getting %s %v", key, err)
+		}
+
+		if !ok || actual != val {
+			t.Errorf("Expected %s to be %s, got %s %t", key, val, actual, ok)
+		}
+	}
+
+	count := 0
+	previous := ""
+
+	err := engine.Iterate(func(key string, val string) bool {
+		if key <= previous && count > 0 {
+			t.Errorf("Expected keys in order, got %s after %s", key, previous)
+		}
+
+		if expected[key] != val {
+			t.Errorf("Expected %s to be %s when iterating, got %s", key, expected[key], val)
+		}
+
+		previous = key
+		count++
+
+		return true
+	})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when iterating %v", err)
+	}
+
+	if count != len(expected) {
+		t.Errorf("Expected %d keys when iterating, got %d", len(expected), count)
+	}
+}
+
+func TestLSMEngineShouldFlushAndCompact(t *testing.T) {
+	dir := t.TempDir()
+
+	engine, err := OpenLSMEngine(dir, smallLSMConfig)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening %v", err)
+	}
+
+	expected := make(map[string]string)
+
+	for round := range 5 {
+		for i := range 200 {
+			key := fmt.Sprintf("key-%04d", i)
+
+			if i%7 == round {
+				engine.Delete(key)
+				delete(expected, key)
+
+				continue
+			}
+
+			val := fmt.Sprintf("value-%d-%d", round, i)
+
+			err := engine.Put(key, val)
+
+			if err != nil {
+				t.Fatalf("Did not expect an error when putting %v", err)
+			}
+
+			expected[key] = val
+		}
+	}
+
+	err = engine.Sync()
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when syncing %v", err)
+	}
+
+	engine.RLock()
+	deepest := 0
+
+	for level, tables := range engine.levels {
+		if len(tables) > 0 {
+			deepest = level
+		}
+	}
+
+	engine.RUnlock()
+
+	if deepest == 0 {
+		t.Errorf("Expected compaction to move tables below level 0")
+	}
+
+	checkEngine(t, engine, expected)
+
+	err = engine.Close()
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when closing %v", err)
+	}
+
+	reopened, err := OpenLSMEngine(dir, smallLSMConfig)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when reopening %v", err)
+	}
+
+	defer reopened.Close()
+
+	checkEngine(t, reopened, expected)
+}
+
+func TestLSMSnapshotShouldOutliveCompaction(t *testing.T) {
+	engine, err := OpenLSMEngine(t.TempDir(), smallLSMConfig)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening %v", err)
+	}
+
+	defer engine.Close()
+
+	for i := range 100 {
+		engine.Put(fmt.Sprintf("key-%04d", i), "old")
+	}
+
+	engine.Sync()
+
+	snapshot, err := engine.Snapshot()
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when taking a snapshot %v", err)
+	}
+
+	defer snapshot.Close()
+
+	// rewrite everything so the tables the snapshot holds get compacted away.
+	for range 3 {
+		for i := range 100 {
+			engine.Put(fmt.Sprintf("key-%04d", i), "new")
+		}
+
+		engine.Sync()
+	}
+
+	count := 0
+
+	err = snapshot.Iterate(func(key string, val string) bool {
+		if val != "old" {
+			t.Errorf("Expected %s to be old in the snapshot, got %s", key, val)
+		}
+
+		count++
+
+		return true
+	})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when iterating the snapshot %v", err)
+	}
+
+	if count != 100 {
+		t.Errorf("Expected 100 keys in the snapshot, got %d", count)
+	}
+}
+
+func TestLSMEngineShouldRemoveOrphanedTables(t *testing.T) {
+	dir := t.TempDir()
+	orphan := filepath.Join(dir, "000000000099.sst")
+
+	os.WriteFile(orphan, []byte("partial"), 0666)
+
+	engine, err := OpenLSMEngine(dir, nil)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening %v", err)
+	}
+
+	defer engine.Close()
+
+	if _, err := os.Stat(orphan); !os.IsNotExist(err) {
+		t.Errorf("Expected orphaned SSTable to be removed, got %v", err)
+	}
+}
+
+func TestBloomFilterShouldHaveNoFalseNegatives(t *testing.T) {
+	filter := newBloomFilter(1000)
+
+	for i := range 1000 {
+		filter.add(fmt.Sprintf("key-%d", i))
+	}
+
+	decoded, err := decodeBloomFilter(filter.encode())
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when decoding %v", err)
+	}
+
+	falsePositives := 0
+
+	for i := range 1000 {
+		if !decoded.mayContain(fmt.Sprintf("key-%d", i)) {
+			t.Fatalf("Expected key-%d to be in the filter", i)
+		}
+
+		if decoded.mayContain(fmt.Sprintf("other-%d", i)) {
+			falsePositives++
+		}
+	}
+
+	if falsePositives > 50 {
+		t.Errorf("Expected about 1%% false positives, got %d in 1000", falsePositives)
+	}
+}
+
+func TestSSTableShouldSeekPastTheBlocksBeforeStart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "1.sst")
+
+	writer, err := createSSTable(path, 1)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when creating a table %v", err)
+	}
+
+	for i := range 1000 {
+		writer.add(record{key: fmt.Sprintf("key-%04d", i), val: fmt.Sprintf("value-%d", i)})
+	}
+
+	meta, err := writer.finish()
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when writing a table %v", err)
+	}
+
+	// break the first block, which a seek past it should never read.
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening the table file %v", err)
+	}
+
+	file.WriteAt([]byte{0xff, 0xff}, 0)
+	file.Close()
+
+	table, err := openSSTable(path, *meta)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening a table %v", err)
+	}
+
+	defer table.unref()
+
+	if len(table.index) < 3 {
+		t.Fatalf("Expected the table to have a few blocks, got %d", len(table.index))
+	}
+
+	_, _, err = table.iterator().next()
+
+	if err == nil {
+		t.Fatalf("Expected reading the broken first block to fail")
+	}
+
+	start := table.index[1].lastKey
+	r, ok, err := table.iteratorFrom(start).next()
+
+	if err != nil || !ok || r.key != start {
+		t.Errorf("Expected iterating from %s to start there without reading the first block, got %v %t %v", start, r, ok, err)
+	}
+}
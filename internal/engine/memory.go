@@ -0,0 +1,151 @@
+package engine
+
+import (
+	"sort"
+	"sync"
+)
+
+// MemoryEngine keeps everything in a map, and the keys in order in a skip
+// list. It relies on the store's WAL and snapshots for durability.
+type MemoryEngine struct {
+	sync.RWMutex
+	data map[string]string
+	keys *keyList
+}
+
+func NewMemoryEngine() *MemoryEngine {
+	return &MemoryEngine{
+		data: make(map[string]string),
+		keys: newKeyList(),
+	}
+}
+
+func (engine *MemoryEngine) Get(key string) (string, bool, error) {
+	engine.RLock()
+	defer engine.RUnlock()
+
+	val, ok := engine.data[key]
+
+	return val, ok, nil
+}
+
+func (engine *MemoryEngine) Put(key string, val string) error {
+	engine.Lock()
+	defer engine.Unlock()
+
+	if _, ok := engine.data[key]; !ok {
+		engine.keys.add(key)
+	}
+
+	engine.data[key] = val
+
+	return nil
+}
+
+func (engine *MemoryEngine) Delete(key string) error {
+	engine.Lock()
+	defer engine.Unlock()
+
+	if _, ok := engine.data[key]; ok {
+		engine.keys.remove(key)
+	}
+
+	delete(engine.data, key)
+
+	return nil
+}
+
+// Iterate only holds the engine's lock while it reads a batch of keys, so fn
+// is free to write to the engine. A key written while it runs may or may not
+// be seen.
+func (engine *MemoryEngine) Iterate(fn func(key string, val string) bool) error {
+	return engine.IterateFrom("", fn)
+}
+
+func (engine *MemoryEngine) IterateFrom(start string, fn func(key string, val string) bool) error {
+	for {
+		engine.RLock()
+
+		keys := engine.keys.from(start, iterateBatchSize)
+		vals := make([]string, len(keys))
+
+		for i, key := range keys {
+			vals[i] = engine.data[key]
+		}
+
+		engine.RUnlock()
+
+		for i, key := range keys {
+			if !fn(key, vals[i]) {
+				return nil
+			}
+		}
+
+		if len(keys) < iterateBatchSize {
+			return nil
+		}
+
+		start = after(keys[len(keys)-1])
+	}
+}
+
+// Snapshot copies the map, so it holds the engine's lock for as long as that
+// takes.
+func (engine *MemoryEngine) Snapshot() (Snapshot, error) {
+	engine.RLock()
+	defer engine.RUnlock()
+
+	data := make(map[string]string, len(engine.data))
+
+	for key, val := range engine.data {
+		data[key] = val
+	}
+
+	return &memorySnapshot{data: data, keys: engine.keys.all()}, nil
+}
+
+func (engine *MemoryEngine) Close() error {
+	return nil
+}
+
+// memorySnapshot is a private copy of some data, so it needs no locking.
+type memorySnapshot struct {
+	data map[string]string
+	keys []string // sorted, or nil until they are first needed
+}
+
+func (snapshot *memorySnapshot) Get(key string) (string, bool, error) {
+	val, ok := snapshot.data[key]
+
+	return val, ok, nil
+}
+
+func (snapshot *memorySnapshot) Iterate(fn func(key string, val string) bool) error {
+	return snapshot.IterateFrom("", fn)
+}
+
+// IterateFrom sorts the keys the first time it's called, and only searches
+// them after that.
+func (snapshot *memorySnapshot) IterateFrom(start string, fn func(key string, val string) bool) error {
+	if snapshot.keys == nil {
+		snapshot.keys = make([]string, 0, len(snapshot.data))
+
+		for key := range snapshot.data {
+			snapshot.keys = append(snapshot.keys, key)
+		}
+
+		sort.Strings(snapshot.keys)
+	}
+
+	for _, key := range searchKeys(snapshot.keys, start) {
+		if !fn(key, snapshot.data[key]) {
+			break
+		}
+	}
+
+	return nil
+}
+
+func (snapshot *memorySnapshot) Close() error {
+	return nil
+}
@@ -0,0 +1,139 @@
+package engine
+
+import (
+	"math/rand"
+)
+
+// record is a key as the LSM engine stores it. Deletes are kept as tombstones
+// until compaction can prove there is no older value left for them to hide.
+type record struct {
+	key     string
+	val     string
+	deleted bool
+}
+
+const maxSkipListHeight = 12
+
+type skipListNode struct {
+	record
+	next []*skipListNode
+}
+
+// memtable holds the newest writes in a skip list, sorted by key. It is not
+// safe for concurrent use. The LSM engine only writes to it under its lock,
+// and once it is frozen for flushing it is never written again, so readers
+// can use it without locking.
+type memtable struct {
+	head   *skipListNode
+	height int
+	size   int64 // roughly how many bytes it would take on disk
+	count  int
+	rand   *rand.Rand
+}
+
+func newMemtable() *memtable {
+	return &memtable{
+		head:   &skipListNode{next: make([]*skipListNode, maxSkipListHeight)},
+		height: 1,
+		rand:   rand.New(rand.NewSource(rand.Int63())),
+	}
+}
+
+// findPath returns the last node before key at every level.
+func (mem *memtable) findPath(key string) [maxSkipListHeight]*skipListNode {
+	var path [maxSkipListHeight]*skipListNode
+
+	node := mem.head
+
+	for level := mem.height - 1; level >= 0; level-- {
+		for node.next[level] != nil && node.next[level].key < key {
+			node = node.next[level]
+		}
+
+		path[level] = node
+	}
+
+	return path
+}
+
+func (mem *memtable) put(r record) {
+	path := mem.findPath(r.key)
+
+	if existing := path[0].next[0]; existing != nil && existing.key == r.key {
+		mem.size += int64(len(r.val) - len(existing.val))
+		existing.record = r
+
+		return
+	}
+
+	height := 1
+
+	// each level has a quarter of the nodes of the level below it.
+	for height < maxSkipListHeight && mem.rand.Intn(4) == 0 {
+		height++
+	}
+
+	for level := mem.height; level < height; level++ {
+		path[level] = mem.head
+	}
+
+	mem.height = max(mem.height, height)
+
+	node := &skipListNode{record: r, next: make([]*skipListNode, height)}
+
+	for level := range height {
+		node.next[level] = path[level].next[level]
+		path[level].next[level] = node
+	}
+
+	mem.size += int64(len(r.key) + len(r.val) + 16)
+	mem.count++
+}
+
+func (mem *memtable) get(key string) (record, bool) {
+	node := mem.findPath(key)[0].next[0]
+
+	if node == nil || node.key != key {
+		return record{}, false
+	}
+
+	return node.record, true
+}
+
+// records copies every record out in key order.
+func (mem *memtable) records() []record {
+	return mem.recordsFrom("")
+}
+
+// recordsFrom copies out the records that aren't before start in key order.
+func (mem *memtable) recordsFrom(start string) []record {
+	records := []record{}
+
+	for node := mem.findPath(start)[0].next[0]; node != nil; node = node.next[0] {
+		records = append(records, node.record)
+	}
+
+	return records
+}
+
+func (mem *memtable) iterator() recordIterator {
+	return mem.iteratorFrom("")
+}
+
+func (mem *memtable) iteratorFrom(start string) recordIterator {
+	return &memtableIterator{node: mem.findPath(start)[0]}
+}
+
+type memtableIterator struct {
+	node *skipListNode
+}
+
+func (iterator *memtableIterator) next() (record, bool, error) {
+	if iterator.node == nil || iterator.node.next[0] == nil {
+		return record{}, false, nil
+	}
+
+	iterator.node = iterator.node.next[0]
+
+	return iterator.node.record, true, nil
+}
@@ -0,0 +1,486 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"sort"
+	"sync/atomic"
+)
+
+var sstableMagic = [4]byte{'G', 'K', 'S', 'T'}
+
+const (
+	sstableVersion    = 1
+	sstableBlockSize  = 4096
+	sstableFooterSize = 40
+	tombstoneFlag     = 1
+)
+
+// An SSTable is an immutable file of records sorted by key.
+//
+// | Section      | Contents                                                               |
+// | ------------ | ---------------------------------------------------------------------- |
+// | Data blocks  | Records, each block about 4KB and followed by a CRC32 of the block     |
+// | Index        | For each block, its last key, offset and length, followed by a CRC32   |
+// | Bloom filter | The keys in the table, followed by a CRC32                             |
+// | Footer       | Where the index and bloom filter are, the record count, magic, version |
+//
+// A record is a uvarint key length, a uvarint value length, a flags byte, then
+// the key and value bytes. Index entries are a uvarint key length, the key, an
+// 8 byte offset and a 4 byte length. All fixed size integers are little endian.
+type sstableFooter struct {
+	IndexOffset uint64
+	IndexLength uint32
+	BloomOffset uint64
+	BloomLength uint32
+	RecordCount uint64
+	Magic       [4]byte
+	Version     uint32
+}
+
+// tableMeta is what the LSM manifest keeps about each table. Keys are byte
+// slices so they survive the trip through JSON whatever bytes they contain.
+type tableMeta struct {
+	Number   uint64 `json:"number"`
+	Smallest []byte `json:"smallest"`
+	Largest  []byte `json:"largest"`
+	Size     int64  `json:"size"`
+}
+
+func (meta *tableMeta) overlaps(smallest string, largest string) bool {
+	return string(meta.Largest) >= smallest && string(meta.Smallest) <= largest
+}
+
+type indexEntry struct {
+	lastKey string
+	offset  uint64
+	length  uint32
+}
+
+type sstableWriter struct {
+	file    *os.File
+	buf     *bufio.Writer
+	offset  uint64
+	block   bytes.Buffer
+	lastKey string
+	index   []indexEntry
+	keys    []string
+	meta    tableMeta
+}
+
+func createSSTable(path string, number uint64) (*sstableWriter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0666)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &sstableWriter{
+		file: file,
+		buf:  bufio.NewWriter(file),
+		meta: tableMeta{Number: number},
+	}, nil
+}
+
+// add appends a record. Records have to be added in ascending key order.
+func (writer *sstableWriter) add(r record) error {
+	if len(writer.keys) == 0 {
+		writer.meta.Smallest = []byte(r.key)
+	}
+
+	flags := byte(0)
+
+	if r.deleted {
+		flags = tombstoneFlag
+	}
+
+	writer.block.Write(binary.AppendUvarint(nil, uint64(len(r.key))))
+	writer.block.Write(binary.AppendUvarint(nil, uint64(len(r.val))))
+	writer.block.WriteByte(flags)
+	writer.block.WriteString(r.key)
+	writer.block.WriteString(r.val)
+
+	writer.lastKey = r.key
+	writer.keys = append(writer.keys, r.key)
+
+	if writer.block.Len() >= sstableBlockSize {
+		return writer.flushBlock()
+	}
+
+	return nil
+}
+
+// size is how big the file will be, not counting the index and filter.
+func (writer *sstableWriter) size() int64 {
+	return int64(writer.offset) + int64(writer.block.Len())
+}
+
+func (writer *sstableWriter) flushBlock() error {
+	if writer.block.Len() == 0 {
+		return nil
+	}
+
+	writer.block.Write(binary.LittleEndian.AppendUint32(nil, crc32.ChecksumIEEE(writer.block.Bytes())))
+
+	writer.index = append(writer.index, indexEntry{
+		lastKey: writer.lastKey,
+		offset:  writer.offset,
+		length:  uint32(writer.block.Len()),
+	})
+
+	err := writer.write(writer.block.Bytes())
+
+	writer.block.Reset()
+
+	return err
+}
+
+func (writer *sstableWriter) write(buf []byte) error {
+	_, err := writer.buf.Write(buf)
+
+	writer.offset += uint64(len(buf))
+
+	return err
+}
+
+// finish writes the index, filter and footer and syncs the file.
+func (writer *sstableWriter) finish() (*tableMeta, error) {
+	err := writer.flushBlock()
+
+	if err != nil {
+		writer.file.Close()
+		return nil, err
+	}
+
+	writer.meta.Largest = []byte(writer.lastKey)
+
+	index := []byte{}
+
+	for _, entry := range writer.index {
+		index = binary.AppendUvarint(index, uint64(len(entry.lastKey)))
+		index = append(index, entry.lastKey...)
+		index = binary.LittleEndian.AppendUint64(index, entry.offset)
+		index = binary.LittleEndian.AppendUint32(index, entry.length)
+	}
+
+	index = binary.LittleEndian.AppendUint32(index, crc32.ChecksumIEEE(index))
+
+	filter := newBloomFilter(len(writer.keys))
+
+	for _, key := range writer.keys {
+		filter.add(key)
+	}
+
+	bloom := filter.encode()
+	bloom = binary.LittleEndian.AppendUint32(bloom, crc32.ChecksumIEEE(bloom))
+
+	footer := sstableFooter{
+		IndexOffset: writer.offset,
+		IndexLength: uint32(len(index)),
+		BloomOffset: writer.offset + uint64(len(index)),
+		BloomLength: uint32(len(bloom)),
+		RecordCount: uint64(len(writer.keys)),
+		Magic:       sstableMagic,
+		Version:     sstableVersion,
+	}
+
+	err = writer.write(index)
+
+	if err == nil {
+		err = writer.write(bloom)
+	}
+
+	if err == nil {
+		err = binary.Write(writer.buf, binary.LittleEndian, &footer)
+		writer.offset += sstableFooterSize
+	}
+
+	if err == nil {
+		err = writer.buf.Flush()
+	}
+
+	if err == nil {
+		err = writer.file.Sync()
+	}
+
+	closeErr := writer.file.Close()
+
+	if err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	writer.meta.Size = int64(writer.offset)
+
+	return &writer.meta, nil
+}
+
+// abort gives up on a table that is part way through being written.
+func (writer *sstableWriter) abort() {
+	writer.file.Close()
+	os.Remove(writer.file.Name())
+}
+
+// sstable is an open SSTable. Its index and bloom filter are kept in memory,
+// data blocks are read from disk as they are needed.
+//
+// The engine holds one reference for as long as the table is live, and every
+// read holds another while it uses the file. The file is closed and deleted
+// once a compaction has replaced the table and the last reader is done.
+type sstable struct {
+	meta     tableMeta
+	path     string
+	file     *os.File
+	index    []indexEntry
+	bloom    *bloomFilter
+	refs     atomic.Int32
+	obsolete atomic.Bool
+}
+
+func openSSTable(path string, meta tableMeta) (*sstable, error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	table := &sstable{meta: meta, path: path, file: file}
+
+	err = table.load()
+
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("SSTable %s: %w", path, err)
+	}
+
+	table.refs.Store(1)
+
+	return table, nil
+}
+
+func (table *sstable) load() error {
+	info, err := table.file.Stat()
+
+	if err != nil {
+		return err
+	}
+
+	if info.Size() < sstableFooterSize {
+		return errors.New("file is too short")
+	}
+
+	footerBuf := make([]byte, sstableFooterSize)
+
+	_, err = table.file.ReadAt(footerBuf, info.Size()-sstableFooterSize)
+
+	if err != nil {
+		return err
+	}
+
+	var footer sstableFooter
+
+	binary.Read(bytes.NewReader(footerBuf), binary.LittleEndian, &footer)
+
+	if footer.Magic != sstableMagic {
+		return errors.New("invalid magic bytes")
+	}
+
+	if footer.Version != sstableVersion {
+		return fmt.Errorf("unsupported version %d", footer.Version)
+	}
+
+	index, err := table.readChecked(footer.IndexOffset, footer.IndexLength)
+
+	if err != nil {
+		return fmt.Errorf("index: %w", err)
+	}
+
+	for len(index) > 0 {
+		keyLength, n := binary.Uvarint(index)
+
+		if n <= 0 || uint64(len(index)-n) < keyLength+12 {
+			return errors.New("index is malformed")
+		}
+
+		index = index[n:]
+
+		table.index = append(table.index, indexEntry{
+			lastKey: string(index[:keyLength]),
+			offset:  binary.LittleEndian.Uint64(index[keyLength:]),
+			length:  binary.LittleEndian.Uint32(index[keyLength+8:]),
+		})
+
+		index = index[keyLength+12:]
+	}
+
+	bloom, err := table.readChecked(footer.BloomOffset, footer.BloomLength)
+
+	if err != nil {
+		return fmt.Errorf("bloom filter: %w", err)
+	}
+
+	table.bloom, err = decodeBloomFilter(bloom)
+
+	return err
+}
+
+// readChecked reads a section that ends in a CRC32 and returns it without the
+// checksum.
+func (table *sstable) readChecked(offset uint64, length uint32) ([]byte, error) {
+	if length < 4 {
+		return nil, errors.New("section is too short")
+	}
+
+	buf := make([]byte, length)
+
+	_, err := table.file.ReadAt(buf, int64(offset))
+
+	if err != nil {
+		return nil, err
+	}
+
+	body := buf[:length-4]
+
+	if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(buf[length-4:]) {
+		return nil, fmt.Errorf("checksum mismatch at offset %d", offset)
+	}
+
+	return body, nil
+}
+
+func (table *sstable) readBlock(i int) ([]record, error) {
+	entry := table.index[i]
+
+	block, err := table.readChecked(entry.offset, entry.length)
+
+	if err != nil {
+		return nil, fmt.Errorf("SSTable %s block %d: %w", table.path, i, err)
+	}
+
+	records := []record{}
+
+	for len(block) > 0 {
+		keyLength, n := binary.Uvarint(block)
+
+		if n <= 0 {
+			return nil, fmt.Errorf("SSTable %s block %d is malformed", table.path, i)
+		}
+
+		block = block[n:]
+
+		valueLength, n := binary.Uvarint(block)
+
+		if n <= 0 || uint64(len(block)-n) < 1+keyLength+valueLength {
+			return nil, fmt.Errorf("SSTable %s block %d is malformed", table.path, i)
+		}
+
+		block = block[n:]
+		flags := block[0]
+		block = block[1:]
+
+		records = append(records, record{
+			key:     string(block[:keyLength]),
+			val:     string(block[keyLength : keyLength+valueLength]),
+			deleted: flags&tombstoneFlag != 0,
+		})
+
+		block = block[keyLength+valueLength:]
+	}
+
+	return records, nil
+}
+
+// get looks key up, returning a tombstone if the table has one for it.
+func (table *sstable) get(key string) (record, bool, error) {
+	if key < string(table.meta.Smallest) || key > string(table.meta.Largest) || !table.bloom.mayContain(key) {
+		return record{}, false, nil
+	}
+
+	i := sort.Search(len(table.index), func(i int) bool { return table.index[i].lastKey >= key })
+
+	if i == len(table.index) {
+		return record{}, false, nil
+	}
+
+	records, err := table.readBlock(i)
+
+	if err != nil {
+		return record{}, false, err
+	}
+
+	r, ok := searchRecords(records, key)
+
+	return r, ok, nil
+}
+
+func (table *sstable) iterator() recordIterator {
+	return table.iteratorFrom("")
+}
+
+// iteratorFrom uses the index to skip straight to the block start would be
+// in, so none of the blocks before it are read.
+func (table *sstable) iteratorFrom(start string) recordIterator {
+	block := sort.Search(len(table.index), func(i int) bool { return table.index[i].lastKey >= start })
+
+	return &sstableIterator{table: table, block: block, start: start}
+}
+
+func (table *sstable) ref() {
+	table.refs.Add(1)
+}
+
+// unref drops a reference, deleting the file if it was the last one to a
+// table compaction has replaced.
+func (table *sstable) unref() {
+	if table.refs.Add(-1) > 0 {
+		return
+	}
+
+	table.file.Close()
+
+	if table.obsolete.Load() {
+		os.Remove(table.path)
+	}
+}
+
+type sstableIterator struct {
+	table   *sstable
+	block   int
+	records []record
+	start   string // records before it are dropped from the first block read
+}
+
+func (iterator *sstableIterator) next() (record, bool, error) {
+	for len(iterator.records) == 0 {
+		if iterator.block >= len(iterator.table.index) {
+			return record{}, false, nil
+		}
+
+		records, err := iterator.table.readBlock(iterator.block)
+
+		if err != nil {
+			return record{}, false, err
+		}
+
+		if iterator.start != "" {
+			records = records[sort.Search(len(records), func(i int) bool { return records[i].key >= iterator.start }):]
+			iterator.start = ""
+		}
+
+		iterator.records = records
+		iterator.block++
+	}
+
+	r := iterator.records[0]
+	iterator.records = iterator.records[1:]
+
+	return r, true, nil
+}
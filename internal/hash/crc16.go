@@ -0,0 +1,33 @@
+package hash
+
+// crc16Table is CRC16-XMODEM (polynomial 0x1021, starting from 0), a byte at
+// a time.
+var crc16Table = func() [256]uint16 {
+	var table [256]uint16
+
+	for i := range table {
+		crc := uint16(i) << 8
+
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+
+		table[i] = crc
+	}
+
+	return table
+}()
+
+func crc16(buf []byte) uint16 {
+	crc := uint16(0)
+
+	for _, b := range buf {
+		crc = crc<<8 ^ crc16Table[byte(crc>>8)^b]
+	}
+
+	return crc
+}
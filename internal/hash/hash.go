@@ -1,17 +1,112 @@
 package hash
 
 import (
+	"fmt"
 	"hash/crc32"
+	"strings"
+
+	"github.com/cespare/xxhash/v2"
 )
 
 const NumHashSlots = 16384
 
-func GetHashSlot(key string) uint32 {
-	crc32Value := crc32.ChecksumIEEE([]byte(key))
+// Function is the hash keys are put into slots with. Every node in a cluster
+// has to use the same one, and it is part of the cluster config.
+type Function string
+
+const (
+	// CRC16 is CRC16-XMODEM, the same as Redis Cluster, so a key is in the
+	// same slot here as it would be there. The default.
+	CRC16 Function = "crc16"
+	// XXHash is the low bits of xxHash64. Spreads keys a bit more evenly.
+	XXHash Function = "xxhash"
+	// CRC32 is CRC32-IEEE, which every key was hashed with before the hash
+	// function could be picked. Clusters that already hold data keep using it.
+	CRC32 Function = "crc32"
+)
+
+const DefaultFunction = CRC16
+
+var Functions = []Function{CRC16, XXHash, CRC32}
+
+// ParseFunction parses the name of a hash function. An empty name is the
+// default.
+func ParseFunction(name string) (Function, error) {
+	if name == "" {
+		return DefaultFunction, nil
+	}
+
+	for _, function := range Functions {
+		if Function(name) == function {
+			return function, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown hash function %q, expected one of %v", name, Functions)
+}
+
+// Resolved is the function itself, or the default for the zero value.
+func (function Function) Resolved() Function {
+	if function == "" {
+		return DefaultFunction
+	}
+
+	return function
+}
+
+// HashSlot is the only place a key is turned into a slot. Routing, batches,
+// tooling and anything that moves slots around all go through it, so they
+// always agree on where a key lives. The zero value hashes with the default.
+func (function Function) HashSlot(key string) uint32 {
+	tag := []byte(HashTag(key))
 
-	hashSlot := crc32Value % NumHashSlots
+	switch function {
+	case XXHash:
+		return uint32(xxhash.Sum64(tag) % NumHashSlots)
+	case CRC32:
+		return crc32.ChecksumIEEE(tag) % NumHashSlots
+	}
+
+	return uint32(crc16(tag)) % NumHashSlots
+}
+
+// SameSlot returns the slot every key is in, or false if they aren't all in
+// the same one.
+func (function Function) SameSlot(keys []string) (uint32, bool) {
+	if len(keys) == 0 {
+		return 0, true
+	}
+
+	hashSlot := function.HashSlot(keys[0])
+
+	for _, key := range keys[1:] {
+		if function.HashSlot(key) != hashSlot {
+			return 0, false
+		}
+	}
+
+	return hashSlot, true
+}
+
+// HashTag returns the part of the key that is hashed. Like Redis, when the key
+// has a "{" with a "}" somewhere after it, and something in between, only what
+// is between the first "{" and the first "}" after it is hashed. So
+// "{user:42}:profile" and "{user:42}:settings" are always in the same slot.
+// Otherwise the whole key is hashed.
+func HashTag(key string) string {
+	start := strings.IndexByte(key, '{')
+
+	if start == -1 {
+		return key
+	}
+
+	end := strings.IndexByte(key[start+1:], '}')
+
+	if end <= 0 {
+		return key
+	}
 
-	return hashSlot
+	return key[start+1 : start+1+end]
 }
 
 func CalculateHashSlotRanges(numNodes int, numSlots int) map[int][]int {
@@ -0,0 +1,72 @@
+package hash
+
+import "testing"
+
+func TestHashTag(t *testing.T) {
+	cases := map[string]string{
+		"user:42:profile":   "user:42:profile",
+		"{user:42}:profile": "user:42",
+		"profile:{user:42}": "user:42",
+		"{user:42}:{other}": "user:42",
+		"{}:profile":        "{}:profile",
+		"{user:42:profile":  "{user:42:profile",
+		"}user{42}":         "42",
+		"{{user}}":          "{user",
+		"user:42}{":         "user:42}{",
+		"":                  "",
+	}
+
+	for key, expected := range cases {
+		if tag := HashTag(key); tag != expected {
+			t.Errorf("Expected the hash tag of %q to be %q, got %q", key, expected, tag)
+		}
+	}
+}
+
+func TestKeysWithTheSameHashTagShouldShareASlot(t *testing.T) {
+	for _, function := range Functions {
+		profile := function.HashSlot("{user:42}:profile")
+		settings := function.HashSlot("{user:42}:settings")
+
+		if profile != settings {
+			t.Errorf("Expected both keys in the same %s slot, got %d and %d", function, profile, settings)
+		}
+
+		if profile != function.HashSlot("user:42") {
+			t.Errorf("Expected the %s slot of the tag itself, got %d", function, profile)
+		}
+	}
+}
+
+func TestCRC16ShouldMatchRedisCluster(t *testing.T) {
+	if checksum := crc16([]byte("123456789")); checksum != 0x31c3 {
+		t.Errorf("Expected the XMODEM check value 0x31c3, got %#x", checksum)
+	}
+
+	// from CLUSTER KEYSLOT.
+	for key, expected := range map[string]uint32{"foo": 12182, "bar": 5061, "hello": 866} {
+		if hashSlot := CRC16.HashSlot(key); hashSlot != expected {
+			t.Errorf("Expected %s to be in slot %d, got %d", key, expected, hashSlot)
+		}
+	}
+}
+
+func TestParseFunction(t *testing.T) {
+	function, err := ParseFunction("")
+
+	if err != nil || function != CRC16 {
+		t.Errorf("Expected the default to be crc16, got %s %v", function, err)
+	}
+
+	function, err = ParseFunction("xxhash")
+
+	if err != nil || function != XXHash {
+		t.Errorf("Expected xxhash, got %s %v", function, err)
+	}
+
+	_, err = ParseFunction("md5")
+
+	if err == nil {
+		t.Errorf("Expected an error for an unknown hash function")
+	}
+}
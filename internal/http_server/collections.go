@@ -0,0 +1,408 @@
+package http_server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/ethan-stone/go-key-store/internal/configuration"
+	"github.com/ethan-stone/go-key-store/internal/rpc"
+	"github.com/ethan-stone/go-key-store/internal/service"
+	"github.com/ethan-stone/go-key-store/internal/store"
+)
+
+// Lists, hashes and sets take and return their values base64 encoded in JSON,
+// the same as batches do. The one exception is a single hash field, which is
+// returned as raw bytes like a get.
+
+type ValuesBody struct {
+	Values [][]byte `json:"values"`
+}
+
+type FieldsBody struct {
+	Fields map[string][]byte `json:"fields"`
+}
+
+type MembersBody struct {
+	Members [][]byte `json:"members"`
+}
+
+type LengthResponse struct {
+	Length int `json:"length"`
+}
+
+type CountResponse struct {
+	Count int `json:"count"` // how many values were added or removed
+}
+
+type IsMemberResponse struct {
+	IsMember bool `json:"is_member"`
+}
+
+// routeKey gets the store for the key in the path, writing an error if there
+// isn't one.
+func routeKey(w http.ResponseWriter, r *http.Request, configManager configuration.ConfigurationManager, rpcClientManager rpc.RpcClientManager) (service.StoreService, string, bool) {
+	key := r.PathValue("key")
+
+	store, err := store.GetStore(key, configManager.GetClusterConfig(), rpcClientManager)
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return nil, "", false
+	}
+
+	return store, key, true
+}
+
+// writeCollectionError writes the status for a failed list, hash, set or
+// sorted set operation.
+func writeCollectionError(w http.ResponseWriter, err error) {
+	if errors.Is(err, service.ErrWrongType) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	if errors.Is(err, service.ErrInvalidScore) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if errors.Is(err, service.ErrNotQuorumReplicated) {
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+		return
+	}
+
+	w.WriteHeader(http.StatusInternalServerError)
+}
+
+func writeJSON(w http.ResponseWriter, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(body)
+}
+
+// decodeBody reads a JSON request body into body, writing an error if it
+// can't.
+func decodeBody(w http.ResponseWriter, r *http.Request, body any) bool {
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxValueSize)).Decode(body)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+
+	return true
+}
+
+// parseListSide reads the side query param, which is right if it isn't given.
+func parseListSide(r *http.Request) (service.ListSide, error) {
+	switch side := r.URL.Query().Get("side"); side {
+	case "", string(service.ListRight):
+		return service.ListRight, nil
+	case string(service.ListLeft):
+		return service.ListLeft, nil
+	default:
+		return "", fmt.Errorf("invalid side %q, expected left or right", side)
+	}
+}
+
+// parseIntParam reads an integer query param, or fallback if it isn't given.
+func parseIntParam(r *http.Request, name string, fallback int) (int, error) {
+	value := r.URL.Query().Get(name)
+
+	if value == "" {
+		return fallback, nil
+	}
+
+	n, err := strconv.Atoi(value)
+
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q, expected an integer", name, value)
+	}
+
+	return n, nil
+}
+
+func listPushHandler(configManager configuration.ConfigurationManager, rpcClientManager rpc.RpcClientManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		side, err := parseListSide(r)
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var body ValuesBody
+
+		if !decodeBody(w, r, &body) {
+			return
+		}
+
+		store, key, ok := routeKey(w, r, configManager, rpcClientManager)
+
+		if !ok {
+			return
+		}
+
+		length, err := store.ListPush(key, side, body.Values)
+
+		if err != nil {
+			writeCollectionError(w, err)
+			return
+		}
+
+		writeJSON(w, LengthResponse{Length: length})
+	}
+}
+
+func listPopHandler(configManager configuration.ConfigurationManager, rpcClientManager rpc.RpcClientManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		side, err := parseListSide(r)
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		count, err := parseIntParam(r, "count", 1)
+
+		if err != nil || count < 1 {
+			http.Error(w, "count has to be a positive integer", http.StatusBadRequest)
+			return
+		}
+
+		store, key, ok := routeKey(w, r, configManager, rpcClientManager)
+
+		if !ok {
+			return
+		}
+
+		vals, err := store.ListPop(key, side, count)
+
+		if err != nil {
+			writeCollectionError(w, err)
+			return
+		}
+
+		writeJSON(w, ValuesBody{Values: vals})
+	}
+}
+
+// listRangeHandler returns the whole list unless start or stop are given.
+func listRangeHandler(configManager configuration.ConfigurationManager, rpcClientManager rpc.RpcClientManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start, err := parseIntParam(r, "start", 0)
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		stop, err := parseIntParam(r, "stop", -1)
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		store, key, ok := routeKey(w, r, configManager, rpcClientManager)
+
+		if !ok {
+			return
+		}
+
+		vals, err := store.ListRange(key, start, stop)
+
+		if err != nil {
+			writeCollectionError(w, err)
+			return
+		}
+
+		writeJSON(w, ValuesBody{Values: vals})
+	}
+}
+
+func hashSetHandler(configManager configuration.ConfigurationManager, rpcClientManager rpc.RpcClientManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body FieldsBody
+
+		if !decodeBody(w, r, &body) {
+			return
+		}
+
+		store, key, ok := routeKey(w, r, configManager, rpcClientManager)
+
+		if !ok {
+			return
+		}
+
+		added, err := store.HashSet(key, body.Fields)
+
+		if err != nil {
+			writeCollectionError(w, err)
+			return
+		}
+
+		writeJSON(w, CountResponse{Count: added})
+	}
+}
+
+func hashGetAllHandler(configManager configuration.ConfigurationManager, rpcClientManager rpc.RpcClientManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		store, key, ok := routeKey(w, r, configManager, rpcClientManager)
+
+		if !ok {
+			return
+		}
+
+		fields, err := store.HashGetAll(key)
+
+		if err != nil {
+			writeCollectionError(w, err)
+			return
+		}
+
+		writeJSON(w, FieldsBody{Fields: fields})
+	}
+}
+
+func hashGetHandler(configManager configuration.ConfigurationManager, rpcClientManager rpc.RpcClientManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		store, key, ok := routeKey(w, r, configManager, rpcClientManager)
+
+		if !ok {
+			return
+		}
+
+		val, found, err := store.HashGet(key, r.PathValue("field"))
+
+		if err != nil {
+			writeCollectionError(w, err)
+			return
+		}
+
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", defaultContentType)
+		w.WriteHeader(http.StatusOK)
+		w.Write(val)
+	}
+}
+
+func hashDeleteHandler(configManager configuration.ConfigurationManager, rpcClientManager rpc.RpcClientManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		store, key, ok := routeKey(w, r, configManager, rpcClientManager)
+
+		if !ok {
+			return
+		}
+
+		deleted, err := store.HashDelete(key, []string{r.PathValue("field")})
+
+		if err != nil {
+			writeCollectionError(w, err)
+			return
+		}
+
+		if deleted == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func setAddHandler(configManager configuration.ConfigurationManager, rpcClientManager rpc.RpcClientManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body MembersBody
+
+		if !decodeBody(w, r, &body) {
+			return
+		}
+
+		store, key, ok := routeKey(w, r, configManager, rpcClientManager)
+
+		if !ok {
+			return
+		}
+
+		added, err := store.SetAdd(key, body.Members)
+
+		if err != nil {
+			writeCollectionError(w, err)
+			return
+		}
+
+		writeJSON(w, CountResponse{Count: added})
+	}
+}
+
+func setRemoveHandler(configManager configuration.ConfigurationManager, rpcClientManager rpc.RpcClientManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body MembersBody
+
+		if !decodeBody(w, r, &body) {
+			return
+		}
+
+		store, key, ok := routeKey(w, r, configManager, rpcClientManager)
+
+		if !ok {
+			return
+		}
+
+		removed, err := store.SetRemove(key, body.Members)
+
+		if err != nil {
+			writeCollectionError(w, err)
+			return
+		}
+
+		writeJSON(w, CountResponse{Count: removed})
+	}
+}
+
+func setMembersHandler(configManager configuration.ConfigurationManager, rpcClientManager rpc.RpcClientManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		store, key, ok := routeKey(w, r, configManager, rpcClientManager)
+
+		if !ok {
+			return
+		}
+
+		members, err := store.SetMembers(key)
+
+		if err != nil {
+			writeCollectionError(w, err)
+			return
+		}
+
+		writeJSON(w, MembersBody{Members: members})
+	}
+}
+
+// setIsMemberHandler takes the member as a plain query param, not base64.
+func setIsMemberHandler(configManager configuration.ConfigurationManager, rpcClientManager rpc.RpcClientManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		store, key, ok := routeKey(w, r, configManager, rpcClientManager)
+
+		if !ok {
+			return
+		}
+
+		isMember, err := store.SetIsMember(key, []byte(r.URL.Query().Get("member")))
+
+		if err != nil {
+			writeCollectionError(w, err)
+			return
+		}
+
+		writeJSON(w, IsMemberResponse{IsMember: isMember})
+	}
+}
@@ -2,21 +2,172 @@ package http_server
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ethan-stone/go-key-store/internal/configuration"
 	"github.com/ethan-stone/go-key-store/internal/rpc"
+	"github.com/ethan-stone/go-key-store/internal/service"
 	"github.com/ethan-stone/go-key-store/internal/store"
 )
 
-type KeyValueResponse struct {
+// MaxValueSize is the largest request body accepted as a value.
+const MaxValueSize = 64 << 20
+
+// values that were put without a content type are served as raw bytes.
+const defaultContentType = "application/octet-stream"
+
+// BatchRequestOp is one op in a batch. Values are base64 encoded, since JSON
+// can't hold raw bytes. TTL, IfMatch and IfNoneMatch take the same values as
+// the query param and headers do for a single key.
+type BatchRequestOp struct {
+	Op          string `json:"op"` // "put" or "delete"
+	Key         string `json:"key"`
+	Value       []byte `json:"value,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	TTL         string `json:"ttl,omitempty"`
+	IfMatch     string `json:"if_match,omitempty"`
+	IfNoneMatch string `json:"if_none_match,omitempty"`
+}
+
+type BatchRequestBody struct {
+	Ops []BatchRequestOp `json:"ops"`
+}
+
+type BatchResponse struct {
+	Versions []uint64 `json:"versions"` // in the same order as the ops, 0 for deletes
+}
+
+// scans return DefaultScanLimit keys a page unless asked for more, and never
+// more than MaxScanLimit.
+const (
+	DefaultScanLimit = 100
+	MaxScanLimit     = 1000
+)
+
+type ScanResponseItem struct {
+	Key         string `json:"key"`
+	Type        string `json:"type"`
+	Value       []byte `json:"value,omitempty"` // base64 encoded, like batch values, and only for strings
+	ContentType string `json:"content_type,omitempty"`
+	Version     uint64 `json:"version"`
+}
+
+type ScanResponse struct {
+	Items  []ScanResponseItem `json:"items"`
+	Cursor string             `json:"cursor"` // pass back as ?cursor= for the next page, empty once the scan is done
+}
+
+type IncrResponse struct {
 	Key   string `json:"key"`
-	Value string `json:"value"`
+	Value int64  `json:"value"` // the value after the increment
+}
+
+type TTLResponse struct {
+	Key string `json:"key"`
+	TTL int64  `json:"ttl_ms"` // -1 when the key never expires
+}
+
+// parseTTL reads the ttl query param, or failing that the X-TTL header. Either
+// can be whole seconds or a duration like "90s" or "2h". No TTL is 0.
+func parseTTL(r *http.Request) (time.Duration, error) {
+	value := r.URL.Query().Get("ttl")
+
+	if value == "" {
+		value = r.Header.Get("X-TTL")
+	}
+
+	return parseTTLValue(value)
+}
+
+func parseTTLValue(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	ttl, err := time.ParseDuration(value)
+
+	if err != nil {
+		seconds, err := strconv.ParseInt(value, 10, 64)
+
+		if err != nil {
+			return 0, fmt.Errorf("invalid ttl %q", value)
+		}
+
+		ttl = time.Duration(seconds) * time.Second
+	}
+
+	if ttl <= 0 {
+		return 0, fmt.Errorf("ttl must be positive, got %q", value)
+	}
+
+	return ttl, nil
 }
 
-type PutRequestBody struct {
-	Value string `json:"value"`
+// ETags are the key's version in quotes.
+func etag(version uint64) string {
+	return fmt.Sprintf("\"%d\"", version)
+}
+
+// parseCondition turns If-Match and If-None-Match into a condition. If-Match
+// takes a single ETag, or * for any existing value. If-None-Match only takes
+// *, for a key that doesn't exist yet.
+func parseCondition(r *http.Request) (service.Condition, error) {
+	return parseConditionValues(r.Header.Get("If-Match"), r.Header.Get("If-None-Match"))
+}
+
+func parseConditionValues(ifMatch string, ifNoneMatch string) (service.Condition, error) {
+	ifMatch = strings.TrimSpace(ifMatch)
+	ifNoneMatch = strings.TrimSpace(ifNoneMatch)
+
+	if ifMatch != "" && ifNoneMatch != "" {
+		return service.Condition{}, errors.New("only one of If-Match and If-None-Match can be given")
+	}
+
+	if ifNoneMatch != "" {
+		if ifNoneMatch != "*" {
+			return service.Condition{}, fmt.Errorf("If-None-Match only supports *, got %q", ifNoneMatch)
+		}
+
+		return service.Condition{IfAbsent: true}, nil
+	}
+
+	if ifMatch == "" {
+		return service.Condition{}, nil
+	}
+
+	if ifMatch == "*" {
+		return service.Condition{IfPresent: true}, nil
+	}
+
+	version, err := strconv.ParseUint(strings.Trim(ifMatch, "\""), 10, 64)
+
+	if err != nil || version == 0 || !strings.HasPrefix(ifMatch, "\"") || !strings.HasSuffix(ifMatch, "\"") {
+		return service.Condition{}, fmt.Errorf("invalid If-Match %q", ifMatch)
+	}
+
+	return service.Condition{IfVersion: version}, nil
+}
+
+// writeErrorStatus picks the status for a failed put or delete.
+func writeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrConflict):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, service.ErrInvalidCondition):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrReadOnlyReplica), errors.Is(err, service.ErrNotLeader), errors.Is(err, service.ErrNoQuorum):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, service.ErrNotQuorumReplicated):
+		return http.StatusNotImplemented
+	}
+
+	return http.StatusInternalServerError
 }
 
 func getHandler(configManager configuration.ConfigurationManager, rpcClientManager rpc.RpcClientManager) http.HandlerFunc {
@@ -25,7 +176,14 @@ func getHandler(configManager configuration.ConfigurationManager, rpcClientManag
 
 		clusterConfig := configManager.GetClusterConfig()
 
-		store, err := store.GetStore(key, clusterConfig, rpcClientManager)
+		getStore := store.GetStore
+
+		// a replica can answer a stale read from its own copy.
+		if r.URL.Query().Get("stale") == "true" {
+			getStore = store.GetStaleStore
+		}
+
+		store, err := getStore(key, clusterConfig, rpcClientManager)
 
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
@@ -34,6 +192,16 @@ func getHandler(configManager configuration.ConfigurationManager, rpcClientManag
 
 		result, err := store.Get(key)
 
+		if errors.Is(err, service.ErrWrongType) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+
+		if errors.Is(err, service.ErrNotLeader) || errors.Is(err, service.ErrNoQuorum) {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			return
@@ -44,9 +212,20 @@ func getHandler(configManager configuration.ConfigurationManager, rpcClientManag
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
+		contentType := result.ContentType
+
+		if contentType == "" {
+			contentType = defaultContentType
+		}
+
+		w.Header().Set("Content-Type", contentType)
+
+		if result.Version != 0 {
+			w.Header().Set("ETag", etag(result.Version))
+		}
+
 		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(KeyValueResponse{Key: key, Value: result.Val})
+		w.Write(result.Val)
 	}
 }
 
@@ -54,6 +233,27 @@ func putHandler(configManager configuration.ConfigurationManager, rpcClientManag
 	return func(w http.ResponseWriter, r *http.Request) {
 		key := r.PathValue("key")
 
+		contentType := r.Header.Get("Content-Type")
+
+		if len(contentType) > store.MaxContentTypeLength {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		ttl, err := parseTTL(r)
+
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		condition, err := parseCondition(r)
+
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
 		clusterConfig := configManager.GetClusterConfig()
 
 		store, err := store.GetStore(key, clusterConfig, rpcClientManager)
@@ -63,26 +263,126 @@ func putHandler(configManager configuration.ConfigurationManager, rpcClientManag
 			return
 		}
 
-		var body PutRequestBody
+		// the body is stored as is, whatever it is.
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxValueSize))
+
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+
+			if errors.As(err, &maxBytesErr) {
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
 
-		err = json.NewDecoder(r.Body).Decode(&body)
+		result, err := store.Put(key, body, &service.PutOptions{
+			ContentType: contentType,
+			TTL:         ttl,
+			Condition:   condition,
+		})
+
+		if err != nil {
+			w.WriteHeader(writeErrorStatus(err))
+			return
+		}
+
+		w.Header().Set("ETag", etag(result.Version))
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func deleteHandler(configManager configuration.ConfigurationManager, rpcClientManager rpc.RpcClientManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.PathValue("key")
+
+		condition, err := parseCondition(r)
 
 		if err != nil {
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
 
-		store.Put(key, body.Value)
+		clusterConfig := configManager.GetClusterConfig()
+
+		store, err := store.GetStore(key, clusterConfig, rpcClientManager)
+
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		err = store.Delete(key, &service.DeleteOptions{
+			Condition: condition,
+		})
+
+		if err != nil {
+			w.WriteHeader(writeErrorStatus(err))
+			return
+		}
 
 		w.WriteHeader(http.StatusOK)
+	}
+
+}
+
+func expireHandler(configManager configuration.ConfigurationManager, rpcClientManager rpc.RpcClientManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.PathValue("key")
+
+		ttl, err := parseTTL(r)
 
+		if err != nil || ttl == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		clusterConfig := configManager.GetClusterConfig()
+
+		store, err := store.GetStore(key, clusterConfig, rpcClientManager)
+
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		ok, err := store.Expire(key, ttl)
+
+		if err != nil {
+			w.WriteHeader(writeErrorStatus(err))
+			return
+		}
+
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
 	}
 }
 
-func deleteHandler(configManager configuration.ConfigurationManager, rpcClientManager rpc.RpcClientManager) http.HandlerFunc {
+// incrHandler adds the by query param to the key's value, 1 if it isn't given.
+// A negative by decrements.
+func incrHandler(configManager configuration.ConfigurationManager, rpcClientManager rpc.RpcClientManager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		key := r.PathValue("key")
 
+		delta := int64(1)
+
+		if by := r.URL.Query().Get("by"); by != "" {
+			var err error
+
+			delta, err = strconv.ParseInt(by, 10, 64)
+
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid by %q, expected an integer", by), http.StatusBadRequest)
+				return
+			}
+		}
+
 		clusterConfig := configManager.GetClusterConfig()
 
 		store, err := store.GetStore(key, clusterConfig, rpcClientManager)
@@ -92,11 +392,252 @@ func deleteHandler(configManager configuration.ConfigurationManager, rpcClientMa
 			return
 		}
 
-		store.Delete(key)
+		result, err := store.Incr(key, delta)
 
+		if errors.Is(err, service.ErrNotInteger) || errors.Is(err, service.ErrOverflow) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", etag(result.Version))
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(IncrResponse{Key: key, Value: result.Val})
+	}
+}
+
+func persistHandler(configManager configuration.ConfigurationManager, rpcClientManager rpc.RpcClientManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.PathValue("key")
+
+		clusterConfig := configManager.GetClusterConfig()
+
+		store, err := store.GetStore(key, clusterConfig, rpcClientManager)
+
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		ok, err := store.Persist(key)
+
+		if err != nil {
+			w.WriteHeader(writeErrorStatus(err))
+			return
+		}
+
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func ttlHandler(configManager configuration.ConfigurationManager, rpcClientManager rpc.RpcClientManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.PathValue("key")
+
+		clusterConfig := configManager.GetClusterConfig()
+
+		store, err := store.GetStore(key, clusterConfig, rpcClientManager)
+
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		result, err := store.TTL(key)
+
+		if err != nil {
+			w.WriteHeader(writeErrorStatus(err))
+			return
+		}
+
+		if !result.Ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		ttl := int64(-1)
+
+		if result.Expires {
+			ttl = result.TTL.Milliseconds()
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(TTLResponse{Key: key, TTL: ttl})
+	}
+}
+
+func parseBatchOps(body *BatchRequestBody) ([]*service.BatchOp, error) {
+	ops := make([]*service.BatchOp, len(body.Ops))
+
+	for i, requestOp := range body.Ops {
+		if requestOp.Op != "put" && requestOp.Op != "delete" {
+			return nil, fmt.Errorf("op %d: unknown op %q, expected put or delete", i, requestOp.Op)
+		}
+
+		ttl, err := parseTTLValue(requestOp.TTL)
+
+		if err != nil {
+			return nil, fmt.Errorf("op %d: %w", i, err)
+		}
+
+		condition, err := parseConditionValues(requestOp.IfMatch, requestOp.IfNoneMatch)
+
+		if err != nil {
+			return nil, fmt.Errorf("op %d: %w", i, err)
+		}
+
+		if len(requestOp.ContentType) > store.MaxContentTypeLength {
+			return nil, fmt.Errorf("op %d: %w", i, store.ErrContentTypeTooLong)
+		}
+
+		ops[i] = &service.BatchOp{
+			Key:         requestOp.Key,
+			Delete:      requestOp.Op == "delete",
+			Val:         requestOp.Value,
+			ContentType: requestOp.ContentType,
+			TTL:         ttl,
+			Condition:   condition,
+		}
+	}
+
+	return ops, nil
+}
+
+func batchHandler(configManager configuration.ConfigurationManager, rpcClientManager rpc.RpcClientManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body BatchRequestBody
+
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxValueSize)).Decode(&body)
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ops, err := parseBatchOps(&body)
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		keys := make([]string, len(ops))
+
+		for i, op := range ops {
+			keys[i] = op.Key
+		}
+
+		clusterConfig := configManager.GetClusterConfig()
+
+		store, err := store.GetBatchStore(keys, clusterConfig, rpcClientManager)
+
+		if errors.Is(err, service.ErrCrossSlot) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		result, err := store.Batch(ops)
+
+		if errors.Is(err, service.ErrCrossSlot) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err != nil {
+			w.WriteHeader(writeErrorStatus(err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(BatchResponse{Versions: result.Versions})
+	}
+}
+
+// parseScanLimit reads the limit query param, DefaultScanLimit if there isn't one.
+func parseScanLimit(value string) (int, error) {
+	if value == "" {
+		return DefaultScanLimit, nil
+	}
+
+	limit, err := strconv.Atoi(value)
+
+	if err != nil || limit <= 0 || limit > MaxScanLimit {
+		return 0, fmt.Errorf("invalid limit %q, expected a number from 1 to %d", value, MaxScanLimit)
 	}
 
+	return limit, nil
+}
+
+// scanHandler scans the whole cluster a page at a time. prefix, start and end
+// pick the keys, and can be combined.
+func scanHandler(configManager configuration.ConfigurationManager, rpcClientManager rpc.RpcClientManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		limit, err := parseScanLimit(query.Get("limit"))
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		options := &service.ScanOptions{
+			Prefix: query.Get("prefix"),
+			Start:  query.Get("start"),
+			End:    query.Get("end"),
+			Limit:  limit,
+		}
+
+		clusterConfig := configManager.GetClusterConfig()
+
+		result, err := store.ScanCluster(options, query.Get("cursor"), clusterConfig, rpcClientManager)
+
+		if errors.Is(err, service.ErrInvalidCursor) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		response := ScanResponse{
+			Items:  make([]ScanResponseItem, len(result.Items)),
+			Cursor: result.Cursor,
+		}
+
+		for i, item := range result.Items {
+			response.Items[i] = ScanResponseItem{
+				Key:         item.Key,
+				Type:        string(item.Type),
+				Value:       item.Val,
+				ContentType: item.ContentType,
+				Version:     item.Version,
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(response)
+	}
 }
 
 type HttpServerConfig struct {
@@ -111,6 +652,30 @@ func NewHttpServer(config *HttpServerConfig) *http.Server {
 	mux.HandleFunc("GET /item/{key}", getHandler(config.ConfigManager, config.RpcClientManager))
 	mux.HandleFunc("POST /item/{key}", putHandler(config.ConfigManager, config.RpcClientManager))
 	mux.HandleFunc("DELETE /item/{key}", deleteHandler(config.ConfigManager, config.RpcClientManager))
+	mux.HandleFunc("POST /item/{key}/expire", expireHandler(config.ConfigManager, config.RpcClientManager))
+	mux.HandleFunc("POST /item/{key}/persist", persistHandler(config.ConfigManager, config.RpcClientManager))
+	mux.HandleFunc("POST /item/{key}/incr", incrHandler(config.ConfigManager, config.RpcClientManager))
+	mux.HandleFunc("GET /item/{key}/ttl", ttlHandler(config.ConfigManager, config.RpcClientManager))
+	mux.HandleFunc("POST /batch", batchHandler(config.ConfigManager, config.RpcClientManager))
+	mux.HandleFunc("GET /items", scanHandler(config.ConfigManager, config.RpcClientManager))
+	mux.HandleFunc("GET /watch", watchHandler(config.ConfigManager, config.RpcClientManager))
+	mux.HandleFunc("POST /list/{key}/push", listPushHandler(config.ConfigManager, config.RpcClientManager))
+	mux.HandleFunc("POST /list/{key}/pop", listPopHandler(config.ConfigManager, config.RpcClientManager))
+	mux.HandleFunc("GET /list/{key}", listRangeHandler(config.ConfigManager, config.RpcClientManager))
+	mux.HandleFunc("POST /hash/{key}", hashSetHandler(config.ConfigManager, config.RpcClientManager))
+	mux.HandleFunc("GET /hash/{key}", hashGetAllHandler(config.ConfigManager, config.RpcClientManager))
+	mux.HandleFunc("GET /hash/{key}/{field}", hashGetHandler(config.ConfigManager, config.RpcClientManager))
+	mux.HandleFunc("DELETE /hash/{key}/{field}", hashDeleteHandler(config.ConfigManager, config.RpcClientManager))
+	mux.HandleFunc("POST /set/{key}/add", setAddHandler(config.ConfigManager, config.RpcClientManager))
+	mux.HandleFunc("POST /set/{key}/remove", setRemoveHandler(config.ConfigManager, config.RpcClientManager))
+	mux.HandleFunc("GET /set/{key}", setMembersHandler(config.ConfigManager, config.RpcClientManager))
+	mux.HandleFunc("GET /set/{key}/contains", setIsMemberHandler(config.ConfigManager, config.RpcClientManager))
+	mux.HandleFunc("POST /zset/{key}/add", sortedSetAddHandler(config.ConfigManager, config.RpcClientManager))
+	mux.HandleFunc("POST /zset/{key}/remove", sortedSetRemoveHandler(config.ConfigManager, config.RpcClientManager))
+	mux.HandleFunc("POST /zset/{key}/incr", sortedSetIncrHandler(config.ConfigManager, config.RpcClientManager))
+	mux.HandleFunc("GET /zset/{key}", sortedSetRangeByRankHandler(config.ConfigManager, config.RpcClientManager))
+	mux.HandleFunc("GET /zset/{key}/scores", sortedSetRangeByScoreHandler(config.ConfigManager, config.RpcClientManager))
+	mux.HandleFunc("GET /zset/{key}/rank/{member}", sortedSetRankHandler(config.ConfigManager, config.RpcClientManager))
 
 	// this is the actual server
 	httpServer := &http.Server{
@@ -0,0 +1,250 @@
+package http_server
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/ethan-stone/go-key-store/internal/configuration"
+	"github.com/ethan-stone/go-key-store/internal/rpc"
+)
+
+// Unlike the other collections, sorted set members are plain strings in JSON
+// since they're also used in paths and compared by the index.
+
+type ScoresBody struct {
+	Members map[string]float64 `json:"members"`
+}
+
+type MemberNamesBody struct {
+	Members []string `json:"members"`
+}
+
+type SortedSetIncrBody struct {
+	Member string  `json:"member"`
+	By     float64 `json:"by"`
+}
+
+type ScoredMemberResponse struct {
+	Member string  `json:"member"`
+	Score  float64 `json:"score"`
+}
+
+type ScoredMembersResponse struct {
+	Members []ScoredMemberResponse `json:"members"`
+}
+
+type RankResponse struct {
+	Member string  `json:"member"`
+	Rank   int     `json:"rank"`
+	Score  float64 `json:"score"`
+}
+
+// parseScoreParam reads a score query param, or fallback if it isn't given.
+// inf and -inf are allowed.
+func parseScoreParam(r *http.Request, name string, fallback float64) (float64, error) {
+	value := r.URL.Query().Get(name)
+
+	if value == "" {
+		return fallback, nil
+	}
+
+	score, err := strconv.ParseFloat(value, 64)
+
+	if err != nil || math.IsNaN(score) {
+		return 0, fmt.Errorf("invalid %s %q, expected a number", name, value)
+	}
+
+	return score, nil
+}
+
+func sortedSetAddHandler(configManager configuration.ConfigurationManager, rpcClientManager rpc.RpcClientManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body ScoresBody
+
+		if !decodeBody(w, r, &body) {
+			return
+		}
+
+		store, key, ok := routeKey(w, r, configManager, rpcClientManager)
+
+		if !ok {
+			return
+		}
+
+		added, err := store.SortedSetAdd(key, body.Members)
+
+		if err != nil {
+			writeCollectionError(w, err)
+			return
+		}
+
+		writeJSON(w, CountResponse{Count: added})
+	}
+}
+
+func sortedSetRemoveHandler(configManager configuration.ConfigurationManager, rpcClientManager rpc.RpcClientManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body MemberNamesBody
+
+		if !decodeBody(w, r, &body) {
+			return
+		}
+
+		store, key, ok := routeKey(w, r, configManager, rpcClientManager)
+
+		if !ok {
+			return
+		}
+
+		removed, err := store.SortedSetRemove(key, body.Members)
+
+		if err != nil {
+			writeCollectionError(w, err)
+			return
+		}
+
+		writeJSON(w, CountResponse{Count: removed})
+	}
+}
+
+func sortedSetIncrHandler(configManager configuration.ConfigurationManager, rpcClientManager rpc.RpcClientManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body SortedSetIncrBody
+
+		if !decodeBody(w, r, &body) {
+			return
+		}
+
+		store, key, ok := routeKey(w, r, configManager, rpcClientManager)
+
+		if !ok {
+			return
+		}
+
+		score, err := store.SortedSetIncr(key, body.Member, body.By)
+
+		if err != nil {
+			writeCollectionError(w, err)
+			return
+		}
+
+		writeJSON(w, ScoredMemberResponse{Member: body.Member, Score: score})
+	}
+}
+
+// sortedSetRangeByRankHandler returns the whole sorted set, lowest score
+// first, unless start or stop are given.
+func sortedSetRangeByRankHandler(configManager configuration.ConfigurationManager, rpcClientManager rpc.RpcClientManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start, err := parseIntParam(r, "start", 0)
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		stop, err := parseIntParam(r, "stop", -1)
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		store, key, ok := routeKey(w, r, configManager, rpcClientManager)
+
+		if !ok {
+			return
+		}
+
+		members, err := store.SortedSetRangeByRank(key, start, stop)
+
+		if err != nil {
+			writeCollectionError(w, err)
+			return
+		}
+
+		response := ScoredMembersResponse{Members: []ScoredMemberResponse{}}
+
+		for _, member := range members {
+			response.Members = append(response.Members, ScoredMemberResponse{Member: member.Member, Score: member.Score})
+		}
+
+		writeJSON(w, response)
+	}
+}
+
+// sortedSetRangeByScoreHandler returns the members with min <= score <= max.
+// A limit of 0, the default, returns all of them.
+func sortedSetRangeByScoreHandler(configManager configuration.ConfigurationManager, rpcClientManager rpc.RpcClientManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		min, err := parseScoreParam(r, "min", math.Inf(-1))
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		max, err := parseScoreParam(r, "max", math.Inf(1))
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		limit, err := parseIntParam(r, "limit", 0)
+
+		if err != nil || limit < 0 {
+			http.Error(w, "limit has to be a positive integer", http.StatusBadRequest)
+			return
+		}
+
+		store, key, ok := routeKey(w, r, configManager, rpcClientManager)
+
+		if !ok {
+			return
+		}
+
+		members, err := store.SortedSetRangeByScore(key, min, max, limit)
+
+		if err != nil {
+			writeCollectionError(w, err)
+			return
+		}
+
+		response := ScoredMembersResponse{Members: []ScoredMemberResponse{}}
+
+		for _, member := range members {
+			response.Members = append(response.Members, ScoredMemberResponse{Member: member.Member, Score: member.Score})
+		}
+
+		writeJSON(w, response)
+	}
+}
+
+func sortedSetRankHandler(configManager configuration.ConfigurationManager, rpcClientManager rpc.RpcClientManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		store, key, ok := routeKey(w, r, configManager, rpcClientManager)
+
+		if !ok {
+			return
+		}
+
+		member := r.PathValue("member")
+
+		result, err := store.SortedSetRank(key, member)
+
+		if err != nil {
+			writeCollectionError(w, err)
+			return
+		}
+
+		if !result.Ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		writeJSON(w, RankResponse{Member: member, Rank: result.Rank, Score: result.Score})
+	}
+}
@@ -0,0 +1,132 @@
+package http_server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ethan-stone/go-key-store/internal/configuration"
+	"github.com/ethan-stone/go-key-store/internal/rpc"
+	"github.com/ethan-stone/go-key-store/internal/service"
+	"github.com/ethan-stone/go-key-store/internal/store"
+)
+
+// watchHeartbeat is how often an idle watch sends a comment, so proxies don't
+// close the connection.
+const watchHeartbeat = 15 * time.Second
+
+type WatchEventData struct {
+	Key      string `json:"key"`
+	Version  uint64 `json:"version,omitempty"`
+	Sequence uint64 `json:"sequence"`
+}
+
+// watchHandler streams changes as server-sent events. Every event's id is a
+// cursor, so a client that reconnects with Last-Event-ID, or passes it as
+// the cursor param, carries on where it left off. Each node's start event
+// only sets the id, and isn't seen by the client.
+func watchHandler(configManager configuration.ConfigurationManager, rpcClientManager rpc.RpcClientManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		options := &service.WatchOptions{
+			Key:    query.Get("key"),
+			Prefix: query.Get("prefix"),
+		}
+
+		cursor := r.Header.Get("Last-Event-ID")
+
+		if cursor == "" {
+			cursor = query.Get("cursor")
+		}
+
+		controller := http.NewResponseController(w)
+
+		// a watch stays open for as long as the client wants it.
+		controller.SetWriteDeadline(time.Time{})
+
+		frames := make(chan string)
+		done := make(chan error, 1)
+
+		go func() {
+			done <- store.WatchCluster(r.Context(), options, cursor, configManager.GetClusterConfig(), rpcClientManager, func(event *service.WatchEvent, cursor string) error {
+				frame := fmt.Sprintf("id: %s\n\n", cursor)
+
+				if event.Type != service.WatchStart {
+					data, _ := json.Marshal(WatchEventData{Key: event.Key, Version: event.Version, Sequence: event.Sequence})
+					frame = fmt.Sprintf("id: %s\nevent: %s\ndata: %s\n\n", cursor, event.Type, data)
+				}
+
+				select {
+				case frames <- frame:
+					return nil
+				case <-r.Context().Done():
+					return r.Context().Err()
+				}
+			})
+		}()
+
+		heartbeat := time.NewTicker(watchHeartbeat)
+
+		defer heartbeat.Stop()
+
+		started := false
+
+		for {
+			var frame string
+
+			select {
+			case frame = <-frames:
+			case <-heartbeat.C:
+				frame = ": heartbeat\n\n"
+			case err := <-done:
+				if r.Context().Err() != nil {
+					return
+				}
+
+				if !started {
+					writeWatchError(w, err)
+					return
+				}
+
+				fmt.Fprintf(w, "event: error\ndata: %s\n\n", err.Error())
+				controller.Flush()
+
+				return
+			}
+
+			if !started {
+				w.Header().Set("Content-Type", "text/event-stream")
+				w.Header().Set("Cache-Control", "no-cache")
+				w.WriteHeader(http.StatusOK)
+				started = true
+			}
+
+			_, err := fmt.Fprint(w, frame)
+
+			if err != nil {
+				return
+			}
+
+			controller.Flush()
+		}
+	}
+}
+
+// writeWatchError writes the status for a watch that failed before it sent
+// anything.
+func writeWatchError(w http.ResponseWriter, err error) {
+	if errors.Is(err, service.ErrInvalidCursor) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if errors.Is(err, service.ErrSequenceExpired) {
+		http.Error(w, err.Error(), http.StatusGone)
+		return
+	}
+
+	w.WriteHeader(http.StatusInternalServerError)
+}
@@ -0,0 +1,396 @@
+package raft
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sync"
+
+	"github.com/ethan-stone/go-key-store/internal/configuration"
+	"github.com/ethan-stone/go-key-store/internal/hash"
+	"github.com/ethan-stone/go-key-store/internal/rpc"
+	"github.com/ethan-stone/go-key-store/internal/service"
+)
+
+// ConfigGroupName is the raft group every node in a cluster is a member of,
+// whose log is the cluster's slot map. Every entry has the whole list of
+// nodes, the hash function and the replication mode, and every node is a
+// member. A config is applied on a node as soon as the node knows it is
+// committed, with the entry's index as its epoch. A node whose hash function
+// isn't the cluster's refuses the config, since the two would disagree on
+// which node every key lives on.
+const ConfigGroupName = "config"
+
+type ConfigGroupConfig struct {
+	// DataDir is the node's data directory. The group's state is kept in its
+	// raft directory.
+	DataDir          string
+	ConfigManager    configuration.ConfigurationManager
+	RpcClientManager rpc.RpcClientManager
+	Transport        Transport // an RpcTransport over RpcClientManager if nil
+}
+
+// configEntry is the data of an entry in the config group.
+type configEntry struct {
+	Nodes           []*configuration.NodeConfig   `json:"nodes"`
+	HashFunction    hash.Function                 `json:"hashFunction"`
+	ReplicationMode configuration.ReplicationMode `json:"replicationMode,omitempty"`
+	Quorum          *configuration.QuorumConfig   `json:"quorum,omitempty"`
+	// Epoch is the index of the config this one was made from. The entry is
+	// skipped if another config was committed after it.
+	Epoch uint64 `json:"epoch"`
+}
+
+type ConfigGroup struct {
+	sync.Mutex
+	node             *Node
+	configManager    configuration.ConfigurationManager
+	rpcClientManager rpc.RpcClientManager
+	err              error // set once a config with another hash function has been refused
+	// epoch is the index of the last config committed, whether or not this
+	// node is in it, so every node checks an entry's epoch the same way.
+	epoch   uint64
+	skipped map[uint64]bool // recent entries whose epoch was out of date
+}
+
+// OpenConfigGroup joins this node to the cluster config group, and applies the
+// last config it knows was committed. It fails if the cluster hashes keys
+// with a different hash function than this node.
+func OpenConfigGroup(config *ConfigGroupConfig) (*ConfigGroup, error) {
+	thisNode := config.ConfigManager.GetClusterConfig().ThisNode
+
+	transport := config.Transport
+
+	if transport == nil {
+		transport = NewRpcTransport(config.RpcClientManager)
+	}
+
+	group := &ConfigGroup{
+		configManager:    config.ConfigManager,
+		rpcClientManager: config.RpcClientManager,
+		skipped:          make(map[uint64]bool),
+	}
+
+	node, err := OpenNode(&NodeConfig{
+		ID:        thisNode.ID,
+		Address:   thisNode.Address,
+		Group:     ConfigGroupName,
+		Dir:       filepath.Join(config.DataDir, "raft"),
+		Transport: transport,
+		Apply:     group.apply,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = group.refused()
+
+	if err != nil {
+		node.Close()
+		return nil, err
+	}
+
+	group.node = node
+
+	return group, nil
+}
+
+func (group *ConfigGroup) Close() {
+	group.node.Close()
+}
+
+// Bootstrap starts the group on this node, which has to have never been in a
+// cluster. The config to start the cluster with is proposed after. Starting a
+// group again is fine until a config is committed, so a create that failed
+// part way through can be run again to finish it. The config it proposes is
+// made from epoch 0 like the first, so it can't replace one committed since.
+func (group *ConfigGroup) Bootstrap() error {
+	err := group.node.Bootstrap(0)
+
+	if errors.Is(err, ErrAlreadyStarted) {
+		group.Lock()
+		epoch := group.epoch
+		group.Unlock()
+
+		if epoch == 0 {
+			log.Printf("Cluster config group was already started with no config committed, carrying on")
+			return nil
+		}
+	}
+
+	return err
+}
+
+// ProposeClusterConfig commits a config with the nodes and replication mode,
+// forwarding it to the leader if this node isn't it and forward is set. An
+// empty mode keeps the mode and quorum the cluster has, and quorum is only
+// kept with QuorumReplication. It fails with service.ErrEpochChanged if the
+// config has moved past epoch, the one the nodes were worked out from.
+func (group *ConfigGroup) ProposeClusterConfig(nodes []*configuration.NodeConfig, mode configuration.ReplicationMode, quorum configuration.QuorumConfig, epoch uint64, forward bool) error {
+	err := group.refused()
+
+	if err != nil {
+		return err
+	}
+
+	if mode == "" {
+		mode = group.configManager.GetClusterConfig().ReplicationMode
+		quorum = group.configManager.GetClusterConfig().Quorum
+	}
+
+	entry := &configEntry{
+		Nodes:           nodes,
+		HashFunction:    group.configManager.GetClusterConfig().HashFunction.Resolved(),
+		ReplicationMode: mode,
+		Epoch:           epoch,
+	}
+
+	if mode == configuration.QuorumReplication {
+		quorum = quorum.Resolved()
+		entry.Quorum = &quorum
+	}
+
+	data, err := json.Marshal(entry)
+
+	if err != nil {
+		return err
+	}
+
+	members := []Member{}
+
+	for _, node := range nodes {
+		members = append(members, Member{ID: node.ID, Address: node.Address})
+	}
+
+	err = group.changeMembers(members, epoch)
+
+	if err == nil {
+		var index uint64
+
+		index, err = group.node.Propose(data, members)
+
+		if err == nil {
+			return group.checkSkipped(index)
+		}
+	}
+
+	var notLeader *service.NotLeaderError
+
+	if !forward || !errors.As(err, &notLeader) || notLeader.LeaderAddress == "" {
+		return err
+	}
+
+	log.Printf("Forwarding cluster config to the leader at %s", notLeader.LeaderAddress)
+
+	client, err := group.rpcClientManager.GetOrCreateRpcClient(&rpc.RpcClientConfig{
+		Address: notLeader.LeaderAddress,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	rpcNodes := []*rpc.NodeConfig{}
+
+	for _, node := range nodes {
+		rpcNodes = append(rpcNodes, &rpc.NodeConfig{
+			NodeId:         node.ID,
+			Address:        node.Address,
+			HashSlotsStart: uint32(node.HashSlots[0]),
+			HashSlotsEnd:   uint32(node.HashSlots[1]),
+			ReplicaOf:      node.ReplicaOf,
+		})
+	}
+
+	req := &rpc.SetClusterConfigRequest{
+		OtherNodes:      rpcNodes,
+		HashFunction:    string(group.configManager.GetClusterConfig().HashFunction.Resolved()),
+		Forwarded:       true,
+		ReplicationMode: string(mode),
+		Epoch:           epoch,
+	}
+
+	// the leader checks a quorum it's sent, and there is none outside quorum
+	// replication.
+	if entry.Quorum != nil {
+		req.Quorum = &rpc.QuorumConfig{N: uint32(quorum.N), R: uint32(quorum.R), W: uint32(quorum.W)}
+	}
+
+	_, err = client.SetClusterConfig(req)
+
+	return rpc.ServiceError(err)
+}
+
+// changeMembers adds and removes the group's members one at a time, with
+// entries that have no config, until they are the nodes in the config. It
+// checks the config is still at epoch first, so a config that would be skipped
+// doesn't change them, and that every node joining hashes keys the same way.
+func (group *ConfigGroup) changeMembers(members []Member, epoch uint64) error {
+	if !group.node.IsLeader() {
+		_, leaderAddress := group.node.Leader()
+		return &service.NotLeaderError{LeaderAddress: leaderAddress}
+	}
+
+	group.Lock()
+	current := group.epoch
+	group.Unlock()
+
+	if current != epoch {
+		return service.ErrEpochChanged
+	}
+
+	err := group.checkJoining(members)
+
+	if err != nil {
+		return err
+	}
+
+	return group.node.ChangeMembers(members)
+}
+
+// checkJoining asks every node that isn't a member yet which hash function it
+// uses. A node that hashes keys differently would refuse every config after it
+// joined, so it is turned away before it becomes a member.
+func (group *ConfigGroup) checkJoining(members []Member) error {
+	hashFunction := group.configManager.GetClusterConfig().HashFunction.Resolved()
+
+	current := map[string]bool{}
+
+	for _, member := range group.node.Members() {
+		current[member.ID] = true
+	}
+
+	for _, member := range members {
+		if current[member.ID] {
+			continue
+		}
+
+		client, err := group.rpcClientManager.GetOrCreateRpcClient(&rpc.RpcClientConfig{
+			Address: member.Address,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		resp, err := client.GetClusterConfig(&rpc.GetClusterConfigRequest{})
+
+		if err != nil {
+			return fmt.Errorf("failed to get the hash function of node %s: %w", member.Address, err)
+		}
+
+		if rpc.RemoteHashFunction(resp.GetHashFunction()) != hashFunction {
+			return fmt.Errorf("node %s uses the %s hash function but this cluster uses %s", member.Address, rpc.RemoteHashFunction(resp.GetHashFunction()), hashFunction)
+		}
+	}
+
+	return nil
+}
+
+// refused returns the error a config was refused with, if one was.
+func (group *ConfigGroup) refused() error {
+	group.Lock()
+	defer group.Unlock()
+
+	return group.err
+}
+
+// checkSkipped fails with service.ErrEpochChanged if the entry at index was
+// skipped, once it has been applied.
+func (group *ConfigGroup) checkSkipped(index uint64) error {
+	group.Lock()
+	defer group.Unlock()
+
+	if group.skipped[index] {
+		delete(group.skipped, index)
+		return service.ErrEpochChanged
+	}
+
+	return nil
+}
+
+// skippedKept is how many entries back a skipped entry is remembered for its
+// proposer to find.
+const skippedKept = 1000
+
+// apply makes a committed config this node's.
+func (group *ConfigGroup) apply(entry *Entry) {
+	if len(entry.Data) == 0 {
+		return
+	}
+
+	var config configEntry
+
+	err := json.Unmarshal(entry.Data, &config)
+
+	if err != nil {
+		log.Printf("Failed to read cluster config at index %d %v", entry.Index, err)
+		return
+	}
+
+	group.Lock()
+
+	for index := range group.skipped {
+		if index+skippedKept < entry.Index {
+			delete(group.skipped, index)
+		}
+	}
+
+	if config.Epoch != group.epoch {
+		log.Printf("Skipping cluster config at index %d, it was made from epoch %d but the config is at %d", entry.Index, config.Epoch, group.epoch)
+		group.skipped[entry.Index] = true
+		group.Unlock()
+		return
+	}
+
+	group.epoch = entry.Index
+	group.Unlock()
+
+	clusterConfig := group.configManager.GetClusterConfig()
+
+	if config.HashFunction != clusterConfig.HashFunction.Resolved() {
+		err := fmt.Errorf("the cluster config at index %d hashes keys with %s but this node uses %s", entry.Index, config.HashFunction, clusterConfig.HashFunction.Resolved())
+		log.Printf("Refusing cluster config: %v", err)
+
+		group.Lock()
+		group.err = err
+		group.Unlock()
+
+		return
+	}
+
+	quorum := configuration.QuorumConfig{}
+
+	if config.Quorum != nil {
+		quorum = *config.Quorum
+	}
+
+	var thisNode *configuration.NodeConfig
+
+	otherNodes := []*configuration.NodeConfig{}
+
+	for _, node := range config.Nodes {
+		if node.ID == clusterConfig.ThisNode.ID {
+			thisNode = node
+		} else {
+			otherNodes = append(otherNodes, node)
+		}
+	}
+
+	if thisNode == nil {
+		log.Printf("Cluster config at index %d doesn't have this node, leaving it out", entry.Index)
+		return
+	}
+
+	group.configManager.SetClusterConfig(&configuration.ClusterConfig{
+		ThisNode:        thisNode,
+		OtherNodes:      otherNodes,
+		HashFunction:    clusterConfig.HashFunction,
+		ReplicationMode: config.ReplicationMode.Resolved(),
+		Quorum:          quorum,
+		Epoch:           entry.Index,
+	})
+}
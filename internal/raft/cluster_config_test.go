@@ -0,0 +1,316 @@
+package raft
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/ethan-stone/go-key-store/internal/configuration"
+	"github.com/ethan-stone/go-key-store/internal/hash"
+	"github.com/ethan-stone/go-key-store/internal/rpc"
+	"github.com/ethan-stone/go-key-store/internal/service"
+)
+
+func TestCommittedClusterConfigShouldBeApplied(t *testing.T) {
+	configManager := configuration.NewBaseConfigurationManager(&configuration.ClusterConfig{
+		ThisNode:     &configuration.NodeConfig{ID: "n0", Address: "n0", HashSlots: []int{0, 16383}},
+		HashFunction: hash.CRC16,
+	})
+
+	dataDir := t.TempDir()
+	network := &memNetwork{nodes: make(map[string]*Node), down: make(map[string]bool)}
+
+	group, err := OpenConfigGroup(&ConfigGroupConfig{
+		DataDir:       dataDir,
+		ConfigManager: configManager,
+		Transport:     &memTransport{network: network, from: "n0"},
+	})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening the config group %v", err)
+	}
+
+	err = group.Bootstrap()
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when starting the config group %v", err)
+	}
+
+	err = group.ProposeClusterConfig([]*configuration.NodeConfig{
+		{ID: "n0", Address: "n0", HashSlots: []int{0, 8191}},
+	}, configuration.QuorumReplication, configuration.QuorumConfig{N: 2, R: 1, W: 2}, 0, true)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when proposing a config %v", err)
+	}
+
+	clusterConfig := configManager.GetClusterConfig()
+
+	// the first entry is the empty one the group started with.
+	if clusterConfig.Epoch != 2 || clusterConfig.ThisNode.HashSlots[1] != 8191 || clusterConfig.HashFunction != hash.CRC16 || clusterConfig.ReplicationMode != configuration.QuorumReplication || clusterConfig.Quorum.W != 2 {
+		t.Fatalf("Expected the config to be applied at epoch 2, got %v at epoch %d", clusterConfig.ThisNode, clusterConfig.Epoch)
+	}
+
+	// a config without this node is left out.
+	data, _ := json.Marshal(&configEntry{Nodes: []*configuration.NodeConfig{{ID: "other", Address: "other", HashSlots: []int{0, 16383}}}, HashFunction: hash.CRC16, Epoch: 2})
+
+	group.apply(&Entry{Index: 3, Data: data})
+
+	if configManager.GetClusterConfig().Epoch != 2 {
+		t.Errorf("Did not expect a config without this node to be applied")
+	}
+
+	group.Close()
+
+	restartedManager := configuration.NewBaseConfigurationManager(&configuration.ClusterConfig{
+		ThisNode: &configuration.NodeConfig{ID: "n0", Address: "n0", HashSlots: []int{0, 16383}},
+	})
+
+	restarted, err := OpenConfigGroup(&ConfigGroupConfig{
+		DataDir:       dataDir,
+		ConfigManager: restartedManager,
+		Transport:     &memTransport{network: network, from: "n0"},
+	})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when reopening the config group %v", err)
+	}
+
+	defer restarted.Close()
+
+	if restartedManager.GetClusterConfig().Epoch != 2 || restartedManager.GetClusterConfig().ThisNode.HashSlots[1] != 8191 || restartedManager.GetClusterConfig().ReplicationMode != configuration.QuorumReplication {
+		t.Errorf("Expected the committed config to be applied again after a restart")
+	}
+}
+
+func TestNodeWithAnotherHashFunctionShouldRefuseTheClusterConfig(t *testing.T) {
+	configManager := configuration.NewBaseConfigurationManager(&configuration.ClusterConfig{
+		ThisNode:     &configuration.NodeConfig{ID: "n0", Address: "n0", HashSlots: []int{0, 16383}},
+		HashFunction: hash.CRC16,
+	})
+
+	dataDir := t.TempDir()
+	network := &memNetwork{nodes: make(map[string]*Node), down: make(map[string]bool)}
+
+	group, err := OpenConfigGroup(&ConfigGroupConfig{
+		DataDir:       dataDir,
+		ConfigManager: configManager,
+		Transport:     &memTransport{network: network, from: "n0"},
+	})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening the config group %v", err)
+	}
+
+	err = group.Bootstrap()
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when starting the config group %v", err)
+	}
+
+	err = group.ProposeClusterConfig([]*configuration.NodeConfig{
+		{ID: "n0", Address: "n0", HashSlots: []int{0, 16383}},
+	}, configuration.SingleOwner, configuration.QuorumConfig{}, 0, true)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when proposing a config %v", err)
+	}
+
+	group.Close()
+
+	// the node is restarted with --hash-function=crc32.
+	restartedManager := configuration.NewBaseConfigurationManager(&configuration.ClusterConfig{
+		ThisNode:     &configuration.NodeConfig{ID: "n0", Address: "n0", HashSlots: []int{0, 16383}},
+		HashFunction: hash.CRC32,
+	})
+
+	_, err = OpenConfigGroup(&ConfigGroupConfig{
+		DataDir:       dataDir,
+		ConfigManager: restartedManager,
+		Transport:     &memTransport{network: network, from: "n0"},
+	})
+
+	if err == nil {
+		t.Fatalf("Expected a node with another hash function to refuse the cluster config")
+	}
+
+	if restartedManager.GetClusterConfig().Epoch != 0 {
+		t.Errorf("Did not expect the cluster config to be applied, got epoch %d", restartedManager.GetClusterConfig().Epoch)
+	}
+}
+
+func TestClusterConfigFromAnOldEpochShouldBeSkipped(t *testing.T) {
+	configManager := configuration.NewBaseConfigurationManager(&configuration.ClusterConfig{
+		ThisNode: &configuration.NodeConfig{ID: "n0", Address: "n0", HashSlots: []int{0, 16383}},
+	})
+
+	network := &memNetwork{nodes: make(map[string]*Node), down: make(map[string]bool)}
+
+	group, err := OpenConfigGroup(&ConfigGroupConfig{
+		DataDir:       t.TempDir(),
+		ConfigManager: configManager,
+		Transport:     &memTransport{network: network, from: "n0"},
+	})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening the config group %v", err)
+	}
+
+	err = group.Bootstrap()
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when starting the config group %v", err)
+	}
+
+	defer group.Close()
+
+	propose := func(end int, epoch uint64) error {
+		return group.ProposeClusterConfig([]*configuration.NodeConfig{
+			{ID: "n0", Address: "n0", HashSlots: []int{0, end}},
+		}, configuration.SingleOwner, configuration.QuorumConfig{}, epoch, true)
+	}
+
+	err = propose(8191, 0)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when proposing a config %v", err)
+	}
+
+	// both of these were made from the first config, so the second undoes
+	// nothing.
+	err = propose(4095, 2)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when proposing a config %v", err)
+	}
+
+	err = propose(100, 2)
+
+	if !errors.Is(err, service.ErrEpochChanged) {
+		t.Fatalf("Expected a config made from an old epoch to be skipped, got %v", err)
+	}
+
+	clusterConfig := configManager.GetClusterConfig()
+
+	if clusterConfig.ThisNode.HashSlots[1] != 4095 || clusterConfig.Epoch != 3 {
+		t.Errorf("Expected the config at epoch 3 to be kept, got %v at epoch %d", clusterConfig.ThisNode, clusterConfig.Epoch)
+	}
+
+	err = propose(100, 3)
+
+	if err != nil || configManager.GetClusterConfig().ThisNode.HashSlots[1] != 100 {
+		t.Errorf("Expected a config made from the current epoch to be applied, got %v", err)
+	}
+}
+
+// fakeClusterClient answers GetClusterConfig with the hash function its node
+// uses.
+type fakeClusterClient struct {
+	rpc.RpcClient
+	hashFunction hash.Function
+}
+
+func (client *fakeClusterClient) GetClusterConfig(req *rpc.GetClusterConfigRequest) (*rpc.GetClusterConfigResponse, error) {
+	return &rpc.GetClusterConfigResponse{Ok: true, HashFunction: string(client.hashFunction)}, nil
+}
+
+type fakeClusterClientManager struct {
+	rpc.RpcClientManager
+	hashFunctions map[string]hash.Function
+}
+
+func (manager *fakeClusterClientManager) GetOrCreateRpcClient(config *rpc.RpcClientConfig) (rpc.RpcClient, error) {
+	return &fakeClusterClient{hashFunction: manager.hashFunctions[config.Address]}, nil
+}
+
+func TestNodeWithAnotherHashFunctionShouldNotJoin(t *testing.T) {
+	configManager := configuration.NewBaseConfigurationManager(&configuration.ClusterConfig{
+		ThisNode:     &configuration.NodeConfig{ID: "n0", Address: "n0", HashSlots: []int{0, 16383}},
+		HashFunction: hash.CRC16,
+	})
+
+	network := &memNetwork{nodes: make(map[string]*Node), down: make(map[string]bool)}
+
+	group, err := OpenConfigGroup(&ConfigGroupConfig{
+		DataDir:          t.TempDir(),
+		ConfigManager:    configManager,
+		RpcClientManager: &fakeClusterClientManager{hashFunctions: map[string]hash.Function{"n1": hash.CRC32}},
+		Transport:        &memTransport{network: network, from: "n0"},
+	})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening the config group %v", err)
+	}
+
+	err = group.Bootstrap()
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when starting the config group %v", err)
+	}
+
+	defer group.Close()
+
+	err = group.ProposeClusterConfig([]*configuration.NodeConfig{
+		{ID: "n0", Address: "n0", HashSlots: []int{0, 8191}},
+		{ID: "n1", Address: "n1", HashSlots: []int{8192, 16383}},
+	}, configuration.SingleOwner, configuration.QuorumConfig{}, 0, true)
+
+	if err == nil {
+		t.Fatalf("Expected a node with another hash function to be turned away")
+	}
+
+	if members := group.node.Members(); len(members) != 1 || members[0].ID != "n0" {
+		t.Errorf("Did not expect the node to become a member, got %v", members)
+	}
+
+	if configManager.GetClusterConfig().Epoch != 0 {
+		t.Errorf("Did not expect the config to be committed, got epoch %d", configManager.GetClusterConfig().Epoch)
+	}
+}
+
+func TestBootstrapShouldCarryOnUntilAConfigIsCommitted(t *testing.T) {
+	configManager := configuration.NewBaseConfigurationManager(&configuration.ClusterConfig{
+		ThisNode: &configuration.NodeConfig{ID: "n0", Address: "n0", HashSlots: []int{0, 16383}},
+	})
+
+	network := &memNetwork{nodes: make(map[string]*Node), down: make(map[string]bool)}
+
+	group, err := OpenConfigGroup(&ConfigGroupConfig{
+		DataDir:       t.TempDir(),
+		ConfigManager: configManager,
+		Transport:     &memTransport{network: network, from: "n0"},
+	})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening the config group %v", err)
+	}
+
+	defer group.Close()
+
+	err = group.Bootstrap()
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when starting the config group %v", err)
+	}
+
+	// a create that timed out before its config was committed is run again.
+	err = group.Bootstrap()
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when starting the config group again %v", err)
+	}
+
+	err = group.ProposeClusterConfig([]*configuration.NodeConfig{
+		{ID: "n0", Address: "n0", HashSlots: []int{0, 16383}},
+	}, configuration.SingleOwner, configuration.QuorumConfig{}, 0, true)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when proposing a config %v", err)
+	}
+
+	err = group.Bootstrap()
+
+	if !errors.Is(err, ErrAlreadyStarted) {
+		t.Errorf("Expected a cluster with a config not to be started again, got %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package raft
+
+import (
+	"sync"
+
+	"github.com/ethan-stone/go-key-store/internal/configuration"
+	"github.com/ethan-stone/go-key-store/internal/rpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Host hands raft messages to the group on this node they are for.
+type Host struct {
+	sync.RWMutex
+	configGroup *ConfigGroup
+	groups      map[string]*Node
+}
+
+func NewHost(configGroup *ConfigGroup) *Host {
+	return &Host{
+		configGroup: configGroup,
+		groups: map[string]*Node{
+			ConfigGroupName: configGroup.node,
+		},
+	}
+}
+
+// AddGroup starts handing messages for the group to node.
+func (host *Host) AddGroup(name string, node *Node) {
+	host.Lock()
+	defer host.Unlock()
+
+	host.groups[name] = node
+}
+
+func (host *Host) RemoveGroup(name string) {
+	host.Lock()
+	defer host.Unlock()
+
+	delete(host.groups, name)
+}
+
+func (host *Host) group(name string) (*Node, error) {
+	host.RLock()
+	defer host.RUnlock()
+
+	node, ok := host.groups[name]
+
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "this node isn't in raft group %s", name)
+	}
+
+	return node, nil
+}
+
+func (host *Host) RaftVote(req *rpc.RaftVoteRequest) (*rpc.RaftVoteResponse, error) {
+	node, err := host.group(req.GetGroup())
+
+	if err != nil {
+		return nil, err
+	}
+
+	return node.HandleVote(req), nil
+}
+
+func (host *Host) RaftAppend(req *rpc.RaftAppendRequest) (*rpc.RaftAppendResponse, error) {
+	node, err := host.group(req.GetGroup())
+
+	if err != nil {
+		return nil, err
+	}
+
+	return node.HandleAppend(req), nil
+}
+
+func (host *Host) BootstrapClusterConfig() error {
+	return host.configGroup.Bootstrap()
+}
+
+func (host *Host) ProposeClusterConfig(nodes []*configuration.NodeConfig, mode configuration.ReplicationMode, quorum configuration.QuorumConfig, epoch uint64, forward bool) error {
+	return host.configGroup.ProposeClusterConfig(nodes, mode, quorum, epoch, forward)
+}
@@ -0,0 +1,1282 @@
+// Package raft keeps a log replicated across a group of nodes with the raft
+// consensus algorithm, https://raft.github.io/raft.pdf. A group elects a
+// leader, the leader appends every change to its log and sends it to the other
+// members, and a change is committed and applied on every member once a
+// majority of the members have it.
+//
+// The members of a group are the ones given by the last entry in the log, so
+// a change of members takes effect as soon as it is appended, before it is
+// committed. Members are added or removed one at a time, and a change has to
+// be committed before the next one is made, so a majority of the old members
+// and a majority of the new ones always have a member in common. A node with
+// an empty log isn't a member of anything, and only starts an election once a
+// leader has sent it an entry that makes it one. A group is only ever started
+// by calling Bootstrap on one node.
+//
+// Whatever applies the entries can let a group drop the ones it has applied
+// with Compact. A member that is missing entries the leader has dropped is
+// asked to load the leader's state some other way, and carries on from there
+// once it calls Restore.
+package raft
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethan-stone/go-key-store/internal/rpc"
+	"github.com/ethan-stone/go-key-store/internal/service"
+)
+
+const (
+	DefaultElectionTimeout   = time.Second
+	DefaultHeartbeatInterval = 200 * time.Millisecond
+	// ProposeTimeout is how long a proposal waits to be committed.
+	ProposeTimeout = 5 * time.Second
+	tickInterval   = 20 * time.Millisecond
+	// maxAppendEntries is the most entries sent in one append, so a member
+	// that is far behind catches up over a few.
+	maxAppendEntries = 100
+)
+
+// ErrProposalLost is returned when the leader loses its leadership before a
+// proposal is committed, and the new leader doesn't have it.
+var ErrProposalLost = errors.New("proposal was lost to a new leader")
+
+// ErrProposalTimeout is returned when a proposal isn't committed in time. It
+// could still be committed later.
+var ErrProposalTimeout = errors.New("timed out waiting for the proposal to be committed")
+
+// ErrBehind is returned by ProposeAt when the log already has an entry at the
+// index, which a new leader has until whatever applies its entries catches up.
+var ErrBehind = fmt.Errorf("%w: still applying entries from the last leader", service.ErrNotLeader)
+
+// ErrReadTimeout is returned when the leader can't hear from a majority of the
+// members in time to make sure it is still the leader.
+var ErrReadTimeout = errors.New("timed out making sure this node is still the leader")
+
+// ErrClosed is returned once the node has been closed.
+var ErrClosed = errors.New("raft node is closed")
+
+// ErrMembershipChange is returned for a proposal that would add or remove more
+// than one member, or change the members while an earlier change hasn't been
+// committed yet.
+var ErrMembershipChange = errors.New("members can only be added or removed one at a time")
+
+// ErrAlreadyStarted is returned by Bootstrap on a node that is already in a
+// group.
+var ErrAlreadyStarted = errors.New("raft group has already been started")
+
+type Member struct {
+	ID      string `json:"id"`
+	Address string `json:"address"`
+}
+
+type Entry struct {
+	Term  uint64 `json:"term"`
+	Index uint64 `json:"index"`
+	// Data is empty for the entry a new leader appends to commit everything
+	// from earlier terms.
+	Data    []byte   `json:"data,omitempty"`
+	Members []Member `json:"members"`
+}
+
+// Transport sends raft messages to the other members.
+type Transport interface {
+	RequestVote(address string, req *rpc.RaftVoteRequest) (*rpc.RaftVoteResponse, error)
+	AppendEntries(address string, req *rpc.RaftAppendRequest) (*rpc.RaftAppendResponse, error)
+}
+
+type NodeConfig struct {
+	ID      string
+	Address string
+	Group   string
+	// Dir is where the group's term, vote and log are kept.
+	Dir       string
+	Transport Transport
+	// Apply is called with every committed entry once, in order.
+	Apply func(entry *Entry)
+	// InstallSnapshot is called in the background when the leader no longer
+	// has entries this member is missing. It should load the leader's state
+	// up to some index and call Restore with it. Groups that never compact
+	// don't need it.
+	InstallSnapshot   func(leaderAddress string) error
+	ElectionTimeout   time.Duration // DefaultElectionTimeout if 0
+	HeartbeatInterval time.Duration // DefaultHeartbeatInterval if 0
+}
+
+type role int
+
+const (
+	follower role = iota
+	candidate
+	leader
+)
+
+type Node struct {
+	sync.Mutex
+	config  *NodeConfig
+	state   *persistentState
+	saved   persistentState // what was last saved of state, to skip saving it again
+	storage *storage
+	// log has the entries after the state's SnapshotIndex.
+	log           []*Entry
+	logged        uint64 // the last index in the log file
+	rewrite       bool   // whether entries have been dropped since the log file was written
+	role          role
+	leaderID      string
+	leaderAddress string
+	// the election starts if the deadline passes without hearing from a
+	// leader.
+	electionDeadline time.Time
+	lastHeartbeat    time.Time
+	nextIndex        map[string]uint64
+	matchIndex       map[string]uint64
+	appending        map[string]bool // members with an append in flight
+	// acked is when the last append each member answered was sent, which is
+	// how the leader knows it still is one.
+	acked         map[string]time.Time
+	leaderContact time.Time // when the last append from the leader arrived
+	restoring     bool      // whether InstallSnapshot is running
+	closed        bool
+	applyLock     sync.Mutex
+	lastApplied   uint64 // handed to apply
+	applied       uint64 // apply has returned for
+	changed       *sync.Cond
+	stop          chan struct{}
+}
+
+// OpenNode loads the group's state from the config's Dir, applies the entries
+// it knows are committed and starts taking part in the group.
+func OpenNode(config *NodeConfig) (*Node, error) {
+	if config.ElectionTimeout == 0 {
+		config.ElectionTimeout = DefaultElectionTimeout
+	}
+
+	if config.HeartbeatInterval == 0 {
+		config.HeartbeatInterval = DefaultHeartbeatInterval
+	}
+
+	err := os.MkdirAll(config.Dir, 0755)
+
+	if err != nil {
+		return nil, err
+	}
+
+	storage, state, entries, err := openStorage(config.Dir, config.Group)
+
+	if err != nil {
+		return nil, err
+	}
+
+	node := &Node{
+		config:      config,
+		state:       state,
+		saved:       *state,
+		storage:     storage,
+		nextIndex:   make(map[string]uint64),
+		matchIndex:  make(map[string]uint64),
+		appending:   make(map[string]bool),
+		acked:       make(map[string]time.Time),
+		lastApplied: state.SnapshotIndex,
+		applied:     state.SnapshotIndex,
+		stop:        make(chan struct{}),
+	}
+
+	// a crash between saving a new snapshot index and writing the log out
+	// without the entries before it leaves them in the file.
+	for _, entry := range entries {
+		if entry.Index > state.SnapshotIndex {
+			node.log = append(node.log, entry)
+		}
+	}
+
+	node.logged = node.lastIndex()
+	node.changed = sync.NewCond(node)
+	node.resetElectionDeadline()
+
+	node.applyCommitted()
+
+	go node.run()
+
+	return node, nil
+}
+
+// Close stops the node taking part in the group.
+func (node *Node) Close() {
+	node.Lock()
+	defer node.Unlock()
+
+	if node.closed {
+		return
+	}
+
+	node.closed = true
+	node.role = follower
+	node.storage.close()
+	close(node.stop)
+	node.changed.Broadcast()
+}
+
+// Leader returns the ID and address of the leader, empty if the node doesn't
+// know of one.
+func (node *Node) Leader() (string, string) {
+	node.Lock()
+	defer node.Unlock()
+
+	return node.leaderID, node.leaderAddress
+}
+
+func (node *Node) IsLeader() bool {
+	node.Lock()
+	defer node.Unlock()
+
+	return node.role == leader
+}
+
+// Members returns the members of the group, none if the node's log is empty.
+func (node *Node) Members() []Member {
+	node.Lock()
+	defer node.Unlock()
+
+	return append([]Member{}, node.members()...)
+}
+
+// LastIndex returns the index of the last entry in the log, 0 if it is empty.
+func (node *Node) LastIndex() uint64 {
+	node.Lock()
+	defer node.Unlock()
+
+	return node.lastIndex()
+}
+
+// TermAt returns the term of the entry at index, 0 if the log doesn't have it
+// anymore or yet.
+func (node *Node) TermAt(index uint64) uint64 {
+	node.Lock()
+	defer node.Unlock()
+
+	return node.termAt(index)
+}
+
+func (node *Node) run() {
+	ticker := time.NewTicker(tickInterval)
+
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-node.stop:
+			return
+		case <-ticker.C:
+		}
+
+		node.Lock()
+
+		switch {
+		case node.role == leader && time.Since(node.lastHeartbeat) >= node.config.HeartbeatInterval:
+			node.replicate()
+		case node.role != leader && time.Now().After(node.electionDeadline) && node.isMember(node.config.ID):
+			node.campaign()
+		}
+
+		node.Unlock()
+	}
+}
+
+// Bootstrap starts the group with this node as its only member and first
+// leader. Its log starts after index, for callers that already have everything
+// up to it, with an entry that has no data. It fails on a node that is already
+// in a group, so a node that has lost its state can't start one of its own.
+func (node *Node) Bootstrap(index uint64) error {
+	node.Lock()
+	defer node.Unlock()
+
+	if node.closed {
+		return ErrClosed
+	}
+
+	if node.lastIndex() > 0 || node.leaderID != "" || len(node.members()) > 0 {
+		return ErrAlreadyStarted
+	}
+
+	log.Printf("Starting raft group %s", node.config.Group)
+
+	node.state.SnapshotIndex = index
+	node.state.SnapshotMembers = []Member{{ID: node.config.ID, Address: node.config.Address}}
+	node.state.Commit = index
+	node.state.Term++
+	node.state.VotedFor = node.config.ID
+	node.lastApplied = index
+	node.applied = index
+	node.rewrite = true
+
+	err := node.persist()
+
+	if err != nil {
+		return err
+	}
+
+	node.becomeLeader()
+
+	return nil
+}
+
+// Propose appends data to the log with the given members, and waits for it to
+// be committed and applied on this node. It fails with a
+// *service.NotLeaderError on a node that isn't the leader.
+func (node *Node) Propose(data []byte, members []Member) (uint64, error) {
+	return node.propose(0, data, members, true)
+}
+
+// ProposeAt is Propose for an entry that has to be at index, which only waits
+// for it to be committed. Nil members keeps the members the group has.
+func (node *Node) ProposeAt(index uint64, data []byte, members []Member) error {
+	_, err := node.propose(index, data, members, false)
+
+	return err
+}
+
+// ChangeMembers adds and removes members one at a time until the group has
+// the given ones, committing an entry with no data for each.
+func (node *Node) ChangeMembers(members []Member) error {
+	for {
+		next := NextMembers(node.Members(), members)
+
+		if next == nil {
+			return nil
+		}
+
+		_, err := node.Propose(nil, next)
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// NextMembers is current with one member added or removed on the way to
+// target, adding before removing. It is nil once current has the same members
+// as target.
+func NextMembers(current []Member, target []Member) []Member {
+	for _, member := range target {
+		if !hasMember(current, member.ID) {
+			return append(append([]Member{}, current...), member)
+		}
+	}
+
+	for i, member := range current {
+		if !hasMember(target, member.ID) {
+			return append(append([]Member{}, current[:i]...), current[i+1:]...)
+		}
+	}
+
+	return nil
+}
+
+func (node *Node) propose(index uint64, data []byte, members []Member, waitForApply bool) (uint64, error) {
+	node.Lock()
+	defer node.Unlock()
+
+	if node.closed {
+		return 0, ErrClosed
+	}
+
+	if node.role != leader {
+		return 0, &service.NotLeaderError{LeaderAddress: node.leaderAddress}
+	}
+
+	if index == 0 {
+		index = node.lastIndex() + 1
+	}
+
+	if index != node.lastIndex()+1 {
+		return 0, ErrBehind
+	}
+
+	if members == nil {
+		members = node.members()
+	}
+
+	if changed := membersChanged(node.members(), members); changed > 1 || (changed == 1 && node.changingMembers()) {
+		return 0, ErrMembershipChange
+	}
+
+	entry := &Entry{
+		Term:    node.state.Term,
+		Index:   index,
+		Data:    data,
+		Members: members,
+	}
+
+	node.log = append(node.log, entry)
+
+	err := node.persist()
+
+	if err != nil {
+		node.log = node.log[:len(node.log)-1]
+		return 0, err
+	}
+
+	node.replicate()
+	node.advanceCommit()
+
+	timedOut := false
+
+	timer := time.AfterFunc(ProposeTimeout, func() {
+		node.Lock()
+		timedOut = true
+		node.changed.Broadcast()
+		node.Unlock()
+	})
+
+	defer timer.Stop()
+
+	for {
+		if (waitForApply && node.applied >= entry.Index) || (!waitForApply && node.state.Commit >= entry.Index) {
+			return entry.Index, nil
+		}
+
+		if node.closed {
+			return 0, ErrClosed
+		}
+
+		if node.termAt(entry.Index) != entry.Term {
+			return 0, ErrProposalLost
+		}
+
+		if timedOut {
+			return 0, ErrProposalTimeout
+		}
+
+		node.changed.Wait()
+	}
+}
+
+// ReadIndex makes sure this node is still the leader, and returns the commit
+// index a read has to wait to be applied to see every write committed before
+// it started. The leader is sure while a majority of the members have answered
+// an append sent in the last half an election timeout, since none of them
+// votes for another leader until it hasn't heard from this one for a whole
+// one. Otherwise it sends another round of appends.
+func (node *Node) ReadIndex() (uint64, error) {
+	node.Lock()
+	defer node.Unlock()
+
+	start := time.Now()
+	sent := false
+	timedOut := false
+
+	timer := time.AfterFunc(ProposeTimeout, func() {
+		node.Lock()
+		timedOut = true
+		node.changed.Broadcast()
+		node.Unlock()
+	})
+
+	defer timer.Stop()
+
+	for {
+		if node.closed {
+			return 0, ErrClosed
+		}
+
+		if node.role != leader {
+			return 0, &service.NotLeaderError{LeaderAddress: node.leaderAddress}
+		}
+
+		// a new leader doesn't know how much is committed until an entry
+		// from its own term is.
+		if node.termAt(node.state.Commit) == node.state.Term {
+			if node.heardFromQuorumSince(time.Now().Add(-node.config.ElectionTimeout/2)) || node.heardFromQuorumSince(start) {
+				return node.state.Commit, nil
+			}
+
+			if !sent {
+				node.replicate()
+				sent = true
+			}
+		}
+
+		if timedOut {
+			return 0, ErrReadTimeout
+		}
+
+		node.changed.Wait()
+	}
+}
+
+// Compact drops the entries up to index from the log, or up to the last one
+// that has been applied if that is earlier.
+func (node *Node) Compact(index uint64) error {
+	node.Lock()
+	defer node.Unlock()
+
+	index = min(index, node.applied)
+
+	if node.closed || index <= node.state.SnapshotIndex {
+		return nil
+	}
+
+	dropped := node.entry(index)
+
+	node.state.SnapshotTerm = dropped.Term
+	node.state.SnapshotMembers = dropped.Members
+	node.log = append([]*Entry{}, node.log[index-node.state.SnapshotIndex:]...)
+	node.state.SnapshotIndex = index
+	node.rewrite = true
+
+	return node.persist()
+}
+
+// Restore carries on after index, whose entry was from term, once whatever
+// applies the entries has everything up to it from elsewhere. The entries the
+// log has after index are kept if the log has the same entry at index.
+func (node *Node) Restore(index uint64, term uint64) error {
+	node.applyLock.Lock()
+	defer node.applyLock.Unlock()
+
+	node.Lock()
+	defer node.Unlock()
+
+	if node.closed {
+		return ErrClosed
+	}
+
+	members := node.members()
+
+	if index >= node.state.SnapshotIndex && index <= node.lastIndex() && node.termAt(index) == term {
+		node.log = append([]*Entry{}, node.log[index-node.state.SnapshotIndex:]...)
+	} else {
+		node.log = nil
+	}
+
+	log.Printf("Restored raft group %s at index %d", node.config.Group, index)
+
+	node.state.SnapshotIndex = index
+	node.state.SnapshotTerm = term
+	node.state.SnapshotMembers = members
+	node.state.Commit = max(node.state.Commit, index)
+	node.lastApplied = index
+	node.applied = index
+	node.rewrite = true
+
+	err := node.persist()
+
+	if err != nil {
+		return err
+	}
+
+	node.changed.Broadcast()
+
+	go node.applyCommitted()
+
+	return nil
+}
+
+// HandleVote answers a candidate asking for this node's vote, or whether it
+// would get it if it started an election.
+func (node *Node) HandleVote(req *rpc.RaftVoteRequest) *rpc.RaftVoteResponse {
+	node.Lock()
+	defer node.Unlock()
+
+	// a member that has heard from the leader recently ignores candidates, so
+	// a member that was cut off can't depose a leader that is still working,
+	// and the leader can count on its followers for reads.
+	if node.role == leader || (node.leaderID != "" && time.Since(node.leaderContact) < node.config.ElectionTimeout) {
+		return &rpc.RaftVoteResponse{Term: node.state.Term}
+	}
+
+	// a candidate whose log is behind this node's could be missing committed
+	// entries.
+	upToDate := req.GetLastLogTerm() > node.lastTerm() || (req.GetLastLogTerm() == node.lastTerm() && req.GetLastLogIndex() >= node.lastIndex())
+
+	if req.GetPreVote() {
+		return &rpc.RaftVoteResponse{
+			Term:    node.state.Term,
+			Granted: upToDate && req.GetTerm() >= node.state.Term,
+		}
+	}
+
+	if req.GetTerm() > node.state.Term {
+		node.stepDown(req.GetTerm())
+	}
+
+	response := &rpc.RaftVoteResponse{Term: node.state.Term}
+
+	if req.GetTerm() < node.state.Term {
+		return response
+	}
+
+	if !upToDate || (node.state.VotedFor != "" && node.state.VotedFor != req.GetCandidateId()) {
+		return response
+	}
+
+	node.state.VotedFor = req.GetCandidateId()
+
+	err := node.persist()
+
+	if err != nil {
+		log.Printf("Failed to save raft group %s vote %v", node.config.Group, err)
+		return response
+	}
+
+	node.resetElectionDeadline()
+
+	response.Granted = true
+
+	return response
+}
+
+// HandleAppend appends the leader's entries to this node's log, replacing any
+// that don't match the leader's, and commits what the leader has.
+func (node *Node) HandleAppend(req *rpc.RaftAppendRequest) *rpc.RaftAppendResponse {
+	node.Lock()
+	defer node.Unlock()
+
+	response := &rpc.RaftAppendResponse{Term: node.state.Term}
+
+	if req.GetTerm() < node.state.Term {
+		return response
+	}
+
+	if req.GetTerm() > node.state.Term || node.role != follower {
+		node.stepDown(req.GetTerm())
+		response.Term = node.state.Term
+	}
+
+	if node.leaderID != req.GetLeaderId() {
+		log.Printf("Following raft group %s leader %s at term %d", node.config.Group, req.GetLeaderId(), req.GetTerm())
+	}
+
+	node.leaderID = req.GetLeaderId()
+	node.leaderAddress = req.GetLeaderAddress()
+	node.leaderContact = time.Now()
+	node.resetElectionDeadline()
+
+	prev := req.GetPrevLogIndex()
+	last := prev + uint64(len(req.GetEntries()))
+
+	switch {
+	case prev > node.lastIndex():
+		response.ConflictIndex = node.lastIndex() + 1
+	// entries up to the snapshot are committed, so they are the leader's
+	// too.
+	case prev >= node.state.SnapshotIndex && node.termAt(prev) != req.GetPrevLogTerm():
+		// go back to the start of the term that doesn't match, rather than
+		// one entry at a time.
+		conflict := prev
+
+		for conflict > node.state.SnapshotIndex+1 && node.termAt(conflict-1) == node.termAt(prev) {
+			conflict--
+		}
+
+		response.ConflictIndex = conflict
+	}
+
+	if response.ConflictIndex > 0 {
+		if req.GetSnapshot() {
+			node.installSnapshot()
+		}
+
+		return response
+	}
+
+	changed := false
+
+	for _, rpcEntry := range req.GetEntries() {
+		entry := fromRpcEntry(rpcEntry)
+
+		if entry.Index <= node.state.SnapshotIndex {
+			continue
+		}
+
+		if entry.Index <= node.lastIndex() {
+			if node.termAt(entry.Index) == entry.Term {
+				continue
+			}
+
+			node.log = node.log[:entry.Index-node.state.SnapshotIndex-1]
+			node.rewrite = true
+		}
+
+		node.log = append(node.log, entry)
+		changed = true
+	}
+
+	if commit := min(req.GetLeaderCommit(), last); commit > node.state.Commit {
+		node.state.Commit = commit
+		changed = true
+
+		go node.applyCommitted()
+	}
+
+	if changed {
+		err := node.persist()
+
+		if err != nil {
+			log.Printf("Failed to save raft group %s log %v", node.config.Group, err)
+			return response
+		}
+	}
+
+	response.Success = true
+
+	return response
+}
+
+// installSnapshot asks for the leader's state in the background, unless it
+// already is being.
+func (node *Node) installSnapshot() {
+	if node.restoring || node.config.InstallSnapshot == nil {
+		return
+	}
+
+	node.restoring = true
+	leaderAddress := node.leaderAddress
+
+	go func() {
+		log.Printf("Loading raft group %s from the leader at %s", node.config.Group, leaderAddress)
+
+		err := node.config.InstallSnapshot(leaderAddress)
+
+		if err != nil {
+			log.Printf("Failed to load raft group %s from the leader %v", node.config.Group, err)
+		}
+
+		node.Lock()
+		node.restoring = false
+		node.Unlock()
+	}()
+}
+
+// campaign asks the members whether they would vote for this node before it
+// starts an election, so a member that has been cut off from the others
+// doesn't make them give up on a working leader by starting elections at ever
+// higher terms.
+func (node *Node) campaign() {
+	node.leaderID = ""
+	node.leaderAddress = ""
+	node.resetElectionDeadline()
+
+	term := node.state.Term
+	votes := 1
+
+	if votes >= node.quorum() {
+		node.elect()
+		return
+	}
+
+	req := &rpc.RaftVoteRequest{
+		Group:        node.config.Group,
+		Term:         term + 1,
+		CandidateId:  node.config.ID,
+		LastLogIndex: node.lastIndex(),
+		LastLogTerm:  node.lastTerm(),
+		PreVote:      true,
+	}
+
+	for _, member := range node.members() {
+		if member.ID == node.config.ID {
+			continue
+		}
+
+		go func() {
+			r, err := node.config.Transport.RequestVote(member.Address, req)
+
+			if err != nil {
+				return
+			}
+
+			node.Lock()
+			defer node.Unlock()
+
+			if r.GetTerm() > node.state.Term {
+				node.stepDown(r.GetTerm())
+				return
+			}
+
+			// an election has started or a leader has been heard from
+			// since.
+			if node.role == leader || node.state.Term != term || node.leaderID != "" || !r.GetGranted() {
+				return
+			}
+
+			votes++
+
+			if votes >= node.quorum() {
+				node.elect()
+			}
+		}()
+	}
+}
+
+// elect starts an election at the next term.
+func (node *Node) elect() {
+	node.state.Term++
+	node.state.VotedFor = node.config.ID
+	node.role = candidate
+	node.leaderID = ""
+	node.leaderAddress = ""
+	node.resetElectionDeadline()
+
+	err := node.persist()
+
+	if err != nil {
+		log.Printf("Failed to save raft group %s term %v", node.config.Group, err)
+		return
+	}
+
+	log.Printf("Starting an election for raft group %s at term %d", node.config.Group, node.state.Term)
+
+	term := node.state.Term
+	votes := 1
+
+	if votes >= node.quorum() {
+		node.becomeLeader()
+		return
+	}
+
+	req := &rpc.RaftVoteRequest{
+		Group:        node.config.Group,
+		Term:         term,
+		CandidateId:  node.config.ID,
+		LastLogIndex: node.lastIndex(),
+		LastLogTerm:  node.lastTerm(),
+	}
+
+	for _, member := range node.members() {
+		if member.ID == node.config.ID {
+			continue
+		}
+
+		go func() {
+			r, err := node.config.Transport.RequestVote(member.Address, req)
+
+			if err != nil {
+				return
+			}
+
+			node.Lock()
+			defer node.Unlock()
+
+			if r.GetTerm() > node.state.Term {
+				node.stepDown(r.GetTerm())
+				return
+			}
+
+			if node.role != candidate || node.state.Term != term || !r.GetGranted() {
+				return
+			}
+
+			votes++
+
+			if votes >= node.quorum() {
+				node.becomeLeader()
+			}
+		}()
+	}
+}
+
+// becomeLeader starts sending appends to the other members. A leader can only
+// tell entries from earlier terms are committed once one from its own term
+// is, so it appends an empty one straight away.
+func (node *Node) becomeLeader() {
+	log.Printf("Became leader of raft group %s at term %d", node.config.Group, node.state.Term)
+
+	node.role = leader
+	node.leaderID = node.config.ID
+	node.leaderAddress = node.config.Address
+	node.nextIndex = make(map[string]uint64)
+	node.matchIndex = make(map[string]uint64)
+	node.acked = make(map[string]time.Time)
+
+	for _, member := range node.members() {
+		node.nextIndex[member.ID] = node.lastIndex() + 1
+	}
+
+	node.log = append(node.log, &Entry{
+		Term:    node.state.Term,
+		Index:   node.lastIndex() + 1,
+		Members: node.members(),
+	})
+
+	err := node.persist()
+
+	if err != nil {
+		log.Printf("Failed to save raft group %s log %v", node.config.Group, err)
+	}
+
+	node.replicate()
+	node.advanceCommit()
+}
+
+// stepDown follows whoever leads term, which is newer than this node's.
+func (node *Node) stepDown(term uint64) {
+	if term > node.state.Term {
+		node.state.Term = term
+		node.state.VotedFor = ""
+	}
+
+	if node.role == leader {
+		log.Printf("Stepping down as leader of raft group %s at term %d", node.config.Group, term)
+	}
+
+	node.role = follower
+	node.leaderID = ""
+	node.leaderAddress = ""
+	node.resetElectionDeadline()
+
+	err := node.persist()
+
+	if err != nil {
+		log.Printf("Failed to save raft group %s term %v", node.config.Group, err)
+	}
+
+	node.changed.Broadcast()
+}
+
+// replicate sends every other member the entries it is missing, or a
+// heartbeat if it isn't missing any.
+func (node *Node) replicate() {
+	node.lastHeartbeat = time.Now()
+
+	for _, member := range node.members() {
+		if member.ID != node.config.ID && !node.appending[member.ID] {
+			node.appendTo(member)
+		}
+	}
+}
+
+func (node *Node) appendTo(member Member) {
+	next, ok := node.nextIndex[member.ID]
+
+	// a member that has just been added could have nothing.
+	if !ok || next < 1 {
+		next = 1
+	}
+
+	// the member is missing entries that have been dropped, so it has to
+	// load the state they led to first.
+	snapshot := next <= node.state.SnapshotIndex
+
+	if snapshot {
+		next = node.state.SnapshotIndex + 1
+	}
+
+	prev := next - 1
+	entries := []*rpc.RaftEntry{}
+
+	for index := next; index <= node.lastIndex() && len(entries) < maxAppendEntries; index++ {
+		entries = append(entries, toRpcEntry(node.entry(index)))
+	}
+
+	req := &rpc.RaftAppendRequest{
+		Group:         node.config.Group,
+		Term:          node.state.Term,
+		LeaderId:      node.config.ID,
+		LeaderAddress: node.config.Address,
+		PrevLogIndex:  prev,
+		PrevLogTerm:   node.termAt(prev),
+		Entries:       entries,
+		LeaderCommit:  node.state.Commit,
+		Snapshot:      snapshot,
+	}
+
+	node.appending[member.ID] = true
+	sentAt := time.Now()
+
+	go func() {
+		r, err := node.config.Transport.AppendEntries(member.Address, req)
+
+		node.Lock()
+		defer node.Unlock()
+
+		node.appending[member.ID] = false
+
+		if err != nil {
+			return
+		}
+
+		if r.GetTerm() > node.state.Term {
+			node.stepDown(r.GetTerm())
+			return
+		}
+
+		if node.role != leader || node.state.Term != req.GetTerm() {
+			return
+		}
+
+		// even a member that doesn't have the entries is following this
+		// node.
+		if sentAt.After(node.acked[member.ID]) {
+			node.acked[member.ID] = sentAt
+			node.changed.Broadcast()
+		}
+
+		if !r.GetSuccess() {
+			node.nextIndex[member.ID] = max(1, min(r.GetConflictIndex(), prev))
+
+			// a member loading a snapshot is sent another with the next
+			// heartbeat.
+			if !snapshot {
+				node.appendTo(member)
+			}
+
+			return
+		}
+
+		match := prev + uint64(len(entries))
+
+		if match > node.matchIndex[member.ID] {
+			node.matchIndex[member.ID] = match
+		}
+
+		node.nextIndex[member.ID] = match + 1
+
+		node.advanceCommit()
+
+		if match < node.lastIndex() {
+			node.appendTo(member)
+		}
+	}()
+}
+
+// advanceCommit commits the newest entry from this term that a majority of the
+// members have.
+func (node *Node) advanceCommit() {
+	for index := node.lastIndex(); index > node.state.Commit; index-- {
+		if node.termAt(index) != node.state.Term {
+			return
+		}
+
+		count := 0
+
+		for _, member := range node.members() {
+			if member.ID == node.config.ID || node.matchIndex[member.ID] >= index {
+				count++
+			}
+		}
+
+		if count < node.quorum() {
+			continue
+		}
+
+		node.state.Commit = index
+
+		err := node.persist()
+
+		if err != nil {
+			log.Printf("Failed to save raft group %s commit %v", node.config.Group, err)
+		}
+
+		node.changed.Broadcast()
+
+		go node.applyCommitted()
+
+		return
+	}
+}
+
+// applyCommitted applies every committed entry that hasn't been yet.
+func (node *Node) applyCommitted() {
+	node.applyLock.Lock()
+	defer node.applyLock.Unlock()
+
+	node.Lock()
+
+	entries := node.log[node.lastApplied-node.state.SnapshotIndex : node.state.Commit-node.state.SnapshotIndex]
+	node.lastApplied = node.state.Commit
+
+	node.Unlock()
+
+	for _, entry := range entries {
+		if node.config.Apply != nil {
+			node.config.Apply(entry)
+		}
+
+		node.Lock()
+		node.applied = max(node.applied, entry.Index)
+		node.changed.Broadcast()
+		node.Unlock()
+	}
+}
+
+// persist saves the state if it has changed, and the entries that haven't
+// been saved yet.
+func (node *Node) persist() error {
+	if node.closed {
+		return ErrClosed
+	}
+
+	if !node.state.equal(&node.saved) {
+		err := node.storage.saveState(node.state)
+
+		if err != nil {
+			return err
+		}
+
+		node.saved = *node.state
+	}
+
+	var err error
+
+	switch {
+	case node.rewrite:
+		err = node.storage.rewriteLog(node.log)
+	case node.logged < node.lastIndex():
+		err = node.storage.appendLog(node.log[node.logged-node.state.SnapshotIndex:])
+	}
+
+	if err != nil {
+		return err
+	}
+
+	node.rewrite = false
+	node.logged = node.lastIndex()
+
+	return nil
+}
+
+func (node *Node) resetElectionDeadline() {
+	timeout := node.config.ElectionTimeout
+
+	node.electionDeadline = time.Now().Add(timeout + time.Duration(rand.Int63n(int64(timeout))))
+}
+
+func (node *Node) members() []Member {
+	if len(node.log) == 0 {
+		return node.state.SnapshotMembers
+	}
+
+	return node.log[len(node.log)-1].Members
+}
+
+func (node *Node) isMember(id string) bool {
+	return hasMember(node.members(), id)
+}
+
+// changingMembers reports whether an entry that changes the members hasn't
+// been committed yet.
+func (node *Node) changingMembers() bool {
+	previous := node.state.SnapshotMembers
+
+	for index := node.state.SnapshotIndex + 1; index <= node.lastIndex(); index++ {
+		members := node.entry(index).Members
+
+		if index > node.state.Commit && membersChanged(previous, members) > 0 {
+			return true
+		}
+
+		previous = members
+	}
+
+	return false
+}
+
+func hasMember(members []Member, id string) bool {
+	for _, member := range members {
+		if member.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// membersChanged counts the members added and removed going from a to b.
+func membersChanged(a []Member, b []Member) int {
+	changed := 0
+
+	for _, member := range a {
+		if !hasMember(b, member.ID) {
+			changed++
+		}
+	}
+
+	for _, member := range b {
+		if !hasMember(a, member.ID) {
+			changed++
+		}
+	}
+
+	return changed
+}
+
+func (node *Node) quorum() int {
+	return len(node.members())/2 + 1
+}
+
+// heardFromQuorumSince reports whether a majority of the members, counting
+// this one, have answered an append sent at or after since.
+func (node *Node) heardFromQuorumSince(since time.Time) bool {
+	count := 0
+
+	for _, member := range node.members() {
+		if member.ID == node.config.ID || !node.acked[member.ID].Before(since) {
+			count++
+		}
+	}
+
+	return count >= node.quorum()
+}
+
+func (node *Node) lastIndex() uint64 {
+	return node.state.SnapshotIndex + uint64(len(node.log))
+}
+
+// entry returns the entry at index, which has to be in the log.
+func (node *Node) entry(index uint64) *Entry {
+	return node.log[index-node.state.SnapshotIndex-1]
+}
+
+func (node *Node) lastTerm() uint64 {
+	return node.termAt(node.lastIndex())
+}
+
+// termAt returns the term of the entry at index, 0 if there isn't one or it
+// has been dropped.
+func (node *Node) termAt(index uint64) uint64 {
+	switch {
+	case index == node.state.SnapshotIndex:
+		return node.state.SnapshotTerm
+	case index < node.state.SnapshotIndex || index > node.lastIndex():
+		return 0
+	}
+
+	return node.entry(index).Term
+}
+
+func toRpcEntry(entry *Entry) *rpc.RaftEntry {
+	members := []*rpc.RaftMember{}
+
+	for _, member := range entry.Members {
+		members = append(members, &rpc.RaftMember{NodeId: member.ID, Address: member.Address})
+	}
+
+	return &rpc.RaftEntry{
+		Term:    entry.Term,
+		Index:   entry.Index,
+		Data:    entry.Data,
+		Members: members,
+	}
+}
+
+func fromRpcEntry(rpcEntry *rpc.RaftEntry) *Entry {
+	members := []Member{}
+
+	for _, member := range rpcEntry.GetMembers() {
+		members = append(members, Member{ID: member.GetNodeId(), Address: member.GetAddress()})
+	}
+
+	return &Entry{
+		Term:    rpcEntry.GetTerm(),
+		Index:   rpcEntry.GetIndex(),
+		Data:    rpcEntry.GetData(),
+		Members: members,
+	}
+}
@@ -0,0 +1,620 @@
+package raft
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethan-stone/go-key-store/internal/rpc"
+	"github.com/ethan-stone/go-key-store/internal/service"
+)
+
+// memNetwork delivers raft messages between nodes in the same process, and
+// can cut a node off from the rest.
+type memNetwork struct {
+	sync.Mutex
+	nodes map[string]*Node
+	down  map[string]bool
+}
+
+func (network *memNetwork) node(from string, to string) (*Node, error) {
+	network.Lock()
+	defer network.Unlock()
+
+	node, ok := network.nodes[to]
+
+	if !ok || network.down[from] || network.down[to] {
+		return nil, fmt.Errorf("can't reach %s", to)
+	}
+
+	return node, nil
+}
+
+func (network *memNetwork) setDown(address string, down bool) {
+	network.Lock()
+	defer network.Unlock()
+
+	network.down[address] = down
+}
+
+// memTransport is one node's view of a memNetwork.
+type memTransport struct {
+	network *memNetwork
+	from    string
+}
+
+func (transport *memTransport) RequestVote(address string, req *rpc.RaftVoteRequest) (*rpc.RaftVoteResponse, error) {
+	node, err := transport.network.node(transport.from, address)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return node.HandleVote(req), nil
+}
+
+func (transport *memTransport) AppendEntries(address string, req *rpc.RaftAppendRequest) (*rpc.RaftAppendResponse, error) {
+	node, err := transport.network.node(transport.from, address)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return node.HandleAppend(req), nil
+}
+
+// testGroup is a group of nodes called n0, n1 and so on, each recording the
+// data it applies.
+type testGroup struct {
+	network *memNetwork
+	dirs    map[string]string
+	applied map[string]*[]string
+	lock    sync.Mutex
+	members []Member
+}
+
+func newTestGroup(t *testing.T, size int) *testGroup {
+	group := &testGroup{
+		network: &memNetwork{nodes: make(map[string]*Node), down: make(map[string]bool)},
+		dirs:    make(map[string]string),
+		applied: make(map[string]*[]string),
+	}
+
+	for i := range size {
+		id := fmt.Sprintf("n%d", i)
+
+		group.members = append(group.members, Member{ID: id, Address: id})
+		group.dirs[id] = t.TempDir()
+		group.open(t, id)
+	}
+
+	t.Cleanup(func() {
+		for _, node := range group.network.nodes {
+			node.Close()
+		}
+	})
+
+	return group
+}
+
+func (group *testGroup) open(t *testing.T, id string) *Node {
+	applied := &[]string{}
+
+	var node *Node
+
+	node, err := OpenNode(&NodeConfig{
+		ID:                id,
+		Address:           id,
+		Group:             "test",
+		Dir:               group.dirs[id],
+		Transport:         &memTransport{network: group.network, from: id},
+		ElectionTimeout:   100 * time.Millisecond,
+		HeartbeatInterval: 20 * time.Millisecond,
+		Apply: func(entry *Entry) {
+			if len(entry.Data) == 0 {
+				return
+			}
+
+			group.lock.Lock()
+			*applied = append(*applied, string(entry.Data))
+			group.lock.Unlock()
+		},
+		// a snapshot is a copy of what the leader has applied.
+		InstallSnapshot: func(leaderAddress string) error {
+			leader, err := group.network.node(id, leaderAddress)
+
+			if err != nil {
+				return err
+			}
+
+			index := leader.LastIndex()
+
+			group.lock.Lock()
+			*applied = append([]string{}, *group.applied[leaderAddress]...)
+			group.lock.Unlock()
+
+			return node.Restore(index, leader.TermAt(index))
+		},
+	})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening node %s %v", id, err)
+	}
+
+	group.network.Lock()
+	group.network.nodes[id] = node
+	group.applied[id] = applied
+	group.network.Unlock()
+
+	return node
+}
+
+// start bootstraps the group on n0 and adds the other members one at a time.
+func (group *testGroup) start(t *testing.T) *Node {
+	first := group.network.nodes["n0"]
+
+	err := first.Bootstrap(0)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when starting the group %v", err)
+	}
+
+	err = first.ChangeMembers(group.members)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when adding the members %v", err)
+	}
+
+	return first
+}
+
+// waitForApplied waits for the node to have applied want, in order.
+func (group *testGroup) waitForApplied(t *testing.T, id string, want ...string) {
+	deadline := time.Now().Add(5 * time.Second)
+
+	for time.Now().Before(deadline) {
+		group.lock.Lock()
+		got := fmt.Sprint(*group.applied[id])
+		group.lock.Unlock()
+
+		if got == fmt.Sprint(want) {
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	group.lock.Lock()
+	defer group.lock.Unlock()
+
+	t.Fatalf("Expected %s to apply %v, got %v", id, want, *group.applied[id])
+}
+
+// waitForLeader waits for a node other than except to lead the group.
+func (group *testGroup) waitForLeader(t *testing.T, except string) *Node {
+	deadline := time.Now().Add(5 * time.Second)
+
+	for time.Now().Before(deadline) {
+		for id, node := range group.network.nodes {
+			if id != except && node.IsLeader() {
+				return node
+			}
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("Expected a leader to be elected")
+
+	return nil
+}
+
+func TestGroupShouldCommitProposalsToEveryMember(t *testing.T) {
+	group := newTestGroup(t, 3)
+
+	first := group.start(t)
+
+	_, err := first.Propose([]byte("a"), group.members)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when proposing %v", err)
+	}
+
+	_, err = first.Propose([]byte("b"), group.members)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when proposing %v", err)
+	}
+
+	for _, member := range group.members {
+		group.waitForApplied(t, member.ID, "a", "b")
+	}
+
+	_, err = group.network.nodes["n1"].Propose([]byte("c"), group.members)
+
+	var notLeader *service.NotLeaderError
+
+	if !errors.As(err, &notLeader) || notLeader.LeaderAddress != "n0" {
+		t.Errorf("Expected a follower to point at the leader, got %v", err)
+	}
+}
+
+func TestGroupShouldElectANewLeaderWhenTheLeaderFails(t *testing.T) {
+	group := newTestGroup(t, 3)
+
+	_, err := group.start(t).Propose([]byte("a"), group.members)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when proposing %v", err)
+	}
+
+	group.waitForApplied(t, "n1", "a")
+	group.waitForApplied(t, "n2", "a")
+
+	group.network.setDown("n0", true)
+
+	leader := group.waitForLeader(t, "n0")
+
+	_, err = leader.Propose([]byte("b"), group.members)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when proposing to the new leader %v", err)
+	}
+
+	group.waitForApplied(t, "n1", "a", "b")
+	group.waitForApplied(t, "n2", "a", "b")
+
+	group.network.setDown("n0", false)
+
+	group.waitForApplied(t, "n0", "a", "b")
+
+	if group.network.nodes["n0"].IsLeader() {
+		t.Errorf("Expected the old leader to step down")
+	}
+}
+
+func TestGroupShouldNotCommitWithoutAMajority(t *testing.T) {
+	group := newTestGroup(t, 3)
+
+	_, err := group.start(t).Propose([]byte("a"), group.members)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when proposing %v", err)
+	}
+
+	group.waitForApplied(t, "n1", "a")
+
+	group.network.setDown("n1", true)
+	group.network.setDown("n2", true)
+
+	_, err = group.network.nodes["n0"].Propose([]byte("b"), group.members)
+
+	if !errors.Is(err, ErrProposalTimeout) && !errors.Is(err, ErrProposalLost) {
+		t.Errorf("Expected a proposal without a majority to fail, got %v", err)
+	}
+
+	group.waitForApplied(t, "n0", "a")
+}
+
+func TestMemberShouldApplyWhatWasCommittedAfterARestart(t *testing.T) {
+	group := newTestGroup(t, 1)
+
+	node := group.start(t)
+
+	for _, data := range []string{"a", "b"} {
+		_, err := node.Propose([]byte(data), group.members)
+
+		if err != nil {
+			t.Fatalf("Did not expect an error when proposing %v", err)
+		}
+	}
+
+	node.Close()
+
+	restarted := group.open(t, "n0")
+
+	group.waitForApplied(t, "n0", "a", "b")
+
+	// a single member elects itself again.
+	leader := group.waitForLeader(t, "")
+
+	if leader != restarted {
+		t.Fatalf("Expected the restarted node to lead")
+	}
+
+	_, err := restarted.Propose([]byte("c"), group.members)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when proposing after a restart %v", err)
+	}
+
+	group.waitForApplied(t, "n0", "a", "b", "c")
+}
+
+func TestFollowerShouldReplaceEntriesThatDontMatchTheLeader(t *testing.T) {
+	group := newTestGroup(t, 1)
+
+	node := group.network.nodes["n0"]
+	members := []*rpc.RaftMember{{NodeId: "leader", Address: "leader"}, {NodeId: "n0", Address: "n0"}}
+
+	r := node.HandleAppend(&rpc.RaftAppendRequest{
+		Term:     1,
+		LeaderId: "leader",
+		Entries: []*rpc.RaftEntry{
+			{Term: 1, Index: 1, Data: []byte("a"), Members: members},
+			{Term: 1, Index: 2, Data: []byte("stale"), Members: members},
+		},
+		LeaderCommit: 1,
+	})
+
+	if !r.GetSuccess() {
+		t.Fatalf("Expected the first append to succeed")
+	}
+
+	r = node.HandleAppend(&rpc.RaftAppendRequest{
+		Term:         2,
+		LeaderId:     "leader",
+		PrevLogIndex: 3,
+		PrevLogTerm:  2,
+	})
+
+	if r.GetSuccess() || r.GetConflictIndex() != 3 {
+		t.Fatalf("Expected an append past the end of the log to fail at index 3, got %v", r)
+	}
+
+	r = node.HandleAppend(&rpc.RaftAppendRequest{
+		Term:         2,
+		LeaderId:     "leader",
+		PrevLogIndex: 1,
+		PrevLogTerm:  1,
+		Entries: []*rpc.RaftEntry{
+			{Term: 2, Index: 2, Data: []byte("b"), Members: members},
+		},
+		LeaderCommit: 2,
+	})
+
+	if !r.GetSuccess() {
+		t.Fatalf("Expected the second append to succeed")
+	}
+
+	group.waitForApplied(t, "n0", "a", "b")
+
+	vote := node.HandleVote(&rpc.RaftVoteRequest{Term: 3, CandidateId: "behind", LastLogIndex: 5, LastLogTerm: 1})
+
+	if vote.GetGranted() {
+		t.Errorf("Did not expect a vote for a candidate with an older log")
+	}
+}
+
+func TestMemberShouldLoadASnapshotWhenTheLeaderHasDroppedWhatItIsMissing(t *testing.T) {
+	group := newTestGroup(t, 3)
+
+	leader := group.start(t)
+
+	_, err := leader.Propose([]byte("a"), group.members)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when proposing %v", err)
+	}
+
+	group.waitForApplied(t, "n2", "a")
+
+	group.network.setDown("n2", true)
+
+	for _, data := range []string{"b", "c"} {
+		_, err = leader.Propose([]byte(data), group.members)
+
+		if err != nil {
+			t.Fatalf("Did not expect an error when proposing %v", err)
+		}
+	}
+
+	err = leader.Compact(leader.LastIndex())
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when compacting %v", err)
+	}
+
+	group.network.setDown("n2", false)
+
+	group.waitForApplied(t, "n2", "a", "b", "c")
+
+	_, err = leader.Propose([]byte("d"), group.members)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when proposing after the snapshot %v", err)
+	}
+
+	group.waitForApplied(t, "n2", "a", "b", "c", "d")
+}
+
+func TestMemberShouldCarryOnFromACompactedLogAfterARestart(t *testing.T) {
+	group := newTestGroup(t, 1)
+
+	node := group.start(t)
+
+	for _, data := range []string{"a", "b", "c"} {
+		_, err := node.Propose([]byte(data), group.members)
+
+		if err != nil {
+			t.Fatalf("Did not expect an error when proposing %v", err)
+		}
+	}
+
+	// the first entry is the empty one the group started with.
+	err := node.Compact(2)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when compacting %v", err)
+	}
+
+	node.Close()
+
+	// entries that were dropped aren't applied again.
+	node = group.open(t, "n0")
+
+	group.waitForApplied(t, "n0", "b", "c")
+
+	err = node.Compact(node.LastIndex())
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when compacting the whole log %v", err)
+	}
+
+	node.Close()
+
+	// the members are kept with the snapshot once there are no entries left.
+	node = group.open(t, "n0")
+
+	if len(node.Members()) != 1 {
+		t.Fatalf("Expected the members to be kept after compacting the whole log, got %v", node.Members())
+	}
+
+	group.waitForLeader(t, "")
+
+	_, err = node.Propose([]byte("d"), group.members)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when proposing after a restart %v", err)
+	}
+
+	group.waitForApplied(t, "n0", "d")
+}
+
+func TestMemberThatWasCutOffShouldNotDeposeTheLeader(t *testing.T) {
+	group := newTestGroup(t, 3)
+
+	leader := group.start(t)
+
+	_, err := leader.Propose([]byte("a"), group.members)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when proposing %v", err)
+	}
+
+	group.waitForApplied(t, "n2", "a")
+
+	// n2 would start several elections in this time without pre-votes.
+	group.network.setDown("n2", true)
+	time.Sleep(500 * time.Millisecond)
+	group.network.setDown("n2", false)
+
+	_, err = leader.Propose([]byte("b"), group.members)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when proposing after n2 came back %v", err)
+	}
+
+	group.waitForApplied(t, "n2", "a", "b")
+
+	if !leader.IsLeader() {
+		t.Errorf("Expected n0 to still lead")
+	}
+}
+
+func TestLeaderShouldOnlyServeReadsWhileItCanReachAMajority(t *testing.T) {
+	group := newTestGroup(t, 3)
+
+	leader := group.start(t)
+
+	index, err := leader.Propose([]byte("a"), group.members)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when proposing %v", err)
+	}
+
+	readIndex, err := leader.ReadIndex()
+
+	if err != nil || readIndex < index {
+		t.Fatalf("Expected reads to wait for index %d, got %d %v", index, readIndex, err)
+	}
+
+	_, err = group.network.nodes["n1"].ReadIndex()
+
+	var notLeader *service.NotLeaderError
+
+	if !errors.As(err, &notLeader) {
+		t.Errorf("Expected a follower to point at the leader, got %v", err)
+	}
+
+	// once the followers might have stopped waiting for it.
+	group.network.setDown("n0", true)
+	time.Sleep(100 * time.Millisecond)
+
+	_, err = leader.ReadIndex()
+
+	if err == nil {
+		t.Errorf("Did not expect a leader that was cut off to serve reads")
+	}
+}
+
+func TestNodeShouldOnlyStartAGroupWhenBootstrapped(t *testing.T) {
+	group := newTestGroup(t, 2)
+
+	_, err := group.network.nodes["n1"].Propose([]byte("a"), group.members)
+
+	if !errors.Is(err, service.ErrNotLeader) {
+		t.Fatalf("Expected a node with an empty log not to start a group, got %v", err)
+	}
+
+	group.start(t)
+
+	err = group.network.nodes["n0"].Bootstrap(0)
+
+	if !errors.Is(err, ErrAlreadyStarted) {
+		t.Errorf("Expected a second bootstrap to fail, got %v", err)
+	}
+
+	err = group.network.nodes["n1"].Bootstrap(0)
+
+	if !errors.Is(err, ErrAlreadyStarted) {
+		t.Errorf("Expected a member of the group not to start another, got %v", err)
+	}
+}
+
+func TestMembersShouldChangeOneAtATime(t *testing.T) {
+	group := newTestGroup(t, 3)
+
+	leader := group.network.nodes["n0"]
+
+	err := leader.Bootstrap(0)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when starting the group %v", err)
+	}
+
+	_, err = leader.Propose([]byte("a"), group.members)
+
+	if !errors.Is(err, ErrMembershipChange) {
+		t.Fatalf("Expected adding two members at once to fail, got %v", err)
+	}
+
+	err = leader.ChangeMembers(group.members)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when adding members one at a time %v", err)
+	}
+
+	_, err = leader.Propose([]byte("a"), group.members[:1])
+
+	if !errors.Is(err, ErrMembershipChange) {
+		t.Fatalf("Expected removing two members at once to fail, got %v", err)
+	}
+
+	// without n1 and n2 removing n2 can't be committed, and another change
+	// has to wait for it.
+	group.network.setDown("n1", true)
+	group.network.setDown("n2", true)
+
+	go leader.Propose(nil, group.members[:2])
+
+	time.Sleep(50 * time.Millisecond)
+
+	_, err = leader.Propose(nil, group.members[:1])
+
+	if !errors.Is(err, ErrMembershipChange) {
+		t.Errorf("Expected a change while another is uncommitted to fail, got %v", err)
+	}
+}
@@ -0,0 +1,236 @@
+package raft
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// persistentState is everything a member has to remember across a restart
+// apart from the log. The commit index doesn't have to be kept, but keeping it
+// lets a member apply the entries it knows are committed before it hears from
+// a leader. The snapshot fields say where the log starts, once the entries
+// before it have been dropped because whatever applies them has them.
+type persistentState struct {
+	Term            uint64   `json:"term"`
+	VotedFor        string   `json:"votedFor"`
+	Commit          uint64   `json:"commit"`
+	SnapshotIndex   uint64   `json:"snapshotIndex,omitempty"`
+	SnapshotTerm    uint64   `json:"snapshotTerm,omitempty"`
+	SnapshotMembers []Member `json:"snapshotMembers,omitempty"`
+}
+
+func (state *persistentState) equal(other *persistentState) bool {
+	return state.Term == other.Term && state.VotedFor == other.VotedFor && state.Commit == other.Commit &&
+		state.SnapshotIndex == other.SnapshotIndex && state.SnapshotTerm == other.SnapshotTerm
+}
+
+// storage keeps a group's state in one file, replaced whole every time it
+// changes, and its log in another, one JSON entry per line. New entries are
+// appended to the log, and it is only written out again when entries are
+// dropped from either end.
+type storage struct {
+	dir     string
+	group   string
+	logFile *os.File
+}
+
+// stateFile is where a group's state is kept, one file per group.
+func stateFile(dir string, group string) string {
+	return filepath.Join(dir, group+".json")
+}
+
+func logFile(dir string, group string) string {
+	return filepath.Join(dir, group+".log")
+}
+
+// openStorage reads a group's state and log, or returns empty ones if the
+// group has never been saved.
+func openStorage(dir string, group string) (*storage, *persistentState, []*Entry, error) {
+	state := &persistentState{}
+
+	contents, err := os.ReadFile(stateFile(dir, group))
+
+	if err == nil {
+		err = json.Unmarshal(contents, state)
+	} else if errors.Is(err, os.ErrNotExist) {
+		err = nil
+	}
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	entries, err := readLog(logFile(dir, group))
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	storage := &storage{dir: dir, group: group}
+
+	// the log is written out again so a line torn by a crash isn't left in
+	// the middle of it.
+	err = storage.rewriteLog(entries)
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return storage, state, entries, nil
+}
+
+// readLog reads every whole entry in the log file. A line that can't be read
+// is the end of an append a crash cut short, and it and anything after it are
+// ignored.
+func readLog(path string) ([]*Entry, error) {
+	contents, err := os.ReadFile(path)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []*Entry{}
+
+	for line := range bytes.Lines(contents) {
+		var entry Entry
+
+		if !bytes.HasSuffix(line, []byte("\n")) || json.Unmarshal(line, &entry) != nil {
+			break
+		}
+
+		entries = append(entries, &entry)
+	}
+
+	return entries, nil
+}
+
+// saveState replaces the group's state with a rename, so a crash part way
+// through leaves the old state.
+func (storage *storage) saveState(state *persistentState) error {
+	contents, err := json.Marshal(state)
+
+	if err != nil {
+		return err
+	}
+
+	return storage.replace(stateFile(storage.dir, storage.group), contents)
+}
+
+// appendLog adds entries to the end of the log file.
+func (storage *storage) appendLog(entries []*Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	contents, err := encodeEntries(entries)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = storage.logFile.Write(contents)
+
+	if err != nil {
+		return err
+	}
+
+	return storage.logFile.Sync()
+}
+
+// rewriteLog replaces the log file with entries.
+func (storage *storage) rewriteLog(entries []*Entry) error {
+	contents, err := encodeEntries(entries)
+
+	if err != nil {
+		return err
+	}
+
+	path := logFile(storage.dir, storage.group)
+
+	err = storage.replace(path, contents)
+
+	if err != nil {
+		return err
+	}
+
+	if storage.logFile != nil {
+		storage.logFile.Close()
+	}
+
+	storage.logFile, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0666)
+
+	return err
+}
+
+func (storage *storage) close() {
+	if storage.logFile != nil {
+		storage.logFile.Close()
+	}
+}
+
+func encodeEntries(entries []*Entry) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	encoder := json.NewEncoder(&buffer)
+
+	for _, entry := range entries {
+		err := encoder.Encode(entry)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// replace writes contents to path with a rename, so a crash part way through
+// leaves the old file.
+func (storage *storage) replace(path string, contents []byte) error {
+	tmpPath := path + ".tmp"
+
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(contents)
+
+	if err == nil {
+		err = file.Sync()
+	}
+
+	closeErr := file.Close()
+
+	if err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmpPath, path)
+
+	if err != nil {
+		return err
+	}
+
+	dirFile, err := os.Open(storage.dir)
+
+	if err != nil {
+		return err
+	}
+
+	defer dirFile.Close()
+
+	return dirFile.Sync()
+}
@@ -0,0 +1,40 @@
+package raft
+
+import (
+	"github.com/ethan-stone/go-key-store/internal/rpc"
+)
+
+// RpcTransport sends raft messages over the nodes' gRPC servers.
+type RpcTransport struct {
+	rpcClientManager rpc.RpcClientManager
+}
+
+func NewRpcTransport(rpcClientManager rpc.RpcClientManager) *RpcTransport {
+	return &RpcTransport{
+		rpcClientManager: rpcClientManager,
+	}
+}
+
+func (transport *RpcTransport) RequestVote(address string, req *rpc.RaftVoteRequest) (*rpc.RaftVoteResponse, error) {
+	client, err := transport.rpcClientManager.GetOrCreateRpcClient(&rpc.RpcClientConfig{
+		Address: address,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return client.RaftVote(req)
+}
+
+func (transport *RpcTransport) AppendEntries(address string, req *rpc.RaftAppendRequest) (*rpc.RaftAppendResponse, error) {
+	client, err := transport.rpcClientManager.GetOrCreateRpcClient(&rpc.RpcClientConfig{
+		Address: address,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return client.RaftAppend(req)
+}
@@ -2,7 +2,9 @@ package rpc
 
 import (
 	"context"
+	"io"
 	"log"
+	"sync"
 	"time"
 
 	"google.golang.org/grpc"
@@ -12,12 +14,47 @@ import (
 type RpcClient interface {
 	Ping() (bool, error)
 	Get(key string) (*GetResponse, error)
-	Put(key string, val string) (*PutResponse, error)
-	Delete(key string) (*DeleteResponse, error)
-	Gossip(req *GossipRequest) (*GossipResponse, error)
+	Put(req *PutRequest) (*PutResponse, error)
+	Delete(req *DeleteRequest) (*DeleteResponse, error)
+	Expire(req *ExpireRequest) (*ExpireResponse, error)
+	Persist(req *PersistRequest) (*PersistResponse, error)
+	Ttl(req *TtlRequest) (*TtlResponse, error)
+	Incr(req *IncrRequest) (*IncrResponse, error)
+	ListPush(req *ListPushRequest) (*ListPushResponse, error)
+	ListPop(req *ListPopRequest) (*ListPopResponse, error)
+	ListRange(req *ListRangeRequest) (*ListRangeResponse, error)
+	HashSet(req *HashSetRequest) (*HashSetResponse, error)
+	HashGet(req *HashGetRequest) (*HashGetResponse, error)
+	HashDelete(req *HashDeleteRequest) (*HashDeleteResponse, error)
+	HashGetAll(req *HashGetAllRequest) (*HashGetAllResponse, error)
+	SetAdd(req *SetAddRequest) (*SetAddResponse, error)
+	SetRemove(req *SetRemoveRequest) (*SetRemoveResponse, error)
+	SetMembers(req *SetMembersRequest) (*SetMembersResponse, error)
+	SetIsMember(req *SetIsMemberRequest) (*SetIsMemberResponse, error)
+	SortedSetAdd(req *SortedSetAddRequest) (*SortedSetAddResponse, error)
+	SortedSetRemove(req *SortedSetRemoveRequest) (*SortedSetRemoveResponse, error)
+	SortedSetIncr(req *SortedSetIncrRequest) (*SortedSetIncrResponse, error)
+	SortedSetRangeByRank(req *SortedSetRangeByRankRequest) (*SortedSetRangeByRankResponse, error)
+	SortedSetRangeByScore(req *SortedSetRangeByScoreRequest) (*SortedSetRangeByScoreResponse, error)
+	SortedSetRank(req *SortedSetRankRequest) (*SortedSetRankResponse, error)
+	Batch(req *BatchRequest) (*BatchResponse, error)
+	// Scan reads the whole stream, and returns the items and whether the
+	// limit cut the scan short.
+	Scan(req *ScanRequest) ([]*ScanItem, bool, error)
+	// Watch calls fn with every change the node sends until ctx is done,
+	// the stream breaks or fn returns an error.
+	Watch(ctx context.Context, req *WatchRequest, fn func(event *WatchResponse) error) error
+	// Replicate calls fn with everything the primary sends until ctx is
+	// done, the stream breaks or fn returns an error.
+	Replicate(ctx context.Context, req *ReplicateRequest, fn func(r *ReplicateResponse) error) error
+	CheckNode(req *CheckNodeRequest) (*CheckNodeResponse, error)
+	RaftVote(req *RaftVoteRequest) (*RaftVoteResponse, error)
+	RaftAppend(req *RaftAppendRequest) (*RaftAppendResponse, error)
 	GetAddress() string
 	SetClusterConfig(req *SetClusterConfigRequest) (*SetClusterConfigResponse, error)
 	GetClusterConfig(req *GetClusterConfigRequest) (*GetClusterConfigResponse, error)
+	QuorumRead(req *QuorumReadRequest) (*VersionedValue, error)
+	QuorumWrite(req *QuorumWriteRequest) (*QuorumWriteResponse, error)
 }
 
 type GrpcClient struct {
@@ -95,15 +132,12 @@ func (rpcClient *GrpcClient) Get(key string) (*GetResponse, error) {
 	return r, nil
 }
 
-func (rpcClient *GrpcClient) Put(key string, val string) (*PutResponse, error) {
+func (rpcClient *GrpcClient) Put(req *PutRequest) (*PutResponse, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 
 	defer cancel()
 
-	r, err := rpcClient.client.Put(ctx, &PutRequest{
-		Key: key,
-		Val: val,
-	})
+	r, err := rpcClient.client.Put(ctx, req)
 
 	if err != nil {
 		return nil, err
@@ -114,14 +148,12 @@ func (rpcClient *GrpcClient) Put(key string, val string) (*PutResponse, error) {
 	return r, nil
 }
 
-func (rpcClient *GrpcClient) Delete(key string) (*DeleteResponse, error) {
+func (rpcClient *GrpcClient) Delete(req *DeleteRequest) (*DeleteResponse, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 
 	defer cancel()
 
-	r, err := rpcClient.client.Delete(ctx, &DeleteRequest{
-		Key: key,
-	})
+	r, err := rpcClient.client.Delete(ctx, req)
 
 	if err != nil {
 		return nil, err
@@ -132,27 +164,496 @@ func (rpcClient *GrpcClient) Delete(key string) (*DeleteResponse, error) {
 	return r, nil
 }
 
-func (rpcClient *GrpcClient) Gossip(req *GossipRequest) (*GossipResponse, error) {
+func (rpcClient *GrpcClient) Expire(req *ExpireRequest) (*ExpireResponse, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 
 	defer cancel()
 
-	r, err := rpcClient.client.Gossip(ctx, req)
+	r, err := rpcClient.client.Expire(ctx, req)
 
 	if err != nil {
 		return nil, err
 	}
 
-	log.Printf("Gossip result ok = %t", r.GetOk())
+	log.Printf("Expire result ok = %t", r.GetOk())
 
 	return r, nil
 }
 
-func (rpcClient *GrpcClient) SetClusterConfig(req *SetClusterConfigRequest) (*SetClusterConfigResponse, error) {
+func (rpcClient *GrpcClient) Persist(req *PersistRequest) (*PersistResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+
+	defer cancel()
+
+	r, err := rpcClient.client.Persist(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Persist result ok = %t", r.GetOk())
+
+	return r, nil
+}
+
+func (rpcClient *GrpcClient) Ttl(req *TtlRequest) (*TtlResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+
+	defer cancel()
+
+	r, err := rpcClient.client.Ttl(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Ttl result ok = %t", r.GetOk())
+
+	return r, nil
+}
+
+func (rpcClient *GrpcClient) Incr(req *IncrRequest) (*IncrResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+
+	defer cancel()
+
+	r, err := rpcClient.client.Incr(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Incr result ok = %t", r.GetOk())
+
+	return r, nil
+}
+
+func (rpcClient *GrpcClient) ListPush(req *ListPushRequest) (*ListPushResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+
+	defer cancel()
+
+	r, err := rpcClient.client.ListPush(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("ListPush result ok = %t", r.GetOk())
+
+	return r, nil
+}
+
+func (rpcClient *GrpcClient) ListPop(req *ListPopRequest) (*ListPopResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+
+	defer cancel()
+
+	r, err := rpcClient.client.ListPop(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("ListPop result ok = %t", r.GetOk())
+
+	return r, nil
+}
+
+func (rpcClient *GrpcClient) ListRange(req *ListRangeRequest) (*ListRangeResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+
+	defer cancel()
+
+	r, err := rpcClient.client.ListRange(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("ListRange result ok = %t", r.GetOk())
+
+	return r, nil
+}
+
+func (rpcClient *GrpcClient) HashSet(req *HashSetRequest) (*HashSetResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+
+	defer cancel()
+
+	r, err := rpcClient.client.HashSet(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("HashSet result ok = %t", r.GetOk())
+
+	return r, nil
+}
+
+func (rpcClient *GrpcClient) HashGet(req *HashGetRequest) (*HashGetResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+
+	defer cancel()
+
+	r, err := rpcClient.client.HashGet(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("HashGet result ok = %t", r.GetOk())
+
+	return r, nil
+}
+
+func (rpcClient *GrpcClient) HashDelete(req *HashDeleteRequest) (*HashDeleteResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+
+	defer cancel()
+
+	r, err := rpcClient.client.HashDelete(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("HashDelete result ok = %t", r.GetOk())
+
+	return r, nil
+}
+
+func (rpcClient *GrpcClient) HashGetAll(req *HashGetAllRequest) (*HashGetAllResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+
+	defer cancel()
+
+	r, err := rpcClient.client.HashGetAll(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("HashGetAll result ok = %t", r.GetOk())
+
+	return r, nil
+}
+
+func (rpcClient *GrpcClient) SetAdd(req *SetAddRequest) (*SetAddResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+
+	defer cancel()
+
+	r, err := rpcClient.client.SetAdd(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("SetAdd result ok = %t", r.GetOk())
+
+	return r, nil
+}
+
+func (rpcClient *GrpcClient) SetRemove(req *SetRemoveRequest) (*SetRemoveResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+
+	defer cancel()
+
+	r, err := rpcClient.client.SetRemove(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("SetRemove result ok = %t", r.GetOk())
+
+	return r, nil
+}
+
+func (rpcClient *GrpcClient) SetMembers(req *SetMembersRequest) (*SetMembersResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+
+	defer cancel()
+
+	r, err := rpcClient.client.SetMembers(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("SetMembers result ok = %t", r.GetOk())
+
+	return r, nil
+}
+
+func (rpcClient *GrpcClient) SetIsMember(req *SetIsMemberRequest) (*SetIsMemberResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+
+	defer cancel()
+
+	r, err := rpcClient.client.SetIsMember(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("SetIsMember result ok = %t", r.GetOk())
+
+	return r, nil
+}
+
+func (rpcClient *GrpcClient) SortedSetAdd(req *SortedSetAddRequest) (*SortedSetAddResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+
+	defer cancel()
+
+	r, err := rpcClient.client.SortedSetAdd(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("SortedSetAdd result ok = %t", r.GetOk())
+
+	return r, nil
+}
+
+func (rpcClient *GrpcClient) SortedSetRemove(req *SortedSetRemoveRequest) (*SortedSetRemoveResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+
+	defer cancel()
+
+	r, err := rpcClient.client.SortedSetRemove(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("SortedSetRemove result ok = %t", r.GetOk())
+
+	return r, nil
+}
+
+func (rpcClient *GrpcClient) SortedSetIncr(req *SortedSetIncrRequest) (*SortedSetIncrResponse, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 
 	defer cancel()
 
+	r, err := rpcClient.client.SortedSetIncr(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("SortedSetIncr result ok = %t", r.GetOk())
+
+	return r, nil
+}
+
+func (rpcClient *GrpcClient) SortedSetRangeByRank(req *SortedSetRangeByRankRequest) (*SortedSetRangeByRankResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+
+	defer cancel()
+
+	r, err := rpcClient.client.SortedSetRangeByRank(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("SortedSetRangeByRank result ok = %t", r.GetOk())
+
+	return r, nil
+}
+
+func (rpcClient *GrpcClient) SortedSetRangeByScore(req *SortedSetRangeByScoreRequest) (*SortedSetRangeByScoreResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+
+	defer cancel()
+
+	r, err := rpcClient.client.SortedSetRangeByScore(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("SortedSetRangeByScore result ok = %t", r.GetOk())
+
+	return r, nil
+}
+
+func (rpcClient *GrpcClient) SortedSetRank(req *SortedSetRankRequest) (*SortedSetRankResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+
+	defer cancel()
+
+	r, err := rpcClient.client.SortedSetRank(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("SortedSetRank result ok = %t", r.GetOk())
+
+	return r, nil
+}
+
+func (rpcClient *GrpcClient) Batch(req *BatchRequest) (*BatchResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+
+	defer cancel()
+
+	r, err := rpcClient.client.Batch(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Batch result ok = %t", r.GetOk())
+
+	return r, nil
+}
+
+// Watch has no timeout, since a watch is meant to stay open. The stream is
+// closed when ctx is done.
+func (rpcClient *GrpcClient) Watch(ctx context.Context, req *WatchRequest, fn func(event *WatchResponse) error) error {
+	stream, err := rpcClient.client.Watch(ctx, req)
+
+	if err != nil {
+		return err
+	}
+
+	for {
+		r, err := stream.Recv()
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		err = fn(r)
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Replicate has no timeout either, a replica follows its primary for as long
+// as it can.
+func (rpcClient *GrpcClient) Replicate(ctx context.Context, req *ReplicateRequest, fn func(r *ReplicateResponse) error) error {
+	stream, err := rpcClient.client.Replicate(ctx, req)
+
+	if err != nil {
+		return err
+	}
+
+	for {
+		r, err := stream.Recv()
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		err = fn(r)
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Scan gets longer than other calls, since without a limit it streams every
+// key the node has.
+func (rpcClient *GrpcClient) Scan(req *ScanRequest) ([]*ScanItem, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+
+	defer cancel()
+
+	stream, err := rpcClient.client.Scan(ctx, req)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	items := []*ScanItem{}
+
+	for {
+		r, err := stream.Recv()
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		if r.GetItem() == nil {
+			log.Printf("Scan result %d items, more = %t", len(items), r.GetMore())
+
+			return items, r.GetMore(), nil
+		}
+
+		items = append(items, r.GetItem())
+	}
+}
+
+func (rpcClient *GrpcClient) CheckNode(req *CheckNodeRequest) (*CheckNodeResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+
+	defer cancel()
+
+	r, err := rpcClient.client.CheckNode(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// raft messages time out sooner than the rest, so a node that is down doesn't
+// hold up an election or a heartbeat for long.
+const raftTimeout = time.Second
+
+func (rpcClient *GrpcClient) RaftVote(req *RaftVoteRequest) (*RaftVoteResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), raftTimeout)
+
+	defer cancel()
+
+	return rpcClient.client.RaftVote(ctx, req)
+}
+
+func (rpcClient *GrpcClient) RaftAppend(req *RaftAppendRequest) (*RaftAppendResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), raftTimeout)
+
+	defer cancel()
+
+	return rpcClient.client.RaftAppend(ctx, req)
+}
+
+// bootstrapTimeout is how long starting a cluster gets. The first node adds
+// every other node to the cluster config group one at a time before the config
+// is committed, which can take longer than other calls.
+const bootstrapTimeout = time.Minute
+
+func (rpcClient *GrpcClient) SetClusterConfig(req *SetClusterConfigRequest) (*SetClusterConfigResponse, error) {
+	timeout := time.Second * 5
+
+	if req.GetBootstrap() {
+		timeout = bootstrapTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+
+	defer cancel()
+
 	r, err := rpcClient.client.SetClusterConfig(ctx, req)
 
 	if err != nil {
@@ -180,54 +681,108 @@ func (rpcClient *GrpcClient) GetClusterConfig(req *GetClusterConfigRequest) (*Ge
 	return r, nil
 }
 
+func (rpcClient *GrpcClient) QuorumRead(req *QuorumReadRequest) (*VersionedValue, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+
+	defer cancel()
+
+	return rpcClient.client.QuorumRead(ctx, req)
+}
+
+func (rpcClient *GrpcClient) QuorumWrite(req *QuorumWriteRequest) (*QuorumWriteResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+
+	defer cancel()
+
+	return rpcClient.client.QuorumWrite(ctx, req)
+}
+
 type RpcClientConfig struct {
 	Address string
 }
 
 type RpcClientManager interface {
 	GetOrCreateRpcClient(config *RpcClientConfig) (RpcClient, error)
+	// Reachable reports whether the node at address has answered a ping
+	// recently. A node nothing has connected to yet hasn't.
+	Reachable(address string) bool
 }
 
+// Clients ping their node in the background. A node that hasn't answered for
+// unreachableAfter is taken to be down, which is what failover goes by.
+const (
+	pingInterval     = 2 * time.Second
+	unreachableAfter = 6 * time.Second
+)
+
 type GrpcClientManager struct {
+	sync.Mutex
 	creator    RpcClientCreator // Dependency injected creator
 	rpcClients map[string]*GrpcClient
+	lastPinged map[string]time.Time // when each node last answered a ping
 }
 
 func NewGrpcClientManager(creator RpcClientCreator) *GrpcClientManager {
 	return &GrpcClientManager{
 		creator:    creator,
 		rpcClients: make(map[string]*GrpcClient),
+		lastPinged: make(map[string]time.Time),
 	}
 }
 
 func (rpcClientManager *GrpcClientManager) GetOrCreateRpcClient(config *RpcClientConfig) (RpcClient, error) {
+	rpcClientManager.Lock()
 	existingClient, ok := rpcClientManager.rpcClients[config.Address]
+	rpcClientManager.Unlock()
 
 	if ok && existingClient != nil {
 		return existingClient, nil
 	}
 
-	// Use the injected creator here
+	// Use the injected creator here. It pings the node, so it isn't done
+	// while holding the lock.
 	newClient, err := rpcClientManager.creator(config.Address, grpc.WithTransportCredentials(insecure.NewCredentials()))
 
 	if err != nil {
 		return nil, err
 	}
 
+	rpcClientManager.Lock()
+	defer rpcClientManager.Unlock()
+
+	// someone else connected while this was.
+	if existingClient, ok := rpcClientManager.rpcClients[config.Address]; ok && existingClient != nil {
+		newClient.(*GrpcClient).conn.Close()
+		return existingClient, nil
+	}
+
 	rpcClientManager.rpcClients[config.Address] = newClient.(*GrpcClient)
+	rpcClientManager.lastPinged[config.Address] = time.Now()
 
 	go func() {
-		for range time.NewTicker(time.Second * 5).C {
-
+		for range time.NewTicker(pingInterval).C {
 			r, err := newClient.Ping()
 
 			if err != nil || !r {
-				// IMPORTANT: Change log.Fatalf to log.Printf in production code
-				// to avoid crashing the entire application on a background ping failure.
-				log.Fatalf("Could not ping server %v", err)
+				// the connection reconnects by itself, so keep pinging.
+				log.Printf("Could not ping server %s %v", config.Address, err)
+				continue
 			}
+
+			rpcClientManager.Lock()
+			rpcClientManager.lastPinged[config.Address] = time.Now()
+			rpcClientManager.Unlock()
 		}
 	}()
 
 	return newClient, nil
 }
+
+func (rpcClientManager *GrpcClientManager) Reachable(address string) bool {
+	rpcClientManager.Lock()
+	defer rpcClientManager.Unlock()
+
+	lastPinged, ok := rpcClientManager.lastPinged[address]
+
+	return ok && time.Since(lastPinged) < unreachableAfter
+}
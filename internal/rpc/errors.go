@@ -0,0 +1,122 @@
+package rpc
+
+import (
+	"errors"
+
+	"github.com/ethan-stone/go-key-store/internal/hash"
+	"github.com/ethan-stone/go-key-store/internal/service"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// the service errors callers need to tell apart, and the status codes they
+// are sent as so they survive the trip to the other node.
+var statusErrors = []struct {
+	err  error
+	code codes.Code
+}{
+	{service.ErrConflict, codes.FailedPrecondition},
+	{service.ErrInvalidCondition, codes.InvalidArgument},
+	{service.ErrCrossSlot, codes.InvalidArgument},
+	{service.ErrNotInteger, codes.FailedPrecondition},
+	{service.ErrOverflow, codes.FailedPrecondition},
+	{service.ErrWrongType, codes.FailedPrecondition},
+	{service.ErrInvalidScore, codes.InvalidArgument},
+	{service.ErrSequenceExpired, codes.OutOfRange},
+	{service.ErrReadOnlyReplica, codes.FailedPrecondition},
+	{service.ErrNotLeader, codes.Unavailable},
+	{service.ErrEpochChanged, codes.Aborted},
+	{service.ErrNoQuorum, codes.Unavailable},
+	{service.ErrNotQuorumReplicated, codes.Unavailable},
+}
+
+// notLeaderReason marks the details of a not leader status, which carry the
+// leader's address so the caller can go to it.
+const notLeaderReason = "NOT_LEADER"
+
+func toStatus(err error) error {
+	for _, statusErr := range statusErrors {
+		if !errors.Is(err, statusErr.err) {
+			continue
+		}
+
+		st := status.New(statusErr.code, statusErr.err.Error())
+
+		var notLeader *service.NotLeaderError
+
+		if errors.As(err, &notLeader) && notLeader.LeaderAddress != "" {
+			withLeader, detailsErr := st.WithDetails(&errdetails.ErrorInfo{
+				Reason:   notLeaderReason,
+				Metadata: map[string]string{"leader": notLeader.LeaderAddress},
+			})
+
+			if detailsErr == nil {
+				st = withLeader
+			}
+		}
+
+		return st.Err()
+	}
+
+	return err
+}
+
+// ServiceError turns an error from an rpc back into the service error it
+// started as, if there was one.
+func ServiceError(err error) error {
+	st, ok := status.FromError(err)
+
+	if !ok {
+		return err
+	}
+
+	for _, statusErr := range statusErrors {
+		if st.Code() != statusErr.code || st.Message() != statusErr.err.Error() {
+			continue
+		}
+
+		if statusErr.err == service.ErrNotLeader {
+			return &service.NotLeaderError{LeaderAddress: leaderOf(st)}
+		}
+
+		return statusErr.err
+	}
+
+	return err
+}
+
+// leaderOf reads the leader's address from a not leader status, empty if it
+// wasn't sent.
+func leaderOf(st *status.Status) string {
+	for _, detail := range st.Details() {
+		info, ok := detail.(*errdetails.ErrorInfo)
+
+		if ok && info.GetReason() == notLeaderReason {
+			return info.GetMetadata()["leader"]
+		}
+	}
+
+	return ""
+}
+
+// RemoteHashFunction reads the hash function another node sent. Nodes from
+// before the hash function could be picked don't send one, and always hashed
+// with crc32.
+func RemoteHashFunction(name string) hash.Function {
+	if name == "" {
+		return hash.CRC32
+	}
+
+	return hash.Function(name)
+}
+
+// checkHashFunction refuses a node whose hash function isn't this node's,
+// since the two would disagree on which node every key lives on.
+func checkHashFunction(own hash.Function, remote string) error {
+	if RemoteHashFunction(remote) != own.Resolved() {
+		return status.Errorf(codes.FailedPrecondition, "node uses the %s hash function but this cluster uses %s", RemoteHashFunction(remote), own.Resolved())
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ethan-stone/go-key-store/internal/service"
+)
+
+// failingStoreService fails every call with err.
+type failingStoreService struct {
+	service.StoreService
+	err error
+}
+
+func (s *failingStoreService) Expire(key string, ttl time.Duration) (bool, error) {
+	return false, s.err
+}
+
+func (s *failingStoreService) Persist(key string) (bool, error) {
+	return false, s.err
+}
+
+func (s *failingStoreService) TTL(key string) (*service.TTLResult, error) {
+	return nil, s.err
+}
+
+func TestExpiryHandlersShouldSendServiceErrors(t *testing.T) {
+	server := &RpcServer{storeService: &failingStoreService{err: service.ErrReadOnlyReplica}}
+
+	_, expireErr := server.Expire(context.Background(), &ExpireRequest{Key: "a", TtlMs: 1000})
+	_, persistErr := server.Persist(context.Background(), &PersistRequest{Key: "a"})
+	_, ttlErr := server.Ttl(context.Background(), &TtlRequest{Key: "a"})
+
+	for name, err := range map[string]error{"expire": expireErr, "persist": persistErr, "ttl": ttlErr} {
+		if !errors.Is(ServiceError(err), service.ErrReadOnlyReplica) {
+			t.Errorf("Expected %s to come back as a read only replica, got %v", name, err)
+		}
+	}
+}
+
+func TestQuorumErrorsShouldSurviveTheTripToAnotherNode(t *testing.T) {
+	for _, err := range []error{service.ErrNoQuorum, service.ErrNotQuorumReplicated} {
+		sent := toStatus(fmt.Errorf("put a: %w", err))
+
+		if got := ServiceError(sent); !errors.Is(got, err) {
+			t.Errorf("Expected %v to come back from another node, got %v", err, got)
+		}
+	}
+}
+
+func TestNotLeaderErrorShouldKeepTheLeaderAddress(t *testing.T) {
+	sent := toStatus(fmt.Errorf("put a: %w", &service.NotLeaderError{LeaderAddress: "localhost:9301"}))
+
+	var notLeader *service.NotLeaderError
+
+	if got := ServiceError(sent); !errors.As(got, &notLeader) || notLeader.LeaderAddress != "localhost:9301" {
+		t.Fatalf("Expected the leader's address to come back from another node, got %v", got)
+	}
+
+	sent = toStatus(&service.NotLeaderError{})
+
+	if got := ServiceError(sent); !errors.As(got, &notLeader) || notLeader.LeaderAddress != "" {
+		t.Errorf("Expected a not leader error with no leader to come back without one, got %v", got)
+	}
+}
@@ -149,7 +149,9 @@ type GetResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
 	Key           string                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
-	Val           string                 `protobuf:"bytes,3,opt,name=val,proto3" json:"val,omitempty"`
+	Val           []byte                 `protobuf:"bytes,3,opt,name=val,proto3" json:"val,omitempty"`
+	ContentType   string                 `protobuf:"bytes,4,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Version       uint64                 `protobuf:"varint,5,opt,name=version,proto3" json:"version,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -198,24 +200,102 @@ func (x *GetResponse) GetKey() string {
 	return ""
 }
 
-func (x *GetResponse) GetVal() string {
+func (x *GetResponse) GetVal() []byte {
 	if x != nil {
 		return x.Val
 	}
+	return nil
+}
+
+func (x *GetResponse) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
 	return ""
 }
 
+func (x *GetResponse) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+// at most one field can be set. An empty condition always holds.
+type Condition struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	IfVersion     uint64                 `protobuf:"varint,1,opt,name=if_version,json=ifVersion,proto3" json:"if_version,omitempty"`
+	IfAbsent      bool                   `protobuf:"varint,2,opt,name=if_absent,json=ifAbsent,proto3" json:"if_absent,omitempty"`
+	IfPresent     bool                   `protobuf:"varint,3,opt,name=if_present,json=ifPresent,proto3" json:"if_present,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Condition) Reset() {
+	*x = Condition{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Condition) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Condition) ProtoMessage() {}
+
+func (x *Condition) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Condition.ProtoReflect.Descriptor instead.
+func (*Condition) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Condition) GetIfVersion() uint64 {
+	if x != nil {
+		return x.IfVersion
+	}
+	return 0
+}
+
+func (x *Condition) GetIfAbsent() bool {
+	if x != nil {
+		return x.IfAbsent
+	}
+	return false
+}
+
+func (x *Condition) GetIfPresent() bool {
+	if x != nil {
+		return x.IfPresent
+	}
+	return false
+}
+
 type PutRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
-	Val           string                 `protobuf:"bytes,2,opt,name=val,proto3" json:"val,omitempty"`
+	Val           []byte                 `protobuf:"bytes,2,opt,name=val,proto3" json:"val,omitempty"`
+	ContentType   string                 `protobuf:"bytes,3,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	TtlMs         int64                  `protobuf:"varint,4,opt,name=ttl_ms,json=ttlMs,proto3" json:"ttl_ms,omitempty"` // 0 for no expiry
+	Condition     *Condition             `protobuf:"bytes,5,opt,name=condition,proto3" json:"condition,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *PutRequest) Reset() {
 	*x = PutRequest{}
-	mi := &file_internal_rpc_node_rpc_proto_msgTypes[4]
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -227,7 +307,7 @@ func (x *PutRequest) String() string {
 func (*PutRequest) ProtoMessage() {}
 
 func (x *PutRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_rpc_node_rpc_proto_msgTypes[4]
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -240,7 +320,7 @@ func (x *PutRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PutRequest.ProtoReflect.Descriptor instead.
 func (*PutRequest) Descriptor() ([]byte, []int) {
-	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{4}
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *PutRequest) GetKey() string {
@@ -250,23 +330,46 @@ func (x *PutRequest) GetKey() string {
 	return ""
 }
 
-func (x *PutRequest) GetVal() string {
+func (x *PutRequest) GetVal() []byte {
 	if x != nil {
 		return x.Val
 	}
+	return nil
+}
+
+func (x *PutRequest) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
 	return ""
 }
 
+func (x *PutRequest) GetTtlMs() int64 {
+	if x != nil {
+		return x.TtlMs
+	}
+	return 0
+}
+
+func (x *PutRequest) GetCondition() *Condition {
+	if x != nil {
+		return x.Condition
+	}
+	return nil
+}
+
+// a condition that doesn't hold fails with FAILED_PRECONDITION.
 type PutResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Version       uint64                 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *PutResponse) Reset() {
 	*x = PutResponse{}
-	mi := &file_internal_rpc_node_rpc_proto_msgTypes[5]
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -278,7 +381,7 @@ func (x *PutResponse) String() string {
 func (*PutResponse) ProtoMessage() {}
 
 func (x *PutResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_rpc_node_rpc_proto_msgTypes[5]
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -291,7 +394,7 @@ func (x *PutResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PutResponse.ProtoReflect.Descriptor instead.
 func (*PutResponse) Descriptor() ([]byte, []int) {
-	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{5}
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *PutResponse) GetOk() bool {
@@ -301,16 +404,24 @@ func (x *PutResponse) GetOk() bool {
 	return false
 }
 
+func (x *PutResponse) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
 type DeleteRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Condition     *Condition             `protobuf:"bytes,2,opt,name=condition,proto3" json:"condition,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *DeleteRequest) Reset() {
 	*x = DeleteRequest{}
-	mi := &file_internal_rpc_node_rpc_proto_msgTypes[6]
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -322,7 +433,7 @@ func (x *DeleteRequest) String() string {
 func (*DeleteRequest) ProtoMessage() {}
 
 func (x *DeleteRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_rpc_node_rpc_proto_msgTypes[6]
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -335,7 +446,7 @@ func (x *DeleteRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteRequest.ProtoReflect.Descriptor instead.
 func (*DeleteRequest) Descriptor() ([]byte, []int) {
-	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{6}
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *DeleteRequest) GetKey() string {
@@ -345,6 +456,13 @@ func (x *DeleteRequest) GetKey() string {
 	return ""
 }
 
+func (x *DeleteRequest) GetCondition() *Condition {
+	if x != nil {
+		return x.Condition
+	}
+	return nil
+}
+
 type DeleteResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
@@ -354,7 +472,7 @@ type DeleteResponse struct {
 
 func (x *DeleteResponse) Reset() {
 	*x = DeleteResponse{}
-	mi := &file_internal_rpc_node_rpc_proto_msgTypes[7]
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -366,7 +484,7 @@ func (x *DeleteResponse) String() string {
 func (*DeleteResponse) ProtoMessage() {}
 
 func (x *DeleteResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_rpc_node_rpc_proto_msgTypes[7]
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -379,7 +497,7 @@ func (x *DeleteResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteResponse.ProtoReflect.Descriptor instead.
 func (*DeleteResponse) Descriptor() ([]byte, []int) {
-	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{7}
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *DeleteResponse) GetOk() bool {
@@ -389,31 +507,34 @@ func (x *DeleteResponse) GetOk() bool {
 	return false
 }
 
-type GossipRequest struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	NodeId         string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
-	Address        string                 `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
-	HashSlotsStart uint32                 `protobuf:"varint,3,opt,name=hash_slots_start,json=hashSlotsStart,proto3" json:"hash_slots_start,omitempty"`
-	HashSlotsEnd   uint32                 `protobuf:"varint,4,opt,name=hash_slots_end,json=hashSlotsEnd,proto3" json:"hash_slots_end,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+// ttl_ms and content_type only apply to puts.
+type BatchOp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Delete        bool                   `protobuf:"varint,2,opt,name=delete,proto3" json:"delete,omitempty"`
+	Val           []byte                 `protobuf:"bytes,3,opt,name=val,proto3" json:"val,omitempty"`
+	ContentType   string                 `protobuf:"bytes,4,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	TtlMs         int64                  `protobuf:"varint,5,opt,name=ttl_ms,json=ttlMs,proto3" json:"ttl_ms,omitempty"`
+	Condition     *Condition             `protobuf:"bytes,6,opt,name=condition,proto3" json:"condition,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GossipRequest) Reset() {
-	*x = GossipRequest{}
-	mi := &file_internal_rpc_node_rpc_proto_msgTypes[8]
+func (x *BatchOp) Reset() {
+	*x = BatchOp{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GossipRequest) String() string {
+func (x *BatchOp) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GossipRequest) ProtoMessage() {}
+func (*BatchOp) ProtoMessage() {}
 
-func (x *GossipRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_rpc_node_rpc_proto_msgTypes[8]
+func (x *BatchOp) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -424,129 +545,76 @@ func (x *GossipRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GossipRequest.ProtoReflect.Descriptor instead.
-func (*GossipRequest) Descriptor() ([]byte, []int) {
-	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{8}
-}
-
-func (x *GossipRequest) GetNodeId() string {
-	if x != nil {
-		return x.NodeId
-	}
-	return ""
+// Deprecated: Use BatchOp.ProtoReflect.Descriptor instead.
+func (*BatchOp) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *GossipRequest) GetAddress() string {
+func (x *BatchOp) GetKey() string {
 	if x != nil {
-		return x.Address
+		return x.Key
 	}
 	return ""
 }
 
-func (x *GossipRequest) GetHashSlotsStart() uint32 {
-	if x != nil {
-		return x.HashSlotsStart
-	}
-	return 0
-}
-
-func (x *GossipRequest) GetHashSlotsEnd() uint32 {
-	if x != nil {
-		return x.HashSlotsEnd
-	}
-	return 0
-}
-
-type NodeConfig struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	NodeId         string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
-	Address        string                 `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
-	HashSlotsStart uint32                 `protobuf:"varint,3,opt,name=hash_slots_start,json=hashSlotsStart,proto3" json:"hash_slots_start,omitempty"`
-	HashSlotsEnd   uint32                 `protobuf:"varint,4,opt,name=hash_slots_end,json=hashSlotsEnd,proto3" json:"hash_slots_end,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
-}
-
-func (x *NodeConfig) Reset() {
-	*x = NodeConfig{}
-	mi := &file_internal_rpc_node_rpc_proto_msgTypes[9]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
-}
-
-func (x *NodeConfig) String() string {
-	return protoimpl.X.MessageStringOf(x)
-}
-
-func (*NodeConfig) ProtoMessage() {}
-
-func (x *NodeConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_rpc_node_rpc_proto_msgTypes[9]
+func (x *BatchOp) GetDelete() bool {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.Delete
 	}
-	return mi.MessageOf(x)
-}
-
-// Deprecated: Use NodeConfig.ProtoReflect.Descriptor instead.
-func (*NodeConfig) Descriptor() ([]byte, []int) {
-	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{9}
+	return false
 }
 
-func (x *NodeConfig) GetNodeId() string {
+func (x *BatchOp) GetVal() []byte {
 	if x != nil {
-		return x.NodeId
+		return x.Val
 	}
-	return ""
+	return nil
 }
 
-func (x *NodeConfig) GetAddress() string {
+func (x *BatchOp) GetContentType() string {
 	if x != nil {
-		return x.Address
+		return x.ContentType
 	}
 	return ""
 }
 
-func (x *NodeConfig) GetHashSlotsStart() uint32 {
+func (x *BatchOp) GetTtlMs() int64 {
 	if x != nil {
-		return x.HashSlotsStart
+		return x.TtlMs
 	}
 	return 0
 }
 
-func (x *NodeConfig) GetHashSlotsEnd() uint32 {
+func (x *BatchOp) GetCondition() *Condition {
 	if x != nil {
-		return x.HashSlotsEnd
+		return x.Condition
 	}
-	return 0
+	return nil
 }
 
-type GossipResponse struct {
+// every key has to be in the same hash slot, or the batch fails with
+// INVALID_ARGUMENT.
+type BatchRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
-	OtherNodes    []*NodeConfig          `protobuf:"bytes,2,rep,name=other_nodes,json=otherNodes,proto3" json:"other_nodes,omitempty"`
+	Ops           []*BatchOp             `protobuf:"bytes,1,rep,name=ops,proto3" json:"ops,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GossipResponse) Reset() {
-	*x = GossipResponse{}
+func (x *BatchRequest) Reset() {
+	*x = BatchRequest{}
 	mi := &file_internal_rpc_node_rpc_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GossipResponse) String() string {
+func (x *BatchRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GossipResponse) ProtoMessage() {}
+func (*BatchRequest) ProtoMessage() {}
 
-func (x *GossipResponse) ProtoReflect() protoreflect.Message {
+func (x *BatchRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_internal_rpc_node_rpc_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -558,47 +626,40 @@ func (x *GossipResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GossipResponse.ProtoReflect.Descriptor instead.
-func (*GossipResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use BatchRequest.ProtoReflect.Descriptor instead.
+func (*BatchRequest) Descriptor() ([]byte, []int) {
 	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{10}
 }
 
-func (x *GossipResponse) GetOk() bool {
-	if x != nil {
-		return x.Ok
-	}
-	return false
-}
-
-func (x *GossipResponse) GetOtherNodes() []*NodeConfig {
+func (x *BatchRequest) GetOps() []*BatchOp {
 	if x != nil {
-		return x.OtherNodes
+		return x.Ops
 	}
 	return nil
 }
 
-type SetNodeConfigOptions struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	HashSlotsStart uint32                 `protobuf:"varint,1,opt,name=hash_slots_start,json=hashSlotsStart,proto3" json:"hash_slots_start,omitempty"`
-	HashSlotsEnd   uint32                 `protobuf:"varint,2,opt,name=hash_slots_end,json=hashSlotsEnd,proto3" json:"hash_slots_end,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+type BatchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Versions      []uint64               `protobuf:"varint,2,rep,packed,name=versions,proto3" json:"versions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SetNodeConfigOptions) Reset() {
-	*x = SetNodeConfigOptions{}
+func (x *BatchResponse) Reset() {
+	*x = BatchResponse{}
 	mi := &file_internal_rpc_node_rpc_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SetNodeConfigOptions) String() string {
+func (x *BatchResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SetNodeConfigOptions) ProtoMessage() {}
+func (*BatchResponse) ProtoMessage() {}
 
-func (x *SetNodeConfigOptions) ProtoReflect() protoreflect.Message {
+func (x *BatchResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_internal_rpc_node_rpc_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -610,47 +671,48 @@ func (x *SetNodeConfigOptions) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SetNodeConfigOptions.ProtoReflect.Descriptor instead.
-func (*SetNodeConfigOptions) Descriptor() ([]byte, []int) {
+// Deprecated: Use BatchResponse.ProtoReflect.Descriptor instead.
+func (*BatchResponse) Descriptor() ([]byte, []int) {
 	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{11}
 }
 
-func (x *SetNodeConfigOptions) GetHashSlotsStart() uint32 {
+func (x *BatchResponse) GetOk() bool {
 	if x != nil {
-		return x.HashSlotsStart
+		return x.Ok
 	}
-	return 0
+	return false
 }
 
-func (x *SetNodeConfigOptions) GetHashSlotsEnd() uint32 {
+func (x *BatchResponse) GetVersions() []uint64 {
 	if x != nil {
-		return x.HashSlotsEnd
+		return x.Versions
 	}
-	return 0
+	return nil
 }
 
-type SetClusterConfigRequest struct {
+// both ends are inclusive.
+type HashSlotRange struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ThisNode      *SetNodeConfigOptions  `protobuf:"bytes,1,opt,name=this_node,json=thisNode,proto3" json:"this_node,omitempty"`
-	OtherNodes    []*NodeConfig          `protobuf:"bytes,2,rep,name=other_nodes,json=otherNodes,proto3" json:"other_nodes,omitempty"`
+	Start         uint32                 `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
+	End           uint32                 `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SetClusterConfigRequest) Reset() {
-	*x = SetClusterConfigRequest{}
+func (x *HashSlotRange) Reset() {
+	*x = HashSlotRange{}
 	mi := &file_internal_rpc_node_rpc_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SetClusterConfigRequest) String() string {
+func (x *HashSlotRange) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SetClusterConfigRequest) ProtoMessage() {}
+func (*HashSlotRange) ProtoMessage() {}
 
-func (x *SetClusterConfigRequest) ProtoReflect() protoreflect.Message {
+func (x *HashSlotRange) ProtoReflect() protoreflect.Message {
 	mi := &file_internal_rpc_node_rpc_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -662,46 +724,53 @@ func (x *SetClusterConfigRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SetClusterConfigRequest.ProtoReflect.Descriptor instead.
-func (*SetClusterConfigRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use HashSlotRange.ProtoReflect.Descriptor instead.
+func (*HashSlotRange) Descriptor() ([]byte, []int) {
 	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{12}
 }
 
-func (x *SetClusterConfigRequest) GetThisNode() *SetNodeConfigOptions {
+func (x *HashSlotRange) GetStart() uint32 {
 	if x != nil {
-		return x.ThisNode
+		return x.Start
 	}
-	return nil
+	return 0
 }
 
-func (x *SetClusterConfigRequest) GetOtherNodes() []*NodeConfig {
+func (x *HashSlotRange) GetEnd() uint32 {
 	if x != nil {
-		return x.OtherNodes
+		return x.End
 	}
-	return nil
+	return 0
 }
 
-type SetClusterConfigResponse struct {
+// keys in the slot range are hashed with the node's own hash function. No
+// range scans every key on the node.
+type ScanRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Prefix        string                 `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	Start         string                 `protobuf:"bytes,2,opt,name=start,proto3" json:"start,omitempty"`
+	End           string                 `protobuf:"bytes,3,opt,name=end,proto3" json:"end,omitempty"`
+	After         string                 `protobuf:"bytes,4,opt,name=after,proto3" json:"after,omitempty"`
+	Limit         uint32                 `protobuf:"varint,5,opt,name=limit,proto3" json:"limit,omitempty"`
+	HashSlots     *HashSlotRange         `protobuf:"bytes,6,opt,name=hash_slots,json=hashSlots,proto3" json:"hash_slots,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SetClusterConfigResponse) Reset() {
-	*x = SetClusterConfigResponse{}
+func (x *ScanRequest) Reset() {
+	*x = ScanRequest{}
 	mi := &file_internal_rpc_node_rpc_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SetClusterConfigResponse) String() string {
+func (x *ScanRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SetClusterConfigResponse) ProtoMessage() {}
+func (*ScanRequest) ProtoMessage() {}
 
-func (x *SetClusterConfigResponse) ProtoReflect() protoreflect.Message {
+func (x *ScanRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_internal_rpc_node_rpc_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -713,39 +782,3773 @@ func (x *SetClusterConfigResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SetClusterConfigResponse.ProtoReflect.Descriptor instead.
-func (*SetClusterConfigResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use ScanRequest.ProtoReflect.Descriptor instead.
+func (*ScanRequest) Descriptor() ([]byte, []int) {
 	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{13}
 }
 
-func (x *SetClusterConfigResponse) GetOk() bool {
+func (x *ScanRequest) GetPrefix() string {
 	if x != nil {
-		return x.Ok
+		return x.Prefix
 	}
-	return false
+	return ""
 }
 
-type GetClusterConfigRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *ScanRequest) GetStart() string {
+	if x != nil {
+		return x.Start
+	}
+	return ""
+}
+
+func (x *ScanRequest) GetEnd() string {
+	if x != nil {
+		return x.End
+	}
+	return ""
+}
+
+func (x *ScanRequest) GetAfter() string {
+	if x != nil {
+		return x.After
+	}
+	return ""
+}
+
+func (x *ScanRequest) GetLimit() uint32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ScanRequest) GetHashSlots() *HashSlotRange {
+	if x != nil {
+		return x.HashSlots
+	}
+	return nil
+}
+
+// val is only set for strings. type is empty from nodes older than value
+// types, which only had strings.
+type ScanItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Val           []byte                 `protobuf:"bytes,2,opt,name=val,proto3" json:"val,omitempty"`
+	ContentType   string                 `protobuf:"bytes,3,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Version       uint64                 `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`
+	Type          string                 `protobuf:"bytes,5,opt,name=type,proto3" json:"type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanItem) Reset() {
+	*x = ScanItem{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanItem) ProtoMessage() {}
+
+func (x *ScanItem) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanItem.ProtoReflect.Descriptor instead.
+func (*ScanItem) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ScanItem) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *ScanItem) GetVal() []byte {
+	if x != nil {
+		return x.Val
+	}
+	return nil
+}
+
+func (x *ScanItem) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *ScanItem) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *ScanItem) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+// every item is sent in its own message, then one last message without an
+// item says whether the limit cut the scan short.
+type ScanResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Item          *ScanItem              `protobuf:"bytes,1,opt,name=item,proto3" json:"item,omitempty"`
+	More          bool                   `protobuf:"varint,2,opt,name=more,proto3" json:"more,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanResponse) Reset() {
+	*x = ScanResponse{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanResponse) ProtoMessage() {}
+
+func (x *ScanResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanResponse.ProtoReflect.Descriptor instead.
+func (*ScanResponse) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ScanResponse) GetItem() *ScanItem {
+	if x != nil {
+		return x.Item
+	}
+	return nil
+}
+
+func (x *ScanResponse) GetMore() bool {
+	if x != nil {
+		return x.More
+	}
+	return false
+}
+
+// key takes priority over prefix. without resume the watch only gets
+// changes from now on, and after is ignored.
+type WatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Prefix        string                 `protobuf:"bytes,2,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	After         uint64                 `protobuf:"varint,3,opt,name=after,proto3" json:"after,omitempty"`
+	Resume        bool                   `protobuf:"varint,4,opt,name=resume,proto3" json:"resume,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *WatchRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *WatchRequest) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *WatchRequest) GetAfter() uint64 {
+	if x != nil {
+		return x.After
+	}
+	return 0
+}
+
+func (x *WatchRequest) GetResume() bool {
+	if x != nil {
+		return x.Resume
+	}
+	return false
+}
+
+// every change is sent in its own message. type is put or delete, and
+// version is 0 for deletes. the first message is a start, with only the
+// sequence the watch starts after.
+type WatchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sequence      uint64                 `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Key           string                 `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`
+	Version       uint64                 `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchResponse) Reset() {
+	*x = WatchResponse{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchResponse) ProtoMessage() {}
+
+func (x *WatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchResponse.ProtoReflect.Descriptor instead.
+func (*WatchResponse) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *WatchResponse) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *WatchResponse) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *WatchResponse) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *WatchResponse) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+// a ttl of zero or less expires the key straight away.
+type ExpireRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	TtlMs         int64                  `protobuf:"varint,2,opt,name=ttl_ms,json=ttlMs,proto3" json:"ttl_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExpireRequest) Reset() {
+	*x = ExpireRequest{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExpireRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExpireRequest) ProtoMessage() {}
+
+func (x *ExpireRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExpireRequest.ProtoReflect.Descriptor instead.
+func (*ExpireRequest) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ExpireRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *ExpireRequest) GetTtlMs() int64 {
+	if x != nil {
+		return x.TtlMs
+	}
+	return 0
+}
+
+// ok is false when the key doesn't exist.
+type ExpireResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExpireResponse) Reset() {
+	*x = ExpireResponse{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExpireResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExpireResponse) ProtoMessage() {}
+
+func (x *ExpireResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExpireResponse.ProtoReflect.Descriptor instead.
+func (*ExpireResponse) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ExpireResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+type PersistRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PersistRequest) Reset() {
+	*x = PersistRequest{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PersistRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PersistRequest) ProtoMessage() {}
+
+func (x *PersistRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PersistRequest.ProtoReflect.Descriptor instead.
+func (*PersistRequest) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *PersistRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type PersistResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PersistResponse) Reset() {
+	*x = PersistResponse{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PersistResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PersistResponse) ProtoMessage() {}
+
+func (x *PersistResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PersistResponse.ProtoReflect.Descriptor instead.
+func (*PersistResponse) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *PersistResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+type TtlRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TtlRequest) Reset() {
+	*x = TtlRequest{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TtlRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TtlRequest) ProtoMessage() {}
+
+func (x *TtlRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TtlRequest.ProtoReflect.Descriptor instead.
+func (*TtlRequest) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *TtlRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type TtlResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Expires       bool                   `protobuf:"varint,2,opt,name=expires,proto3" json:"expires,omitempty"`
+	TtlMs         int64                  `protobuf:"varint,3,opt,name=ttl_ms,json=ttlMs,proto3" json:"ttl_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TtlResponse) Reset() {
+	*x = TtlResponse{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TtlResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TtlResponse) ProtoMessage() {}
+
+func (x *TtlResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TtlResponse.ProtoReflect.Descriptor instead.
+func (*TtlResponse) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *TtlResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *TtlResponse) GetExpires() bool {
+	if x != nil {
+		return x.Expires
+	}
+	return false
+}
+
+func (x *TtlResponse) GetTtlMs() int64 {
+	if x != nil {
+		return x.TtlMs
+	}
+	return 0
+}
+
+// a negative delta decrements.
+type IncrRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Delta         int64                  `protobuf:"varint,2,opt,name=delta,proto3" json:"delta,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IncrRequest) Reset() {
+	*x = IncrRequest{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IncrRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IncrRequest) ProtoMessage() {}
+
+func (x *IncrRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IncrRequest.ProtoReflect.Descriptor instead.
+func (*IncrRequest) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *IncrRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *IncrRequest) GetDelta() int64 {
+	if x != nil {
+		return x.Delta
+	}
+	return 0
+}
+
+type IncrResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Val           int64                  `protobuf:"varint,2,opt,name=val,proto3" json:"val,omitempty"`
+	Version       uint64                 `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IncrResponse) Reset() {
+	*x = IncrResponse{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IncrResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IncrResponse) ProtoMessage() {}
+
+func (x *IncrResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IncrResponse.ProtoReflect.Descriptor instead.
+func (*IncrResponse) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *IncrResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *IncrResponse) GetVal() int64 {
+	if x != nil {
+		return x.Val
+	}
+	return 0
+}
+
+func (x *IncrResponse) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type ListPushRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Left          bool                   `protobuf:"varint,2,opt,name=left,proto3" json:"left,omitempty"`
+	Vals          [][]byte               `protobuf:"bytes,3,rep,name=vals,proto3" json:"vals,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPushRequest) Reset() {
+	*x = ListPushRequest{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPushRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPushRequest) ProtoMessage() {}
+
+func (x *ListPushRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPushRequest.ProtoReflect.Descriptor instead.
+func (*ListPushRequest) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *ListPushRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *ListPushRequest) GetLeft() bool {
+	if x != nil {
+		return x.Left
+	}
+	return false
+}
+
+func (x *ListPushRequest) GetVals() [][]byte {
+	if x != nil {
+		return x.Vals
+	}
+	return nil
+}
+
+type ListPushResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Length        int64                  `protobuf:"varint,2,opt,name=length,proto3" json:"length,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPushResponse) Reset() {
+	*x = ListPushResponse{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPushResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPushResponse) ProtoMessage() {}
+
+func (x *ListPushResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPushResponse.ProtoReflect.Descriptor instead.
+func (*ListPushResponse) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *ListPushResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *ListPushResponse) GetLength() int64 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
+}
+
+type ListPopRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Left          bool                   `protobuf:"varint,2,opt,name=left,proto3" json:"left,omitempty"`
+	Count         uint32                 `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPopRequest) Reset() {
+	*x = ListPopRequest{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPopRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPopRequest) ProtoMessage() {}
+
+func (x *ListPopRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPopRequest.ProtoReflect.Descriptor instead.
+func (*ListPopRequest) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *ListPopRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *ListPopRequest) GetLeft() bool {
+	if x != nil {
+		return x.Left
+	}
+	return false
+}
+
+func (x *ListPopRequest) GetCount() uint32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type ListPopResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Vals          [][]byte               `protobuf:"bytes,2,rep,name=vals,proto3" json:"vals,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPopResponse) Reset() {
+	*x = ListPopResponse{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPopResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPopResponse) ProtoMessage() {}
+
+func (x *ListPopResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPopResponse.ProtoReflect.Descriptor instead.
+func (*ListPopResponse) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *ListPopResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *ListPopResponse) GetVals() [][]byte {
+	if x != nil {
+		return x.Vals
+	}
+	return nil
+}
+
+type ListRangeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Start         int64                  `protobuf:"varint,2,opt,name=start,proto3" json:"start,omitempty"`
+	Stop          int64                  `protobuf:"varint,3,opt,name=stop,proto3" json:"stop,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRangeRequest) Reset() {
+	*x = ListRangeRequest{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRangeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRangeRequest) ProtoMessage() {}
+
+func (x *ListRangeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRangeRequest.ProtoReflect.Descriptor instead.
+func (*ListRangeRequest) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ListRangeRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *ListRangeRequest) GetStart() int64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *ListRangeRequest) GetStop() int64 {
+	if x != nil {
+		return x.Stop
+	}
+	return 0
+}
+
+type ListRangeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Vals          [][]byte               `protobuf:"bytes,2,rep,name=vals,proto3" json:"vals,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRangeResponse) Reset() {
+	*x = ListRangeResponse{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRangeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRangeResponse) ProtoMessage() {}
+
+func (x *ListRangeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRangeResponse.ProtoReflect.Descriptor instead.
+func (*ListRangeResponse) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *ListRangeResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *ListRangeResponse) GetVals() [][]byte {
+	if x != nil {
+		return x.Vals
+	}
+	return nil
+}
+
+type HashSetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Fields        map[string][]byte      `protobuf:"bytes,2,rep,name=fields,proto3" json:"fields,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HashSetRequest) Reset() {
+	*x = HashSetRequest{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HashSetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HashSetRequest) ProtoMessage() {}
+
+func (x *HashSetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HashSetRequest.ProtoReflect.Descriptor instead.
+func (*HashSetRequest) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *HashSetRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *HashSetRequest) GetFields() map[string][]byte {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+type HashSetResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Added         int64                  `protobuf:"varint,2,opt,name=added,proto3" json:"added,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HashSetResponse) Reset() {
+	*x = HashSetResponse{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HashSetResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HashSetResponse) ProtoMessage() {}
+
+func (x *HashSetResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HashSetResponse.ProtoReflect.Descriptor instead.
+func (*HashSetResponse) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *HashSetResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *HashSetResponse) GetAdded() int64 {
+	if x != nil {
+		return x.Added
+	}
+	return 0
+}
+
+type HashGetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Field         string                 `protobuf:"bytes,2,opt,name=field,proto3" json:"field,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HashGetRequest) Reset() {
+	*x = HashGetRequest{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HashGetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HashGetRequest) ProtoMessage() {}
+
+func (x *HashGetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HashGetRequest.ProtoReflect.Descriptor instead.
+func (*HashGetRequest) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *HashGetRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *HashGetRequest) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+type HashGetResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Found         bool                   `protobuf:"varint,2,opt,name=found,proto3" json:"found,omitempty"`
+	Val           []byte                 `protobuf:"bytes,3,opt,name=val,proto3" json:"val,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HashGetResponse) Reset() {
+	*x = HashGetResponse{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HashGetResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HashGetResponse) ProtoMessage() {}
+
+func (x *HashGetResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HashGetResponse.ProtoReflect.Descriptor instead.
+func (*HashGetResponse) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *HashGetResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *HashGetResponse) GetFound() bool {
+	if x != nil {
+		return x.Found
+	}
+	return false
+}
+
+func (x *HashGetResponse) GetVal() []byte {
+	if x != nil {
+		return x.Val
+	}
+	return nil
+}
+
+type HashDeleteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Fields        []string               `protobuf:"bytes,2,rep,name=fields,proto3" json:"fields,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HashDeleteRequest) Reset() {
+	*x = HashDeleteRequest{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HashDeleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HashDeleteRequest) ProtoMessage() {}
+
+func (x *HashDeleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HashDeleteRequest.ProtoReflect.Descriptor instead.
+func (*HashDeleteRequest) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *HashDeleteRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *HashDeleteRequest) GetFields() []string {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+type HashDeleteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Deleted       int64                  `protobuf:"varint,2,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HashDeleteResponse) Reset() {
+	*x = HashDeleteResponse{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HashDeleteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HashDeleteResponse) ProtoMessage() {}
+
+func (x *HashDeleteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HashDeleteResponse.ProtoReflect.Descriptor instead.
+func (*HashDeleteResponse) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *HashDeleteResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *HashDeleteResponse) GetDeleted() int64 {
+	if x != nil {
+		return x.Deleted
+	}
+	return 0
+}
+
+type HashGetAllRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HashGetAllRequest) Reset() {
+	*x = HashGetAllRequest{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HashGetAllRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HashGetAllRequest) ProtoMessage() {}
+
+func (x *HashGetAllRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HashGetAllRequest.ProtoReflect.Descriptor instead.
+func (*HashGetAllRequest) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *HashGetAllRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type HashGetAllResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Fields        map[string][]byte      `protobuf:"bytes,2,rep,name=fields,proto3" json:"fields,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HashGetAllResponse) Reset() {
+	*x = HashGetAllResponse{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HashGetAllResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HashGetAllResponse) ProtoMessage() {}
+
+func (x *HashGetAllResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HashGetAllResponse.ProtoReflect.Descriptor instead.
+func (*HashGetAllResponse) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *HashGetAllResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *HashGetAllResponse) GetFields() map[string][]byte {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+type SetAddRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Members       [][]byte               `protobuf:"bytes,2,rep,name=members,proto3" json:"members,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetAddRequest) Reset() {
+	*x = SetAddRequest{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetAddRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetAddRequest) ProtoMessage() {}
+
+func (x *SetAddRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetAddRequest.ProtoReflect.Descriptor instead.
+func (*SetAddRequest) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *SetAddRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *SetAddRequest) GetMembers() [][]byte {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+type SetAddResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Added         int64                  `protobuf:"varint,2,opt,name=added,proto3" json:"added,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetAddResponse) Reset() {
+	*x = SetAddResponse{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetAddResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetAddResponse) ProtoMessage() {}
+
+func (x *SetAddResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetAddResponse.ProtoReflect.Descriptor instead.
+func (*SetAddResponse) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *SetAddResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *SetAddResponse) GetAdded() int64 {
+	if x != nil {
+		return x.Added
+	}
+	return 0
+}
+
+type SetRemoveRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Members       [][]byte               `protobuf:"bytes,2,rep,name=members,proto3" json:"members,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetRemoveRequest) Reset() {
+	*x = SetRemoveRequest{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetRemoveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetRemoveRequest) ProtoMessage() {}
+
+func (x *SetRemoveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetRemoveRequest.ProtoReflect.Descriptor instead.
+func (*SetRemoveRequest) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *SetRemoveRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *SetRemoveRequest) GetMembers() [][]byte {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+type SetRemoveResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Removed       int64                  `protobuf:"varint,2,opt,name=removed,proto3" json:"removed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetRemoveResponse) Reset() {
+	*x = SetRemoveResponse{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetRemoveResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetRemoveResponse) ProtoMessage() {}
+
+func (x *SetRemoveResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetRemoveResponse.ProtoReflect.Descriptor instead.
+func (*SetRemoveResponse) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *SetRemoveResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *SetRemoveResponse) GetRemoved() int64 {
+	if x != nil {
+		return x.Removed
+	}
+	return 0
+}
+
+type SetMembersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetMembersRequest) Reset() {
+	*x = SetMembersRequest{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetMembersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetMembersRequest) ProtoMessage() {}
+
+func (x *SetMembersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetMembersRequest.ProtoReflect.Descriptor instead.
+func (*SetMembersRequest) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *SetMembersRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type SetMembersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Members       [][]byte               `protobuf:"bytes,2,rep,name=members,proto3" json:"members,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetMembersResponse) Reset() {
+	*x = SetMembersResponse{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetMembersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetMembersResponse) ProtoMessage() {}
+
+func (x *SetMembersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetMembersResponse.ProtoReflect.Descriptor instead.
+func (*SetMembersResponse) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *SetMembersResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *SetMembersResponse) GetMembers() [][]byte {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+type SetIsMemberRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Member        []byte                 `protobuf:"bytes,2,opt,name=member,proto3" json:"member,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetIsMemberRequest) Reset() {
+	*x = SetIsMemberRequest{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetIsMemberRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetIsMemberRequest) ProtoMessage() {}
+
+func (x *SetIsMemberRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetIsMemberRequest.ProtoReflect.Descriptor instead.
+func (*SetIsMemberRequest) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *SetIsMemberRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *SetIsMemberRequest) GetMember() []byte {
+	if x != nil {
+		return x.Member
+	}
+	return nil
+}
+
+type SetIsMemberResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	IsMember      bool                   `protobuf:"varint,2,opt,name=is_member,json=isMember,proto3" json:"is_member,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetIsMemberResponse) Reset() {
+	*x = SetIsMemberResponse{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetIsMemberResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetIsMemberResponse) ProtoMessage() {}
+
+func (x *SetIsMemberResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetIsMemberResponse.ProtoReflect.Descriptor instead.
+func (*SetIsMemberResponse) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *SetIsMemberResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *SetIsMemberResponse) GetIsMember() bool {
+	if x != nil {
+		return x.IsMember
+	}
+	return false
+}
+
+type ScoredMember struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Member        string                 `protobuf:"bytes,1,opt,name=member,proto3" json:"member,omitempty"`
+	Score         float64                `protobuf:"fixed64,2,opt,name=score,proto3" json:"score,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScoredMember) Reset() {
+	*x = ScoredMember{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScoredMember) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScoredMember) ProtoMessage() {}
+
+func (x *ScoredMember) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScoredMember.ProtoReflect.Descriptor instead.
+func (*ScoredMember) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *ScoredMember) GetMember() string {
+	if x != nil {
+		return x.Member
+	}
+	return ""
+}
+
+func (x *ScoredMember) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+type SortedSetAddRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Members       map[string]float64     `protobuf:"bytes,2,rep,name=members,proto3" json:"members,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SortedSetAddRequest) Reset() {
+	*x = SortedSetAddRequest{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[49]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SortedSetAddRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SortedSetAddRequest) ProtoMessage() {}
+
+func (x *SortedSetAddRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[49]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SortedSetAddRequest.ProtoReflect.Descriptor instead.
+func (*SortedSetAddRequest) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *SortedSetAddRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *SortedSetAddRequest) GetMembers() map[string]float64 {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+type SortedSetAddResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Added         int64                  `protobuf:"varint,2,opt,name=added,proto3" json:"added,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SortedSetAddResponse) Reset() {
+	*x = SortedSetAddResponse{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[50]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SortedSetAddResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SortedSetAddResponse) ProtoMessage() {}
+
+func (x *SortedSetAddResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[50]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SortedSetAddResponse.ProtoReflect.Descriptor instead.
+func (*SortedSetAddResponse) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *SortedSetAddResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *SortedSetAddResponse) GetAdded() int64 {
+	if x != nil {
+		return x.Added
+	}
+	return 0
+}
+
+type SortedSetRemoveRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Members       []string               `protobuf:"bytes,2,rep,name=members,proto3" json:"members,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SortedSetRemoveRequest) Reset() {
+	*x = SortedSetRemoveRequest{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[51]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SortedSetRemoveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SortedSetRemoveRequest) ProtoMessage() {}
+
+func (x *SortedSetRemoveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[51]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SortedSetRemoveRequest.ProtoReflect.Descriptor instead.
+func (*SortedSetRemoveRequest) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{51}
+}
+
+func (x *SortedSetRemoveRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *SortedSetRemoveRequest) GetMembers() []string {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+type SortedSetRemoveResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Removed       int64                  `protobuf:"varint,2,opt,name=removed,proto3" json:"removed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SortedSetRemoveResponse) Reset() {
+	*x = SortedSetRemoveResponse{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[52]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SortedSetRemoveResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SortedSetRemoveResponse) ProtoMessage() {}
+
+func (x *SortedSetRemoveResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[52]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SortedSetRemoveResponse.ProtoReflect.Descriptor instead.
+func (*SortedSetRemoveResponse) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{52}
+}
+
+func (x *SortedSetRemoveResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *SortedSetRemoveResponse) GetRemoved() int64 {
+	if x != nil {
+		return x.Removed
+	}
+	return 0
+}
+
+type SortedSetIncrRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Member        string                 `protobuf:"bytes,2,opt,name=member,proto3" json:"member,omitempty"`
+	Delta         float64                `protobuf:"fixed64,3,opt,name=delta,proto3" json:"delta,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SortedSetIncrRequest) Reset() {
+	*x = SortedSetIncrRequest{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[53]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SortedSetIncrRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SortedSetIncrRequest) ProtoMessage() {}
+
+func (x *SortedSetIncrRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[53]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SortedSetIncrRequest.ProtoReflect.Descriptor instead.
+func (*SortedSetIncrRequest) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{53}
+}
+
+func (x *SortedSetIncrRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *SortedSetIncrRequest) GetMember() string {
+	if x != nil {
+		return x.Member
+	}
+	return ""
+}
+
+func (x *SortedSetIncrRequest) GetDelta() float64 {
+	if x != nil {
+		return x.Delta
+	}
+	return 0
+}
+
+type SortedSetIncrResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Score         float64                `protobuf:"fixed64,2,opt,name=score,proto3" json:"score,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SortedSetIncrResponse) Reset() {
+	*x = SortedSetIncrResponse{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[54]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SortedSetIncrResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SortedSetIncrResponse) ProtoMessage() {}
+
+func (x *SortedSetIncrResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[54]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SortedSetIncrResponse.ProtoReflect.Descriptor instead.
+func (*SortedSetIncrResponse) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{54}
+}
+
+func (x *SortedSetIncrResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *SortedSetIncrResponse) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+type SortedSetRangeByRankRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Start         int64                  `protobuf:"varint,2,opt,name=start,proto3" json:"start,omitempty"`
+	Stop          int64                  `protobuf:"varint,3,opt,name=stop,proto3" json:"stop,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SortedSetRangeByRankRequest) Reset() {
+	*x = SortedSetRangeByRankRequest{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[55]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SortedSetRangeByRankRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SortedSetRangeByRankRequest) ProtoMessage() {}
+
+func (x *SortedSetRangeByRankRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[55]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SortedSetRangeByRankRequest.ProtoReflect.Descriptor instead.
+func (*SortedSetRangeByRankRequest) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{55}
+}
+
+func (x *SortedSetRangeByRankRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *SortedSetRangeByRankRequest) GetStart() int64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *SortedSetRangeByRankRequest) GetStop() int64 {
+	if x != nil {
+		return x.Stop
+	}
+	return 0
+}
+
+type SortedSetRangeByRankResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Members       []*ScoredMember        `protobuf:"bytes,2,rep,name=members,proto3" json:"members,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SortedSetRangeByRankResponse) Reset() {
+	*x = SortedSetRangeByRankResponse{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[56]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SortedSetRangeByRankResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SortedSetRangeByRankResponse) ProtoMessage() {}
+
+func (x *SortedSetRangeByRankResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[56]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SortedSetRangeByRankResponse.ProtoReflect.Descriptor instead.
+func (*SortedSetRangeByRankResponse) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{56}
+}
+
+func (x *SortedSetRangeByRankResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *SortedSetRangeByRankResponse) GetMembers() []*ScoredMember {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+// a limit of 0 returns every member in the range.
+type SortedSetRangeByScoreRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Min           float64                `protobuf:"fixed64,2,opt,name=min,proto3" json:"min,omitempty"`
+	Max           float64                `protobuf:"fixed64,3,opt,name=max,proto3" json:"max,omitempty"`
+	Limit         uint32                 `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SortedSetRangeByScoreRequest) Reset() {
+	*x = SortedSetRangeByScoreRequest{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[57]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SortedSetRangeByScoreRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SortedSetRangeByScoreRequest) ProtoMessage() {}
+
+func (x *SortedSetRangeByScoreRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[57]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SortedSetRangeByScoreRequest.ProtoReflect.Descriptor instead.
+func (*SortedSetRangeByScoreRequest) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{57}
+}
+
+func (x *SortedSetRangeByScoreRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *SortedSetRangeByScoreRequest) GetMin() float64 {
+	if x != nil {
+		return x.Min
+	}
+	return 0
+}
+
+func (x *SortedSetRangeByScoreRequest) GetMax() float64 {
+	if x != nil {
+		return x.Max
+	}
+	return 0
+}
+
+func (x *SortedSetRangeByScoreRequest) GetLimit() uint32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type SortedSetRangeByScoreResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Members       []*ScoredMember        `protobuf:"bytes,2,rep,name=members,proto3" json:"members,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SortedSetRangeByScoreResponse) Reset() {
+	*x = SortedSetRangeByScoreResponse{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[58]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SortedSetRangeByScoreResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SortedSetRangeByScoreResponse) ProtoMessage() {}
+
+func (x *SortedSetRangeByScoreResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[58]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SortedSetRangeByScoreResponse.ProtoReflect.Descriptor instead.
+func (*SortedSetRangeByScoreResponse) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{58}
+}
+
+func (x *SortedSetRangeByScoreResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *SortedSetRangeByScoreResponse) GetMembers() []*ScoredMember {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+type SortedSetRankRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Member        string                 `protobuf:"bytes,2,opt,name=member,proto3" json:"member,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SortedSetRankRequest) Reset() {
+	*x = SortedSetRankRequest{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[59]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SortedSetRankRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SortedSetRankRequest) ProtoMessage() {}
+
+func (x *SortedSetRankRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[59]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SortedSetRankRequest.ProtoReflect.Descriptor instead.
+func (*SortedSetRankRequest) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{59}
+}
+
+func (x *SortedSetRankRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *SortedSetRankRequest) GetMember() string {
+	if x != nil {
+		return x.Member
+	}
+	return ""
+}
+
+type SortedSetRankResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Found         bool                   `protobuf:"varint,2,opt,name=found,proto3" json:"found,omitempty"`
+	Rank          int64                  `protobuf:"varint,3,opt,name=rank,proto3" json:"rank,omitempty"`
+	Score         float64                `protobuf:"fixed64,4,opt,name=score,proto3" json:"score,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SortedSetRankResponse) Reset() {
+	*x = SortedSetRankResponse{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[60]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SortedSetRankResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SortedSetRankResponse) ProtoMessage() {}
+
+func (x *SortedSetRankResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[60]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SortedSetRankResponse.ProtoReflect.Descriptor instead.
+func (*SortedSetRankResponse) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{60}
+}
+
+func (x *SortedSetRankResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *SortedSetRankResponse) GetFound() bool {
+	if x != nil {
+		return x.Found
+	}
+	return false
+}
+
+func (x *SortedSetRankResponse) GetRank() int64 {
+	if x != nil {
+		return x.Rank
+	}
+	return 0
+}
+
+func (x *SortedSetRankResponse) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+// replica_of is the ID of the node's primary, empty if it is a primary.
+type NodeConfig struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	NodeId         string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Address        string                 `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	HashSlotsStart uint32                 `protobuf:"varint,3,opt,name=hash_slots_start,json=hashSlotsStart,proto3" json:"hash_slots_start,omitempty"`
+	HashSlotsEnd   uint32                 `protobuf:"varint,4,opt,name=hash_slots_end,json=hashSlotsEnd,proto3" json:"hash_slots_end,omitempty"`
+	ReplicaOf      string                 `protobuf:"bytes,5,opt,name=replica_of,json=replicaOf,proto3" json:"replica_of,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *NodeConfig) Reset() {
+	*x = NodeConfig{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[61]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NodeConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodeConfig) ProtoMessage() {}
+
+func (x *NodeConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[61]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodeConfig.ProtoReflect.Descriptor instead.
+func (*NodeConfig) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{61}
+}
+
+func (x *NodeConfig) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *NodeConfig) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *NodeConfig) GetHashSlotsStart() uint32 {
+	if x != nil {
+		return x.HashSlotsStart
+	}
+	return 0
+}
+
+func (x *NodeConfig) GetHashSlotsEnd() uint32 {
+	if x != nil {
+		return x.HashSlotsEnd
+	}
+	return 0
+}
+
+func (x *NodeConfig) GetReplicaOf() string {
+	if x != nil {
+		return x.ReplicaOf
+	}
+	return ""
+}
+
+type SetNodeConfigOptions struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	HashSlotsStart uint32                 `protobuf:"varint,1,opt,name=hash_slots_start,json=hashSlotsStart,proto3" json:"hash_slots_start,omitempty"`
+	HashSlotsEnd   uint32                 `protobuf:"varint,2,opt,name=hash_slots_end,json=hashSlotsEnd,proto3" json:"hash_slots_end,omitempty"`
+	ReplicaOf      string                 `protobuf:"bytes,3,opt,name=replica_of,json=replicaOf,proto3" json:"replica_of,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SetNodeConfigOptions) Reset() {
+	*x = SetNodeConfigOptions{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[62]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetNodeConfigOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetNodeConfigOptions) ProtoMessage() {}
+
+func (x *SetNodeConfigOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[62]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetNodeConfigOptions.ProtoReflect.Descriptor instead.
+func (*SetNodeConfigOptions) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{62}
+}
+
+func (x *SetNodeConfigOptions) GetHashSlotsStart() uint32 {
+	if x != nil {
+		return x.HashSlotsStart
+	}
+	return 0
+}
+
+func (x *SetNodeConfigOptions) GetHashSlotsEnd() uint32 {
+	if x != nil {
+		return x.HashSlotsEnd
+	}
+	return 0
+}
+
+func (x *SetNodeConfigOptions) GetReplicaOf() string {
+	if x != nil {
+		return x.ReplicaOf
+	}
+	return ""
+}
+
+// the config is proposed to the cluster config raft group, and applies to every
+// node once a majority of them have it. other_nodes has every node in the new
+// config, and this_node is only used if the node sent the request isn't in it.
+// A node that isn't the leader forwards the request to the leader, setting
+// forwarded so it is never forwarded twice. A node refuses to join a cluster
+// with a different hash function. replication_mode is left as it is if empty.
+type SetClusterConfigRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	ThisNode        *SetNodeConfigOptions  `protobuf:"bytes,1,opt,name=this_node,json=thisNode,proto3" json:"this_node,omitempty"`
+	OtherNodes      []*NodeConfig          `protobuf:"bytes,2,rep,name=other_nodes,json=otherNodes,proto3" json:"other_nodes,omitempty"`
+	HashFunction    string                 `protobuf:"bytes,3,opt,name=hash_function,json=hashFunction,proto3" json:"hash_function,omitempty"`
+	Forwarded       bool                   `protobuf:"varint,5,opt,name=forwarded,proto3" json:"forwarded,omitempty"`
+	ReplicationMode string                 `protobuf:"bytes,6,opt,name=replication_mode,json=replicationMode,proto3" json:"replication_mode,omitempty"`
+	Quorum          *QuorumConfig          `protobuf:"bytes,7,opt,name=quorum,proto3" json:"quorum,omitempty"`
+	// the epoch of the config this one was made from. It isn't committed if
+	// the config has changed since.
+	Epoch uint64 `protobuf:"varint,8,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	// starts the cluster config group on the node this is sent to, which has
+	// to have never been in a cluster.
+	Bootstrap     bool `protobuf:"varint,9,opt,name=bootstrap,proto3" json:"bootstrap,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetClusterConfigRequest) Reset() {
+	*x = SetClusterConfigRequest{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[63]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetClusterConfigRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetClusterConfigRequest) ProtoMessage() {}
+
+func (x *SetClusterConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[63]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetClusterConfigRequest.ProtoReflect.Descriptor instead.
+func (*SetClusterConfigRequest) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{63}
+}
+
+func (x *SetClusterConfigRequest) GetThisNode() *SetNodeConfigOptions {
+	if x != nil {
+		return x.ThisNode
+	}
+	return nil
+}
+
+func (x *SetClusterConfigRequest) GetOtherNodes() []*NodeConfig {
+	if x != nil {
+		return x.OtherNodes
+	}
+	return nil
+}
+
+func (x *SetClusterConfigRequest) GetHashFunction() string {
+	if x != nil {
+		return x.HashFunction
+	}
+	return ""
+}
+
+func (x *SetClusterConfigRequest) GetForwarded() bool {
+	if x != nil {
+		return x.Forwarded
+	}
+	return false
+}
+
+func (x *SetClusterConfigRequest) GetReplicationMode() string {
+	if x != nil {
+		return x.ReplicationMode
+	}
+	return ""
+}
+
+func (x *SetClusterConfigRequest) GetQuorum() *QuorumConfig {
+	if x != nil {
+		return x.Quorum
+	}
+	return nil
+}
+
+func (x *SetClusterConfigRequest) GetEpoch() uint64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+func (x *SetClusterConfigRequest) GetBootstrap() bool {
+	if x != nil {
+		return x.Bootstrap
+	}
+	return false
+}
+
+type SetClusterConfigResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetClusterConfigResponse) Reset() {
+	*x = SetClusterConfigResponse{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[64]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetClusterConfigResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetClusterConfigResponse) ProtoMessage() {}
+
+func (x *SetClusterConfigResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[64]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetClusterConfigResponse.ProtoReflect.Descriptor instead.
+func (*SetClusterConfigResponse) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{64}
+}
+
+func (x *SetClusterConfigResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+type GetClusterConfigRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetClusterConfigRequest) Reset() {
+	*x = GetClusterConfigRequest{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[65]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetClusterConfigRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetClusterConfigRequest) ProtoMessage() {}
+
+func (x *GetClusterConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[65]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetClusterConfigRequest.ProtoReflect.Descriptor instead.
+func (*GetClusterConfigRequest) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{65}
+}
+
+type GetClusterConfigResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Ok              bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	ThisNode        *NodeConfig            `protobuf:"bytes,2,opt,name=this_node,json=thisNode,proto3" json:"this_node,omitempty"`
+	OtherNodes      []*NodeConfig          `protobuf:"bytes,3,rep,name=other_nodes,json=otherNodes,proto3" json:"other_nodes,omitempty"`
+	HashFunction    string                 `protobuf:"bytes,4,opt,name=hash_function,json=hashFunction,proto3" json:"hash_function,omitempty"`
+	Epoch           uint64                 `protobuf:"varint,5,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	ReplicationMode string                 `protobuf:"bytes,6,opt,name=replication_mode,json=replicationMode,proto3" json:"replication_mode,omitempty"`
+	Quorum          *QuorumConfig          `protobuf:"bytes,7,opt,name=quorum,proto3" json:"quorum,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *GetClusterConfigResponse) Reset() {
+	*x = GetClusterConfigResponse{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[66]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetClusterConfigResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetClusterConfigResponse) ProtoMessage() {}
+
+func (x *GetClusterConfigResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[66]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetClusterConfigResponse.ProtoReflect.Descriptor instead.
+func (*GetClusterConfigResponse) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{66}
+}
+
+func (x *GetClusterConfigResponse) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *GetClusterConfigResponse) GetThisNode() *NodeConfig {
+	if x != nil {
+		return x.ThisNode
+	}
+	return nil
+}
+
+func (x *GetClusterConfigResponse) GetOtherNodes() []*NodeConfig {
+	if x != nil {
+		return x.OtherNodes
+	}
+	return nil
+}
+
+func (x *GetClusterConfigResponse) GetHashFunction() string {
+	if x != nil {
+		return x.HashFunction
+	}
+	return ""
+}
+
+func (x *GetClusterConfigResponse) GetEpoch() uint64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+func (x *GetClusterConfigResponse) GetReplicationMode() string {
+	if x != nil {
+		return x.ReplicationMode
+	}
+	return ""
+}
+
+func (x *GetClusterConfigResponse) GetQuorum() *QuorumConfig {
+	if x != nil {
+		return x.Quorum
+	}
+	return nil
+}
+
+// n is how many nodes keep a copy of each key with quorum replication, and r
+// and w how many of them a read or write waits for.
+type QuorumConfig struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	N             uint32                 `protobuf:"varint,1,opt,name=n,proto3" json:"n,omitempty"`
+	R             uint32                 `protobuf:"varint,2,opt,name=r,proto3" json:"r,omitempty"`
+	W             uint32                 `protobuf:"varint,3,opt,name=w,proto3" json:"w,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QuorumConfig) Reset() {
+	*x = QuorumConfig{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[67]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QuorumConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuorumConfig) ProtoMessage() {}
+
+func (x *QuorumConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[67]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuorumConfig.ProtoReflect.Descriptor instead.
+func (*QuorumConfig) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{67}
+}
+
+func (x *QuorumConfig) GetN() uint32 {
+	if x != nil {
+		return x.N
+	}
+	return 0
+}
+
+func (x *QuorumConfig) GetR() uint32 {
+	if x != nil {
+		return x.R
+	}
+	return 0
+}
+
+func (x *QuorumConfig) GetW() uint32 {
+	if x != nil {
+		return x.W
+	}
+	return 0
+}
+
+// primary_id is the primary the replica last synced from, and after_lsn the
+// last entry it has. The primary only streams the entries after it if it is
+// the same primary and still has them, otherwise it sends a snapshot first.
+type ReplicateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ReplicaId     string                 `protobuf:"bytes,1,opt,name=replica_id,json=replicaId,proto3" json:"replica_id,omitempty"`
+	PrimaryId     string                 `protobuf:"bytes,2,opt,name=primary_id,json=primaryId,proto3" json:"primary_id,omitempty"`
+	AfterLsn      uint64                 `protobuf:"varint,3,opt,name=after_lsn,json=afterLsn,proto3" json:"after_lsn,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplicateRequest) Reset() {
+	*x = ReplicateRequest{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[68]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplicateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicateRequest) ProtoMessage() {}
+
+func (x *ReplicateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[68]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicateRequest.ProtoReflect.Descriptor instead.
+func (*ReplicateRequest) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{68}
+}
+
+func (x *ReplicateRequest) GetReplicaId() string {
+	if x != nil {
+		return x.ReplicaId
+	}
+	return ""
+}
+
+func (x *ReplicateRequest) GetPrimaryId() string {
+	if x != nil {
+		return x.PrimaryId
+	}
+	return ""
+}
+
+func (x *ReplicateRequest) GetAfterLsn() uint64 {
+	if x != nil {
+		return x.AfterLsn
+	}
+	return 0
+}
+
+type SnapshotItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value         []byte                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotItem) Reset() {
+	*x = SnapshotItem{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[69]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotItem) ProtoMessage() {}
+
+func (x *SnapshotItem) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[69]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotItem.ProtoReflect.Descriptor instead.
+func (*SnapshotItem) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{69}
+}
+
+func (x *SnapshotItem) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *SnapshotItem) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+type ReplicationEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Lsn           uint64                 `protobuf:"varint,1,opt,name=lsn,proto3" json:"lsn,omitempty"`
+	OpType        uint32                 `protobuf:"varint,2,opt,name=op_type,json=opType,proto3" json:"op_type,omitempty"`
+	Key           string                 `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`
+	Value         []byte                 `protobuf:"bytes,4,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplicationEntry) Reset() {
+	*x = ReplicationEntry{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[70]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplicationEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicationEntry) ProtoMessage() {}
+
+func (x *ReplicationEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[70]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicationEntry.ProtoReflect.Descriptor instead.
+func (*ReplicationEntry) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{70}
+}
+
+func (x *ReplicationEntry) GetLsn() uint64 {
+	if x != nil {
+		return x.Lsn
+	}
+	return 0
+}
+
+func (x *ReplicationEntry) GetOpType() uint32 {
+	if x != nil {
+		return x.OpType
+	}
+	return 0
+}
+
+func (x *ReplicationEntry) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *ReplicationEntry) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+// A snapshot is sent as a snapshot_start, any number of responses with items,
+// and a snapshot_end. The items are raw engine values, and snapshot_lsn is the
+// last entry they include. Every other response has a WAL entry. In a raft
+// shard, raft_term is the term of the entry at snapshot_lsn.
+type ReplicateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PrimaryId     string                 `protobuf:"bytes,1,opt,name=primary_id,json=primaryId,proto3" json:"primary_id,omitempty"`
+	SnapshotStart bool                   `protobuf:"varint,2,opt,name=snapshot_start,json=snapshotStart,proto3" json:"snapshot_start,omitempty"`
+	SnapshotLsn   uint64                 `protobuf:"varint,3,opt,name=snapshot_lsn,json=snapshotLsn,proto3" json:"snapshot_lsn,omitempty"`
+	Items         []*SnapshotItem        `protobuf:"bytes,4,rep,name=items,proto3" json:"items,omitempty"`
+	SnapshotEnd   bool                   `protobuf:"varint,5,opt,name=snapshot_end,json=snapshotEnd,proto3" json:"snapshot_end,omitempty"`
+	Entry         *ReplicationEntry      `protobuf:"bytes,6,opt,name=entry,proto3" json:"entry,omitempty"`
+	RaftTerm      uint64                 `protobuf:"varint,7,opt,name=raft_term,json=raftTerm,proto3" json:"raft_term,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplicateResponse) Reset() {
+	*x = ReplicateResponse{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[71]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplicateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicateResponse) ProtoMessage() {}
+
+func (x *ReplicateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[71]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicateResponse.ProtoReflect.Descriptor instead.
+func (*ReplicateResponse) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{71}
+}
+
+func (x *ReplicateResponse) GetPrimaryId() string {
+	if x != nil {
+		return x.PrimaryId
+	}
+	return ""
+}
+
+func (x *ReplicateResponse) GetSnapshotStart() bool {
+	if x != nil {
+		return x.SnapshotStart
+	}
+	return false
+}
+
+func (x *ReplicateResponse) GetSnapshotLsn() uint64 {
+	if x != nil {
+		return x.SnapshotLsn
+	}
+	return 0
+}
+
+func (x *ReplicateResponse) GetItems() []*SnapshotItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *ReplicateResponse) GetSnapshotEnd() bool {
+	if x != nil {
+		return x.SnapshotEnd
+	}
+	return false
+}
+
+func (x *ReplicateResponse) GetEntry() *ReplicationEntry {
+	if x != nil {
+		return x.Entry
+	}
+	return nil
+}
+
+func (x *ReplicateResponse) GetRaftTerm() uint64 {
+	if x != nil {
+		return x.RaftTerm
+	}
+	return 0
+}
+
+// suspected is whether the node asked can't reach node_id either. last_lsn is
+// how far the node asked has replicated node_id, 0 if it isn't a replica of it
+// that has synced since it started.
+type CheckNodeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckNodeRequest) Reset() {
+	*x = CheckNodeRequest{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[72]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckNodeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckNodeRequest) ProtoMessage() {}
+
+func (x *CheckNodeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[72]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckNodeRequest.ProtoReflect.Descriptor instead.
+func (*CheckNodeRequest) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{72}
+}
+
+func (x *CheckNodeRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+type CheckNodeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Suspected     bool                   `protobuf:"varint,1,opt,name=suspected,proto3" json:"suspected,omitempty"`
+	LastLsn       uint64                 `protobuf:"varint,2,opt,name=last_lsn,json=lastLsn,proto3" json:"last_lsn,omitempty"`
+	Epoch         uint64                 `protobuf:"varint,3,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckNodeResponse) Reset() {
+	*x = CheckNodeResponse{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[73]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckNodeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckNodeResponse) ProtoMessage() {}
+
+func (x *CheckNodeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[73]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckNodeResponse.ProtoReflect.Descriptor instead.
+func (*CheckNodeResponse) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{73}
+}
+
+func (x *CheckNodeResponse) GetSuspected() bool {
+	if x != nil {
+		return x.Suspected
+	}
+	return false
+}
+
+func (x *CheckNodeResponse) GetLastLsn() uint64 {
+	if x != nil {
+		return x.LastLsn
+	}
+	return 0
+}
+
+func (x *CheckNodeResponse) GetEpoch() uint64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+type RaftMember struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Address       string                 `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RaftMember) Reset() {
+	*x = RaftMember{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[74]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RaftMember) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RaftMember) ProtoMessage() {}
+
+func (x *RaftMember) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[74]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RaftMember.ProtoReflect.Descriptor instead.
+func (*RaftMember) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{74}
+}
+
+func (x *RaftMember) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *RaftMember) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+// members are the voters in the group from this entry on.
+type RaftEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Term          uint64                 `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	Index         uint64                 `protobuf:"varint,2,opt,name=index,proto3" json:"index,omitempty"`
+	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	Members       []*RaftMember          `protobuf:"bytes,4,rep,name=members,proto3" json:"members,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RaftEntry) Reset() {
+	*x = RaftEntry{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[75]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RaftEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RaftEntry) ProtoMessage() {}
+
+func (x *RaftEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[75]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RaftEntry.ProtoReflect.Descriptor instead.
+func (*RaftEntry) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{75}
+}
+
+func (x *RaftEntry) GetTerm() uint64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *RaftEntry) GetIndex() uint64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *RaftEntry) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *RaftEntry) GetMembers() []*RaftMember {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+// group is the raft group the message is for. A pre_vote asks whether the
+// node would vote for the candidate at term, without changing anything.
+type RaftVoteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Group         string                 `protobuf:"bytes,1,opt,name=group,proto3" json:"group,omitempty"`
+	Term          uint64                 `protobuf:"varint,2,opt,name=term,proto3" json:"term,omitempty"`
+	CandidateId   string                 `protobuf:"bytes,3,opt,name=candidate_id,json=candidateId,proto3" json:"candidate_id,omitempty"`
+	LastLogIndex  uint64                 `protobuf:"varint,4,opt,name=last_log_index,json=lastLogIndex,proto3" json:"last_log_index,omitempty"`
+	LastLogTerm   uint64                 `protobuf:"varint,5,opt,name=last_log_term,json=lastLogTerm,proto3" json:"last_log_term,omitempty"`
+	PreVote       bool                   `protobuf:"varint,6,opt,name=pre_vote,json=preVote,proto3" json:"pre_vote,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RaftVoteRequest) Reset() {
+	*x = RaftVoteRequest{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[76]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RaftVoteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RaftVoteRequest) ProtoMessage() {}
+
+func (x *RaftVoteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[76]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RaftVoteRequest.ProtoReflect.Descriptor instead.
+func (*RaftVoteRequest) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{76}
+}
+
+func (x *RaftVoteRequest) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *RaftVoteRequest) GetTerm() uint64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *RaftVoteRequest) GetCandidateId() string {
+	if x != nil {
+		return x.CandidateId
+	}
+	return ""
+}
+
+func (x *RaftVoteRequest) GetLastLogIndex() uint64 {
+	if x != nil {
+		return x.LastLogIndex
+	}
+	return 0
+}
+
+func (x *RaftVoteRequest) GetLastLogTerm() uint64 {
+	if x != nil {
+		return x.LastLogTerm
+	}
+	return 0
+}
+
+func (x *RaftVoteRequest) GetPreVote() bool {
+	if x != nil {
+		return x.PreVote
+	}
+	return false
+}
+
+type RaftVoteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Term          uint64                 `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	Granted       bool                   `protobuf:"varint,2,opt,name=granted,proto3" json:"granted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RaftVoteResponse) Reset() {
+	*x = RaftVoteResponse{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[77]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RaftVoteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RaftVoteResponse) ProtoMessage() {}
+
+func (x *RaftVoteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[77]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RaftVoteResponse.ProtoReflect.Descriptor instead.
+func (*RaftVoteResponse) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{77}
+}
+
+func (x *RaftVoteResponse) GetTerm() uint64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *RaftVoteResponse) GetGranted() bool {
+	if x != nil {
+		return x.Granted
+	}
+	return false
+}
+
+// an append with no entries is a heartbeat. snapshot is set when the follower
+// is missing entries the leader has dropped, and a follower whose log doesn't
+// match prev_log_index loads the leader's state up to some index instead.
+type RaftAppendRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Group         string                 `protobuf:"bytes,1,opt,name=group,proto3" json:"group,omitempty"`
+	Term          uint64                 `protobuf:"varint,2,opt,name=term,proto3" json:"term,omitempty"`
+	LeaderId      string                 `protobuf:"bytes,3,opt,name=leader_id,json=leaderId,proto3" json:"leader_id,omitempty"`
+	LeaderAddress string                 `protobuf:"bytes,4,opt,name=leader_address,json=leaderAddress,proto3" json:"leader_address,omitempty"`
+	PrevLogIndex  uint64                 `protobuf:"varint,5,opt,name=prev_log_index,json=prevLogIndex,proto3" json:"prev_log_index,omitempty"`
+	PrevLogTerm   uint64                 `protobuf:"varint,6,opt,name=prev_log_term,json=prevLogTerm,proto3" json:"prev_log_term,omitempty"`
+	Entries       []*RaftEntry           `protobuf:"bytes,7,rep,name=entries,proto3" json:"entries,omitempty"`
+	LeaderCommit  uint64                 `protobuf:"varint,8,opt,name=leader_commit,json=leaderCommit,proto3" json:"leader_commit,omitempty"`
+	Snapshot      bool                   `protobuf:"varint,9,opt,name=snapshot,proto3" json:"snapshot,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetClusterConfigRequest) Reset() {
-	*x = GetClusterConfigRequest{}
-	mi := &file_internal_rpc_node_rpc_proto_msgTypes[14]
+func (x *RaftAppendRequest) Reset() {
+	*x = RaftAppendRequest{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[78]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetClusterConfigRequest) String() string {
+func (x *RaftAppendRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetClusterConfigRequest) ProtoMessage() {}
+func (*RaftAppendRequest) ProtoMessage() {}
 
-func (x *GetClusterConfigRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_rpc_node_rpc_proto_msgTypes[14]
+func (x *RaftAppendRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[78]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -756,35 +4559,104 @@ func (x *GetClusterConfigRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetClusterConfigRequest.ProtoReflect.Descriptor instead.
-func (*GetClusterConfigRequest) Descriptor() ([]byte, []int) {
-	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{14}
+// Deprecated: Use RaftAppendRequest.ProtoReflect.Descriptor instead.
+func (*RaftAppendRequest) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{78}
 }
 
-type GetClusterConfigResponse struct {
+func (x *RaftAppendRequest) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *RaftAppendRequest) GetTerm() uint64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *RaftAppendRequest) GetLeaderId() string {
+	if x != nil {
+		return x.LeaderId
+	}
+	return ""
+}
+
+func (x *RaftAppendRequest) GetLeaderAddress() string {
+	if x != nil {
+		return x.LeaderAddress
+	}
+	return ""
+}
+
+func (x *RaftAppendRequest) GetPrevLogIndex() uint64 {
+	if x != nil {
+		return x.PrevLogIndex
+	}
+	return 0
+}
+
+func (x *RaftAppendRequest) GetPrevLogTerm() uint64 {
+	if x != nil {
+		return x.PrevLogTerm
+	}
+	return 0
+}
+
+func (x *RaftAppendRequest) GetEntries() []*RaftEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *RaftAppendRequest) GetLeaderCommit() uint64 {
+	if x != nil {
+		return x.LeaderCommit
+	}
+	return 0
+}
+
+func (x *RaftAppendRequest) GetSnapshot() bool {
+	if x != nil {
+		return x.Snapshot
+	}
+	return false
+}
+
+// One node's copy of a key with quorum replication. found is false when the
+// node has never had the key, and deleted is set for the tombstone a delete
+// leaves. expires_at is in unix milliseconds, 0 for never.
+type VersionedValue struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
-	ThisNode      *NodeConfig            `protobuf:"bytes,2,opt,name=this_node,json=thisNode,proto3" json:"this_node,omitempty"`
-	OtherNodes    []*NodeConfig          `protobuf:"bytes,3,rep,name=other_nodes,json=otherNodes,proto3" json:"other_nodes,omitempty"`
+	Found         bool                   `protobuf:"varint,1,opt,name=found,proto3" json:"found,omitempty"`
+	Deleted       bool                   `protobuf:"varint,2,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	Val           []byte                 `protobuf:"bytes,3,opt,name=val,proto3" json:"val,omitempty"`
+	ContentType   string                 `protobuf:"bytes,4,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Version       uint64                 `protobuf:"varint,5,opt,name=version,proto3" json:"version,omitempty"`
+	ExpiresAt     int64                  `protobuf:"varint,6,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetClusterConfigResponse) Reset() {
-	*x = GetClusterConfigResponse{}
-	mi := &file_internal_rpc_node_rpc_proto_msgTypes[15]
+func (x *VersionedValue) Reset() {
+	*x = VersionedValue{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[79]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetClusterConfigResponse) String() string {
+func (x *VersionedValue) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetClusterConfigResponse) ProtoMessage() {}
+func (*VersionedValue) ProtoMessage() {}
 
-func (x *GetClusterConfigResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_rpc_node_rpc_proto_msgTypes[15]
+func (x *VersionedValue) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[79]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -795,32 +4667,256 @@ func (x *GetClusterConfigResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetClusterConfigResponse.ProtoReflect.Descriptor instead.
-func (*GetClusterConfigResponse) Descriptor() ([]byte, []int) {
-	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{15}
+// Deprecated: Use VersionedValue.ProtoReflect.Descriptor instead.
+func (*VersionedValue) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{79}
 }
 
-func (x *GetClusterConfigResponse) GetOk() bool {
+func (x *VersionedValue) GetFound() bool {
 	if x != nil {
-		return x.Ok
+		return x.Found
 	}
 	return false
 }
 
-func (x *GetClusterConfigResponse) GetThisNode() *NodeConfig {
+func (x *VersionedValue) GetDeleted() bool {
 	if x != nil {
-		return x.ThisNode
+		return x.Deleted
+	}
+	return false
+}
+
+func (x *VersionedValue) GetVal() []byte {
+	if x != nil {
+		return x.Val
 	}
 	return nil
 }
 
-func (x *GetClusterConfigResponse) GetOtherNodes() []*NodeConfig {
+func (x *VersionedValue) GetContentType() string {
 	if x != nil {
-		return x.OtherNodes
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *VersionedValue) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *VersionedValue) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+type QuorumReadRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QuorumReadRequest) Reset() {
+	*x = QuorumReadRequest{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[80]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QuorumReadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuorumReadRequest) ProtoMessage() {}
+
+func (x *QuorumReadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[80]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuorumReadRequest.ProtoReflect.Descriptor instead.
+func (*QuorumReadRequest) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{80}
+}
+
+func (x *QuorumReadRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type QuorumWriteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value         *VersionedValue        `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QuorumWriteRequest) Reset() {
+	*x = QuorumWriteRequest{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[81]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QuorumWriteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuorumWriteRequest) ProtoMessage() {}
+
+func (x *QuorumWriteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[81]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuorumWriteRequest.ProtoReflect.Descriptor instead.
+func (*QuorumWriteRequest) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{81}
+}
+
+func (x *QuorumWriteRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *QuorumWriteRequest) GetValue() *VersionedValue {
+	if x != nil {
+		return x.Value
 	}
 	return nil
 }
 
+// applied is false when the node already had a newer version.
+type QuorumWriteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Applied       bool                   `protobuf:"varint,1,opt,name=applied,proto3" json:"applied,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QuorumWriteResponse) Reset() {
+	*x = QuorumWriteResponse{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[82]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QuorumWriteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuorumWriteResponse) ProtoMessage() {}
+
+func (x *QuorumWriteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[82]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuorumWriteResponse.ProtoReflect.Descriptor instead.
+func (*QuorumWriteResponse) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{82}
+}
+
+func (x *QuorumWriteResponse) GetApplied() bool {
+	if x != nil {
+		return x.Applied
+	}
+	return false
+}
+
+// conflict_index is where the leader should go back to when the follower's log
+// doesn't match.
+type RaftAppendResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Term          uint64                 `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	Success       bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
+	ConflictIndex uint64                 `protobuf:"varint,3,opt,name=conflict_index,json=conflictIndex,proto3" json:"conflict_index,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RaftAppendResponse) Reset() {
+	*x = RaftAppendResponse{}
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[83]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RaftAppendResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RaftAppendResponse) ProtoMessage() {}
+
+func (x *RaftAppendResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpc_node_rpc_proto_msgTypes[83]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RaftAppendResponse.ProtoReflect.Descriptor instead.
+func (*RaftAppendResponse) Descriptor() ([]byte, []int) {
+	return file_internal_rpc_node_rpc_proto_rawDescGZIP(), []int{83}
+}
+
+func (x *RaftAppendResponse) GetTerm() uint64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *RaftAppendResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *RaftAppendResponse) GetConflictIndex() uint64 {
+	if x != nil {
+		return x.ConflictIndex
+	}
+	return 0
+}
+
 var File_internal_rpc_node_rpc_proto protoreflect.FileDescriptor
 
 const file_internal_rpc_node_rpc_proto_rawDesc = "" +
@@ -831,59 +4927,379 @@ const file_internal_rpc_node_rpc_proto_rawDesc = "" +
 	"\x02ok\x18\x01 \x01(\bR\x02ok\"\x1e\n" +
 	"\n" +
 	"GetRequest\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\"A\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\"~\n" +
 	"\vGetResponse\x12\x0e\n" +
 	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x10\n" +
 	"\x03key\x18\x02 \x01(\tR\x03key\x12\x10\n" +
-	"\x03val\x18\x03 \x01(\tR\x03val\"0\n" +
+	"\x03val\x18\x03 \x01(\fR\x03val\x12!\n" +
+	"\fcontent_type\x18\x04 \x01(\tR\vcontentType\x12\x18\n" +
+	"\aversion\x18\x05 \x01(\x04R\aversion\"f\n" +
+	"\tCondition\x12\x1d\n" +
+	"\n" +
+	"if_version\x18\x01 \x01(\x04R\tifVersion\x12\x1b\n" +
+	"\tif_absent\x18\x02 \x01(\bR\bifAbsent\x12\x1d\n" +
+	"\n" +
+	"if_present\x18\x03 \x01(\bR\tifPresent\"\x9d\x01\n" +
 	"\n" +
 	"PutRequest\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x10\n" +
-	"\x03val\x18\x02 \x01(\tR\x03val\"\x1d\n" +
+	"\x03val\x18\x02 \x01(\fR\x03val\x12!\n" +
+	"\fcontent_type\x18\x03 \x01(\tR\vcontentType\x12\x15\n" +
+	"\x06ttl_ms\x18\x04 \x01(\x03R\x05ttlMs\x121\n" +
+	"\tcondition\x18\x05 \x01(\v2\x13.node_rpc.ConditionR\tcondition\"7\n" +
 	"\vPutResponse\x12\x0e\n" +
-	"\x02ok\x18\x01 \x01(\bR\x02ok\"!\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x04R\aversion\"T\n" +
 	"\rDeleteRequest\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\" \n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x121\n" +
+	"\tcondition\x18\x02 \x01(\v2\x13.node_rpc.ConditionR\tcondition\" \n" +
 	"\x0eDeleteResponse\x12\x0e\n" +
-	"\x02ok\x18\x01 \x01(\bR\x02ok\"\x92\x01\n" +
-	"\rGossipRequest\x12\x17\n" +
-	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12\x18\n" +
-	"\aaddress\x18\x02 \x01(\tR\aaddress\x12(\n" +
-	"\x10hash_slots_start\x18\x03 \x01(\rR\x0ehashSlotsStart\x12$\n" +
-	"\x0ehash_slots_end\x18\x04 \x01(\rR\fhashSlotsEnd\"\x8f\x01\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\"\xb2\x01\n" +
+	"\aBatchOp\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x16\n" +
+	"\x06delete\x18\x02 \x01(\bR\x06delete\x12\x10\n" +
+	"\x03val\x18\x03 \x01(\fR\x03val\x12!\n" +
+	"\fcontent_type\x18\x04 \x01(\tR\vcontentType\x12\x15\n" +
+	"\x06ttl_ms\x18\x05 \x01(\x03R\x05ttlMs\x121\n" +
+	"\tcondition\x18\x06 \x01(\v2\x13.node_rpc.ConditionR\tcondition\"3\n" +
+	"\fBatchRequest\x12#\n" +
+	"\x03ops\x18\x01 \x03(\v2\x11.node_rpc.BatchOpR\x03ops\";\n" +
+	"\rBatchResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x1a\n" +
+	"\bversions\x18\x02 \x03(\x04R\bversions\"7\n" +
+	"\rHashSlotRange\x12\x14\n" +
+	"\x05start\x18\x01 \x01(\rR\x05start\x12\x10\n" +
+	"\x03end\x18\x02 \x01(\rR\x03end\"\xb1\x01\n" +
+	"\vScanRequest\x12\x16\n" +
+	"\x06prefix\x18\x01 \x01(\tR\x06prefix\x12\x14\n" +
+	"\x05start\x18\x02 \x01(\tR\x05start\x12\x10\n" +
+	"\x03end\x18\x03 \x01(\tR\x03end\x12\x14\n" +
+	"\x05after\x18\x04 \x01(\tR\x05after\x12\x14\n" +
+	"\x05limit\x18\x05 \x01(\rR\x05limit\x126\n" +
+	"\n" +
+	"hash_slots\x18\x06 \x01(\v2\x17.node_rpc.HashSlotRangeR\thashSlots\"\x7f\n" +
+	"\bScanItem\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x10\n" +
+	"\x03val\x18\x02 \x01(\fR\x03val\x12!\n" +
+	"\fcontent_type\x18\x03 \x01(\tR\vcontentType\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\x04R\aversion\x12\x12\n" +
+	"\x04type\x18\x05 \x01(\tR\x04type\"J\n" +
+	"\fScanResponse\x12&\n" +
+	"\x04item\x18\x01 \x01(\v2\x12.node_rpc.ScanItemR\x04item\x12\x12\n" +
+	"\x04more\x18\x02 \x01(\bR\x04more\"f\n" +
+	"\fWatchRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x16\n" +
+	"\x06prefix\x18\x02 \x01(\tR\x06prefix\x12\x14\n" +
+	"\x05after\x18\x03 \x01(\x04R\x05after\x12\x16\n" +
+	"\x06resume\x18\x04 \x01(\bR\x06resume\"k\n" +
+	"\rWatchResponse\x12\x1a\n" +
+	"\bsequence\x18\x01 \x01(\x04R\bsequence\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x10\n" +
+	"\x03key\x18\x03 \x01(\tR\x03key\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\x04R\aversion\"8\n" +
+	"\rExpireRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x15\n" +
+	"\x06ttl_ms\x18\x02 \x01(\x03R\x05ttlMs\" \n" +
+	"\x0eExpireResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\"\"\n" +
+	"\x0ePersistRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\"!\n" +
+	"\x0fPersistResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\"\x1e\n" +
+	"\n" +
+	"TtlRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\"N\n" +
+	"\vTtlResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x18\n" +
+	"\aexpires\x18\x02 \x01(\bR\aexpires\x12\x15\n" +
+	"\x06ttl_ms\x18\x03 \x01(\x03R\x05ttlMs\"5\n" +
+	"\vIncrRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05delta\x18\x02 \x01(\x03R\x05delta\"J\n" +
+	"\fIncrResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x10\n" +
+	"\x03val\x18\x02 \x01(\x03R\x03val\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\x04R\aversion\"K\n" +
+	"\x0fListPushRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x12\n" +
+	"\x04left\x18\x02 \x01(\bR\x04left\x12\x12\n" +
+	"\x04vals\x18\x03 \x03(\fR\x04vals\":\n" +
+	"\x10ListPushResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x16\n" +
+	"\x06length\x18\x02 \x01(\x03R\x06length\"L\n" +
+	"\x0eListPopRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x12\n" +
+	"\x04left\x18\x02 \x01(\bR\x04left\x12\x14\n" +
+	"\x05count\x18\x03 \x01(\rR\x05count\"5\n" +
+	"\x0fListPopResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x12\n" +
+	"\x04vals\x18\x02 \x03(\fR\x04vals\"N\n" +
+	"\x10ListRangeRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05start\x18\x02 \x01(\x03R\x05start\x12\x12\n" +
+	"\x04stop\x18\x03 \x01(\x03R\x04stop\"7\n" +
+	"\x11ListRangeResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x12\n" +
+	"\x04vals\x18\x02 \x03(\fR\x04vals\"\x9b\x01\n" +
+	"\x0eHashSetRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12<\n" +
+	"\x06fields\x18\x02 \x03(\v2$.node_rpc.HashSetRequest.FieldsEntryR\x06fields\x1a9\n" +
+	"\vFieldsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value:\x028\x01\"7\n" +
+	"\x0fHashSetResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x14\n" +
+	"\x05added\x18\x02 \x01(\x03R\x05added\"8\n" +
+	"\x0eHashGetRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05field\x18\x02 \x01(\tR\x05field\"I\n" +
+	"\x0fHashGetResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x14\n" +
+	"\x05found\x18\x02 \x01(\bR\x05found\x12\x10\n" +
+	"\x03val\x18\x03 \x01(\fR\x03val\"=\n" +
+	"\x11HashDeleteRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x16\n" +
+	"\x06fields\x18\x02 \x03(\tR\x06fields\">\n" +
+	"\x12HashDeleteResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x18\n" +
+	"\adeleted\x18\x02 \x01(\x03R\adeleted\"%\n" +
+	"\x11HashGetAllRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\"\xa1\x01\n" +
+	"\x12HashGetAllResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12@\n" +
+	"\x06fields\x18\x02 \x03(\v2(.node_rpc.HashGetAllResponse.FieldsEntryR\x06fields\x1a9\n" +
+	"\vFieldsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value:\x028\x01\";\n" +
+	"\rSetAddRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x18\n" +
+	"\amembers\x18\x02 \x03(\fR\amembers\"6\n" +
+	"\x0eSetAddResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x14\n" +
+	"\x05added\x18\x02 \x01(\x03R\x05added\">\n" +
+	"\x10SetRemoveRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x18\n" +
+	"\amembers\x18\x02 \x03(\fR\amembers\"=\n" +
+	"\x11SetRemoveResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x18\n" +
+	"\aremoved\x18\x02 \x01(\x03R\aremoved\"%\n" +
+	"\x11SetMembersRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\">\n" +
+	"\x12SetMembersResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x18\n" +
+	"\amembers\x18\x02 \x03(\fR\amembers\">\n" +
+	"\x12SetIsMemberRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x16\n" +
+	"\x06member\x18\x02 \x01(\fR\x06member\"B\n" +
+	"\x13SetIsMemberResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x1b\n" +
+	"\tis_member\x18\x02 \x01(\bR\bisMember\"<\n" +
+	"\fScoredMember\x12\x16\n" +
+	"\x06member\x18\x01 \x01(\tR\x06member\x12\x14\n" +
+	"\x05score\x18\x02 \x01(\x01R\x05score\"\xa9\x01\n" +
+	"\x13SortedSetAddRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12D\n" +
+	"\amembers\x18\x02 \x03(\v2*.node_rpc.SortedSetAddRequest.MembersEntryR\amembers\x1a:\n" +
+	"\fMembersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value:\x028\x01\"<\n" +
+	"\x14SortedSetAddResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x14\n" +
+	"\x05added\x18\x02 \x01(\x03R\x05added\"D\n" +
+	"\x16SortedSetRemoveRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x18\n" +
+	"\amembers\x18\x02 \x03(\tR\amembers\"C\n" +
+	"\x17SortedSetRemoveResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x18\n" +
+	"\aremoved\x18\x02 \x01(\x03R\aremoved\"V\n" +
+	"\x14SortedSetIncrRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x16\n" +
+	"\x06member\x18\x02 \x01(\tR\x06member\x12\x14\n" +
+	"\x05delta\x18\x03 \x01(\x01R\x05delta\"=\n" +
+	"\x15SortedSetIncrResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x14\n" +
+	"\x05score\x18\x02 \x01(\x01R\x05score\"Y\n" +
+	"\x1bSortedSetRangeByRankRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05start\x18\x02 \x01(\x03R\x05start\x12\x12\n" +
+	"\x04stop\x18\x03 \x01(\x03R\x04stop\"`\n" +
+	"\x1cSortedSetRangeByRankResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x120\n" +
+	"\amembers\x18\x02 \x03(\v2\x16.node_rpc.ScoredMemberR\amembers\"j\n" +
+	"\x1cSortedSetRangeByScoreRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x10\n" +
+	"\x03min\x18\x02 \x01(\x01R\x03min\x12\x10\n" +
+	"\x03max\x18\x03 \x01(\x01R\x03max\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\rR\x05limit\"a\n" +
+	"\x1dSortedSetRangeByScoreResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x120\n" +
+	"\amembers\x18\x02 \x03(\v2\x16.node_rpc.ScoredMemberR\amembers\"@\n" +
+	"\x14SortedSetRankRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x16\n" +
+	"\x06member\x18\x02 \x01(\tR\x06member\"g\n" +
+	"\x15SortedSetRankResponse\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x14\n" +
+	"\x05found\x18\x02 \x01(\bR\x05found\x12\x12\n" +
+	"\x04rank\x18\x03 \x01(\x03R\x04rank\x12\x14\n" +
+	"\x05score\x18\x04 \x01(\x01R\x05score\"\xae\x01\n" +
 	"\n" +
 	"NodeConfig\x12\x17\n" +
 	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12\x18\n" +
 	"\aaddress\x18\x02 \x01(\tR\aaddress\x12(\n" +
 	"\x10hash_slots_start\x18\x03 \x01(\rR\x0ehashSlotsStart\x12$\n" +
-	"\x0ehash_slots_end\x18\x04 \x01(\rR\fhashSlotsEnd\"W\n" +
-	"\x0eGossipResponse\x12\x0e\n" +
-	"\x02ok\x18\x01 \x01(\bR\x02ok\x125\n" +
-	"\vother_nodes\x18\x02 \x03(\v2\x14.node_rpc.NodeConfigR\n" +
-	"otherNodes\"f\n" +
+	"\x0ehash_slots_end\x18\x04 \x01(\rR\fhashSlotsEnd\x12\x1d\n" +
+	"\n" +
+	"replica_of\x18\x05 \x01(\tR\treplicaOf\"\x85\x01\n" +
 	"\x14SetNodeConfigOptions\x12(\n" +
 	"\x10hash_slots_start\x18\x01 \x01(\rR\x0ehashSlotsStart\x12$\n" +
-	"\x0ehash_slots_end\x18\x02 \x01(\rR\fhashSlotsEnd\"\x8d\x01\n" +
+	"\x0ehash_slots_end\x18\x02 \x01(\rR\fhashSlotsEnd\x12\x1d\n" +
+	"\n" +
+	"replica_of\x18\x03 \x01(\tR\treplicaOf\"\xe5\x02\n" +
 	"\x17SetClusterConfigRequest\x12;\n" +
 	"\tthis_node\x18\x01 \x01(\v2\x1e.node_rpc.SetNodeConfigOptionsR\bthisNode\x125\n" +
 	"\vother_nodes\x18\x02 \x03(\v2\x14.node_rpc.NodeConfigR\n" +
-	"otherNodes\"*\n" +
+	"otherNodes\x12#\n" +
+	"\rhash_function\x18\x03 \x01(\tR\fhashFunction\x12\x1c\n" +
+	"\tforwarded\x18\x05 \x01(\bR\tforwarded\x12)\n" +
+	"\x10replication_mode\x18\x06 \x01(\tR\x0freplicationMode\x12.\n" +
+	"\x06quorum\x18\a \x01(\v2\x16.node_rpc.QuorumConfigR\x06quorum\x12\x14\n" +
+	"\x05epoch\x18\b \x01(\x04R\x05epoch\x12\x1c\n" +
+	"\tbootstrap\x18\t \x01(\bR\tbootstrapJ\x04\b\x04\x10\x05\"*\n" +
 	"\x18SetClusterConfigResponse\x12\x0e\n" +
 	"\x02ok\x18\x01 \x01(\bR\x02ok\"\x19\n" +
-	"\x17GetClusterConfigRequest\"\x94\x01\n" +
+	"\x17GetClusterConfigRequest\"\xaa\x02\n" +
 	"\x18GetClusterConfigResponse\x12\x0e\n" +
 	"\x02ok\x18\x01 \x01(\bR\x02ok\x121\n" +
 	"\tthis_node\x18\x02 \x01(\v2\x14.node_rpc.NodeConfigR\bthisNode\x125\n" +
 	"\vother_nodes\x18\x03 \x03(\v2\x14.node_rpc.NodeConfigR\n" +
-	"otherNodes2\xeb\x03\n" +
+	"otherNodes\x12#\n" +
+	"\rhash_function\x18\x04 \x01(\tR\fhashFunction\x12\x14\n" +
+	"\x05epoch\x18\x05 \x01(\x04R\x05epoch\x12)\n" +
+	"\x10replication_mode\x18\x06 \x01(\tR\x0freplicationMode\x12.\n" +
+	"\x06quorum\x18\a \x01(\v2\x16.node_rpc.QuorumConfigR\x06quorum\"8\n" +
+	"\fQuorumConfig\x12\f\n" +
+	"\x01n\x18\x01 \x01(\rR\x01n\x12\f\n" +
+	"\x01r\x18\x02 \x01(\rR\x01r\x12\f\n" +
+	"\x01w\x18\x03 \x01(\rR\x01w\"m\n" +
+	"\x10ReplicateRequest\x12\x1d\n" +
+	"\n" +
+	"replica_id\x18\x01 \x01(\tR\treplicaId\x12\x1d\n" +
+	"\n" +
+	"primary_id\x18\x02 \x01(\tR\tprimaryId\x12\x1b\n" +
+	"\tafter_lsn\x18\x03 \x01(\x04R\bafterLsn\"6\n" +
+	"\fSnapshotItem\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value\"e\n" +
+	"\x10ReplicationEntry\x12\x10\n" +
+	"\x03lsn\x18\x01 \x01(\x04R\x03lsn\x12\x17\n" +
+	"\aop_type\x18\x02 \x01(\rR\x06opType\x12\x10\n" +
+	"\x03key\x18\x03 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x04 \x01(\fR\x05value\"\x9c\x02\n" +
+	"\x11ReplicateResponse\x12\x1d\n" +
+	"\n" +
+	"primary_id\x18\x01 \x01(\tR\tprimaryId\x12%\n" +
+	"\x0esnapshot_start\x18\x02 \x01(\bR\rsnapshotStart\x12!\n" +
+	"\fsnapshot_lsn\x18\x03 \x01(\x04R\vsnapshotLsn\x12,\n" +
+	"\x05items\x18\x04 \x03(\v2\x16.node_rpc.SnapshotItemR\x05items\x12!\n" +
+	"\fsnapshot_end\x18\x05 \x01(\bR\vsnapshotEnd\x120\n" +
+	"\x05entry\x18\x06 \x01(\v2\x1a.node_rpc.ReplicationEntryR\x05entry\x12\x1b\n" +
+	"\traft_term\x18\a \x01(\x04R\braftTerm\"+\n" +
+	"\x10CheckNodeRequest\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\"b\n" +
+	"\x11CheckNodeResponse\x12\x1c\n" +
+	"\tsuspected\x18\x01 \x01(\bR\tsuspected\x12\x19\n" +
+	"\blast_lsn\x18\x02 \x01(\x04R\alastLsn\x12\x14\n" +
+	"\x05epoch\x18\x03 \x01(\x04R\x05epoch\"?\n" +
+	"\n" +
+	"RaftMember\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12\x18\n" +
+	"\aaddress\x18\x02 \x01(\tR\aaddress\"y\n" +
+	"\tRaftEntry\x12\x12\n" +
+	"\x04term\x18\x01 \x01(\x04R\x04term\x12\x14\n" +
+	"\x05index\x18\x02 \x01(\x04R\x05index\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\x12.\n" +
+	"\amembers\x18\x04 \x03(\v2\x14.node_rpc.RaftMemberR\amembers\"\xc3\x01\n" +
+	"\x0fRaftVoteRequest\x12\x14\n" +
+	"\x05group\x18\x01 \x01(\tR\x05group\x12\x12\n" +
+	"\x04term\x18\x02 \x01(\x04R\x04term\x12!\n" +
+	"\fcandidate_id\x18\x03 \x01(\tR\vcandidateId\x12$\n" +
+	"\x0elast_log_index\x18\x04 \x01(\x04R\flastLogIndex\x12\"\n" +
+	"\rlast_log_term\x18\x05 \x01(\x04R\vlastLogTerm\x12\x19\n" +
+	"\bpre_vote\x18\x06 \x01(\bR\apreVote\"@\n" +
+	"\x10RaftVoteResponse\x12\x12\n" +
+	"\x04term\x18\x01 \x01(\x04R\x04term\x12\x18\n" +
+	"\agranted\x18\x02 \x01(\bR\agranted\"\xbb\x02\n" +
+	"\x11RaftAppendRequest\x12\x14\n" +
+	"\x05group\x18\x01 \x01(\tR\x05group\x12\x12\n" +
+	"\x04term\x18\x02 \x01(\x04R\x04term\x12\x1b\n" +
+	"\tleader_id\x18\x03 \x01(\tR\bleaderId\x12%\n" +
+	"\x0eleader_address\x18\x04 \x01(\tR\rleaderAddress\x12$\n" +
+	"\x0eprev_log_index\x18\x05 \x01(\x04R\fprevLogIndex\x12\"\n" +
+	"\rprev_log_term\x18\x06 \x01(\x04R\vprevLogTerm\x12-\n" +
+	"\aentries\x18\a \x03(\v2\x13.node_rpc.RaftEntryR\aentries\x12#\n" +
+	"\rleader_commit\x18\b \x01(\x04R\fleaderCommit\x12\x1a\n" +
+	"\bsnapshot\x18\t \x01(\bR\bsnapshot\"\xae\x01\n" +
+	"\x0eVersionedValue\x12\x14\n" +
+	"\x05found\x18\x01 \x01(\bR\x05found\x12\x18\n" +
+	"\adeleted\x18\x02 \x01(\bR\adeleted\x12\x10\n" +
+	"\x03val\x18\x03 \x01(\fR\x03val\x12!\n" +
+	"\fcontent_type\x18\x04 \x01(\tR\vcontentType\x12\x18\n" +
+	"\aversion\x18\x05 \x01(\x04R\aversion\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x06 \x01(\x03R\texpiresAt\"%\n" +
+	"\x11QuorumReadRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\"V\n" +
+	"\x12QuorumWriteRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12.\n" +
+	"\x05value\x18\x02 \x01(\v2\x18.node_rpc.VersionedValueR\x05value\"/\n" +
+	"\x13QuorumWriteResponse\x12\x18\n" +
+	"\aapplied\x18\x01 \x01(\bR\aapplied\"i\n" +
+	"\x12RaftAppendResponse\x12\x12\n" +
+	"\x04term\x18\x01 \x01(\x04R\x04term\x12\x18\n" +
+	"\asuccess\x18\x02 \x01(\bR\asuccess\x12%\n" +
+	"\x0econflict_index\x18\x03 \x01(\x04R\rconflictIndex2\xb9\x14\n" +
 	"\fStoreService\x127\n" +
 	"\x04Ping\x12\x15.node_rpc.PingRequest\x1a\x16.node_rpc.PingResponse\"\x00\x124\n" +
 	"\x03Get\x12\x14.node_rpc.GetRequest\x1a\x15.node_rpc.GetResponse\"\x00\x124\n" +
 	"\x03Put\x12\x14.node_rpc.PutRequest\x1a\x15.node_rpc.PutResponse\"\x00\x12=\n" +
 	"\x06Delete\x12\x17.node_rpc.DeleteRequest\x1a\x18.node_rpc.DeleteResponse\"\x00\x12=\n" +
-	"\x06Gossip\x12\x17.node_rpc.GossipRequest\x1a\x18.node_rpc.GossipResponse\"\x00\x12[\n" +
+	"\x06Expire\x12\x17.node_rpc.ExpireRequest\x1a\x18.node_rpc.ExpireResponse\"\x00\x12@\n" +
+	"\aPersist\x12\x18.node_rpc.PersistRequest\x1a\x19.node_rpc.PersistResponse\"\x00\x124\n" +
+	"\x03Ttl\x12\x14.node_rpc.TtlRequest\x1a\x15.node_rpc.TtlResponse\"\x00\x127\n" +
+	"\x04Incr\x12\x15.node_rpc.IncrRequest\x1a\x16.node_rpc.IncrResponse\"\x00\x12C\n" +
+	"\bListPush\x12\x19.node_rpc.ListPushRequest\x1a\x1a.node_rpc.ListPushResponse\"\x00\x12@\n" +
+	"\aListPop\x12\x18.node_rpc.ListPopRequest\x1a\x19.node_rpc.ListPopResponse\"\x00\x12F\n" +
+	"\tListRange\x12\x1a.node_rpc.ListRangeRequest\x1a\x1b.node_rpc.ListRangeResponse\"\x00\x12@\n" +
+	"\aHashSet\x12\x18.node_rpc.HashSetRequest\x1a\x19.node_rpc.HashSetResponse\"\x00\x12@\n" +
+	"\aHashGet\x12\x18.node_rpc.HashGetRequest\x1a\x19.node_rpc.HashGetResponse\"\x00\x12I\n" +
+	"\n" +
+	"HashDelete\x12\x1b.node_rpc.HashDeleteRequest\x1a\x1c.node_rpc.HashDeleteResponse\"\x00\x12I\n" +
+	"\n" +
+	"HashGetAll\x12\x1b.node_rpc.HashGetAllRequest\x1a\x1c.node_rpc.HashGetAllResponse\"\x00\x12=\n" +
+	"\x06SetAdd\x12\x17.node_rpc.SetAddRequest\x1a\x18.node_rpc.SetAddResponse\"\x00\x12F\n" +
+	"\tSetRemove\x12\x1a.node_rpc.SetRemoveRequest\x1a\x1b.node_rpc.SetRemoveResponse\"\x00\x12I\n" +
+	"\n" +
+	"SetMembers\x12\x1b.node_rpc.SetMembersRequest\x1a\x1c.node_rpc.SetMembersResponse\"\x00\x12L\n" +
+	"\vSetIsMember\x12\x1c.node_rpc.SetIsMemberRequest\x1a\x1d.node_rpc.SetIsMemberResponse\"\x00\x12O\n" +
+	"\fSortedSetAdd\x12\x1d.node_rpc.SortedSetAddRequest\x1a\x1e.node_rpc.SortedSetAddResponse\"\x00\x12X\n" +
+	"\x0fSortedSetRemove\x12 .node_rpc.SortedSetRemoveRequest\x1a!.node_rpc.SortedSetRemoveResponse\"\x00\x12R\n" +
+	"\rSortedSetIncr\x12\x1e.node_rpc.SortedSetIncrRequest\x1a\x1f.node_rpc.SortedSetIncrResponse\"\x00\x12g\n" +
+	"\x14SortedSetRangeByRank\x12%.node_rpc.SortedSetRangeByRankRequest\x1a&.node_rpc.SortedSetRangeByRankResponse\"\x00\x12j\n" +
+	"\x15SortedSetRangeByScore\x12&.node_rpc.SortedSetRangeByScoreRequest\x1a'.node_rpc.SortedSetRangeByScoreResponse\"\x00\x12R\n" +
+	"\rSortedSetRank\x12\x1e.node_rpc.SortedSetRankRequest\x1a\x1f.node_rpc.SortedSetRankResponse\"\x00\x12:\n" +
+	"\x05Batch\x12\x16.node_rpc.BatchRequest\x1a\x17.node_rpc.BatchResponse\"\x00\x129\n" +
+	"\x04Scan\x12\x15.node_rpc.ScanRequest\x1a\x16.node_rpc.ScanResponse\"\x000\x01\x12<\n" +
+	"\x05Watch\x12\x16.node_rpc.WatchRequest\x1a\x17.node_rpc.WatchResponse\"\x000\x01\x12H\n" +
+	"\tReplicate\x12\x1a.node_rpc.ReplicateRequest\x1a\x1b.node_rpc.ReplicateResponse\"\x000\x01\x12F\n" +
+	"\tCheckNode\x12\x1a.node_rpc.CheckNodeRequest\x1a\x1b.node_rpc.CheckNodeResponse\"\x00\x12C\n" +
+	"\bRaftVote\x12\x19.node_rpc.RaftVoteRequest\x1a\x1a.node_rpc.RaftVoteResponse\"\x00\x12I\n" +
+	"\n" +
+	"RaftAppend\x12\x1b.node_rpc.RaftAppendRequest\x1a\x1c.node_rpc.RaftAppendResponse\"\x00\x12[\n" +
 	"\x10SetClusterConfig\x12!.node_rpc.SetClusterConfigRequest\x1a\".node_rpc.SetClusterConfigResponse\"\x00\x12[\n" +
-	"\x10GetClusterConfig\x12!.node_rpc.GetClusterConfigRequest\x1a\".node_rpc.GetClusterConfigResponse\"\x00B)Z'github.com/ethan-stone/go-key-store/rpcb\x06proto3"
+	"\x10GetClusterConfig\x12!.node_rpc.GetClusterConfigRequest\x1a\".node_rpc.GetClusterConfigResponse\"\x00\x12E\n" +
+	"\n" +
+	"QuorumRead\x12\x1b.node_rpc.QuorumReadRequest\x1a\x18.node_rpc.VersionedValue\"\x00\x12L\n" +
+	"\vQuorumWrite\x12\x1c.node_rpc.QuorumWriteRequest\x1a\x1d.node_rpc.QuorumWriteResponse\"\x00B)Z'github.com/ethan-stone/go-key-store/rpcb\x06proto3"
 
 var (
 	file_internal_rpc_node_rpc_proto_rawDescOnce sync.Once
@@ -897,50 +5313,196 @@ func file_internal_rpc_node_rpc_proto_rawDescGZIP() []byte {
 	return file_internal_rpc_node_rpc_proto_rawDescData
 }
 
-var file_internal_rpc_node_rpc_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
+var file_internal_rpc_node_rpc_proto_msgTypes = make([]protoimpl.MessageInfo, 87)
 var file_internal_rpc_node_rpc_proto_goTypes = []any{
-	(*PingRequest)(nil),              // 0: node_rpc.PingRequest
-	(*PingResponse)(nil),             // 1: node_rpc.PingResponse
-	(*GetRequest)(nil),               // 2: node_rpc.GetRequest
-	(*GetResponse)(nil),              // 3: node_rpc.GetResponse
-	(*PutRequest)(nil),               // 4: node_rpc.PutRequest
-	(*PutResponse)(nil),              // 5: node_rpc.PutResponse
-	(*DeleteRequest)(nil),            // 6: node_rpc.DeleteRequest
-	(*DeleteResponse)(nil),           // 7: node_rpc.DeleteResponse
-	(*GossipRequest)(nil),            // 8: node_rpc.GossipRequest
-	(*NodeConfig)(nil),               // 9: node_rpc.NodeConfig
-	(*GossipResponse)(nil),           // 10: node_rpc.GossipResponse
-	(*SetNodeConfigOptions)(nil),     // 11: node_rpc.SetNodeConfigOptions
-	(*SetClusterConfigRequest)(nil),  // 12: node_rpc.SetClusterConfigRequest
-	(*SetClusterConfigResponse)(nil), // 13: node_rpc.SetClusterConfigResponse
-	(*GetClusterConfigRequest)(nil),  // 14: node_rpc.GetClusterConfigRequest
-	(*GetClusterConfigResponse)(nil), // 15: node_rpc.GetClusterConfigResponse
+	(*PingRequest)(nil),                   // 0: node_rpc.PingRequest
+	(*PingResponse)(nil),                  // 1: node_rpc.PingResponse
+	(*GetRequest)(nil),                    // 2: node_rpc.GetRequest
+	(*GetResponse)(nil),                   // 3: node_rpc.GetResponse
+	(*Condition)(nil),                     // 4: node_rpc.Condition
+	(*PutRequest)(nil),                    // 5: node_rpc.PutRequest
+	(*PutResponse)(nil),                   // 6: node_rpc.PutResponse
+	(*DeleteRequest)(nil),                 // 7: node_rpc.DeleteRequest
+	(*DeleteResponse)(nil),                // 8: node_rpc.DeleteResponse
+	(*BatchOp)(nil),                       // 9: node_rpc.BatchOp
+	(*BatchRequest)(nil),                  // 10: node_rpc.BatchRequest
+	(*BatchResponse)(nil),                 // 11: node_rpc.BatchResponse
+	(*HashSlotRange)(nil),                 // 12: node_rpc.HashSlotRange
+	(*ScanRequest)(nil),                   // 13: node_rpc.ScanRequest
+	(*ScanItem)(nil),                      // 14: node_rpc.ScanItem
+	(*ScanResponse)(nil),                  // 15: node_rpc.ScanResponse
+	(*WatchRequest)(nil),                  // 16: node_rpc.WatchRequest
+	(*WatchResponse)(nil),                 // 17: node_rpc.WatchResponse
+	(*ExpireRequest)(nil),                 // 18: node_rpc.ExpireRequest
+	(*ExpireResponse)(nil),                // 19: node_rpc.ExpireResponse
+	(*PersistRequest)(nil),                // 20: node_rpc.PersistRequest
+	(*PersistResponse)(nil),               // 21: node_rpc.PersistResponse
+	(*TtlRequest)(nil),                    // 22: node_rpc.TtlRequest
+	(*TtlResponse)(nil),                   // 23: node_rpc.TtlResponse
+	(*IncrRequest)(nil),                   // 24: node_rpc.IncrRequest
+	(*IncrResponse)(nil),                  // 25: node_rpc.IncrResponse
+	(*ListPushRequest)(nil),               // 26: node_rpc.ListPushRequest
+	(*ListPushResponse)(nil),              // 27: node_rpc.ListPushResponse
+	(*ListPopRequest)(nil),                // 28: node_rpc.ListPopRequest
+	(*ListPopResponse)(nil),               // 29: node_rpc.ListPopResponse
+	(*ListRangeRequest)(nil),              // 30: node_rpc.ListRangeRequest
+	(*ListRangeResponse)(nil),             // 31: node_rpc.ListRangeResponse
+	(*HashSetRequest)(nil),                // 32: node_rpc.HashSetRequest
+	(*HashSetResponse)(nil),               // 33: node_rpc.HashSetResponse
+	(*HashGetRequest)(nil),                // 34: node_rpc.HashGetRequest
+	(*HashGetResponse)(nil),               // 35: node_rpc.HashGetResponse
+	(*HashDeleteRequest)(nil),             // 36: node_rpc.HashDeleteRequest
+	(*HashDeleteResponse)(nil),            // 37: node_rpc.HashDeleteResponse
+	(*HashGetAllRequest)(nil),             // 38: node_rpc.HashGetAllRequest
+	(*HashGetAllResponse)(nil),            // 39: node_rpc.HashGetAllResponse
+	(*SetAddRequest)(nil),                 // 40: node_rpc.SetAddRequest
+	(*SetAddResponse)(nil),                // 41: node_rpc.SetAddResponse
+	(*SetRemoveRequest)(nil),              // 42: node_rpc.SetRemoveRequest
+	(*SetRemoveResponse)(nil),             // 43: node_rpc.SetRemoveResponse
+	(*SetMembersRequest)(nil),             // 44: node_rpc.SetMembersRequest
+	(*SetMembersResponse)(nil),            // 45: node_rpc.SetMembersResponse
+	(*SetIsMemberRequest)(nil),            // 46: node_rpc.SetIsMemberRequest
+	(*SetIsMemberResponse)(nil),           // 47: node_rpc.SetIsMemberResponse
+	(*ScoredMember)(nil),                  // 48: node_rpc.ScoredMember
+	(*SortedSetAddRequest)(nil),           // 49: node_rpc.SortedSetAddRequest
+	(*SortedSetAddResponse)(nil),          // 50: node_rpc.SortedSetAddResponse
+	(*SortedSetRemoveRequest)(nil),        // 51: node_rpc.SortedSetRemoveRequest
+	(*SortedSetRemoveResponse)(nil),       // 52: node_rpc.SortedSetRemoveResponse
+	(*SortedSetIncrRequest)(nil),          // 53: node_rpc.SortedSetIncrRequest
+	(*SortedSetIncrResponse)(nil),         // 54: node_rpc.SortedSetIncrResponse
+	(*SortedSetRangeByRankRequest)(nil),   // 55: node_rpc.SortedSetRangeByRankRequest
+	(*SortedSetRangeByRankResponse)(nil),  // 56: node_rpc.SortedSetRangeByRankResponse
+	(*SortedSetRangeByScoreRequest)(nil),  // 57: node_rpc.SortedSetRangeByScoreRequest
+	(*SortedSetRangeByScoreResponse)(nil), // 58: node_rpc.SortedSetRangeByScoreResponse
+	(*SortedSetRankRequest)(nil),          // 59: node_rpc.SortedSetRankRequest
+	(*SortedSetRankResponse)(nil),         // 60: node_rpc.SortedSetRankResponse
+	(*NodeConfig)(nil),                    // 61: node_rpc.NodeConfig
+	(*SetNodeConfigOptions)(nil),          // 62: node_rpc.SetNodeConfigOptions
+	(*SetClusterConfigRequest)(nil),       // 63: node_rpc.SetClusterConfigRequest
+	(*SetClusterConfigResponse)(nil),      // 64: node_rpc.SetClusterConfigResponse
+	(*GetClusterConfigRequest)(nil),       // 65: node_rpc.GetClusterConfigRequest
+	(*GetClusterConfigResponse)(nil),      // 66: node_rpc.GetClusterConfigResponse
+	(*QuorumConfig)(nil),                  // 67: node_rpc.QuorumConfig
+	(*ReplicateRequest)(nil),              // 68: node_rpc.ReplicateRequest
+	(*SnapshotItem)(nil),                  // 69: node_rpc.SnapshotItem
+	(*ReplicationEntry)(nil),              // 70: node_rpc.ReplicationEntry
+	(*ReplicateResponse)(nil),             // 71: node_rpc.ReplicateResponse
+	(*CheckNodeRequest)(nil),              // 72: node_rpc.CheckNodeRequest
+	(*CheckNodeResponse)(nil),             // 73: node_rpc.CheckNodeResponse
+	(*RaftMember)(nil),                    // 74: node_rpc.RaftMember
+	(*RaftEntry)(nil),                     // 75: node_rpc.RaftEntry
+	(*RaftVoteRequest)(nil),               // 76: node_rpc.RaftVoteRequest
+	(*RaftVoteResponse)(nil),              // 77: node_rpc.RaftVoteResponse
+	(*RaftAppendRequest)(nil),             // 78: node_rpc.RaftAppendRequest
+	(*VersionedValue)(nil),                // 79: node_rpc.VersionedValue
+	(*QuorumReadRequest)(nil),             // 80: node_rpc.QuorumReadRequest
+	(*QuorumWriteRequest)(nil),            // 81: node_rpc.QuorumWriteRequest
+	(*QuorumWriteResponse)(nil),           // 82: node_rpc.QuorumWriteResponse
+	(*RaftAppendResponse)(nil),            // 83: node_rpc.RaftAppendResponse
+	nil,                                   // 84: node_rpc.HashSetRequest.FieldsEntry
+	nil,                                   // 85: node_rpc.HashGetAllResponse.FieldsEntry
+	nil,                                   // 86: node_rpc.SortedSetAddRequest.MembersEntry
 }
 var file_internal_rpc_node_rpc_proto_depIdxs = []int32{
-	9,  // 0: node_rpc.GossipResponse.other_nodes:type_name -> node_rpc.NodeConfig
-	11, // 1: node_rpc.SetClusterConfigRequest.this_node:type_name -> node_rpc.SetNodeConfigOptions
-	9,  // 2: node_rpc.SetClusterConfigRequest.other_nodes:type_name -> node_rpc.NodeConfig
-	9,  // 3: node_rpc.GetClusterConfigResponse.this_node:type_name -> node_rpc.NodeConfig
-	9,  // 4: node_rpc.GetClusterConfigResponse.other_nodes:type_name -> node_rpc.NodeConfig
-	0,  // 5: node_rpc.StoreService.Ping:input_type -> node_rpc.PingRequest
-	2,  // 6: node_rpc.StoreService.Get:input_type -> node_rpc.GetRequest
-	4,  // 7: node_rpc.StoreService.Put:input_type -> node_rpc.PutRequest
-	6,  // 8: node_rpc.StoreService.Delete:input_type -> node_rpc.DeleteRequest
-	8,  // 9: node_rpc.StoreService.Gossip:input_type -> node_rpc.GossipRequest
-	12, // 10: node_rpc.StoreService.SetClusterConfig:input_type -> node_rpc.SetClusterConfigRequest
-	14, // 11: node_rpc.StoreService.GetClusterConfig:input_type -> node_rpc.GetClusterConfigRequest
-	1,  // 12: node_rpc.StoreService.Ping:output_type -> node_rpc.PingResponse
-	3,  // 13: node_rpc.StoreService.Get:output_type -> node_rpc.GetResponse
-	5,  // 14: node_rpc.StoreService.Put:output_type -> node_rpc.PutResponse
-	7,  // 15: node_rpc.StoreService.Delete:output_type -> node_rpc.DeleteResponse
-	10, // 16: node_rpc.StoreService.Gossip:output_type -> node_rpc.GossipResponse
-	13, // 17: node_rpc.StoreService.SetClusterConfig:output_type -> node_rpc.SetClusterConfigResponse
-	15, // 18: node_rpc.StoreService.GetClusterConfig:output_type -> node_rpc.GetClusterConfigResponse
-	12, // [12:19] is the sub-list for method output_type
-	5,  // [5:12] is the sub-list for method input_type
-	5,  // [5:5] is the sub-list for extension type_name
-	5,  // [5:5] is the sub-list for extension extendee
-	0,  // [0:5] is the sub-list for field type_name
+	4,  // 0: node_rpc.PutRequest.condition:type_name -> node_rpc.Condition
+	4,  // 1: node_rpc.DeleteRequest.condition:type_name -> node_rpc.Condition
+	4,  // 2: node_rpc.BatchOp.condition:type_name -> node_rpc.Condition
+	9,  // 3: node_rpc.BatchRequest.ops:type_name -> node_rpc.BatchOp
+	12, // 4: node_rpc.ScanRequest.hash_slots:type_name -> node_rpc.HashSlotRange
+	14, // 5: node_rpc.ScanResponse.item:type_name -> node_rpc.ScanItem
+	84, // 6: node_rpc.HashSetRequest.fields:type_name -> node_rpc.HashSetRequest.FieldsEntry
+	85, // 7: node_rpc.HashGetAllResponse.fields:type_name -> node_rpc.HashGetAllResponse.FieldsEntry
+	86, // 8: node_rpc.SortedSetAddRequest.members:type_name -> node_rpc.SortedSetAddRequest.MembersEntry
+	48, // 9: node_rpc.SortedSetRangeByRankResponse.members:type_name -> node_rpc.ScoredMember
+	48, // 10: node_rpc.SortedSetRangeByScoreResponse.members:type_name -> node_rpc.ScoredMember
+	62, // 11: node_rpc.SetClusterConfigRequest.this_node:type_name -> node_rpc.SetNodeConfigOptions
+	61, // 12: node_rpc.SetClusterConfigRequest.other_nodes:type_name -> node_rpc.NodeConfig
+	67, // 13: node_rpc.SetClusterConfigRequest.quorum:type_name -> node_rpc.QuorumConfig
+	61, // 14: node_rpc.GetClusterConfigResponse.this_node:type_name -> node_rpc.NodeConfig
+	61, // 15: node_rpc.GetClusterConfigResponse.other_nodes:type_name -> node_rpc.NodeConfig
+	67, // 16: node_rpc.GetClusterConfigResponse.quorum:type_name -> node_rpc.QuorumConfig
+	69, // 17: node_rpc.ReplicateResponse.items:type_name -> node_rpc.SnapshotItem
+	70, // 18: node_rpc.ReplicateResponse.entry:type_name -> node_rpc.ReplicationEntry
+	74, // 19: node_rpc.RaftEntry.members:type_name -> node_rpc.RaftMember
+	75, // 20: node_rpc.RaftAppendRequest.entries:type_name -> node_rpc.RaftEntry
+	79, // 21: node_rpc.QuorumWriteRequest.value:type_name -> node_rpc.VersionedValue
+	0,  // 22: node_rpc.StoreService.Ping:input_type -> node_rpc.PingRequest
+	2,  // 23: node_rpc.StoreService.Get:input_type -> node_rpc.GetRequest
+	5,  // 24: node_rpc.StoreService.Put:input_type -> node_rpc.PutRequest
+	7,  // 25: node_rpc.StoreService.Delete:input_type -> node_rpc.DeleteRequest
+	18, // 26: node_rpc.StoreService.Expire:input_type -> node_rpc.ExpireRequest
+	20, // 27: node_rpc.StoreService.Persist:input_type -> node_rpc.PersistRequest
+	22, // 28: node_rpc.StoreService.Ttl:input_type -> node_rpc.TtlRequest
+	24, // 29: node_rpc.StoreService.Incr:input_type -> node_rpc.IncrRequest
+	26, // 30: node_rpc.StoreService.ListPush:input_type -> node_rpc.ListPushRequest
+	28, // 31: node_rpc.StoreService.ListPop:input_type -> node_rpc.ListPopRequest
+	30, // 32: node_rpc.StoreService.ListRange:input_type -> node_rpc.ListRangeRequest
+	32, // 33: node_rpc.StoreService.HashSet:input_type -> node_rpc.HashSetRequest
+	34, // 34: node_rpc.StoreService.HashGet:input_type -> node_rpc.HashGetRequest
+	36, // 35: node_rpc.StoreService.HashDelete:input_type -> node_rpc.HashDeleteRequest
+	38, // 36: node_rpc.StoreService.HashGetAll:input_type -> node_rpc.HashGetAllRequest
+	40, // 37: node_rpc.StoreService.SetAdd:input_type -> node_rpc.SetAddRequest
+	42, // 38: node_rpc.StoreService.SetRemove:input_type -> node_rpc.SetRemoveRequest
+	44, // 39: node_rpc.StoreService.SetMembers:input_type -> node_rpc.SetMembersRequest
+	46, // 40: node_rpc.StoreService.SetIsMember:input_type -> node_rpc.SetIsMemberRequest
+	49, // 41: node_rpc.StoreService.SortedSetAdd:input_type -> node_rpc.SortedSetAddRequest
+	51, // 42: node_rpc.StoreService.SortedSetRemove:input_type -> node_rpc.SortedSetRemoveRequest
+	53, // 43: node_rpc.StoreService.SortedSetIncr:input_type -> node_rpc.SortedSetIncrRequest
+	55, // 44: node_rpc.StoreService.SortedSetRangeByRank:input_type -> node_rpc.SortedSetRangeByRankRequest
+	57, // 45: node_rpc.StoreService.SortedSetRangeByScore:input_type -> node_rpc.SortedSetRangeByScoreRequest
+	59, // 46: node_rpc.StoreService.SortedSetRank:input_type -> node_rpc.SortedSetRankRequest
+	10, // 47: node_rpc.StoreService.Batch:input_type -> node_rpc.BatchRequest
+	13, // 48: node_rpc.StoreService.Scan:input_type -> node_rpc.ScanRequest
+	16, // 49: node_rpc.StoreService.Watch:input_type -> node_rpc.WatchRequest
+	68, // 50: node_rpc.StoreService.Replicate:input_type -> node_rpc.ReplicateRequest
+	72, // 51: node_rpc.StoreService.CheckNode:input_type -> node_rpc.CheckNodeRequest
+	76, // 52: node_rpc.StoreService.RaftVote:input_type -> node_rpc.RaftVoteRequest
+	78, // 53: node_rpc.StoreService.RaftAppend:input_type -> node_rpc.RaftAppendRequest
+	63, // 54: node_rpc.StoreService.SetClusterConfig:input_type -> node_rpc.SetClusterConfigRequest
+	65, // 55: node_rpc.StoreService.GetClusterConfig:input_type -> node_rpc.GetClusterConfigRequest
+	80, // 56: node_rpc.StoreService.QuorumRead:input_type -> node_rpc.QuorumReadRequest
+	81, // 57: node_rpc.StoreService.QuorumWrite:input_type -> node_rpc.QuorumWriteRequest
+	1,  // 58: node_rpc.StoreService.Ping:output_type -> node_rpc.PingResponse
+	3,  // 59: node_rpc.StoreService.Get:output_type -> node_rpc.GetResponse
+	6,  // 60: node_rpc.StoreService.Put:output_type -> node_rpc.PutResponse
+	8,  // 61: node_rpc.StoreService.Delete:output_type -> node_rpc.DeleteResponse
+	19, // 62: node_rpc.StoreService.Expire:output_type -> node_rpc.ExpireResponse
+	21, // 63: node_rpc.StoreService.Persist:output_type -> node_rpc.PersistResponse
+	23, // 64: node_rpc.StoreService.Ttl:output_type -> node_rpc.TtlResponse
+	25, // 65: node_rpc.StoreService.Incr:output_type -> node_rpc.IncrResponse
+	27, // 66: node_rpc.StoreService.ListPush:output_type -> node_rpc.ListPushResponse
+	29, // 67: node_rpc.StoreService.ListPop:output_type -> node_rpc.ListPopResponse
+	31, // 68: node_rpc.StoreService.ListRange:output_type -> node_rpc.ListRangeResponse
+	33, // 69: node_rpc.StoreService.HashSet:output_type -> node_rpc.HashSetResponse
+	35, // 70: node_rpc.StoreService.HashGet:output_type -> node_rpc.HashGetResponse
+	37, // 71: node_rpc.StoreService.HashDelete:output_type -> node_rpc.HashDeleteResponse
+	39, // 72: node_rpc.StoreService.HashGetAll:output_type -> node_rpc.HashGetAllResponse
+	41, // 73: node_rpc.StoreService.SetAdd:output_type -> node_rpc.SetAddResponse
+	43, // 74: node_rpc.StoreService.SetRemove:output_type -> node_rpc.SetRemoveResponse
+	45, // 75: node_rpc.StoreService.SetMembers:output_type -> node_rpc.SetMembersResponse
+	47, // 76: node_rpc.StoreService.SetIsMember:output_type -> node_rpc.SetIsMemberResponse
+	50, // 77: node_rpc.StoreService.SortedSetAdd:output_type -> node_rpc.SortedSetAddResponse
+	52, // 78: node_rpc.StoreService.SortedSetRemove:output_type -> node_rpc.SortedSetRemoveResponse
+	54, // 79: node_rpc.StoreService.SortedSetIncr:output_type -> node_rpc.SortedSetIncrResponse
+	56, // 80: node_rpc.StoreService.SortedSetRangeByRank:output_type -> node_rpc.SortedSetRangeByRankResponse
+	58, // 81: node_rpc.StoreService.SortedSetRangeByScore:output_type -> node_rpc.SortedSetRangeByScoreResponse
+	60, // 82: node_rpc.StoreService.SortedSetRank:output_type -> node_rpc.SortedSetRankResponse
+	11, // 83: node_rpc.StoreService.Batch:output_type -> node_rpc.BatchResponse
+	15, // 84: node_rpc.StoreService.Scan:output_type -> node_rpc.ScanResponse
+	17, // 85: node_rpc.StoreService.Watch:output_type -> node_rpc.WatchResponse
+	71, // 86: node_rpc.StoreService.Replicate:output_type -> node_rpc.ReplicateResponse
+	73, // 87: node_rpc.StoreService.CheckNode:output_type -> node_rpc.CheckNodeResponse
+	77, // 88: node_rpc.StoreService.RaftVote:output_type -> node_rpc.RaftVoteResponse
+	83, // 89: node_rpc.StoreService.RaftAppend:output_type -> node_rpc.RaftAppendResponse
+	64, // 90: node_rpc.StoreService.SetClusterConfig:output_type -> node_rpc.SetClusterConfigResponse
+	66, // 91: node_rpc.StoreService.GetClusterConfig:output_type -> node_rpc.GetClusterConfigResponse
+	79, // 92: node_rpc.StoreService.QuorumRead:output_type -> node_rpc.VersionedValue
+	82, // 93: node_rpc.StoreService.QuorumWrite:output_type -> node_rpc.QuorumWriteResponse
+	58, // [58:94] is the sub-list for method output_type
+	22, // [22:58] is the sub-list for method input_type
+	22, // [22:22] is the sub-list for extension type_name
+	22, // [22:22] is the sub-list for extension extendee
+	0,  // [0:22] is the sub-list for field type_name
 }
 
 func init() { file_internal_rpc_node_rpc_proto_init() }
@@ -954,7 +5516,7 @@ func file_internal_rpc_node_rpc_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_internal_rpc_node_rpc_proto_rawDesc), len(file_internal_rpc_node_rpc_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   16,
+			NumMessages:   87,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
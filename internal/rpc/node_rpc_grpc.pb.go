@@ -19,13 +19,42 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	StoreService_Ping_FullMethodName             = "/node_rpc.StoreService/Ping"
-	StoreService_Get_FullMethodName              = "/node_rpc.StoreService/Get"
-	StoreService_Put_FullMethodName              = "/node_rpc.StoreService/Put"
-	StoreService_Delete_FullMethodName           = "/node_rpc.StoreService/Delete"
-	StoreService_Gossip_FullMethodName           = "/node_rpc.StoreService/Gossip"
-	StoreService_SetClusterConfig_FullMethodName = "/node_rpc.StoreService/SetClusterConfig"
-	StoreService_GetClusterConfig_FullMethodName = "/node_rpc.StoreService/GetClusterConfig"
+	StoreService_Ping_FullMethodName                  = "/node_rpc.StoreService/Ping"
+	StoreService_Get_FullMethodName                   = "/node_rpc.StoreService/Get"
+	StoreService_Put_FullMethodName                   = "/node_rpc.StoreService/Put"
+	StoreService_Delete_FullMethodName                = "/node_rpc.StoreService/Delete"
+	StoreService_Expire_FullMethodName                = "/node_rpc.StoreService/Expire"
+	StoreService_Persist_FullMethodName               = "/node_rpc.StoreService/Persist"
+	StoreService_Ttl_FullMethodName                   = "/node_rpc.StoreService/Ttl"
+	StoreService_Incr_FullMethodName                  = "/node_rpc.StoreService/Incr"
+	StoreService_ListPush_FullMethodName              = "/node_rpc.StoreService/ListPush"
+	StoreService_ListPop_FullMethodName               = "/node_rpc.StoreService/ListPop"
+	StoreService_ListRange_FullMethodName             = "/node_rpc.StoreService/ListRange"
+	StoreService_HashSet_FullMethodName               = "/node_rpc.StoreService/HashSet"
+	StoreService_HashGet_FullMethodName               = "/node_rpc.StoreService/HashGet"
+	StoreService_HashDelete_FullMethodName            = "/node_rpc.StoreService/HashDelete"
+	StoreService_HashGetAll_FullMethodName            = "/node_rpc.StoreService/HashGetAll"
+	StoreService_SetAdd_FullMethodName                = "/node_rpc.StoreService/SetAdd"
+	StoreService_SetRemove_FullMethodName             = "/node_rpc.StoreService/SetRemove"
+	StoreService_SetMembers_FullMethodName            = "/node_rpc.StoreService/SetMembers"
+	StoreService_SetIsMember_FullMethodName           = "/node_rpc.StoreService/SetIsMember"
+	StoreService_SortedSetAdd_FullMethodName          = "/node_rpc.StoreService/SortedSetAdd"
+	StoreService_SortedSetRemove_FullMethodName       = "/node_rpc.StoreService/SortedSetRemove"
+	StoreService_SortedSetIncr_FullMethodName         = "/node_rpc.StoreService/SortedSetIncr"
+	StoreService_SortedSetRangeByRank_FullMethodName  = "/node_rpc.StoreService/SortedSetRangeByRank"
+	StoreService_SortedSetRangeByScore_FullMethodName = "/node_rpc.StoreService/SortedSetRangeByScore"
+	StoreService_SortedSetRank_FullMethodName         = "/node_rpc.StoreService/SortedSetRank"
+	StoreService_Batch_FullMethodName                 = "/node_rpc.StoreService/Batch"
+	StoreService_Scan_FullMethodName                  = "/node_rpc.StoreService/Scan"
+	StoreService_Watch_FullMethodName                 = "/node_rpc.StoreService/Watch"
+	StoreService_Replicate_FullMethodName             = "/node_rpc.StoreService/Replicate"
+	StoreService_CheckNode_FullMethodName             = "/node_rpc.StoreService/CheckNode"
+	StoreService_RaftVote_FullMethodName              = "/node_rpc.StoreService/RaftVote"
+	StoreService_RaftAppend_FullMethodName            = "/node_rpc.StoreService/RaftAppend"
+	StoreService_SetClusterConfig_FullMethodName      = "/node_rpc.StoreService/SetClusterConfig"
+	StoreService_GetClusterConfig_FullMethodName      = "/node_rpc.StoreService/GetClusterConfig"
+	StoreService_QuorumRead_FullMethodName            = "/node_rpc.StoreService/QuorumRead"
+	StoreService_QuorumWrite_FullMethodName           = "/node_rpc.StoreService/QuorumWrite"
 )
 
 // StoreServiceClient is the client API for StoreService service.
@@ -36,9 +65,38 @@ type StoreServiceClient interface {
 	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error)
 	Put(ctx context.Context, in *PutRequest, opts ...grpc.CallOption) (*PutResponse, error)
 	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
-	Gossip(ctx context.Context, in *GossipRequest, opts ...grpc.CallOption) (*GossipResponse, error)
+	Expire(ctx context.Context, in *ExpireRequest, opts ...grpc.CallOption) (*ExpireResponse, error)
+	Persist(ctx context.Context, in *PersistRequest, opts ...grpc.CallOption) (*PersistResponse, error)
+	Ttl(ctx context.Context, in *TtlRequest, opts ...grpc.CallOption) (*TtlResponse, error)
+	Incr(ctx context.Context, in *IncrRequest, opts ...grpc.CallOption) (*IncrResponse, error)
+	ListPush(ctx context.Context, in *ListPushRequest, opts ...grpc.CallOption) (*ListPushResponse, error)
+	ListPop(ctx context.Context, in *ListPopRequest, opts ...grpc.CallOption) (*ListPopResponse, error)
+	ListRange(ctx context.Context, in *ListRangeRequest, opts ...grpc.CallOption) (*ListRangeResponse, error)
+	HashSet(ctx context.Context, in *HashSetRequest, opts ...grpc.CallOption) (*HashSetResponse, error)
+	HashGet(ctx context.Context, in *HashGetRequest, opts ...grpc.CallOption) (*HashGetResponse, error)
+	HashDelete(ctx context.Context, in *HashDeleteRequest, opts ...grpc.CallOption) (*HashDeleteResponse, error)
+	HashGetAll(ctx context.Context, in *HashGetAllRequest, opts ...grpc.CallOption) (*HashGetAllResponse, error)
+	SetAdd(ctx context.Context, in *SetAddRequest, opts ...grpc.CallOption) (*SetAddResponse, error)
+	SetRemove(ctx context.Context, in *SetRemoveRequest, opts ...grpc.CallOption) (*SetRemoveResponse, error)
+	SetMembers(ctx context.Context, in *SetMembersRequest, opts ...grpc.CallOption) (*SetMembersResponse, error)
+	SetIsMember(ctx context.Context, in *SetIsMemberRequest, opts ...grpc.CallOption) (*SetIsMemberResponse, error)
+	SortedSetAdd(ctx context.Context, in *SortedSetAddRequest, opts ...grpc.CallOption) (*SortedSetAddResponse, error)
+	SortedSetRemove(ctx context.Context, in *SortedSetRemoveRequest, opts ...grpc.CallOption) (*SortedSetRemoveResponse, error)
+	SortedSetIncr(ctx context.Context, in *SortedSetIncrRequest, opts ...grpc.CallOption) (*SortedSetIncrResponse, error)
+	SortedSetRangeByRank(ctx context.Context, in *SortedSetRangeByRankRequest, opts ...grpc.CallOption) (*SortedSetRangeByRankResponse, error)
+	SortedSetRangeByScore(ctx context.Context, in *SortedSetRangeByScoreRequest, opts ...grpc.CallOption) (*SortedSetRangeByScoreResponse, error)
+	SortedSetRank(ctx context.Context, in *SortedSetRankRequest, opts ...grpc.CallOption) (*SortedSetRankResponse, error)
+	Batch(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (*BatchResponse, error)
+	Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ScanResponse], error)
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchResponse], error)
+	Replicate(ctx context.Context, in *ReplicateRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ReplicateResponse], error)
+	CheckNode(ctx context.Context, in *CheckNodeRequest, opts ...grpc.CallOption) (*CheckNodeResponse, error)
+	RaftVote(ctx context.Context, in *RaftVoteRequest, opts ...grpc.CallOption) (*RaftVoteResponse, error)
+	RaftAppend(ctx context.Context, in *RaftAppendRequest, opts ...grpc.CallOption) (*RaftAppendResponse, error)
 	SetClusterConfig(ctx context.Context, in *SetClusterConfigRequest, opts ...grpc.CallOption) (*SetClusterConfigResponse, error)
 	GetClusterConfig(ctx context.Context, in *GetClusterConfigRequest, opts ...grpc.CallOption) (*GetClusterConfigResponse, error)
+	QuorumRead(ctx context.Context, in *QuorumReadRequest, opts ...grpc.CallOption) (*VersionedValue, error)
+	QuorumWrite(ctx context.Context, in *QuorumWriteRequest, opts ...grpc.CallOption) (*QuorumWriteResponse, error)
 }
 
 type storeServiceClient struct {
@@ -89,10 +147,307 @@ func (c *storeServiceClient) Delete(ctx context.Context, in *DeleteRequest, opts
 	return out, nil
 }
 
-func (c *storeServiceClient) Gossip(ctx context.Context, in *GossipRequest, opts ...grpc.CallOption) (*GossipResponse, error) {
+func (c *storeServiceClient) Expire(ctx context.Context, in *ExpireRequest, opts ...grpc.CallOption) (*ExpireResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	out := new(GossipResponse)
-	err := c.cc.Invoke(ctx, StoreService_Gossip_FullMethodName, in, out, cOpts...)
+	out := new(ExpireResponse)
+	err := c.cc.Invoke(ctx, StoreService_Expire_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storeServiceClient) Persist(ctx context.Context, in *PersistRequest, opts ...grpc.CallOption) (*PersistResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PersistResponse)
+	err := c.cc.Invoke(ctx, StoreService_Persist_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storeServiceClient) Ttl(ctx context.Context, in *TtlRequest, opts ...grpc.CallOption) (*TtlResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TtlResponse)
+	err := c.cc.Invoke(ctx, StoreService_Ttl_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storeServiceClient) Incr(ctx context.Context, in *IncrRequest, opts ...grpc.CallOption) (*IncrResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IncrResponse)
+	err := c.cc.Invoke(ctx, StoreService_Incr_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storeServiceClient) ListPush(ctx context.Context, in *ListPushRequest, opts ...grpc.CallOption) (*ListPushResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListPushResponse)
+	err := c.cc.Invoke(ctx, StoreService_ListPush_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storeServiceClient) ListPop(ctx context.Context, in *ListPopRequest, opts ...grpc.CallOption) (*ListPopResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListPopResponse)
+	err := c.cc.Invoke(ctx, StoreService_ListPop_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storeServiceClient) ListRange(ctx context.Context, in *ListRangeRequest, opts ...grpc.CallOption) (*ListRangeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListRangeResponse)
+	err := c.cc.Invoke(ctx, StoreService_ListRange_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storeServiceClient) HashSet(ctx context.Context, in *HashSetRequest, opts ...grpc.CallOption) (*HashSetResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HashSetResponse)
+	err := c.cc.Invoke(ctx, StoreService_HashSet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storeServiceClient) HashGet(ctx context.Context, in *HashGetRequest, opts ...grpc.CallOption) (*HashGetResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HashGetResponse)
+	err := c.cc.Invoke(ctx, StoreService_HashGet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storeServiceClient) HashDelete(ctx context.Context, in *HashDeleteRequest, opts ...grpc.CallOption) (*HashDeleteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HashDeleteResponse)
+	err := c.cc.Invoke(ctx, StoreService_HashDelete_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storeServiceClient) HashGetAll(ctx context.Context, in *HashGetAllRequest, opts ...grpc.CallOption) (*HashGetAllResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HashGetAllResponse)
+	err := c.cc.Invoke(ctx, StoreService_HashGetAll_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storeServiceClient) SetAdd(ctx context.Context, in *SetAddRequest, opts ...grpc.CallOption) (*SetAddResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetAddResponse)
+	err := c.cc.Invoke(ctx, StoreService_SetAdd_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storeServiceClient) SetRemove(ctx context.Context, in *SetRemoveRequest, opts ...grpc.CallOption) (*SetRemoveResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetRemoveResponse)
+	err := c.cc.Invoke(ctx, StoreService_SetRemove_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storeServiceClient) SetMembers(ctx context.Context, in *SetMembersRequest, opts ...grpc.CallOption) (*SetMembersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetMembersResponse)
+	err := c.cc.Invoke(ctx, StoreService_SetMembers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storeServiceClient) SetIsMember(ctx context.Context, in *SetIsMemberRequest, opts ...grpc.CallOption) (*SetIsMemberResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetIsMemberResponse)
+	err := c.cc.Invoke(ctx, StoreService_SetIsMember_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storeServiceClient) SortedSetAdd(ctx context.Context, in *SortedSetAddRequest, opts ...grpc.CallOption) (*SortedSetAddResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SortedSetAddResponse)
+	err := c.cc.Invoke(ctx, StoreService_SortedSetAdd_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storeServiceClient) SortedSetRemove(ctx context.Context, in *SortedSetRemoveRequest, opts ...grpc.CallOption) (*SortedSetRemoveResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SortedSetRemoveResponse)
+	err := c.cc.Invoke(ctx, StoreService_SortedSetRemove_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storeServiceClient) SortedSetIncr(ctx context.Context, in *SortedSetIncrRequest, opts ...grpc.CallOption) (*SortedSetIncrResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SortedSetIncrResponse)
+	err := c.cc.Invoke(ctx, StoreService_SortedSetIncr_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storeServiceClient) SortedSetRangeByRank(ctx context.Context, in *SortedSetRangeByRankRequest, opts ...grpc.CallOption) (*SortedSetRangeByRankResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SortedSetRangeByRankResponse)
+	err := c.cc.Invoke(ctx, StoreService_SortedSetRangeByRank_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storeServiceClient) SortedSetRangeByScore(ctx context.Context, in *SortedSetRangeByScoreRequest, opts ...grpc.CallOption) (*SortedSetRangeByScoreResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SortedSetRangeByScoreResponse)
+	err := c.cc.Invoke(ctx, StoreService_SortedSetRangeByScore_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storeServiceClient) SortedSetRank(ctx context.Context, in *SortedSetRankRequest, opts ...grpc.CallOption) (*SortedSetRankResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SortedSetRankResponse)
+	err := c.cc.Invoke(ctx, StoreService_SortedSetRank_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storeServiceClient) Batch(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (*BatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchResponse)
+	err := c.cc.Invoke(ctx, StoreService_Batch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storeServiceClient) Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ScanResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &StoreService_ServiceDesc.Streams[0], StoreService_Scan_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ScanRequest, ScanResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type StoreService_ScanClient = grpc.ServerStreamingClient[ScanResponse]
+
+func (c *storeServiceClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &StoreService_ServiceDesc.Streams[1], StoreService_Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRequest, WatchResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type StoreService_WatchClient = grpc.ServerStreamingClient[WatchResponse]
+
+func (c *storeServiceClient) Replicate(ctx context.Context, in *ReplicateRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ReplicateResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &StoreService_ServiceDesc.Streams[2], StoreService_Replicate_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ReplicateRequest, ReplicateResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type StoreService_ReplicateClient = grpc.ServerStreamingClient[ReplicateResponse]
+
+func (c *storeServiceClient) CheckNode(ctx context.Context, in *CheckNodeRequest, opts ...grpc.CallOption) (*CheckNodeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CheckNodeResponse)
+	err := c.cc.Invoke(ctx, StoreService_CheckNode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storeServiceClient) RaftVote(ctx context.Context, in *RaftVoteRequest, opts ...grpc.CallOption) (*RaftVoteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RaftVoteResponse)
+	err := c.cc.Invoke(ctx, StoreService_RaftVote_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storeServiceClient) RaftAppend(ctx context.Context, in *RaftAppendRequest, opts ...grpc.CallOption) (*RaftAppendResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RaftAppendResponse)
+	err := c.cc.Invoke(ctx, StoreService_RaftAppend_FullMethodName, in, out, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -119,6 +474,26 @@ func (c *storeServiceClient) GetClusterConfig(ctx context.Context, in *GetCluste
 	return out, nil
 }
 
+func (c *storeServiceClient) QuorumRead(ctx context.Context, in *QuorumReadRequest, opts ...grpc.CallOption) (*VersionedValue, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VersionedValue)
+	err := c.cc.Invoke(ctx, StoreService_QuorumRead_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storeServiceClient) QuorumWrite(ctx context.Context, in *QuorumWriteRequest, opts ...grpc.CallOption) (*QuorumWriteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QuorumWriteResponse)
+	err := c.cc.Invoke(ctx, StoreService_QuorumWrite_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // StoreServiceServer is the server API for StoreService service.
 // All implementations must embed UnimplementedStoreServiceServer
 // for forward compatibility.
@@ -127,9 +502,38 @@ type StoreServiceServer interface {
 	Get(context.Context, *GetRequest) (*GetResponse, error)
 	Put(context.Context, *PutRequest) (*PutResponse, error)
 	Delete(context.Context, *DeleteRequest) (*DeleteResponse, error)
-	Gossip(context.Context, *GossipRequest) (*GossipResponse, error)
+	Expire(context.Context, *ExpireRequest) (*ExpireResponse, error)
+	Persist(context.Context, *PersistRequest) (*PersistResponse, error)
+	Ttl(context.Context, *TtlRequest) (*TtlResponse, error)
+	Incr(context.Context, *IncrRequest) (*IncrResponse, error)
+	ListPush(context.Context, *ListPushRequest) (*ListPushResponse, error)
+	ListPop(context.Context, *ListPopRequest) (*ListPopResponse, error)
+	ListRange(context.Context, *ListRangeRequest) (*ListRangeResponse, error)
+	HashSet(context.Context, *HashSetRequest) (*HashSetResponse, error)
+	HashGet(context.Context, *HashGetRequest) (*HashGetResponse, error)
+	HashDelete(context.Context, *HashDeleteRequest) (*HashDeleteResponse, error)
+	HashGetAll(context.Context, *HashGetAllRequest) (*HashGetAllResponse, error)
+	SetAdd(context.Context, *SetAddRequest) (*SetAddResponse, error)
+	SetRemove(context.Context, *SetRemoveRequest) (*SetRemoveResponse, error)
+	SetMembers(context.Context, *SetMembersRequest) (*SetMembersResponse, error)
+	SetIsMember(context.Context, *SetIsMemberRequest) (*SetIsMemberResponse, error)
+	SortedSetAdd(context.Context, *SortedSetAddRequest) (*SortedSetAddResponse, error)
+	SortedSetRemove(context.Context, *SortedSetRemoveRequest) (*SortedSetRemoveResponse, error)
+	SortedSetIncr(context.Context, *SortedSetIncrRequest) (*SortedSetIncrResponse, error)
+	SortedSetRangeByRank(context.Context, *SortedSetRangeByRankRequest) (*SortedSetRangeByRankResponse, error)
+	SortedSetRangeByScore(context.Context, *SortedSetRangeByScoreRequest) (*SortedSetRangeByScoreResponse, error)
+	SortedSetRank(context.Context, *SortedSetRankRequest) (*SortedSetRankResponse, error)
+	Batch(context.Context, *BatchRequest) (*BatchResponse, error)
+	Scan(*ScanRequest, grpc.ServerStreamingServer[ScanResponse]) error
+	Watch(*WatchRequest, grpc.ServerStreamingServer[WatchResponse]) error
+	Replicate(*ReplicateRequest, grpc.ServerStreamingServer[ReplicateResponse]) error
+	CheckNode(context.Context, *CheckNodeRequest) (*CheckNodeResponse, error)
+	RaftVote(context.Context, *RaftVoteRequest) (*RaftVoteResponse, error)
+	RaftAppend(context.Context, *RaftAppendRequest) (*RaftAppendResponse, error)
 	SetClusterConfig(context.Context, *SetClusterConfigRequest) (*SetClusterConfigResponse, error)
 	GetClusterConfig(context.Context, *GetClusterConfigRequest) (*GetClusterConfigResponse, error)
+	QuorumRead(context.Context, *QuorumReadRequest) (*VersionedValue, error)
+	QuorumWrite(context.Context, *QuorumWriteRequest) (*QuorumWriteResponse, error)
 	mustEmbedUnimplementedStoreServiceServer()
 }
 
@@ -152,8 +556,89 @@ func (UnimplementedStoreServiceServer) Put(context.Context, *PutRequest) (*PutRe
 func (UnimplementedStoreServiceServer) Delete(context.Context, *DeleteRequest) (*DeleteResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
 }
-func (UnimplementedStoreServiceServer) Gossip(context.Context, *GossipRequest) (*GossipResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Gossip not implemented")
+func (UnimplementedStoreServiceServer) Expire(context.Context, *ExpireRequest) (*ExpireResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Expire not implemented")
+}
+func (UnimplementedStoreServiceServer) Persist(context.Context, *PersistRequest) (*PersistResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Persist not implemented")
+}
+func (UnimplementedStoreServiceServer) Ttl(context.Context, *TtlRequest) (*TtlResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Ttl not implemented")
+}
+func (UnimplementedStoreServiceServer) Incr(context.Context, *IncrRequest) (*IncrResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Incr not implemented")
+}
+func (UnimplementedStoreServiceServer) ListPush(context.Context, *ListPushRequest) (*ListPushResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPush not implemented")
+}
+func (UnimplementedStoreServiceServer) ListPop(context.Context, *ListPopRequest) (*ListPopResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPop not implemented")
+}
+func (UnimplementedStoreServiceServer) ListRange(context.Context, *ListRangeRequest) (*ListRangeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRange not implemented")
+}
+func (UnimplementedStoreServiceServer) HashSet(context.Context, *HashSetRequest) (*HashSetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HashSet not implemented")
+}
+func (UnimplementedStoreServiceServer) HashGet(context.Context, *HashGetRequest) (*HashGetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HashGet not implemented")
+}
+func (UnimplementedStoreServiceServer) HashDelete(context.Context, *HashDeleteRequest) (*HashDeleteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HashDelete not implemented")
+}
+func (UnimplementedStoreServiceServer) HashGetAll(context.Context, *HashGetAllRequest) (*HashGetAllResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HashGetAll not implemented")
+}
+func (UnimplementedStoreServiceServer) SetAdd(context.Context, *SetAddRequest) (*SetAddResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetAdd not implemented")
+}
+func (UnimplementedStoreServiceServer) SetRemove(context.Context, *SetRemoveRequest) (*SetRemoveResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetRemove not implemented")
+}
+func (UnimplementedStoreServiceServer) SetMembers(context.Context, *SetMembersRequest) (*SetMembersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetMembers not implemented")
+}
+func (UnimplementedStoreServiceServer) SetIsMember(context.Context, *SetIsMemberRequest) (*SetIsMemberResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetIsMember not implemented")
+}
+func (UnimplementedStoreServiceServer) SortedSetAdd(context.Context, *SortedSetAddRequest) (*SortedSetAddResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SortedSetAdd not implemented")
+}
+func (UnimplementedStoreServiceServer) SortedSetRemove(context.Context, *SortedSetRemoveRequest) (*SortedSetRemoveResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SortedSetRemove not implemented")
+}
+func (UnimplementedStoreServiceServer) SortedSetIncr(context.Context, *SortedSetIncrRequest) (*SortedSetIncrResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SortedSetIncr not implemented")
+}
+func (UnimplementedStoreServiceServer) SortedSetRangeByRank(context.Context, *SortedSetRangeByRankRequest) (*SortedSetRangeByRankResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SortedSetRangeByRank not implemented")
+}
+func (UnimplementedStoreServiceServer) SortedSetRangeByScore(context.Context, *SortedSetRangeByScoreRequest) (*SortedSetRangeByScoreResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SortedSetRangeByScore not implemented")
+}
+func (UnimplementedStoreServiceServer) SortedSetRank(context.Context, *SortedSetRankRequest) (*SortedSetRankResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SortedSetRank not implemented")
+}
+func (UnimplementedStoreServiceServer) Batch(context.Context, *BatchRequest) (*BatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Batch not implemented")
+}
+func (UnimplementedStoreServiceServer) Scan(*ScanRequest, grpc.ServerStreamingServer[ScanResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method Scan not implemented")
+}
+func (UnimplementedStoreServiceServer) Watch(*WatchRequest, grpc.ServerStreamingServer[WatchResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedStoreServiceServer) Replicate(*ReplicateRequest, grpc.ServerStreamingServer[ReplicateResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method Replicate not implemented")
+}
+func (UnimplementedStoreServiceServer) CheckNode(context.Context, *CheckNodeRequest) (*CheckNodeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckNode not implemented")
+}
+func (UnimplementedStoreServiceServer) RaftVote(context.Context, *RaftVoteRequest) (*RaftVoteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RaftVote not implemented")
+}
+func (UnimplementedStoreServiceServer) RaftAppend(context.Context, *RaftAppendRequest) (*RaftAppendResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RaftAppend not implemented")
 }
 func (UnimplementedStoreServiceServer) SetClusterConfig(context.Context, *SetClusterConfigRequest) (*SetClusterConfigResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SetClusterConfig not implemented")
@@ -161,6 +646,12 @@ func (UnimplementedStoreServiceServer) SetClusterConfig(context.Context, *SetClu
 func (UnimplementedStoreServiceServer) GetClusterConfig(context.Context, *GetClusterConfigRequest) (*GetClusterConfigResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetClusterConfig not implemented")
 }
+func (UnimplementedStoreServiceServer) QuorumRead(context.Context, *QuorumReadRequest) (*VersionedValue, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QuorumRead not implemented")
+}
+func (UnimplementedStoreServiceServer) QuorumWrite(context.Context, *QuorumWriteRequest) (*QuorumWriteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QuorumWrite not implemented")
+}
 func (UnimplementedStoreServiceServer) mustEmbedUnimplementedStoreServiceServer() {}
 func (UnimplementedStoreServiceServer) testEmbeddedByValue()                      {}
 
@@ -254,62 +745,563 @@ func _StoreService_Delete_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
-func _StoreService_Gossip_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GossipRequest)
+func _StoreService_Expire_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExpireRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(StoreServiceServer).Gossip(ctx, in)
+		return srv.(StoreServiceServer).Expire(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: StoreService_Gossip_FullMethodName,
+		FullMethod: StoreService_Expire_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(StoreServiceServer).Gossip(ctx, req.(*GossipRequest))
+		return srv.(StoreServiceServer).Expire(ctx, req.(*ExpireRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _StoreService_SetClusterConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(SetClusterConfigRequest)
+func _StoreService_Persist_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PersistRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(StoreServiceServer).SetClusterConfig(ctx, in)
+		return srv.(StoreServiceServer).Persist(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: StoreService_SetClusterConfig_FullMethodName,
+		FullMethod: StoreService_Persist_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(StoreServiceServer).SetClusterConfig(ctx, req.(*SetClusterConfigRequest))
+		return srv.(StoreServiceServer).Persist(ctx, req.(*PersistRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _StoreService_GetClusterConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetClusterConfigRequest)
+func _StoreService_Ttl_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TtlRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(StoreServiceServer).GetClusterConfig(ctx, in)
+		return srv.(StoreServiceServer).Ttl(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: StoreService_GetClusterConfig_FullMethodName,
+		FullMethod: StoreService_Ttl_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(StoreServiceServer).GetClusterConfig(ctx, req.(*GetClusterConfigRequest))
+		return srv.(StoreServiceServer).Ttl(ctx, req.(*TtlRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-// StoreService_ServiceDesc is the grpc.ServiceDesc for StoreService service.
-// It's only intended for direct use with grpc.RegisterService,
+func _StoreService_Incr_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IncrRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreServiceServer).Incr(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StoreService_Incr_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreServiceServer).Incr(ctx, req.(*IncrRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StoreService_ListPush_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPushRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreServiceServer).ListPush(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StoreService_ListPush_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreServiceServer).ListPush(ctx, req.(*ListPushRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StoreService_ListPop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPopRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreServiceServer).ListPop(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StoreService_ListPop_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreServiceServer).ListPop(ctx, req.(*ListPopRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StoreService_ListRange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRangeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreServiceServer).ListRange(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StoreService_ListRange_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreServiceServer).ListRange(ctx, req.(*ListRangeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StoreService_HashSet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HashSetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreServiceServer).HashSet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StoreService_HashSet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreServiceServer).HashSet(ctx, req.(*HashSetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StoreService_HashGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HashGetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreServiceServer).HashGet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StoreService_HashGet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreServiceServer).HashGet(ctx, req.(*HashGetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StoreService_HashDelete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HashDeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreServiceServer).HashDelete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StoreService_HashDelete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreServiceServer).HashDelete(ctx, req.(*HashDeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StoreService_HashGetAll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HashGetAllRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreServiceServer).HashGetAll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StoreService_HashGetAll_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreServiceServer).HashGetAll(ctx, req.(*HashGetAllRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StoreService_SetAdd_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetAddRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreServiceServer).SetAdd(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StoreService_SetAdd_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreServiceServer).SetAdd(ctx, req.(*SetAddRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StoreService_SetRemove_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetRemoveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreServiceServer).SetRemove(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StoreService_SetRemove_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreServiceServer).SetRemove(ctx, req.(*SetRemoveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StoreService_SetMembers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetMembersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreServiceServer).SetMembers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StoreService_SetMembers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreServiceServer).SetMembers(ctx, req.(*SetMembersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StoreService_SetIsMember_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetIsMemberRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreServiceServer).SetIsMember(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StoreService_SetIsMember_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreServiceServer).SetIsMember(ctx, req.(*SetIsMemberRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StoreService_SortedSetAdd_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SortedSetAddRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreServiceServer).SortedSetAdd(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StoreService_SortedSetAdd_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreServiceServer).SortedSetAdd(ctx, req.(*SortedSetAddRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StoreService_SortedSetRemove_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SortedSetRemoveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreServiceServer).SortedSetRemove(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StoreService_SortedSetRemove_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreServiceServer).SortedSetRemove(ctx, req.(*SortedSetRemoveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StoreService_SortedSetIncr_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SortedSetIncrRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreServiceServer).SortedSetIncr(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StoreService_SortedSetIncr_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreServiceServer).SortedSetIncr(ctx, req.(*SortedSetIncrRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StoreService_SortedSetRangeByRank_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SortedSetRangeByRankRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreServiceServer).SortedSetRangeByRank(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StoreService_SortedSetRangeByRank_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreServiceServer).SortedSetRangeByRank(ctx, req.(*SortedSetRangeByRankRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StoreService_SortedSetRangeByScore_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SortedSetRangeByScoreRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreServiceServer).SortedSetRangeByScore(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StoreService_SortedSetRangeByScore_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreServiceServer).SortedSetRangeByScore(ctx, req.(*SortedSetRangeByScoreRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StoreService_SortedSetRank_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SortedSetRankRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreServiceServer).SortedSetRank(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StoreService_SortedSetRank_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreServiceServer).SortedSetRank(ctx, req.(*SortedSetRankRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StoreService_Batch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreServiceServer).Batch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StoreService_Batch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreServiceServer).Batch(ctx, req.(*BatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StoreService_Scan_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ScanRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StoreServiceServer).Scan(m, &grpc.GenericServerStream[ScanRequest, ScanResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type StoreService_ScanServer = grpc.ServerStreamingServer[ScanResponse]
+
+func _StoreService_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StoreServiceServer).Watch(m, &grpc.GenericServerStream[WatchRequest, WatchResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type StoreService_WatchServer = grpc.ServerStreamingServer[WatchResponse]
+
+func _StoreService_Replicate_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ReplicateRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StoreServiceServer).Replicate(m, &grpc.GenericServerStream[ReplicateRequest, ReplicateResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type StoreService_ReplicateServer = grpc.ServerStreamingServer[ReplicateResponse]
+
+func _StoreService_CheckNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckNodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreServiceServer).CheckNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StoreService_CheckNode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreServiceServer).CheckNode(ctx, req.(*CheckNodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StoreService_RaftVote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RaftVoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreServiceServer).RaftVote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StoreService_RaftVote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreServiceServer).RaftVote(ctx, req.(*RaftVoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StoreService_RaftAppend_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RaftAppendRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreServiceServer).RaftAppend(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StoreService_RaftAppend_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreServiceServer).RaftAppend(ctx, req.(*RaftAppendRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StoreService_SetClusterConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetClusterConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreServiceServer).SetClusterConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StoreService_SetClusterConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreServiceServer).SetClusterConfig(ctx, req.(*SetClusterConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StoreService_GetClusterConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetClusterConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreServiceServer).GetClusterConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StoreService_GetClusterConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreServiceServer).GetClusterConfig(ctx, req.(*GetClusterConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StoreService_QuorumRead_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QuorumReadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreServiceServer).QuorumRead(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StoreService_QuorumRead_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreServiceServer).QuorumRead(ctx, req.(*QuorumReadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StoreService_QuorumWrite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QuorumWriteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreServiceServer).QuorumWrite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StoreService_QuorumWrite_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreServiceServer).QuorumWrite(ctx, req.(*QuorumWriteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// StoreService_ServiceDesc is the grpc.ServiceDesc for StoreService service.
+// It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
 var StoreService_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "node_rpc.StoreService",
@@ -332,8 +1324,104 @@ var StoreService_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _StoreService_Delete_Handler,
 		},
 		{
-			MethodName: "Gossip",
-			Handler:    _StoreService_Gossip_Handler,
+			MethodName: "Expire",
+			Handler:    _StoreService_Expire_Handler,
+		},
+		{
+			MethodName: "Persist",
+			Handler:    _StoreService_Persist_Handler,
+		},
+		{
+			MethodName: "Ttl",
+			Handler:    _StoreService_Ttl_Handler,
+		},
+		{
+			MethodName: "Incr",
+			Handler:    _StoreService_Incr_Handler,
+		},
+		{
+			MethodName: "ListPush",
+			Handler:    _StoreService_ListPush_Handler,
+		},
+		{
+			MethodName: "ListPop",
+			Handler:    _StoreService_ListPop_Handler,
+		},
+		{
+			MethodName: "ListRange",
+			Handler:    _StoreService_ListRange_Handler,
+		},
+		{
+			MethodName: "HashSet",
+			Handler:    _StoreService_HashSet_Handler,
+		},
+		{
+			MethodName: "HashGet",
+			Handler:    _StoreService_HashGet_Handler,
+		},
+		{
+			MethodName: "HashDelete",
+			Handler:    _StoreService_HashDelete_Handler,
+		},
+		{
+			MethodName: "HashGetAll",
+			Handler:    _StoreService_HashGetAll_Handler,
+		},
+		{
+			MethodName: "SetAdd",
+			Handler:    _StoreService_SetAdd_Handler,
+		},
+		{
+			MethodName: "SetRemove",
+			Handler:    _StoreService_SetRemove_Handler,
+		},
+		{
+			MethodName: "SetMembers",
+			Handler:    _StoreService_SetMembers_Handler,
+		},
+		{
+			MethodName: "SetIsMember",
+			Handler:    _StoreService_SetIsMember_Handler,
+		},
+		{
+			MethodName: "SortedSetAdd",
+			Handler:    _StoreService_SortedSetAdd_Handler,
+		},
+		{
+			MethodName: "SortedSetRemove",
+			Handler:    _StoreService_SortedSetRemove_Handler,
+		},
+		{
+			MethodName: "SortedSetIncr",
+			Handler:    _StoreService_SortedSetIncr_Handler,
+		},
+		{
+			MethodName: "SortedSetRangeByRank",
+			Handler:    _StoreService_SortedSetRangeByRank_Handler,
+		},
+		{
+			MethodName: "SortedSetRangeByScore",
+			Handler:    _StoreService_SortedSetRangeByScore_Handler,
+		},
+		{
+			MethodName: "SortedSetRank",
+			Handler:    _StoreService_SortedSetRank_Handler,
+		},
+		{
+			MethodName: "Batch",
+			Handler:    _StoreService_Batch_Handler,
+		},
+		{
+			MethodName: "CheckNode",
+			Handler:    _StoreService_CheckNode_Handler,
+		},
+		{
+			MethodName: "RaftVote",
+			Handler:    _StoreService_RaftVote_Handler,
+		},
+		{
+			MethodName: "RaftAppend",
+			Handler:    _StoreService_RaftAppend_Handler,
 		},
 		{
 			MethodName: "SetClusterConfig",
@@ -343,7 +1431,31 @@ var StoreService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetClusterConfig",
 			Handler:    _StoreService_GetClusterConfig_Handler,
 		},
+		{
+			MethodName: "QuorumRead",
+			Handler:    _StoreService_QuorumRead_Handler,
+		},
+		{
+			MethodName: "QuorumWrite",
+			Handler:    _StoreService_QuorumWrite_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Scan",
+			Handler:       _StoreService_Scan_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Watch",
+			Handler:       _StoreService_Watch_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Replicate",
+			Handler:       _StoreService_Replicate_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "internal/rpc/node_rpc.proto",
 }
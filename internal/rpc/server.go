@@ -3,10 +3,13 @@ package rpc
 import (
 	"context"
 	"log"
+	"time"
 
 	"github.com/ethan-stone/go-key-store/internal/configuration"
 	"github.com/ethan-stone/go-key-store/internal/service"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type RpcServer struct {
@@ -14,6 +17,7 @@ type RpcServer struct {
 	storeService     service.StoreService
 	rpcClientManager RpcClientManager
 	configManager    configuration.ConfigurationManager
+	raftHost         RaftHost
 }
 
 func (s *RpcServer) Ping(_ context.Context, req *PingRequest) (*PingResponse, error) {
@@ -27,45 +31,54 @@ func (s *RpcServer) Get(_ context.Context, req *GetRequest) (*GetResponse, error
 	result, err := s.storeService.Get(req.GetKey())
 
 	if err != nil {
-		return nil, err
+		return nil, toStatus(err)
 	}
 
 	if !result.Ok {
 		return &GetResponse{
 			Key: req.GetKey(),
-			Val: "",
+			Val: nil,
 			Ok:  false,
 		}, nil
 	}
 
 	return &GetResponse{
-		Key: req.GetKey(),
-		Val: result.Val,
-		Ok:  true,
+		Key:         req.GetKey(),
+		Val:         result.Val,
+		ContentType: result.ContentType,
+		Version:     result.Version,
+		Ok:          true,
 	}, nil
 }
 
 func (s *RpcServer) Put(_ context.Context, req *PutRequest) (*PutResponse, error) {
 	log.Printf("Put request received for key %s", req.GetKey())
 
-	err := s.storeService.Put(req.GetKey(), req.GetVal())
+	result, err := s.storeService.Put(req.GetKey(), req.GetVal(), &service.PutOptions{
+		ContentType: req.GetContentType(),
+		TTL:         time.Duration(req.GetTtlMs()) * time.Millisecond,
+		Condition:   toCondition(req.GetCondition()),
+	})
 
 	if err != nil {
-		return nil, err
+		return nil, toStatus(err)
 	}
 
 	return &PutResponse{
-		Ok: true,
+		Ok:      true,
+		Version: result.Version,
 	}, nil
 }
 
 func (s *RpcServer) Delete(_ context.Context, req *DeleteRequest) (*DeleteResponse, error) {
 	log.Printf("Delete request received for key %s", req.GetKey())
 
-	err := s.storeService.Delete(req.GetKey())
+	err := s.storeService.Delete(req.GetKey(), &service.DeleteOptions{
+		Condition: toCondition(req.GetCondition()),
+	})
 
 	if err != nil {
-		return nil, err
+		return nil, toStatus(err)
 	}
 
 	return &DeleteResponse{
@@ -73,78 +86,576 @@ func (s *RpcServer) Delete(_ context.Context, req *DeleteRequest) (*DeleteRespon
 	}, nil
 }
 
-func (s *RpcServer) Gossip(_ context.Context, req *GossipRequest) (*GossipResponse, error) {
-	log.Printf("Received Gossip request from node %s", req.GetNodeId())
+func toCondition(condition *Condition) service.Condition {
+	return service.Condition{
+		IfVersion: condition.GetIfVersion(),
+		IfAbsent:  condition.GetIfAbsent(),
+		IfPresent: condition.GetIfPresent(),
+	}
+}
 
-	clusterConfig := s.configManager.GetClusterConfig()
+func (s *RpcServer) Expire(_ context.Context, req *ExpireRequest) (*ExpireResponse, error) {
+	log.Printf("Expire request received for key %s", req.GetKey())
 
-	clusterConfig.OtherNodes = append(clusterConfig.OtherNodes, &configuration.NodeConfig{
-		ID:        req.GetNodeId(),
-		Address:   req.GetAddress(),
-		HashSlots: []int{int(req.GetHashSlotsStart()), int(req.GetHashSlotsEnd())},
-	})
+	ok, err := s.storeService.Expire(req.GetKey(), time.Duration(req.GetTtlMs())*time.Millisecond)
 
-	otherNodes := []*NodeConfig{}
+	if err != nil {
+		return nil, toStatus(err)
+	}
 
-	for i := range clusterConfig.OtherNodes {
-		otherNode := clusterConfig.OtherNodes[i]
+	return &ExpireResponse{
+		Ok: ok,
+	}, nil
+}
 
-		otherNodes = append(otherNodes, &NodeConfig{
-			NodeId:         otherNode.ID,
-			Address:        otherNode.Address,
-			HashSlotsStart: uint32(otherNode.HashSlots[0]),
-			HashSlotsEnd:   uint32(otherNode.HashSlots[1]),
+func (s *RpcServer) Persist(_ context.Context, req *PersistRequest) (*PersistResponse, error) {
+	log.Printf("Persist request received for key %s", req.GetKey())
+
+	ok, err := s.storeService.Persist(req.GetKey())
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &PersistResponse{
+		Ok: ok,
+	}, nil
+}
+
+func (s *RpcServer) Ttl(_ context.Context, req *TtlRequest) (*TtlResponse, error) {
+	log.Printf("Ttl request received for key %s", req.GetKey())
+
+	result, err := s.storeService.TTL(req.GetKey())
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &TtlResponse{
+		Ok:      result.Ok,
+		Expires: result.Expires,
+		TtlMs:   result.TTL.Milliseconds(),
+	}, nil
+}
+
+func (s *RpcServer) Incr(_ context.Context, req *IncrRequest) (*IncrResponse, error) {
+	log.Printf("Incr request received for key %s", req.GetKey())
+
+	result, err := s.storeService.Incr(req.GetKey(), req.GetDelta())
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &IncrResponse{
+		Ok:      true,
+		Val:     result.Val,
+		Version: result.Version,
+	}, nil
+}
+
+// listSide turns the left flag sent over the wire into a side of the list.
+func listSide(left bool) service.ListSide {
+	if left {
+		return service.ListLeft
+	}
+
+	return service.ListRight
+}
+
+func (s *RpcServer) ListPush(_ context.Context, req *ListPushRequest) (*ListPushResponse, error) {
+	log.Printf("ListPush request received for key %s", req.GetKey())
+
+	length, err := s.storeService.ListPush(req.GetKey(), listSide(req.GetLeft()), req.GetVals())
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &ListPushResponse{Ok: true, Length: int64(length)}, nil
+}
+
+func (s *RpcServer) ListPop(_ context.Context, req *ListPopRequest) (*ListPopResponse, error) {
+	log.Printf("ListPop request received for key %s", req.GetKey())
+
+	vals, err := s.storeService.ListPop(req.GetKey(), listSide(req.GetLeft()), int(req.GetCount()))
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &ListPopResponse{Ok: true, Vals: vals}, nil
+}
+
+func (s *RpcServer) ListRange(_ context.Context, req *ListRangeRequest) (*ListRangeResponse, error) {
+	log.Printf("ListRange request received for key %s", req.GetKey())
+
+	vals, err := s.storeService.ListRange(req.GetKey(), int(req.GetStart()), int(req.GetStop()))
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &ListRangeResponse{Ok: true, Vals: vals}, nil
+}
+
+func (s *RpcServer) HashSet(_ context.Context, req *HashSetRequest) (*HashSetResponse, error) {
+	log.Printf("HashSet request received for key %s", req.GetKey())
+
+	added, err := s.storeService.HashSet(req.GetKey(), req.GetFields())
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &HashSetResponse{Ok: true, Added: int64(added)}, nil
+}
+
+func (s *RpcServer) HashGet(_ context.Context, req *HashGetRequest) (*HashGetResponse, error) {
+	log.Printf("HashGet request received for key %s", req.GetKey())
+
+	val, found, err := s.storeService.HashGet(req.GetKey(), req.GetField())
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &HashGetResponse{Ok: true, Found: found, Val: val}, nil
+}
+
+func (s *RpcServer) HashDelete(_ context.Context, req *HashDeleteRequest) (*HashDeleteResponse, error) {
+	log.Printf("HashDelete request received for key %s", req.GetKey())
+
+	deleted, err := s.storeService.HashDelete(req.GetKey(), req.GetFields())
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &HashDeleteResponse{Ok: true, Deleted: int64(deleted)}, nil
+}
+
+func (s *RpcServer) HashGetAll(_ context.Context, req *HashGetAllRequest) (*HashGetAllResponse, error) {
+	log.Printf("HashGetAll request received for key %s", req.GetKey())
+
+	fields, err := s.storeService.HashGetAll(req.GetKey())
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &HashGetAllResponse{Ok: true, Fields: fields}, nil
+}
+
+func (s *RpcServer) SetAdd(_ context.Context, req *SetAddRequest) (*SetAddResponse, error) {
+	log.Printf("SetAdd request received for key %s", req.GetKey())
+
+	added, err := s.storeService.SetAdd(req.GetKey(), req.GetMembers())
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &SetAddResponse{Ok: true, Added: int64(added)}, nil
+}
+
+func (s *RpcServer) SetRemove(_ context.Context, req *SetRemoveRequest) (*SetRemoveResponse, error) {
+	log.Printf("SetRemove request received for key %s", req.GetKey())
+
+	removed, err := s.storeService.SetRemove(req.GetKey(), req.GetMembers())
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &SetRemoveResponse{Ok: true, Removed: int64(removed)}, nil
+}
+
+func (s *RpcServer) SetMembers(_ context.Context, req *SetMembersRequest) (*SetMembersResponse, error) {
+	log.Printf("SetMembers request received for key %s", req.GetKey())
+
+	members, err := s.storeService.SetMembers(req.GetKey())
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &SetMembersResponse{Ok: true, Members: members}, nil
+}
+
+func (s *RpcServer) SetIsMember(_ context.Context, req *SetIsMemberRequest) (*SetIsMemberResponse, error) {
+	log.Printf("SetIsMember request received for key %s", req.GetKey())
+
+	isMember, err := s.storeService.SetIsMember(req.GetKey(), req.GetMember())
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &SetIsMemberResponse{Ok: true, IsMember: isMember}, nil
+}
+
+func toRpcScoredMembers(members []service.ScoredMember) []*ScoredMember {
+	scored := make([]*ScoredMember, len(members))
+
+	for i, member := range members {
+		scored[i] = &ScoredMember{Member: member.Member, Score: member.Score}
+	}
+
+	return scored
+}
+
+func (s *RpcServer) SortedSetAdd(_ context.Context, req *SortedSetAddRequest) (*SortedSetAddResponse, error) {
+	log.Printf("SortedSetAdd request received for key %s", req.GetKey())
+
+	added, err := s.storeService.SortedSetAdd(req.GetKey(), req.GetMembers())
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &SortedSetAddResponse{Ok: true, Added: int64(added)}, nil
+}
+
+func (s *RpcServer) SortedSetRemove(_ context.Context, req *SortedSetRemoveRequest) (*SortedSetRemoveResponse, error) {
+	log.Printf("SortedSetRemove request received for key %s", req.GetKey())
+
+	removed, err := s.storeService.SortedSetRemove(req.GetKey(), req.GetMembers())
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &SortedSetRemoveResponse{Ok: true, Removed: int64(removed)}, nil
+}
+
+func (s *RpcServer) SortedSetIncr(_ context.Context, req *SortedSetIncrRequest) (*SortedSetIncrResponse, error) {
+	log.Printf("SortedSetIncr request received for key %s", req.GetKey())
+
+	score, err := s.storeService.SortedSetIncr(req.GetKey(), req.GetMember(), req.GetDelta())
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &SortedSetIncrResponse{Ok: true, Score: score}, nil
+}
+
+func (s *RpcServer) SortedSetRangeByRank(_ context.Context, req *SortedSetRangeByRankRequest) (*SortedSetRangeByRankResponse, error) {
+	log.Printf("SortedSetRangeByRank request received for key %s", req.GetKey())
+
+	members, err := s.storeService.SortedSetRangeByRank(req.GetKey(), int(req.GetStart()), int(req.GetStop()))
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &SortedSetRangeByRankResponse{Ok: true, Members: toRpcScoredMembers(members)}, nil
+}
+
+func (s *RpcServer) SortedSetRangeByScore(_ context.Context, req *SortedSetRangeByScoreRequest) (*SortedSetRangeByScoreResponse, error) {
+	log.Printf("SortedSetRangeByScore request received for key %s", req.GetKey())
+
+	members, err := s.storeService.SortedSetRangeByScore(req.GetKey(), req.GetMin(), req.GetMax(), int(req.GetLimit()))
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &SortedSetRangeByScoreResponse{Ok: true, Members: toRpcScoredMembers(members)}, nil
+}
+
+func (s *RpcServer) SortedSetRank(_ context.Context, req *SortedSetRankRequest) (*SortedSetRankResponse, error) {
+	log.Printf("SortedSetRank request received for key %s", req.GetKey())
+
+	result, err := s.storeService.SortedSetRank(req.GetKey(), req.GetMember())
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &SortedSetRankResponse{
+		Ok:    true,
+		Found: result.Ok,
+		Rank:  int64(result.Rank),
+		Score: result.Score,
+	}, nil
+}
+
+func (s *RpcServer) Batch(_ context.Context, req *BatchRequest) (*BatchResponse, error) {
+	log.Printf("Batch request received for %d keys", len(req.GetOps()))
+
+	keys := make([]string, len(req.GetOps()))
+
+	for i, op := range req.GetOps() {
+		keys[i] = op.GetKey()
+	}
+
+	if _, ok := s.configManager.GetClusterConfig().HashFunction.SameSlot(keys); !ok {
+		return nil, toStatus(service.ErrCrossSlot)
+	}
+
+	ops := make([]*service.BatchOp, len(req.GetOps()))
+
+	for i, op := range req.GetOps() {
+		ops[i] = &service.BatchOp{
+			Key:         op.GetKey(),
+			Delete:      op.GetDelete(),
+			Val:         op.GetVal(),
+			ContentType: op.GetContentType(),
+			TTL:         time.Duration(op.GetTtlMs()) * time.Millisecond,
+			Condition:   toCondition(op.GetCondition()),
+		}
+	}
+
+	result, err := s.storeService.Batch(ops)
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &BatchResponse{
+		Ok:       true,
+		Versions: result.Versions,
+	}, nil
+}
+
+func (s *RpcServer) Scan(req *ScanRequest, stream grpc.ServerStreamingServer[ScanResponse]) error {
+	log.Printf("Scan request received for prefix %q", req.GetPrefix())
+
+	options := &service.ScanOptions{
+		Prefix: req.GetPrefix(),
+		Start:  req.GetStart(),
+		End:    req.GetEnd(),
+		After:  req.GetAfter(),
+		Limit:  int(req.GetLimit()),
+	}
+
+	if req.GetHashSlots() != nil {
+		options.HashSlots = []int{int(req.GetHashSlots().GetStart()), int(req.GetHashSlots().GetEnd())}
+		options.HashFunction = s.configManager.GetClusterConfig().HashFunction
+	}
+
+	result, err := s.storeService.Scan(options)
+
+	if err != nil {
+		return toStatus(err)
+	}
+
+	for _, item := range result.Items {
+		err = stream.Send(&ScanResponse{
+			Item: &ScanItem{
+				Key:         item.Key,
+				Type:        string(item.Type),
+				Val:         item.Val,
+				ContentType: item.ContentType,
+				Version:     item.Version,
+			},
 		})
+
+		if err != nil {
+			return err
+		}
 	}
 
-	otherNodes = append(otherNodes, &NodeConfig{
-		NodeId:         clusterConfig.ThisNode.ID,
-		Address:        clusterConfig.ThisNode.Address,
-		HashSlotsStart: uint32(clusterConfig.ThisNode.HashSlots[0]),
-		HashSlotsEnd:   uint32(clusterConfig.ThisNode.HashSlots[1]),
+	return stream.Send(&ScanResponse{More: result.More})
+}
+
+// Watch streams the changes made on this node until the client goes away.
+func (s *RpcServer) Watch(req *WatchRequest, stream grpc.ServerStreamingServer[WatchResponse]) error {
+	log.Printf("Watch request received for key %q prefix %q after %d", req.GetKey(), req.GetPrefix(), req.GetAfter())
+
+	err := s.storeService.Watch(stream.Context(), &service.WatchOptions{
+		Key:    req.GetKey(),
+		Prefix: req.GetPrefix(),
+		Resume: req.GetResume(),
+		After:  req.GetAfter(),
+	}, func(event *service.WatchEvent) error {
+		return stream.Send(&WatchResponse{
+			Sequence: event.Sequence,
+			Type:     string(event.Type),
+			Key:      event.Key,
+			Version:  event.Version,
+		})
 	})
 
+	if stream.Context().Err() != nil {
+		return nil
+	}
+
+	return toStatus(err)
+}
+
+// ReplicationSource is a store that can stream its data to replicas.
+type ReplicationSource interface {
+	StreamReplication(ctx context.Context, primaryID string, req *ReplicateRequest, send func(r *ReplicateResponse) error) error
+	// ReplicatedFrom returns the primary the store last synced with since it
+	// started, if any, and the LSN of the last entry it has.
+	ReplicatedFrom() (string, uint64)
+}
+
+// Replicate streams this node's data to one of its replicas until the replica
+// goes away.
+func (s *RpcServer) Replicate(req *ReplicateRequest, stream grpc.ServerStreamingServer[ReplicateResponse]) error {
+	log.Printf("Replicate request received from node %s after LSN %d", req.GetReplicaId(), req.GetAfterLsn())
+
+	thisNode := s.configManager.GetClusterConfig().ThisNode
+
+	if thisNode.IsReplica() {
+		return status.Errorf(codes.FailedPrecondition, "node %s is a replica of %s", thisNode.ID, thisNode.ReplicaOf)
+	}
+
+	source, ok := s.storeService.(ReplicationSource)
+
+	if !ok {
+		return status.Error(codes.Unimplemented, "this node's store can't be replicated")
+	}
+
+	err := source.StreamReplication(stream.Context(), thisNode.ID, req, stream.Send)
+
+	if stream.Context().Err() != nil {
+		return nil
+	}
+
+	return toStatus(err)
+}
+
+// CheckNode tells a replica that is thinking of taking over from its primary
+// whether this node can reach the primary either, and how far this node has
+// replicated it.
+func (s *RpcServer) CheckNode(_ context.Context, req *CheckNodeRequest) (*CheckNodeResponse, error) {
+	clusterConfig := s.configManager.GetClusterConfig()
+
+	node := clusterConfig.FindNode(req.GetNodeId())
+
+	if node == nil {
+		return nil, status.Errorf(codes.NotFound, "node %s is not in the cluster", req.GetNodeId())
+	}
+
+	// connecting pings the node, so a node this one has never talked to is
+	// only suspected if it doesn't answer now.
 	_, err := s.rpcClientManager.GetOrCreateRpcClient(&RpcClientConfig{
-		Address: req.GetAddress(),
+		Address: node.Address,
 	})
 
-	if err != nil {
-		return nil, err
+	response := &CheckNodeResponse{
+		Suspected: err != nil || !s.rpcClientManager.Reachable(node.Address),
+		Epoch:     clusterConfig.Epoch,
 	}
 
-	s.configManager.SetClusterConfig(clusterConfig)
+	if source, ok := s.storeService.(ReplicationSource); ok {
+		primaryID, lsn := source.ReplicatedFrom()
 
-	return &GossipResponse{
-		OtherNodes: otherNodes,
-		Ok:         true,
-	}, nil
+		if primaryID == node.ID {
+			response.LastLsn = lsn
+		}
+	}
+
+	log.Printf("Checked node %s, suspected = %t", node.ID, response.Suspected)
+
+	return response, nil
+}
+
+// RaftHost is the raft groups this node is a member of.
+type RaftHost interface {
+	RaftVote(req *RaftVoteRequest) (*RaftVoteResponse, error)
+	RaftAppend(req *RaftAppendRequest) (*RaftAppendResponse, error)
+	// ProposeClusterConfig commits a config with the nodes and replication
+	// mode through the cluster config group, keeping the mode and quorum the
+	// cluster has if it is empty, unless the config has moved past epoch. A
+	// node that isn't the leader forwards it to the leader if forward is set.
+	ProposeClusterConfig(nodes []*configuration.NodeConfig, mode configuration.ReplicationMode, quorum configuration.QuorumConfig, epoch uint64, forward bool) error
+	// BootstrapClusterConfig starts the cluster config group with this node
+	// as its only member.
+	BootstrapClusterConfig() error
+}
+
+func (s *RpcServer) RaftVote(_ context.Context, req *RaftVoteRequest) (*RaftVoteResponse, error) {
+	return s.raftHost.RaftVote(req)
+}
+
+func (s *RpcServer) RaftAppend(_ context.Context, req *RaftAppendRequest) (*RaftAppendResponse, error) {
+	return s.raftHost.RaftAppend(req)
 }
 
+// SetClusterConfig proposes the config to the cluster config group, and
+// returns once it is committed.
 func (s *RpcServer) SetClusterConfig(_ context.Context, req *SetClusterConfigRequest) (*SetClusterConfigResponse, error) {
-	log.Println("Received SetClusterConfig request")
+	log.Printf("Received SetClusterConfig request, forwarded = %t", req.GetForwarded())
 
 	clusterConfig := s.configManager.GetClusterConfig()
 
-	otherNodes := []*configuration.NodeConfig{}
+	err := checkHashFunction(clusterConfig.HashFunction, req.GetHashFunction())
+
+	if err != nil {
+		log.Printf("Refusing to join cluster: %v", err)
+		return nil, err
+	}
+
+	nodes := []*configuration.NodeConfig{}
 
 	for i := range req.OtherNodes {
 		otherNode := req.OtherNodes[i]
 
-		otherNodes = append(otherNodes, &configuration.NodeConfig{
+		nodes = append(nodes, &configuration.NodeConfig{
 			ID:        otherNode.NodeId,
 			Address:   otherNode.Address,
 			HashSlots: []int{int(otherNode.HashSlotsStart), int(otherNode.HashSlotsEnd)},
+			ReplicaOf: otherNode.ReplicaOf,
 		})
 	}
 
-	s.configManager.SetClusterConfig(&configuration.ClusterConfig{
-		ThisNode: &configuration.NodeConfig{
+	included := false
+
+	for _, node := range nodes {
+		included = included || node.ID == clusterConfig.ThisNode.ID
+	}
+
+	if !included {
+		nodes = append(nodes, &configuration.NodeConfig{
 			ID:        clusterConfig.ThisNode.ID,
 			Address:   clusterConfig.ThisNode.Address,
 			HashSlots: []int{int(req.GetThisNode().GetHashSlotsStart()), int(req.GetThisNode().GetHashSlotsEnd())},
-		},
-		OtherNodes: otherNodes,
-	})
+			ReplicaOf: req.GetThisNode().GetReplicaOf(),
+		})
+	}
+
+	mode := configuration.ReplicationMode("")
+	quorum := configuration.QuorumConfig{}
+
+	if req.GetReplicationMode() != "" {
+		mode, err = configuration.ParseReplicationMode(req.GetReplicationMode())
+
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	if req.GetQuorum() != nil {
+		quorum = configuration.QuorumConfig{N: int(req.GetQuorum().GetN()), R: int(req.GetQuorum().GetR()), W: int(req.GetQuorum().GetW())}
+
+		err = quorum.Validate()
+
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	if req.GetBootstrap() {
+		err = s.raftHost.BootstrapClusterConfig()
+
+		if err != nil {
+			log.Printf("Failed to start the cluster config group %v", err)
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+	}
+
+	err = s.raftHost.ProposeClusterConfig(nodes, mode, quorum, req.GetEpoch(), !req.GetForwarded())
+
+	if err != nil {
+		log.Printf("Failed to commit cluster config %v", err)
+		return nil, toStatus(err)
+	}
 
 	return &SetClusterConfigResponse{
 		Ok: true,
@@ -166,28 +677,100 @@ func (s *RpcServer) GetClusterConfig(_ context.Context, req *GetClusterConfigReq
 			Address:        node.Address,
 			HashSlotsStart: uint32(node.HashSlots[0]),
 			HashSlotsEnd:   uint32(node.HashSlots[1]),
+			ReplicaOf:      node.ReplicaOf,
 		})
 	}
 
-	return &GetClusterConfigResponse{
+	response := &GetClusterConfigResponse{
 		Ok: true,
 		ThisNode: &NodeConfig{
 			NodeId:         clusterConfig.ThisNode.ID,
 			Address:        clusterConfig.ThisNode.Address,
 			HashSlotsStart: uint32(clusterConfig.ThisNode.HashSlots[0]),
 			HashSlotsEnd:   uint32(clusterConfig.ThisNode.HashSlots[1]),
+			ReplicaOf:      clusterConfig.ThisNode.ReplicaOf,
 		},
-		OtherNodes: otherNodes,
+		OtherNodes:      otherNodes,
+		HashFunction:    string(clusterConfig.HashFunction.Resolved()),
+		Epoch:           clusterConfig.Epoch,
+		ReplicationMode: string(clusterConfig.ReplicationMode.Resolved()),
+	}
+
+	if clusterConfig.ReplicationMode == configuration.QuorumReplication {
+		quorum := clusterConfig.Quorum.Resolved()
+		response.Quorum = &QuorumConfig{N: uint32(quorum.N), R: uint32(quorum.R), W: uint32(quorum.W)}
+	}
+
+	return response, nil
+}
+
+// QuorumReplica is a store that can keep copies of keys for quorum
+// replication.
+type QuorumReplica interface {
+	ReadVersioned(key string) (*service.VersionedValue, error)
+	// WriteVersioned keeps val unless the store has a newer version, and
+	// returns whether it did.
+	WriteVersioned(key string, val *service.VersionedValue) (bool, error)
+}
+
+// QuorumRead returns this node's copy of a key for a node reading it with
+// quorum replication.
+func (s *RpcServer) QuorumRead(_ context.Context, req *QuorumReadRequest) (*VersionedValue, error) {
+	replica, ok := s.storeService.(QuorumReplica)
+
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "this node's store can't keep quorum copies")
+	}
+
+	val, err := replica.ReadVersioned(req.GetKey())
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &VersionedValue{
+		Found:       val.Found,
+		Deleted:     val.Deleted,
+		Val:         val.Val,
+		ContentType: val.ContentType,
+		Version:     val.Version,
+		ExpiresAt:   val.ExpiresAt,
 	}, nil
 }
 
-func NewRpcServer(storeService service.StoreService, configManager configuration.ConfigurationManager, rpcClientManager RpcClientManager) *grpc.Server {
+// QuorumWrite keeps a copy of a key for a node writing it with quorum
+// replication.
+func (s *RpcServer) QuorumWrite(_ context.Context, req *QuorumWriteRequest) (*QuorumWriteResponse, error) {
+	replica, ok := s.storeService.(QuorumReplica)
+
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "this node's store can't keep quorum copies")
+	}
+
+	applied, err := replica.WriteVersioned(req.GetKey(), &service.VersionedValue{
+		Found:       true,
+		Deleted:     req.GetValue().GetDeleted(),
+		Val:         req.GetValue().GetVal(),
+		ContentType: req.GetValue().GetContentType(),
+		Version:     req.GetValue().GetVersion(),
+		ExpiresAt:   req.GetValue().GetExpiresAt(),
+	})
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &QuorumWriteResponse{Applied: applied}, nil
+}
+
+func NewRpcServer(storeService service.StoreService, configManager configuration.ConfigurationManager, rpcClientManager RpcClientManager, raftHost RaftHost) *grpc.Server {
 	grpcServer := grpc.NewServer()
 
 	RegisterStoreServiceServer(grpcServer, &RpcServer{
 		storeService:     storeService,
 		rpcClientManager: rpcClientManager,
 		configManager:    configManager,
+		raftHost:         raftHost,
 	})
 
 	return grpcServer
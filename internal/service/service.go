@@ -1,12 +1,311 @@
 package service
 
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ethan-stone/go-key-store/internal/hash"
+)
+
+// ErrConflict is returned when a conditional write's condition doesn't hold.
+var ErrConflict = errors.New("condition not met")
+
+// ErrInvalidCondition is returned for a condition with more than one field set.
+var ErrInvalidCondition = errors.New("only one condition can be given")
+
+// ErrCrossSlot is returned for a batch with keys in more than one hash slot.
+var ErrCrossSlot = errors.New("every key in a batch must be in the same hash slot")
+
+// ErrNotInteger is returned when incrementing a key whose value isn't an
+// integer.
+var ErrNotInteger = errors.New("value is not an integer")
+
+// ErrOverflow is returned when an increment would take a value past what an
+// int64 can hold.
+var ErrOverflow = errors.New("increment would overflow")
+
+// ErrWrongType is returned for an operation on a key that holds a different
+// type of value, like pushing onto a key that holds a hash.
+var ErrWrongType = errors.New("key holds the wrong type of value")
+
+// ErrInvalidScore is returned when a sorted set score isn't a number.
+var ErrInvalidScore = errors.New("score is not a number")
+
+// ErrInvalidCursor is returned for a scan or watch cursor that wasn't made by
+// a scan or watch.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ErrSequenceExpired is returned when resuming a watch from a sequence whose
+// changes are no longer kept, either because too many changes have been made
+// since or because the node restarted. The watcher has to read the keys again.
+var ErrSequenceExpired = errors.New("changes after that sequence are no longer kept")
+
+// ErrReadOnlyReplica is returned for a write to a node that is replicating
+// its data from a primary.
+var ErrReadOnlyReplica = errors.New("node is a read only replica")
+
+// ErrNotLeader is returned for a change sent to a raft group member that isn't
+// its leader.
+var ErrNotLeader = errors.New("node is not the leader")
+
+// NotLeaderError is ErrNotLeader with the address of the leader, if the node
+// knows who it is.
+type NotLeaderError struct {
+	LeaderAddress string
+}
+
+func (err *NotLeaderError) Error() string {
+	if err.LeaderAddress == "" {
+		return ErrNotLeader.Error() + ", and there is no leader yet"
+	}
+
+	return ErrNotLeader.Error() + ", the leader is at " + err.LeaderAddress
+}
+
+func (err *NotLeaderError) Is(target error) bool {
+	return target == ErrNotLeader
+}
+
+// ErrEpochChanged is returned for a cluster config made from one that has
+// been replaced since, so a change made from a stale copy can't undo another.
+var ErrEpochChanged = errors.New("the cluster config has changed since the proposed config was made")
+
+// ErrNoQuorum is returned with quorum replication when too few of the nodes on
+// a key's preference list answer a read or write. A write can still have been
+// made on the ones that did.
+var ErrNoQuorum = errors.New("not enough of the key's nodes answered")
+
+// ErrNotQuorumReplicated is returned with quorum replication for anything but
+// getting, putting and deleting strings, which the newest write can't settle.
+var ErrNotQuorumReplicated = errors.New("operation isn't supported with quorum replication")
+
+type ValueType string
+
+const (
+	StringType ValueType = "string"
+	ListType   ValueType = "list"
+	HashType   ValueType = "hash"
+	SetType    ValueType = "set"
+	// SortedSetType is a set of members ordered by score.
+	SortedSetType ValueType = "zset"
+)
+
+// ListSide is the end of a list to push onto or pop from.
+type ListSide string
+
+const (
+	ListLeft  ListSide = "left"
+	ListRight ListSide = "right"
+)
+
 type GetResult struct {
-	Ok  bool
-	Val string
+	Ok          bool
+	Val         []byte
+	ContentType string // whatever the value was put with, empty if nothing was given
+	Version     uint64 // goes up every time the key is put
+}
+
+// Condition makes a put or delete only happen if the key is in a given state.
+// The zero value always holds, and at most one field can be set. An expired
+// key counts as absent.
+type Condition struct {
+	IfVersion uint64 // the key exists at exactly this version
+	IfAbsent  bool
+	IfPresent bool
+}
+
+type PutOptions struct {
+	ContentType string
+	TTL         time.Duration // the key expires this long after the put, 0 for never
+	Condition   Condition
+}
+
+type PutResult struct {
+	Version uint64 // the version the put gave the key
+}
+
+type DeleteOptions struct {
+	Condition Condition
+}
+
+// BatchOp is one put or delete in a batch. TTL and ContentType only apply to
+// puts.
+type BatchOp struct {
+	Key         string
+	Delete      bool // a put when false
+	Val         []byte
+	ContentType string
+	TTL         time.Duration
+	Condition   Condition
+}
+
+type BatchResult struct {
+	Versions []uint64 // the version each op gave its key, 0 for deletes
+}
+
+type IncrResult struct {
+	Val     int64 // the value after the increment
+	Version uint64
+}
+
+type ScoredMember struct {
+	Member string
+	Score  float64
+}
+
+type RankResult struct {
+	Ok    bool // false when the member isn't in the sorted set
+	Rank  int  // 0 for the member with the lowest score
+	Score float64
+}
+
+// VersionedValue is one node's copy of a key with quorum replication. The copy
+// with the highest version is the key's value, and a delete leaves a copy that
+// is Deleted so it can win over older puts.
+type VersionedValue struct {
+	Found       bool // false when the node has never had the key
+	Deleted     bool
+	Val         []byte
+	ContentType string
+	Version     uint64
+	ExpiresAt   int64 // unix milliseconds, 0 for never
+}
+
+type TTLResult struct {
+	Ok      bool // false when the key doesn't exist
+	Expires bool // false when the key never expires
+	TTL     time.Duration
+}
+
+// ScanOptions picks which keys a scan returns. Keys come back in ascending
+// order, and the zero value scans every key.
+type ScanOptions struct {
+	Prefix string
+	Start  string // the first key in the range
+	End    string // the first key after the range, empty for no end
+	After  string // resume after this key, the last key of the previous page
+	Limit  int    // 0 for no limit
+	// HashSlots keeps the scan to keys in a range of hash slots, hashed with
+	// HashFunction. Both ends are inclusive, and nil scans every slot.
+	HashSlots    []int
+	HashFunction hash.Function
+}
+
+type ScanItem struct {
+	Key         string
+	Type        ValueType
+	Val         []byte // only set for strings
+	ContentType string
+	Version     uint64
+}
+
+type ScanResult struct {
+	Items []*ScanItem
+	More  bool // the limit cut the scan short, there are more keys after the last item
+}
+
+type WatchEventType string
+
+const (
+	// WatchStart is always the first event of a watch, and only has the
+	// sequence the watch starts after, so resuming from it misses nothing.
+	WatchStart  WatchEventType = "start"
+	WatchPut    WatchEventType = "put"
+	WatchDelete WatchEventType = "delete"
+)
+
+// WatchEvent is a change to a key. Any write to a key is a put, including
+// pushing onto a list or adding to a set, while changing only its expiry isn't
+// a change.
+type WatchEvent struct {
+	Sequence uint64 // goes up with every change on a node, changes in a batch share one
+	Type     WatchEventType
+	Key      string
+	Version  uint64 // the key's new version, 0 for deletes
+}
+
+// WatchOptions picks which changes a watch gets. Key takes priority over
+// Prefix, and the zero value watches every key.
+type WatchOptions struct {
+	Key    string
+	Prefix string
+	// Resume starts the watch after the sequence After, otherwise it only
+	// gets changes from now on.
+	Resume bool
+	After  uint64
 }
 
 type StoreService interface {
+	// Get returns ErrWrongType for a key that isn't a string.
 	Get(key string) (*GetResult, error)
-	Put(key string, val string) error
-	Delete(key string) error
+	// Put returns ErrConflict if the condition in options doesn't hold.
+	// options can be nil.
+	Put(key string, val []byte, options *PutOptions) (*PutResult, error)
+	// Delete returns ErrConflict if the condition in options doesn't hold.
+	// options can be nil.
+	Delete(key string, options *DeleteOptions) error
+	// Expire sets the key to expire after ttl, straight away if ttl isn't
+	// positive. It returns false if the key doesn't exist.
+	Expire(key string, ttl time.Duration) (bool, error)
+	// Persist removes the key's expiry. It returns false if the key doesn't exist.
+	Persist(key string) (bool, error)
+	TTL(key string) (*TTLResult, error)
+	// Incr adds delta to the key's value, which has to be a base 10 integer.
+	// A missing key counts as 0. It returns ErrNotInteger or ErrOverflow
+	// instead of changing the value.
+	Incr(key string, delta int64) (*IncrResult, error)
+	// Batch applies every op or none of them. It returns ErrConflict if any
+	// op's condition doesn't hold. Every key has to be in the same hash slot,
+	// routing a batch returns ErrCrossSlot otherwise.
+	Batch(ops []*BatchOp) (*BatchResult, error)
+	// Scan returns the keys options picks out, in ascending order. options
+	// can be nil.
+	Scan(options *ScanOptions) (*ScanResult, error)
+	// Watch calls fn with every change options picks out, in order, until
+	// ctx is done or fn returns an error. It returns ErrSequenceExpired if
+	// options.After is too old to resume from.
+	Watch(ctx context.Context, options *WatchOptions, fn func(event *WatchEvent) error) error
+
+	// Lists, hashes, sets and sorted sets are created by the first write to
+	// them and deleted once they are empty. Operations on them return
+	// ErrWrongType for a key that holds another type, and treat a missing key
+	// as empty.
+
+	// ListPush pushes vals onto one side of the list in order, and returns
+	// the list's new length.
+	ListPush(key string, side ListSide, vals [][]byte) (int, error)
+	// ListPop removes and returns up to count values from one side of the list.
+	ListPop(key string, side ListSide, count int) ([][]byte, error)
+	// ListRange returns the values from start to stop, both inclusive.
+	// Negative indexes count back from the end of the list.
+	ListRange(key string, start int, stop int) ([][]byte, error)
+	// HashSet sets the fields, and returns how many of them are new.
+	HashSet(key string, fields map[string][]byte) (int, error)
+	HashGet(key string, field string) ([]byte, bool, error)
+	// HashDelete returns how many of the fields existed.
+	HashDelete(key string, fields []string) (int, error)
+	HashGetAll(key string) (map[string][]byte, error)
+	// SetAdd returns how many of the members are new.
+	SetAdd(key string, members [][]byte) (int, error)
+	// SetRemove returns how many of the members existed.
+	SetRemove(key string, members [][]byte) (int, error)
+	// SetMembers returns the members in ascending order.
+	SetMembers(key string) ([][]byte, error)
+	SetIsMember(key string, member []byte) (bool, error)
+	// SortedSetAdd sets the members' scores, and returns how many of them
+	// are new. Members with the same score are ordered by member.
+	SortedSetAdd(key string, members map[string]float64) (int, error)
+	// SortedSetRemove returns how many of the members existed.
+	SortedSetRemove(key string, members []string) (int, error)
+	// SortedSetIncr adds delta to the member's score, and returns the new
+	// score. A missing member starts at 0.
+	SortedSetIncr(key string, member string, delta float64) (float64, error)
+	// SortedSetRangeByRank returns the members from rank start to stop, both
+	// inclusive. Negative ranks count back from the highest score.
+	SortedSetRangeByRank(key string, start int, stop int) ([]ScoredMember, error)
+	// SortedSetRangeByScore returns up to limit members with a score from min
+	// to max, both inclusive. A limit of 0 returns them all.
+	SortedSetRangeByScore(key string, min float64, max float64, limit int) ([]ScoredMember, error)
+	SortedSetRank(key string, member string) (*RankResult, error)
 }
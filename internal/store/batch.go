@@ -0,0 +1,237 @@
+package store
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/ethan-stone/go-key-store/internal/hash"
+	"github.com/ethan-stone/go-key-store/internal/service"
+	"github.com/ethan-stone/go-key-store/internal/wal"
+)
+
+// batchSlot returns the hash slot every key is in. Batches are kept to one
+// slot rather than one node, so a batch stays on a single node however the
+// slots are moved around later.
+func batchSlot(keys []string, hashFunction hash.Function) (uint32, error) {
+	hashSlot, ok := hashFunction.SameSlot(keys)
+
+	if !ok {
+		return 0, service.ErrCrossSlot
+	}
+
+	return hashSlot, nil
+}
+
+func batchKeys(ops []*service.BatchOp) []string {
+	keys := make([]string, len(ops))
+
+	for i, op := range ops {
+		keys[i] = op.Key
+	}
+
+	return keys
+}
+
+// Batch leaves checking that every key is in one hash slot to the routing in
+// front of it, since the store doesn't know the cluster's hash function.
+func (store *LocalKeyValueStore) Batch(ops []*service.BatchOp) (*service.BatchResult, error) {
+	if len(ops) == 0 {
+		return &service.BatchResult{Versions: []uint64{}}, nil
+	}
+
+	result, pending, err := store.batch(ops)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range ops {
+		if op.Delete {
+			OpLog.AddEntry(&OpLogEntry{OpType: Delete, Key: op.Key, Val: nil})
+		} else {
+			OpLog.AddEntry(&OpLogEntry{OpType: Put, Key: op.Key, Val: op.Val})
+		}
+	}
+
+	err = pending.Wait()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// batch checks every condition against the keys as they were before the
+// batch, then logs the whole batch as one entry and applies it, all under a
+// single hold of the store lock. Readers take the lock too, so they see all
+// of the batch or none of it. Every put in the batch gets the same version.
+func (store *LocalKeyValueStore) batch(ops []*service.BatchOp) (*service.BatchResult, *wal.PendingWrite, error) {
+	store.lockWrites()
+	defer store.unlockWrites()
+
+	for _, op := range ops {
+		err := store.checkCondition(op.Key, op.Condition)
+
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return store.batchLocked(ops)
+}
+
+// batchLocked logs and applies a batch whose conditions have been checked.
+// The caller holds the store lock.
+func (store *LocalKeyValueStore) batchLocked(ops []*service.BatchOp) (*service.BatchResult, *wal.PendingWrite, error) {
+	version := store.nextVersion()
+	now := store.currentTime()
+	entries := make([]*wal.WalEntryWrite, len(ops))
+	values := make([]*storedValue, len(ops))
+	result := &service.BatchResult{Versions: make([]uint64, len(ops))}
+
+	for i, op := range ops {
+		if op.Delete {
+			entries[i] = &wal.WalEntryWrite{
+				OpType:    wal.Del,
+				KeyLength: int32(len(op.Key)),
+				KeyBytes:  []byte(op.Key),
+			}
+
+			continue
+		}
+
+		values[i] = &storedValue{
+			data:        op.Val,
+			contentType: op.ContentType,
+			version:     version,
+			expiresAt:   expiryFor(now, op.TTL),
+		}
+
+		valueBytes, err := values[i].encode()
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		entries[i] = &wal.WalEntryWrite{
+			OpType:      wal.Put,
+			KeyLength:   int32(len(op.Key)),
+			ValueLength: int32(len(valueBytes)),
+			KeyBytes:    []byte(op.Key),
+			ValueBytes:  &valueBytes,
+		}
+
+		result.Versions[i] = version
+	}
+
+	batchBytes, err := wal.EncodeBatch(entries)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pending, err := store.logAndApplyLocked(&wal.WalEntryWrite{
+		OpType:      wal.Batch,
+		KeyLength:   0,
+		ValueLength: int32(len(batchBytes)),
+		KeyBytes:    []byte{},
+		ValueBytes:  &batchBytes,
+	}, func() error {
+		events := make([]*service.WatchEvent, len(ops))
+		priors := make([]*priorValue, 0, len(ops))
+
+		for i, op := range ops {
+			events[i] = &service.WatchEvent{Sequence: version, Key: op.Key}
+
+			prior, err := store.priorValueLocked(op.Key)
+
+			if err != nil {
+				return store.undoBatchLocked(priors, err)
+			}
+
+			priors = append(priors, prior)
+
+			if op.Delete {
+				err = store.engine.Delete(op.Key)
+
+				if err != nil {
+					return store.undoBatchLocked(priors, err)
+				}
+
+				store.trackExpiry(op.Key, 0)
+				store.dropSortedSet(op.Key)
+				events[i].Type = service.WatchDelete
+
+				continue
+			}
+
+			err = store.engine.Put(op.Key, string(*entries[i].ValueBytes))
+
+			if err != nil {
+				return store.undoBatchLocked(priors, err)
+			}
+
+			store.trackExpiry(op.Key, values[i].expiresAt)
+			store.dropSortedSet(op.Key)
+			events[i].Type = service.WatchPut
+			events[i].Version = version
+		}
+
+		store.changes.publish(events...)
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return result, pending, nil
+}
+
+// priorValue is what a key held before a batch changed it.
+type priorValue struct {
+	key       string
+	val       string
+	found     bool
+	expiresAt int64
+}
+
+func (store *LocalKeyValueStore) priorValueLocked(key string) (*priorValue, error) {
+	val, found, err := store.engine.Get(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &priorValue{key: key, val: val, found: found, expiresAt: store.expires[key]}, nil
+}
+
+// undoBatchLocked puts back the keys a batch changed before the engine failed
+// part way through it, newest first, so readers don't see half a batch. The
+// batch is only logged once it has been applied, so it isn't replayed on the
+// next start up either.
+func (store *LocalKeyValueStore) undoBatchLocked(priors []*priorValue, cause error) error {
+	for i := len(priors) - 1; i >= 0; i-- {
+		prior := priors[i]
+
+		var err error
+
+		if prior.found {
+			err = store.engine.Put(prior.key, prior.val)
+		} else {
+			err = store.engine.Delete(prior.key)
+		}
+
+		if err != nil {
+			log.Printf("Failed to undo a batch that failed part way, %s is left changed %v", prior.key, err)
+			return fmt.Errorf("%w, and undoing the batch failed: %v", cause, err)
+		}
+
+		store.trackExpiry(prior.key, prior.expiresAt)
+		store.dropSortedSet(prior.key)
+	}
+
+	return cause
+}
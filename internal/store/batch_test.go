@@ -0,0 +1,223 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ethan-stone/go-key-store/internal/engine"
+	"github.com/ethan-stone/go-key-store/internal/service"
+)
+
+// sameSlotKeys returns n keys that share a hash tag.
+func sameSlotKeys(n int) []string {
+	keys := make([]string, n)
+
+	for i := range keys {
+		keys[i] = fmt.Sprintf("{batch}:key-%d", i)
+	}
+
+	return keys
+}
+
+func TestBatchShouldApplyEveryOp(t *testing.T) {
+	store := &LocalKeyValueStore{
+		engine: engine.NewMemoryEngine(),
+	}
+
+	keys := sameSlotKeys(3)
+
+	store.Put(keys[2], []byte("old"), nil)
+
+	result, err := store.Batch([]*service.BatchOp{
+		{Key: keys[0], Val: []byte("1")},
+		{Key: keys[1], Val: []byte("2"), ContentType: "text/plain"},
+		{Key: keys[2], Delete: true},
+	})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when applying batch %v", err)
+	}
+
+	if result.Versions[0] == 0 || result.Versions[0] != result.Versions[1] || result.Versions[2] != 0 {
+		t.Errorf("Expected both puts to share a version and the delete to have none, got %v", result.Versions)
+	}
+
+	for i, val := range []string{"1", "2"} {
+		r, _ := store.Get(keys[i])
+
+		if !r.Ok || string(r.Val) != val || r.Version != result.Versions[i] {
+			t.Errorf("Expected %s to be %s at version %d, got %v", keys[i], val, result.Versions[i], r)
+		}
+	}
+
+	if r, _ := store.Get(keys[2]); r.Ok {
+		t.Errorf("Did not expect to find key %s", keys[2])
+	}
+}
+
+func TestBatchShouldApplyNothingWhenAConditionFails(t *testing.T) {
+	store := &LocalKeyValueStore{
+		engine: engine.NewMemoryEngine(),
+	}
+
+	keys := sameSlotKeys(2)
+
+	store.Put(keys[1], []byte("old"), nil)
+
+	_, err := store.Batch([]*service.BatchOp{
+		{Key: keys[0], Val: []byte("1")},
+		{Key: keys[1], Val: []byte("2"), Condition: service.Condition{IfAbsent: true}},
+	})
+
+	if !errors.Is(err, service.ErrConflict) {
+		t.Errorf("Expected a conflict, got %v", err)
+	}
+
+	if r, _ := store.Get(keys[0]); r.Ok {
+		t.Errorf("Did not expect any of a failed batch to be applied, found %s", keys[0])
+	}
+}
+
+// failingEngine fails every put of one key.
+type failingEngine struct {
+	*engine.MemoryEngine
+	key string
+}
+
+func (e *failingEngine) Put(key string, val string) error {
+	if key == e.key {
+		return errors.New("disk full")
+	}
+
+	return e.MemoryEngine.Put(key, val)
+}
+
+func TestBatchShouldBeUndoneWhenTheEngineFailsPartWay(t *testing.T) {
+	keys := sameSlotKeys(4)
+
+	store := &LocalKeyValueStore{
+		engine: &failingEngine{MemoryEngine: engine.NewMemoryEngine(), key: keys[3]},
+	}
+
+	store.Put(keys[1], []byte("old"), nil)
+	store.Put(keys[2], []byte("kept"), &service.PutOptions{TTL: time.Hour})
+
+	_, err := store.Batch([]*service.BatchOp{
+		{Key: keys[0], Val: []byte("1"), TTL: time.Hour},
+		{Key: keys[1], Val: []byte("2")},
+		{Key: keys[2], Delete: true},
+		{Key: keys[3], Val: []byte("3")},
+	})
+
+	if err == nil {
+		t.Fatalf("Expected the batch to fail when the engine does")
+	}
+
+	if r, _ := store.Get(keys[0]); r.Ok {
+		t.Errorf("Did not expect %s to be left from a failed batch", keys[0])
+	}
+
+	if r, _ := store.Get(keys[1]); !r.Ok || string(r.Val) != "old" {
+		t.Errorf("Expected %s to be put back to old, got %v", keys[1], r)
+	}
+
+	if r, _ := store.Get(keys[2]); !r.Ok || string(r.Val) != "kept" {
+		t.Errorf("Expected the deleted %s to be put back, got %v", keys[2], r)
+	}
+
+	if _, ok := store.expires[keys[0]]; ok || store.expires[keys[2]] == 0 {
+		t.Errorf("Expected the expiries to be put back too, got %v", store.expires)
+	}
+}
+
+func TestShouldRecoverBatchFromWal(t *testing.T) {
+	dataDir := t.TempDir()
+
+	store, err := InitializeDurableLocalKeyValueStore(&DurableLocalKeyValueStoreConfig{DataDir: dataDir})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when initializing store %v", err)
+	}
+
+	keys := sameSlotKeys(2)
+
+	store.Put(keys[1], []byte("old"), nil)
+
+	_, err = store.Batch([]*service.BatchOp{
+		{Key: keys[0], Val: []byte("1")},
+		{Key: keys[1], Delete: true},
+	})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when applying batch %v", err)
+	}
+
+	store.Close()
+
+	recovered, err := InitializeDurableLocalKeyValueStore(&DurableLocalKeyValueStoreConfig{DataDir: dataDir})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when recovering store %v", err)
+	}
+
+	defer recovered.Close()
+
+	if r, _ := recovered.Get(keys[0]); !r.Ok || string(r.Val) != "1" {
+		t.Errorf("Expected %s to be 1, got %v", keys[0], r)
+	}
+
+	if r, _ := recovered.Get(keys[1]); r.Ok {
+		t.Errorf("Did not expect to find key %s", keys[1])
+	}
+}
+
+func TestFailedBatchShouldNotBeReplayed(t *testing.T) {
+	dataDir := t.TempDir()
+
+	store, err := InitializeDurableLocalKeyValueStore(&DurableLocalKeyValueStoreConfig{DataDir: dataDir})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when initializing store %v", err)
+	}
+
+	keys := sameSlotKeys(3)
+
+	store.engine = &failingEngine{MemoryEngine: store.engine.(*engine.MemoryEngine), key: keys[1]}
+
+	_, err = store.Batch([]*service.BatchOp{
+		{Key: keys[0], Val: []byte("1")},
+		{Key: keys[1], Val: []byte("2")},
+	})
+
+	if err == nil {
+		t.Fatalf("Expected the batch to fail when the engine does")
+	}
+
+	_, err = store.Put(keys[2], []byte("3"), nil)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when putting after the failed batch %v", err)
+	}
+
+	store.Close()
+
+	recovered, err := InitializeDurableLocalKeyValueStore(&DurableLocalKeyValueStoreConfig{DataDir: dataDir})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when recovering store %v", err)
+	}
+
+	defer recovered.Close()
+
+	for _, key := range keys[:2] {
+		if r, _ := recovered.Get(key); r.Ok {
+			t.Errorf("Did not expect %s from the failed batch to be replayed, got %v", key, r)
+		}
+	}
+
+	if r, _ := recovered.Get(keys[2]); !r.Ok || string(r.Val) != "3" {
+		t.Errorf("Expected %s to be 3, got %v", keys[2], r)
+	}
+}
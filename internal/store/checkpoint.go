@@ -0,0 +1,81 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const checkpointFileName = "CHECKPOINT"
+
+// checkpoint records that a persistent engine has everything before a WAL
+// position on disk, so recovery only has to replay the WAL from there.
+type checkpoint struct {
+	Engine     string `json:"engine"` // a checkpoint is only used by the engine that wrote it
+	WalSegment uint64 `json:"walSegment"`
+	WalOffset  int64  `json:"walOffset"`
+}
+
+func readCheckpoint(dataDir string) (*checkpoint, error) {
+	contents, err := os.ReadFile(filepath.Join(dataDir, checkpointFileName))
+
+	if err != nil {
+		return nil, err
+	}
+
+	var cp checkpoint
+
+	err = json.Unmarshal(contents, &cp)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid checkpoint: %w", err)
+	}
+
+	return &cp, nil
+}
+
+// writeCheckpoint replaces the checkpoint with a rename, so a crash leaves
+// either the old or the new one.
+func writeCheckpoint(dataDir string, cp *checkpoint) error {
+	contents, err := json.Marshal(cp)
+
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(dataDir, checkpointFileName)
+	tmpPath := path + ".tmp"
+
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmpPath)
+
+	_, err = file.Write(contents)
+
+	if err == nil {
+		err = file.Sync()
+	}
+
+	closeErr := file.Close()
+
+	if err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmpPath, path)
+
+	if err != nil {
+		return err
+	}
+
+	return syncDir(dataDir)
+}
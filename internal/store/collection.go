@@ -0,0 +1,164 @@
+package store
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ethan-stone/go-key-store/internal/service"
+	"github.com/ethan-stone/go-key-store/internal/wal"
+)
+
+// Lists, hashes and sets are stored as a list of elements.
+//
+// | count (4) | then for every element | length (4) | bytes |
+//
+// A list's elements are its values from left to right. A hash's elements are
+// its fields and values one after the other, sorted by field, and a set's
+// elements are its members, sorted. Every change is logged to the WAL as a put
+// of the whole value it ends up with, so replaying a change doesn't depend on
+// what was there before. That makes writes to big collections expensive, so
+// keep them small.
+func encodeElements(elements [][]byte) []byte {
+	size := 4
+
+	for _, element := range elements {
+		size += 4 + len(element)
+	}
+
+	buf := make([]byte, 0, size)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(elements)))
+
+	for _, element := range elements {
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(element)))
+		buf = append(buf, element...)
+	}
+
+	return buf
+}
+
+func decodeElements(data []byte) ([][]byte, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("collection is too short")
+	}
+
+	count := binary.LittleEndian.Uint32(data)
+	offset := 4
+	elements := make([][]byte, 0, count)
+
+	for range count {
+		if len(data)-offset < 4 {
+			return nil, fmt.Errorf("collection is cut off")
+		}
+
+		length := int(binary.LittleEndian.Uint32(data[offset:]))
+		offset += 4
+
+		if len(data)-offset < length {
+			return nil, fmt.Errorf("collection is cut off")
+		}
+
+		elements = append(elements, data[offset:offset+length])
+		offset += length
+	}
+
+	return elements, nil
+}
+
+// elementsLocked returns the elements of the collection at key, and its
+// current value if it exists. A missing key is an empty collection. The
+// caller holds the store lock.
+func (store *LocalKeyValueStore) elementsLocked(key string, valueType byte) ([][]byte, *storedValue, error) {
+	current, found, err := store.lookup(key)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !found {
+		return [][]byte{}, nil, nil
+	}
+
+	if current.valueType != valueType {
+		return nil, nil, service.ErrWrongType
+	}
+
+	elements, err := decodeElements(current.data)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not read %s %s: %w", valueTypes[valueType], key, err)
+	}
+
+	return elements, current, nil
+}
+
+// readCollection returns the elements of the collection at key.
+func (store *LocalKeyValueStore) readCollection(key string, valueType byte) ([][]byte, error) {
+	err := store.waitForReadIndex()
+
+	if err != nil {
+		return nil, err
+	}
+
+	store.RLock()
+	defer store.RUnlock()
+
+	elements, _, err := store.elementsLocked(key, valueType)
+
+	return elements, err
+}
+
+// updateCollection hands the elements of the collection at key to update, and
+// writes back what it returns if it says they changed. A collection left
+// empty is deleted. The collection keeps its expiry.
+func (store *LocalKeyValueStore) updateCollection(key string, valueType byte, update func(elements [][]byte) ([][]byte, bool, error)) error {
+	pending, entry, err := store.updateCollectionLocked(key, valueType, update)
+
+	if err != nil || entry == nil {
+		return err
+	}
+
+	OpLog.AddEntry(entry)
+
+	return pending.Wait()
+}
+
+func (store *LocalKeyValueStore) updateCollectionLocked(key string, valueType byte, update func(elements [][]byte) ([][]byte, bool, error)) (*wal.PendingWrite, *OpLogEntry, error) {
+	store.lockWrites()
+	defer store.unlockWrites()
+
+	elements, current, err := store.elementsLocked(key, valueType)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	elements, changed, err := update(elements)
+
+	if err != nil || !changed {
+		return nil, nil, err
+	}
+
+	if len(elements) == 0 {
+		if current == nil {
+			return nil, nil, nil
+		}
+
+		pending, err := store.deleteLocked(key)
+
+		return pending, &OpLogEntry{OpType: Delete, Key: key}, err
+	}
+
+	value := &storedValue{
+		data:      encodeElements(elements),
+		version:   store.nextVersion(),
+		valueType: valueType,
+	}
+
+	if current != nil {
+		value.expiresAt = current.expiresAt
+	}
+
+	pending, err := store.writeLocked(key, value)
+
+	return pending, &OpLogEntry{OpType: Put, Key: key, Val: value.data}, err
+}
@@ -0,0 +1,216 @@
+package store
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/ethan-stone/go-key-store/internal/engine"
+	"github.com/ethan-stone/go-key-store/internal/service"
+)
+
+func asStrings(vals [][]byte) []string {
+	out := make([]string, len(vals))
+
+	for i, val := range vals {
+		out[i] = string(val)
+	}
+
+	return out
+}
+
+func bytesOf(vals ...string) [][]byte {
+	out := make([][]byte, len(vals))
+
+	for i, val := range vals {
+		out[i] = []byte(val)
+	}
+
+	return out
+}
+
+func TestShouldPushPopAndRangeLists(t *testing.T) {
+	store := &LocalKeyValueStore{
+		engine: engine.NewMemoryEngine(),
+	}
+
+	length, err := store.ListPush("l", service.ListRight, bytesOf("c", "d"))
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when pushing %v", err)
+	}
+
+	length, _ = store.ListPush("l", service.ListLeft, bytesOf("b", "a"))
+
+	if length != 4 {
+		t.Errorf("Expected a length of 4, got %d", length)
+	}
+
+	vals, err := store.ListRange("l", 0, -1)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when getting a range %v", err)
+	}
+
+	if got := asStrings(vals); !slices.Equal(got, []string{"a", "b", "c", "d"}) {
+		t.Errorf("Expected a, b, c, d, got %v", got)
+	}
+
+	if got, _ := store.ListRange("l", -3, 1); !slices.Equal(asStrings(got), []string{"b"}) {
+		t.Errorf("Expected b, got %v", asStrings(got))
+	}
+
+	popped, _ := store.ListPop("l", service.ListRight, 2)
+
+	if got := asStrings(popped); !slices.Equal(got, []string{"d", "c"}) {
+		t.Errorf("Expected to pop d, c, got %v", got)
+	}
+
+	store.ListPop("l", service.ListLeft, 5)
+
+	if r, _ := store.Get("l"); r.Ok {
+		t.Errorf("Expected an emptied list to be deleted")
+	}
+}
+
+func TestShouldSetGetAndDeleteHashFields(t *testing.T) {
+	store := &LocalKeyValueStore{
+		engine: engine.NewMemoryEngine(),
+	}
+
+	added, err := store.HashSet("h", map[string][]byte{"a": []byte("1"), "b": []byte("2")})
+
+	if err != nil || added != 2 {
+		t.Fatalf("Did not expect an error when setting fields %v %d", err, added)
+	}
+
+	added, _ = store.HashSet("h", map[string][]byte{"a": []byte("3"), "c": []byte("4")})
+
+	if added != 1 {
+		t.Errorf("Expected 1 new field, got %d", added)
+	}
+
+	val, found, _ := store.HashGet("h", "a")
+
+	if !found || string(val) != "3" {
+		t.Errorf("Expected a to be 3, got %s %t", val, found)
+	}
+
+	deleted, _ := store.HashDelete("h", []string{"a", "missing"})
+
+	if deleted != 1 {
+		t.Errorf("Expected 1 deleted field, got %d", deleted)
+	}
+
+	fields, _ := store.HashGetAll("h")
+
+	if len(fields) != 2 || string(fields["b"]) != "2" || string(fields["c"]) != "4" {
+		t.Errorf("Expected b and c to be left, got %v", fields)
+	}
+}
+
+func TestShouldAddRemoveAndCheckSetMembers(t *testing.T) {
+	store := &LocalKeyValueStore{
+		engine: engine.NewMemoryEngine(),
+	}
+
+	added, err := store.SetAdd("s", bytesOf("b", "a", "b"))
+
+	if err != nil || added != 2 {
+		t.Fatalf("Did not expect an error when adding members %v %d", err, added)
+	}
+
+	members, _ := store.SetMembers("s")
+
+	if got := asStrings(members); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("Expected a, b, got %v", got)
+	}
+
+	if ok, _ := store.SetIsMember("s", []byte("a")); !ok {
+		t.Errorf("Expected a to be a member")
+	}
+
+	removed, _ := store.SetRemove("s", bytesOf("a", "c"))
+
+	if removed != 1 {
+		t.Errorf("Expected 1 removed member, got %d", removed)
+	}
+
+	if ok, _ := store.SetIsMember("s", []byte("a")); ok {
+		t.Errorf("Did not expect a to be a member after removing it")
+	}
+}
+
+func TestShouldRefuseOperationsOnTheWrongType(t *testing.T) {
+	store := &LocalKeyValueStore{
+		engine: engine.NewMemoryEngine(),
+	}
+
+	store.Put("str", []byte("1"), nil)
+	store.ListPush("list", service.ListRight, bytesOf("a"))
+
+	if _, err := store.ListPush("str", service.ListRight, bytesOf("a")); !errors.Is(err, service.ErrWrongType) {
+		t.Errorf("Expected a wrong type error pushing onto a string, got %v", err)
+	}
+
+	if _, err := store.HashGetAll("list"); !errors.Is(err, service.ErrWrongType) {
+		t.Errorf("Expected a wrong type error reading a list as a hash, got %v", err)
+	}
+
+	if _, err := store.Get("list"); !errors.Is(err, service.ErrWrongType) {
+		t.Errorf("Expected a wrong type error getting a list, got %v", err)
+	}
+
+	if _, err := store.Incr("list", 1); !errors.Is(err, service.ErrWrongType) {
+		t.Errorf("Expected a wrong type error incrementing a list, got %v", err)
+	}
+
+	store.Put("list", []byte("now a string"), nil)
+
+	if r, err := store.Get("list"); err != nil || string(r.Val) != "now a string" {
+		t.Errorf("Expected a put to replace a list, got %v %v", r, err)
+	}
+}
+
+func TestShouldRecoverCollectionsFromSnapshotAndWal(t *testing.T) {
+	dataDir := t.TempDir()
+
+	store, err := InitializeDurableLocalKeyValueStore(&DurableLocalKeyValueStoreConfig{DataDir: dataDir})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when initializing store %v", err)
+	}
+
+	store.ListPush("l", service.ListRight, bytesOf("a", "b"))
+	store.HashSet("h", map[string][]byte{"f": []byte("1")})
+
+	err = store.Snapshot()
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when snapshotting store %v", err)
+	}
+
+	store.ListPop("l", service.ListLeft, 1)
+	store.SetAdd("s", bytesOf("x"))
+	store.Close()
+
+	recovered, err := InitializeDurableLocalKeyValueStore(&DurableLocalKeyValueStoreConfig{DataDir: dataDir})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when recovering store %v", err)
+	}
+
+	defer recovered.Close()
+
+	if vals, _ := recovered.ListRange("l", 0, -1); !slices.Equal(asStrings(vals), []string{"b"}) {
+		t.Errorf("Expected l to be b, got %v", asStrings(vals))
+	}
+
+	if val, _, _ := recovered.HashGet("h", "f"); string(val) != "1" {
+		t.Errorf("Expected h.f to be 1, got %s", val)
+	}
+
+	if ok, _ := recovered.SetIsMember("s", []byte("x")); !ok {
+		t.Errorf("Expected x to be in s")
+	}
+}
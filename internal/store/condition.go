@@ -0,0 +1,67 @@
+package store
+
+import (
+	"github.com/ethan-stone/go-key-store/internal/service"
+)
+
+// nextVersion is the version the next put gives its key. With a WAL it is the
+// LSN the put's entry is about to get, which only ever goes up, even across
+// deletes and restarts, so a key that is deleted and put again never repeats
+// an old version. The caller holds the store lock, which keeps anything else
+// from taking that LSN first.
+func (store *LocalKeyValueStore) nextVersion() uint64 {
+	if store.wal != nil {
+		return store.wal.NextLSN()
+	}
+
+	store.lastVersion++
+
+	return store.lastVersion
+}
+
+// checkCondition returns service.ErrConflict unless the condition holds for
+// the key right now. The caller holds the store lock.
+func (store *LocalKeyValueStore) checkCondition(key string, condition service.Condition) error {
+	err := validateCondition(condition)
+
+	if err != nil {
+		return err
+	}
+
+	if condition == (service.Condition{}) {
+		return nil
+	}
+
+	current, found, err := store.lookup(key)
+
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case condition.IfAbsent && found:
+		return service.ErrConflict
+	case condition.IfPresent && !found:
+		return service.ErrConflict
+	case condition.IfVersion != 0 && (!found || current.version != condition.IfVersion):
+		return service.ErrConflict
+	}
+
+	return nil
+}
+
+func validateCondition(condition service.Condition) error {
+	set := 0
+
+	for _, isSet := range []bool{condition.IfVersion != 0, condition.IfAbsent, condition.IfPresent} {
+		if isSet {
+			set++
+		}
+	}
+
+	if set > 1 {
+		return service.ErrInvalidCondition
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package store
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethan-stone/go-key-store/internal/engine"
+	"github.com/ethan-stone/go-key-store/internal/service"
+)
+
+func TestShouldOnlyPutWhenConditionHolds(t *testing.T) {
+	store := &LocalKeyValueStore{
+		engine: engine.NewMemoryEngine(),
+	}
+
+	first, err := store.Put("a", []byte("1"), &service.PutOptions{Condition: service.Condition{IfAbsent: true}})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when putting a missing key if absent %v", err)
+	}
+
+	_, err = store.Put("a", []byte("2"), &service.PutOptions{Condition: service.Condition{IfAbsent: true}})
+
+	if !errors.Is(err, service.ErrConflict) {
+		t.Errorf("Expected a conflict when putting an existing key if absent, got %v", err)
+	}
+
+	second, err := store.Put("a", []byte("2"), &service.PutOptions{Condition: service.Condition{IfVersion: first.Version}})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when putting at the current version %v", err)
+	}
+
+	if second.Version <= first.Version {
+		t.Errorf("Expected the version to go up from %d, got %d", first.Version, second.Version)
+	}
+
+	_, err = store.Put("a", []byte("3"), &service.PutOptions{Condition: service.Condition{IfVersion: first.Version}})
+
+	if !errors.Is(err, service.ErrConflict) {
+		t.Errorf("Expected a conflict when putting at a stale version, got %v", err)
+	}
+
+	_, err = store.Put("b", []byte("1"), &service.PutOptions{Condition: service.Condition{IfPresent: true}})
+
+	if !errors.Is(err, service.ErrConflict) {
+		t.Errorf("Expected a conflict when putting a missing key if present, got %v", err)
+	}
+
+	_, err = store.Put("a", []byte("3"), &service.PutOptions{Condition: service.Condition{IfAbsent: true, IfPresent: true}})
+
+	if !errors.Is(err, service.ErrInvalidCondition) {
+		t.Errorf("Expected an invalid condition error, got %v", err)
+	}
+
+	r, _ := store.Get("a")
+
+	if string(r.Val) != "2" || r.Version != second.Version {
+		t.Errorf("Expected a to be 2 at version %d, got %v", second.Version, r)
+	}
+}
+
+func TestShouldOnlyDeleteWhenConditionHolds(t *testing.T) {
+	store := &LocalKeyValueStore{
+		engine: engine.NewMemoryEngine(),
+	}
+
+	first, _ := store.Put("a", []byte("1"), nil)
+	store.Put("a", []byte("2"), nil)
+
+	err := store.Delete("a", &service.DeleteOptions{Condition: service.Condition{IfVersion: first.Version}})
+
+	if !errors.Is(err, service.ErrConflict) {
+		t.Errorf("Expected a conflict when deleting at a stale version, got %v", err)
+	}
+
+	err = store.Delete("a", &service.DeleteOptions{Condition: service.Condition{IfPresent: true}})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when deleting an existing key if present %v", err)
+	}
+
+	if r, _ := store.Get("a"); r.Ok {
+		t.Errorf("Did not expect to find key %s", "a")
+	}
+}
+
+func TestVersionsShouldKeepGoingUpAcrossDeletesAndRestarts(t *testing.T) {
+	dataDir := t.TempDir()
+
+	store, err := InitializeDurableLocalKeyValueStore(&DurableLocalKeyValueStoreConfig{DataDir: dataDir})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when initializing store %v", err)
+	}
+
+	first, _ := store.Put("a", []byte("1"), nil)
+	store.Delete("a", nil)
+	store.Close()
+
+	recovered, err := InitializeDurableLocalKeyValueStore(&DurableLocalKeyValueStoreConfig{DataDir: dataDir})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when recovering store %v", err)
+	}
+
+	defer recovered.Close()
+
+	second, err := recovered.Put("a", []byte("2"), nil)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when putting into store %v", err)
+	}
+
+	if second.Version <= first.Version {
+		t.Errorf("Expected a new version above %d, got %d", first.Version, second.Version)
+	}
+
+	r, _ := recovered.Get("a")
+
+	if r.Version != second.Version {
+		t.Errorf("Expected version %d, got %d", second.Version, r.Version)
+	}
+}
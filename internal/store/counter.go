@@ -0,0 +1,87 @@
+package store
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/ethan-stone/go-key-store/internal/service"
+	"github.com/ethan-stone/go-key-store/internal/wal"
+)
+
+// counters that don't exist yet are created with this content type.
+const counterContentType = "text/plain"
+
+// Incr is logged to the WAL as a put of the value it ends up with, so
+// replaying it doesn't depend on what the value was before.
+func (store *LocalKeyValueStore) Incr(key string, delta int64) (*service.IncrResult, error) {
+	value, pending, err := store.incr(key, delta)
+
+	if err != nil {
+		return nil, err
+	}
+
+	OpLog.AddEntry(&OpLogEntry{
+		OpType: Put,
+		Key:    key,
+		Val:    value.data,
+	})
+
+	err = pending.Wait()
+
+	if err != nil {
+		return nil, err
+	}
+
+	n, _ := strconv.ParseInt(string(value.data), 10, 64)
+
+	return &service.IncrResult{Val: n, Version: value.version}, nil
+}
+
+// incr reads the current value and writes the new one under a single hold of
+// the store lock, so concurrent increments can't lose each other's updates.
+// The key keeps its content type and expiry.
+func (store *LocalKeyValueStore) incr(key string, delta int64) (*storedValue, *wal.PendingWrite, error) {
+	store.lockWrites()
+	defer store.unlockWrites()
+
+	current, found, err := store.lookup(key)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	n := int64(0)
+
+	if found && current.valueType != stringType {
+		return nil, nil, service.ErrWrongType
+	}
+
+	if found {
+		n, err = strconv.ParseInt(string(current.data), 10, 64)
+
+		if err != nil {
+			return nil, nil, service.ErrNotInteger
+		}
+	} else {
+		current = &storedValue{contentType: counterContentType}
+	}
+
+	if (delta > 0 && n > math.MaxInt64-delta) || (delta < 0 && n < math.MinInt64-delta) {
+		return nil, nil, service.ErrOverflow
+	}
+
+	value := &storedValue{
+		data:        []byte(strconv.FormatInt(n+delta, 10)),
+		contentType: current.contentType,
+		version:     store.nextVersion(),
+		expiresAt:   current.expiresAt,
+	}
+
+	pending, err := store.writeLocked(key, value)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return value, pending, nil
+}
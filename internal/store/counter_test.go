@@ -0,0 +1,119 @@
+package store
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethan-stone/go-key-store/internal/engine"
+	"github.com/ethan-stone/go-key-store/internal/service"
+)
+
+func TestIncrShouldStartAtZeroAndKeepTheExpiry(t *testing.T) {
+	store, _ := newExpiringStore()
+
+	result, err := store.Incr("a", 5)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when incrementing a missing key %v", err)
+	}
+
+	if result.Val != 5 {
+		t.Errorf("Expected a to be 5, got %d", result.Val)
+	}
+
+	store.Expire("a", time.Minute)
+
+	result, err = store.Incr("a", -7)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when decrementing %v", err)
+	}
+
+	r, _ := store.Get("a")
+
+	if result.Val != -2 || string(r.Val) != "-2" || r.Version != result.Version {
+		t.Errorf("Expected a to be -2 at version %d, got %d and %v", result.Version, result.Val, r)
+	}
+
+	if ttl, _ := store.TTL("a"); !ttl.Expires {
+		t.Errorf("Expected a to keep its expiry, got %v", ttl)
+	}
+}
+
+func TestIncrShouldRefuseNonIntegersAndOverflow(t *testing.T) {
+	store := &LocalKeyValueStore{
+		engine: engine.NewMemoryEngine(),
+	}
+
+	store.Put("a", []byte("abc"), nil)
+
+	_, err := store.Incr("a", 1)
+
+	if !errors.Is(err, service.ErrNotInteger) {
+		t.Errorf("Expected a not an integer error, got %v", err)
+	}
+
+	store.Incr("b", math.MaxInt64)
+
+	_, err = store.Incr("b", 1)
+
+	if !errors.Is(err, service.ErrOverflow) {
+		t.Errorf("Expected an overflow error, got %v", err)
+	}
+
+	if r, _ := store.Get("a"); string(r.Val) != "abc" {
+		t.Errorf("Did not expect a failed increment to change a, got %s", r.Val)
+	}
+}
+
+func TestIncrShouldNotLoseConcurrentUpdates(t *testing.T) {
+	store := &LocalKeyValueStore{
+		engine: engine.NewMemoryEngine(),
+	}
+
+	var wg sync.WaitGroup
+
+	for range 50 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			store.Incr("a", 1)
+		}()
+	}
+
+	wg.Wait()
+
+	if r, _ := store.Get("a"); string(r.Val) != "50" {
+		t.Errorf("Expected a to be 50, got %s", r.Val)
+	}
+}
+
+func TestShouldRecoverIncrFromWal(t *testing.T) {
+	dataDir := t.TempDir()
+
+	store, err := InitializeDurableLocalKeyValueStore(&DurableLocalKeyValueStoreConfig{DataDir: dataDir})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when initializing store %v", err)
+	}
+
+	store.Incr("a", 2)
+	store.Incr("a", 3)
+	store.Close()
+
+	recovered, err := InitializeDurableLocalKeyValueStore(&DurableLocalKeyValueStoreConfig{DataDir: dataDir})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when recovering store %v", err)
+	}
+
+	defer recovered.Close()
+
+	if r, _ := recovered.Get("a"); string(r.Val) != "5" || r.ContentType != "text/plain" {
+		t.Errorf("Expected a to be 5, got %v", r)
+	}
+}
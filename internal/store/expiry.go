@@ -0,0 +1,280 @@
+package store
+
+import (
+	"encoding/binary"
+	"log"
+	"time"
+
+	"github.com/ethan-stone/go-key-store/internal/service"
+	"github.com/ethan-stone/go-key-store/internal/wal"
+)
+
+// Expired keys are hidden from reads as soon as they expire, but they are only
+// deleted by the sampler. Every interval it looks at a few keys that have an
+// expiry and deletes the ones that have run out, going again straight away
+// while a good share of the sample had expired. A sample's deletes are logged
+// as one batch, so the WAL stays the full history of the data.
+const (
+	DefaultExpiryInterval = 100 * time.Millisecond
+	expirySampleSize      = 20
+	expiryRepeatFraction  = 0.25
+	// bounds how many times a single tick takes the store lock.
+	maxExpiryRounds = 16
+)
+
+func (store *LocalKeyValueStore) currentTime() time.Time {
+	if store.now != nil {
+		return store.now()
+	}
+
+	return time.Now()
+}
+
+// trackExpiry records the key's expiry for the sampler, or forgets it when
+// expiresAt is 0. The caller holds the store lock.
+func (store *LocalKeyValueStore) trackExpiry(key string, expiresAt int64) {
+	if expiresAt == 0 {
+		delete(store.expires, key)
+		return
+	}
+
+	if store.expires == nil {
+		store.expires = make(map[string]int64)
+	}
+
+	store.expires[key] = expiresAt
+}
+
+// loadExpiries finds every key with an expiry after recovery. Expiries are
+// only kept with the values, so this reads every value once.
+func (store *LocalKeyValueStore) loadExpiries() error {
+	store.expires = nil
+
+	return store.engine.Iterate(func(key, val string) bool {
+		valueType, version := typeAndVersionOf(val)
+
+		store.trackExpiry(key, sweepAt(valueType, version, expiryOf(val)))
+		return true
+	})
+}
+
+func (store *LocalKeyValueStore) Expire(key string, ttl time.Duration) (bool, error) {
+	now := store.currentTime()
+
+	// a non positive ttl leaves the key expiring right now, which hides it
+	// until the sampler gets to it.
+	expiresAt := now.UnixMilli()
+
+	if ttl > 0 {
+		expiresAt = expiryFor(now, ttl)
+	}
+
+	return store.changeExpiry(key, expiresAt)
+}
+
+func (store *LocalKeyValueStore) Persist(key string) (bool, error) {
+	return store.changeExpiry(key, 0)
+}
+
+func (store *LocalKeyValueStore) TTL(key string) (*service.TTLResult, error) {
+	err := store.waitForReadIndex()
+
+	if err != nil {
+		return nil, err
+	}
+
+	store.RLock()
+	defer store.RUnlock()
+
+	val, ok, err := store.lookup(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return &service.TTLResult{Ok: false}, nil
+	}
+
+	if val.expiresAt == 0 {
+		return &service.TTLResult{Ok: true, Expires: false}, nil
+	}
+
+	return &service.TTLResult{
+		Ok:      true,
+		Expires: true,
+		TTL:     time.UnixMilli(val.expiresAt).Sub(store.currentTime()),
+	}, nil
+}
+
+// changeExpiry logs an expire entry holding the new expiry, or nothing to
+// remove it, so the value itself doesn't have to be logged again.
+func (store *LocalKeyValueStore) changeExpiry(key string, expiresAt int64) (bool, error) {
+	store.lockWrites()
+
+	_, ok, err := store.lookup(key)
+
+	if err != nil || !ok {
+		store.unlockWrites()
+		return false, err
+	}
+
+	valueBytes := []byte{}
+
+	if expiresAt != 0 {
+		valueBytes = binary.LittleEndian.AppendUint64(valueBytes, uint64(expiresAt))
+	}
+
+	pending, err := store.logAndApplyLocked(&wal.WalEntryWrite{
+		OpType:      wal.Expire,
+		KeyLength:   int32(len(key)),
+		ValueLength: int32(len(valueBytes)),
+		KeyBytes:    []byte(key),
+		ValueBytes:  &valueBytes,
+	}, func() error {
+		return store.setExpiry(key, expiresAt)
+	})
+
+	store.unlockWrites()
+
+	if err != nil {
+		return false, err
+	}
+
+	OpLog.AddEntry(&OpLogEntry{
+		OpType: Expire,
+		Key:    key,
+		Val:    nil,
+	})
+
+	return true, pending.Wait()
+}
+
+// setExpiry rewrites the key's value with a new expiry. A missing key is left
+// alone, since on replay the key may have been deleted since.
+func (store *LocalKeyValueStore) setExpiry(key string, expiresAt int64) error {
+	stored, ok, err := store.engine.Get(key)
+
+	if err != nil || !ok {
+		return err
+	}
+
+	val := decodeValue(stored)
+	val.expiresAt = expiresAt
+
+	encoded, err := val.encode()
+
+	if err != nil {
+		return err
+	}
+
+	err = store.engine.Put(key, string(encoded))
+
+	if err != nil {
+		return err
+	}
+
+	store.trackExpiry(key, expiresAt)
+
+	return nil
+}
+
+// expireSample deletes the expired keys out of a sample of the keys that have
+// an expiry. It returns how many keys it looked at and how many it deleted.
+func (store *LocalKeyValueStore) expireSample() (int, int, error) {
+	store.RLock()
+
+	now := store.currentTime().UnixMilli()
+	sampled := 0
+	expired := []string{}
+
+	// map iteration starts at a random place, which makes for a cheap sample.
+	for key, expiresAt := range store.expires {
+		if sampled == expirySampleSize {
+			break
+		}
+
+		sampled++
+
+		if now >= expiresAt {
+			expired = append(expired, key)
+		}
+	}
+
+	store.RUnlock()
+
+	if len(expired) == 0 {
+		return sampled, 0, nil
+	}
+
+	// the deletes are logged as one batch, so with raft replication the
+	// sample costs one round of the shard instead of one for every key.
+	store.lockWrites()
+
+	ops := []*service.BatchOp{}
+
+	for _, key := range expired {
+		// the key may have been written again since it was sampled.
+		if expiresAt, ok := store.expires[key]; ok && now >= expiresAt {
+			ops = append(ops, &service.BatchOp{Key: key, Delete: true})
+		}
+	}
+
+	if len(ops) == 0 {
+		store.unlockWrites()
+		return sampled, 0, nil
+	}
+
+	_, pending, err := store.batchLocked(ops)
+
+	store.unlockWrites()
+
+	if err != nil {
+		return sampled, 0, err
+	}
+
+	// nobody is waiting on these deletes, but an error still means the log is
+	// in trouble.
+	err = pending.Wait()
+
+	if err != nil {
+		return sampled, len(ops), err
+	}
+
+	return sampled, len(ops), nil
+}
+
+// expire runs sample rounds until one finds few enough expired keys.
+func (store *LocalKeyValueStore) expire() error {
+	// a replica gets its primary's deletes instead.
+	if store.replica.Load() {
+		return nil
+	}
+
+	for range maxExpiryRounds {
+		sampled, expired, err := store.expireSample()
+
+		if err != nil {
+			return err
+		}
+
+		if sampled == 0 || float64(expired) <= float64(sampled)*expiryRepeatFraction {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// StartExpiring deletes expired keys every interval in the background.
+func (store *LocalKeyValueStore) StartExpiring(interval time.Duration) {
+	go func() {
+		for range time.NewTicker(interval).C {
+			err := store.expire()
+
+			if err != nil {
+				log.Printf("Failed to delete expired keys %v", err)
+			}
+		}
+	}()
+}
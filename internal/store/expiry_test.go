@@ -0,0 +1,250 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ethan-stone/go-key-store/internal/engine"
+	"github.com/ethan-stone/go-key-store/internal/service"
+)
+
+// fakeClock lets tests move time forward without sleeping.
+type fakeClock struct {
+	now time.Time
+}
+
+func (clock *fakeClock) Now() time.Time {
+	return clock.now
+}
+
+func newExpiringStore() (*LocalKeyValueStore, *fakeClock) {
+	clock := &fakeClock{now: time.UnixMilli(1_000_000)}
+
+	return &LocalKeyValueStore{
+		engine: engine.NewMemoryEngine(),
+		now:    clock.Now,
+	}, clock
+}
+
+func TestShouldHideExpiredKeys(t *testing.T) {
+	store, clock := newExpiringStore()
+
+	_, err := store.Put("a", []byte("1"), &service.PutOptions{TTL: time.Second})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when putting into store %v", err)
+	}
+
+	r, err := store.Get("a")
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when getting from store %v", err)
+	}
+
+	if !r.Ok {
+		t.Errorf("Expected to find key %s before it expired", "a")
+	}
+
+	clock.now = clock.now.Add(time.Second)
+
+	r, err = store.Get("a")
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when getting from store %v", err)
+	}
+
+	if r.Ok {
+		t.Errorf("Did not expect to find key %s after it expired", "a")
+	}
+
+	ok, err := store.Expire("a", time.Minute)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when expiring key %v", err)
+	}
+
+	if ok {
+		t.Errorf("Did not expect to be able to expire a key that already expired")
+	}
+}
+
+func TestShouldExpirePersistAndReportTTL(t *testing.T) {
+	store, clock := newExpiringStore()
+
+	store.Put("a", []byte("1"), nil)
+
+	r, err := store.TTL("a")
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when getting ttl %v", err)
+	}
+
+	if !r.Ok || r.Expires {
+		t.Errorf("Expected key without an expiry, got %v", r)
+	}
+
+	ok, err := store.Expire("a", 10*time.Second)
+
+	if err != nil || !ok {
+		t.Fatalf("Did not expect an error when expiring key %v %t", err, ok)
+	}
+
+	clock.now = clock.now.Add(4 * time.Second)
+
+	r, _ = store.TTL("a")
+
+	if !r.Ok || !r.Expires || r.TTL != 6*time.Second {
+		t.Errorf("Expected 6s left, got %v", r)
+	}
+
+	ok, err = store.Persist("a")
+
+	if err != nil || !ok {
+		t.Fatalf("Did not expect an error when persisting key %v %t", err, ok)
+	}
+
+	clock.now = clock.now.Add(time.Hour)
+
+	got, _ := store.Get("a")
+
+	if !got.Ok || string(got.Val) != "1" {
+		t.Errorf("Expected a to outlive its old expiry after persist, got %v", got)
+	}
+
+	if r, _ := store.TTL("missing"); r.Ok {
+		t.Errorf("Did not expect a ttl for a missing key, got %v", r)
+	}
+}
+
+func TestSamplerShouldDeleteExpiredKeys(t *testing.T) {
+	store, clock := newExpiringStore()
+
+	for i := range 100 {
+		store.Put(fmt.Sprintf("short-%d", i), []byte("1"), &service.PutOptions{TTL: time.Second})
+	}
+
+	store.Put("long", []byte("1"), &service.PutOptions{TTL: time.Hour})
+	store.Put("forever", []byte("1"), nil)
+
+	clock.now = clock.now.Add(time.Minute)
+
+	// each tick only does a bounded amount of work, so keep going until the
+	// sampler stops finding expired keys.
+	for range 20 {
+		err := store.expire()
+
+		if err != nil {
+			t.Fatalf("Did not expect an error when expiring keys %v", err)
+		}
+	}
+
+	remaining := []string{}
+
+	store.engine.Iterate(func(key, val string) bool {
+		remaining = append(remaining, key)
+		return true
+	})
+
+	if len(remaining) != 2 {
+		t.Errorf("Expected only long and forever to be left, got %v", remaining)
+	}
+
+	if len(store.expires) != 1 {
+		t.Errorf("Expected only long to still have an expiry, got %v", store.expires)
+	}
+}
+
+func TestSamplerShouldLogASamplesDeletesAsOneEntry(t *testing.T) {
+	dataDir := t.TempDir()
+	store := openDurableStore(t, dataDir)
+
+	clock := &fakeClock{now: time.Now()}
+	store.now = clock.Now
+
+	for i := range 10 {
+		store.Put(fmt.Sprintf("short-%d", i), []byte("1"), &service.PutOptions{TTL: time.Second})
+	}
+
+	store.Put("long", []byte("1"), &service.PutOptions{TTL: time.Hour})
+
+	clock.now = clock.now.Add(time.Minute)
+	lsn := store.wal.NextLSN()
+
+	sampled, expired, err := store.expireSample()
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when expiring keys %v", err)
+	}
+
+	if sampled != 11 || expired != 10 {
+		t.Errorf("Expected 10 of the 11 keys to be deleted, got %d of %d", expired, sampled)
+	}
+
+	if store.wal.NextLSN() != lsn+1 {
+		t.Errorf("Expected the deletes to be logged as one entry, got %d", store.wal.NextLSN()-lsn)
+	}
+
+	store.Close()
+
+	recovered := openDurableStore(t, dataDir)
+	defer recovered.Close()
+
+	for i := range 10 {
+		if _, ok, _ := recovered.engine.Get(fmt.Sprintf("short-%d", i)); ok {
+			t.Errorf("Expected short-%d to still be deleted after a restart", i)
+		}
+	}
+
+	if _, ok, _ := recovered.engine.Get("long"); !ok {
+		t.Errorf("Expected long to be kept")
+	}
+}
+
+func TestShouldRecoverExpiries(t *testing.T) {
+	dataDir := t.TempDir()
+
+	store, err := InitializeDurableLocalKeyValueStore(&DurableLocalKeyValueStoreConfig{DataDir: dataDir})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when initializing store %v", err)
+	}
+
+	store.Put("a", []byte("1"), &service.PutOptions{TTL: time.Hour})
+	store.Put("b", []byte("2"), nil)
+
+	err = store.Snapshot()
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when snapshotting store %v", err)
+	}
+
+	store.Expire("b", time.Hour)
+	store.Put("c", []byte("3"), &service.PutOptions{TTL: time.Hour})
+	store.Persist("c")
+	store.Close()
+
+	recovered, err := InitializeDurableLocalKeyValueStore(&DurableLocalKeyValueStoreConfig{DataDir: dataDir})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when recovering store %v", err)
+	}
+
+	defer recovered.Close()
+
+	for key, expires := range map[string]bool{"a": true, "b": true, "c": false} {
+		r, err := recovered.TTL(key)
+
+		if err != nil {
+			t.Fatalf("Did not expect an error when getting ttl %v", err)
+		}
+
+		if !r.Ok || r.Expires != expires {
+			t.Errorf("Expected %s to exist with expires %t, got %v", key, expires, r)
+		}
+	}
+
+	if len(recovered.expires) != 2 {
+		t.Errorf("Expected the sampler to know about a and b, got %v", recovered.expires)
+	}
+}
@@ -5,18 +5,75 @@ import (
 	"log"
 
 	"github.com/ethan-stone/go-key-store/internal/configuration"
-	"github.com/ethan-stone/go-key-store/internal/hash"
 	"github.com/ethan-stone/go-key-store/internal/rpc"
 	"github.com/ethan-stone/go-key-store/internal/service"
 )
 
 func GetStore(key string, clusterConfig *configuration.ClusterConfig, rpcClientManager rpc.RpcClientManager) (service.StoreService, error) {
-	hashSlot := hash.GetHashSlot(key)
+	hashSlot := clusterConfig.HashFunction.HashSlot(key)
 
 	log.Printf("Key %s belongs to hash slot %d", key, hashSlot)
 
-	// If the hash falls into this node, then get the local store.
-	if hashSlot >= uint32(clusterConfig.ThisNode.HashSlots[0]) && hashSlot <= uint32(clusterConfig.ThisNode.HashSlots[1]) {
+	// with quorum replication this node coordinates every key itself.
+	if clusterConfig.ReplicationMode == configuration.QuorumReplication {
+		return getQuorumStore(hashSlot, clusterConfig, rpcClientManager)
+	}
+
+	return getStoreForSlot(hashSlot, clusterConfig, rpcClientManager)
+}
+
+// GetStaleStore is GetStore for reads that don't need the latest write. When
+// this node is a replica of the key's primary it reads its own copy, which
+// can be behind the primary, without checking with the raft shard's leader.
+// With quorum replication a read only waits for one node.
+func GetStaleStore(key string, clusterConfig *configuration.ClusterConfig, rpcClientManager rpc.RpcClientManager) (service.StoreService, error) {
+	hashSlot := clusterConfig.HashFunction.HashSlot(key)
+	thisNode := clusterConfig.ThisNode
+
+	if clusterConfig.ReplicationMode == configuration.QuorumReplication {
+		quorumStore, err := getQuorumStore(hashSlot, clusterConfig, rpcClientManager)
+
+		if err != nil {
+			return nil, err
+		}
+
+		quorumStore.quorum.R = 1
+
+		return quorumStore, nil
+	}
+
+	if thisNode.IsReplica() && hashSlot >= uint32(thisNode.HashSlots[0]) && hashSlot <= uint32(thisNode.HashSlots[1]) {
+		log.Printf("Using local replica for key %s", key)
+		return staleStore{Store}, nil
+	}
+
+	return getStoreForSlot(hashSlot, clusterConfig, rpcClientManager)
+}
+
+// GetBatchStore gets the store for a batch, which has to have all of its keys
+// in one hash slot.
+func GetBatchStore(keys []string, clusterConfig *configuration.ClusterConfig, rpcClientManager rpc.RpcClientManager) (service.StoreService, error) {
+	hashSlot, err := batchSlot(keys, clusterConfig.HashFunction)
+
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Batch of %d keys belongs to hash slot %d", len(keys), hashSlot)
+
+	// the quorum store refuses batches, since they can't be applied on every
+	// node at once.
+	if clusterConfig.ReplicationMode == configuration.QuorumReplication {
+		return getQuorumStore(hashSlot, clusterConfig, rpcClientManager)
+	}
+
+	return getStoreForSlot(hashSlot, clusterConfig, rpcClientManager)
+}
+
+func getStoreForSlot(hashSlot uint32, clusterConfig *configuration.ClusterConfig, rpcClientManager rpc.RpcClientManager) (service.StoreService, error) {
+	// If the hash falls into this node, then get the local store. A replica
+	// has its primary's hash slots, but only the primary owns them.
+	if !clusterConfig.ThisNode.IsReplica() && hashSlot >= uint32(clusterConfig.ThisNode.HashSlots[0]) && hashSlot <= uint32(clusterConfig.ThisNode.HashSlots[1]) {
 		log.Printf("Using local store")
 		return Store, nil
 	}
@@ -26,7 +83,7 @@ func GetStore(key string, clusterConfig *configuration.ClusterConfig, rpcClientM
 	// Find the node that key val belongs to.
 	for i := range clusterConfig.OtherNodes {
 		otherNode := clusterConfig.OtherNodes[i]
-		if hashSlot >= uint32(otherNode.HashSlots[0]) && hashSlot <= uint32(otherNode.HashSlots[1]) {
+		if !otherNode.IsReplica() && hashSlot >= uint32(otherNode.HashSlots[0]) && hashSlot <= uint32(otherNode.HashSlots[1]) {
 			client, err := rpcClientManager.GetOrCreateRpcClient(&rpc.RpcClientConfig{
 				Address: otherNode.Address,
 			})
@@ -1,10 +1,13 @@
 package store
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/ethan-stone/go-key-store/internal/configuration"
 	"github.com/ethan-stone/go-key-store/internal/rpc"
+	"github.com/ethan-stone/go-key-store/internal/service"
 )
 
 type MockRpcClientManager struct {
@@ -12,6 +15,7 @@ type MockRpcClientManager struct {
 		rpc.RpcClient,
 		error,
 	)
+	MockReachable func(address string) bool // every node is reachable when nil
 }
 
 func (m *MockRpcClientManager) GetOrCreateRpcClient(
@@ -20,6 +24,14 @@ func (m *MockRpcClientManager) GetOrCreateRpcClient(
 	return m.MockGetOrCreateRpcClient(config)
 }
 
+func (m *MockRpcClientManager) Reachable(address string) bool {
+	if m.MockReachable == nil {
+		return true
+	}
+
+	return m.MockReachable(address)
+}
+
 type MockRpcClient struct{}
 
 func (m *MockRpcClient) Ping() (bool, error) {
@@ -28,28 +40,154 @@ func (m *MockRpcClient) Ping() (bool, error) {
 func (m *MockRpcClient) Get(key string) (*rpc.GetResponse, error) {
 	return &rpc.GetResponse{
 		Key: "a",
-		Val: "b",
+		Val: []byte("b"),
 		Ok:  true,
 	}, nil
 }
-func (m *MockRpcClient) Put(key string, val string) (*rpc.PutResponse, error) {
+func (m *MockRpcClient) Put(req *rpc.PutRequest) (*rpc.PutResponse, error) {
 	return &rpc.PutResponse{
 		Ok: true,
 	}, nil
 }
-func (m *MockRpcClient) Delete(key string) (*rpc.DeleteResponse, error) {
+func (m *MockRpcClient) Delete(req *rpc.DeleteRequest) (*rpc.DeleteResponse, error) {
 	return &rpc.DeleteResponse{
 		Ok: true,
 	}, nil
 }
-func (m *MockRpcClient) Gossip(
-	req *rpc.GossipRequest,
-) (*rpc.GossipResponse, error) {
-	return &rpc.GossipResponse{
-		Ok:         true,
-		OtherNodes: nil,
+func (m *MockRpcClient) Expire(req *rpc.ExpireRequest) (*rpc.ExpireResponse, error) {
+	return &rpc.ExpireResponse{
+		Ok: true,
+	}, nil
+}
+func (m *MockRpcClient) Persist(req *rpc.PersistRequest) (*rpc.PersistResponse, error) {
+	return &rpc.PersistResponse{
+		Ok: true,
+	}, nil
+}
+func (m *MockRpcClient) Ttl(req *rpc.TtlRequest) (*rpc.TtlResponse, error) {
+	return &rpc.TtlResponse{
+		Ok: true,
+	}, nil
+}
+func (m *MockRpcClient) Incr(req *rpc.IncrRequest) (*rpc.IncrResponse, error) {
+	return &rpc.IncrResponse{
+		Ok:  true,
+		Val: req.GetDelta(),
+	}, nil
+}
+func (m *MockRpcClient) ListPush(req *rpc.ListPushRequest) (*rpc.ListPushResponse, error) {
+	return &rpc.ListPushResponse{
+		Ok: true,
+	}, nil
+}
+func (m *MockRpcClient) ListPop(req *rpc.ListPopRequest) (*rpc.ListPopResponse, error) {
+	return &rpc.ListPopResponse{
+		Ok: true,
+	}, nil
+}
+func (m *MockRpcClient) ListRange(req *rpc.ListRangeRequest) (*rpc.ListRangeResponse, error) {
+	return &rpc.ListRangeResponse{
+		Ok: true,
+	}, nil
+}
+func (m *MockRpcClient) HashSet(req *rpc.HashSetRequest) (*rpc.HashSetResponse, error) {
+	return &rpc.HashSetResponse{
+		Ok: true,
+	}, nil
+}
+func (m *MockRpcClient) HashGet(req *rpc.HashGetRequest) (*rpc.HashGetResponse, error) {
+	return &rpc.HashGetResponse{
+		Ok: true,
+	}, nil
+}
+func (m *MockRpcClient) HashDelete(req *rpc.HashDeleteRequest) (*rpc.HashDeleteResponse, error) {
+	return &rpc.HashDeleteResponse{
+		Ok: true,
+	}, nil
+}
+func (m *MockRpcClient) HashGetAll(req *rpc.HashGetAllRequest) (*rpc.HashGetAllResponse, error) {
+	return &rpc.HashGetAllResponse{
+		Ok: true,
+	}, nil
+}
+func (m *MockRpcClient) SetAdd(req *rpc.SetAddRequest) (*rpc.SetAddResponse, error) {
+	return &rpc.SetAddResponse{
+		Ok: true,
+	}, nil
+}
+func (m *MockRpcClient) SetRemove(req *rpc.SetRemoveRequest) (*rpc.SetRemoveResponse, error) {
+	return &rpc.SetRemoveResponse{
+		Ok: true,
+	}, nil
+}
+func (m *MockRpcClient) SetMembers(req *rpc.SetMembersRequest) (*rpc.SetMembersResponse, error) {
+	return &rpc.SetMembersResponse{
+		Ok: true,
+	}, nil
+}
+func (m *MockRpcClient) SetIsMember(req *rpc.SetIsMemberRequest) (*rpc.SetIsMemberResponse, error) {
+	return &rpc.SetIsMemberResponse{
+		Ok: true,
+	}, nil
+}
+func (m *MockRpcClient) SortedSetAdd(req *rpc.SortedSetAddRequest) (*rpc.SortedSetAddResponse, error) {
+	return &rpc.SortedSetAddResponse{
+		Ok: true,
+	}, nil
+}
+func (m *MockRpcClient) SortedSetRemove(req *rpc.SortedSetRemoveRequest) (*rpc.SortedSetRemoveResponse, error) {
+	return &rpc.SortedSetRemoveResponse{
+		Ok: true,
+	}, nil
+}
+func (m *MockRpcClient) SortedSetIncr(req *rpc.SortedSetIncrRequest) (*rpc.SortedSetIncrResponse, error) {
+	return &rpc.SortedSetIncrResponse{
+		Ok: true,
 	}, nil
 }
+func (m *MockRpcClient) SortedSetRangeByRank(req *rpc.SortedSetRangeByRankRequest) (*rpc.SortedSetRangeByRankResponse, error) {
+	return &rpc.SortedSetRangeByRankResponse{
+		Ok: true,
+	}, nil
+}
+func (m *MockRpcClient) SortedSetRangeByScore(req *rpc.SortedSetRangeByScoreRequest) (*rpc.SortedSetRangeByScoreResponse, error) {
+	return &rpc.SortedSetRangeByScoreResponse{
+		Ok: true,
+	}, nil
+}
+func (m *MockRpcClient) SortedSetRank(req *rpc.SortedSetRankRequest) (*rpc.SortedSetRankResponse, error) {
+	return &rpc.SortedSetRankResponse{
+		Ok: true,
+	}, nil
+}
+func (m *MockRpcClient) Batch(req *rpc.BatchRequest) (*rpc.BatchResponse, error) {
+	return &rpc.BatchResponse{
+		Ok:       true,
+		Versions: make([]uint64, len(req.GetOps())),
+	}, nil
+}
+func (m *MockRpcClient) Scan(req *rpc.ScanRequest) ([]*rpc.ScanItem, bool, error) {
+	return []*rpc.ScanItem{}, false, nil
+}
+func (m *MockRpcClient) Watch(ctx context.Context, req *rpc.WatchRequest, fn func(event *rpc.WatchResponse) error) error {
+	<-ctx.Done()
+
+	return ctx.Err()
+}
+func (m *MockRpcClient) Replicate(ctx context.Context, req *rpc.ReplicateRequest, fn func(r *rpc.ReplicateResponse) error) error {
+	<-ctx.Done()
+
+	return ctx.Err()
+}
+func (m *MockRpcClient) CheckNode(req *rpc.CheckNodeRequest) (*rpc.CheckNodeResponse, error) {
+	return &rpc.CheckNodeResponse{}, nil
+}
+func (m *MockRpcClient) RaftVote(req *rpc.RaftVoteRequest) (*rpc.RaftVoteResponse, error) {
+	return &rpc.RaftVoteResponse{}, nil
+}
+func (m *MockRpcClient) RaftAppend(req *rpc.RaftAppendRequest) (*rpc.RaftAppendResponse, error) {
+	return &rpc.RaftAppendResponse{}, nil
+}
 func (m *MockRpcClient) GetAddress() string {
 	return "localhost:8081"
 }
@@ -65,6 +203,12 @@ func (m *MockRpcClient) GetClusterConfig(
 ) (*rpc.GetClusterConfigResponse, error) {
 	return &rpc.GetClusterConfigResponse{Ok: true, OtherNodes: nil, ThisNode: nil}, nil
 }
+func (m *MockRpcClient) QuorumRead(req *rpc.QuorumReadRequest) (*rpc.VersionedValue, error) {
+	return &rpc.VersionedValue{}, nil
+}
+func (m *MockRpcClient) QuorumWrite(req *rpc.QuorumWriteRequest) (*rpc.QuorumWriteResponse, error) {
+	return &rpc.QuorumWriteResponse{Applied: true}, nil
+}
 
 // a hashes to slot 15939
 // b hashes to slot 12281
@@ -158,3 +302,81 @@ func TestReturnsRemoteStore(t *testing.T) {
 		t.Errorf("Expected *RemoteKeyValueStore, got %T", store)
 	}
 }
+
+func TestBatchStoreShouldRefuseKeysInDifferentSlots(t *testing.T) {
+	keys := []string{"a", "b"}
+
+	clusterConfig := &configuration.ClusterConfig{
+		ThisNode: node1,
+		OtherNodes: []*configuration.NodeConfig{
+			node2, node3, node4,
+		},
+	}
+
+	if clusterConfig.HashFunction.HashSlot(keys[0]) == clusterConfig.HashFunction.HashSlot(keys[1]) {
+		t.Fatalf("Expected %v to be in different slots", keys)
+	}
+
+	mockRpcClientManager := &MockRpcClientManager{
+		MockGetOrCreateRpcClient: func(
+			config *rpc.RpcClientConfig,
+		) (rpc.RpcClient, error) {
+			return &MockRpcClient{}, nil
+		},
+	}
+
+	_, err := GetBatchStore(keys, clusterConfig, mockRpcClientManager)
+
+	if !errors.Is(err, service.ErrCrossSlot) {
+		t.Errorf("Expected a cross slot error, got %v", err)
+	}
+}
+
+func TestOnlyStaleReadsShouldUseAReplica(t *testing.T) {
+	key := "a"
+
+	// current node is a replica of node4, which a falls into.
+	replica := &configuration.NodeConfig{
+		ID:        "replica",
+		Address:   "localhost:8089",
+		HashSlots: node4.HashSlots,
+		ReplicaOf: "node4",
+	}
+
+	clusterConfig := &configuration.ClusterConfig{
+		ThisNode: replica,
+		OtherNodes: []*configuration.NodeConfig{
+			node1, node2, node3, node4,
+		},
+	}
+
+	mockRpcClientManager := &MockRpcClientManager{
+		MockGetOrCreateRpcClient: func(
+			config *rpc.RpcClientConfig,
+		) (rpc.RpcClient, error) {
+			return &MockRpcClient{}, nil
+		},
+	}
+
+	InitializeLocalKeyValueStore()
+
+	store, err := GetStore(key, clusterConfig, mockRpcClientManager)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when getting store %v", err)
+	}
+
+	if _, ok := store.(*RemoteKeyValueStore); !ok {
+		t.Errorf("Expected *RemoteKeyValueStore, got %T", store)
+	}
+
+	store, err = GetStaleStore(key, clusterConfig, mockRpcClientManager)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when getting store %v", err)
+	}
+
+	if _, ok := store.(staleStore); !ok {
+		t.Errorf("Expected the local store, got %T", store)
+	}
+}
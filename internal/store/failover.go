@@ -0,0 +1,123 @@
+package store
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/ethan-stone/go-key-store/internal/configuration"
+	"github.com/ethan-stone/go-key-store/internal/rpc"
+	"github.com/ethan-stone/go-key-store/internal/service"
+)
+
+// A replica takes over from its primary once the primary stops answering pings
+// and more than half the nodes in the cluster, counting the replica, can't
+// reach it either. Of the primary's replicas, the one with the most of the
+// primary's WAL takes over, the lowest ID breaking a tie. The new config is
+// committed through the cluster config raft group, and nodes route the
+// primary's hash slots to the new primary as soon as they apply it.
+const failoverCheckInterval = time.Second
+
+// ConfigProposer commits a new cluster config to every node. It fails with
+// service.ErrEpochChanged if the cluster config isn't at epoch anymore.
+type ConfigProposer interface {
+	ProposeClusterConfig(nodes []*configuration.NodeConfig, mode configuration.ReplicationMode, quorum configuration.QuorumConfig, epoch uint64, forward bool) error
+}
+
+// StartFailover checks on this node's primary every second in the background
+// while this node is a replica.
+func (store *LocalKeyValueStore) StartFailover(configManager configuration.ConfigurationManager, rpcClientManager rpc.RpcClientManager, proposer ConfigProposer) {
+	go func() {
+		for range time.NewTicker(failoverCheckInterval).C {
+			store.checkPrimary(configManager, rpcClientManager, proposer)
+		}
+	}()
+}
+
+// checkPrimary takes over from this node's primary if the primary has failed
+// and this node is the replica that should.
+func (store *LocalKeyValueStore) checkPrimary(configManager configuration.ConfigurationManager, rpcClientManager rpc.RpcClientManager, proposer ConfigProposer) {
+	clusterConfig := configManager.GetClusterConfig()
+	thisNode := clusterConfig.ThisNode
+
+	// a raft shard elects a new leader on its own, see shard.go.
+	if !thisNode.IsReplica() || clusterConfig.ReplicationMode == configuration.RaftReplication {
+		return
+	}
+
+	primary := clusterConfig.FindNode(thisNode.ReplicaOf)
+
+	if primary == nil || rpcClientManager.Reachable(primary.Address) {
+		return
+	}
+
+	// a replica that hasn't synced since it started could have none of the
+	// primary's data.
+	syncedWith, lsn := store.ReplicatedFrom()
+
+	if syncedWith != primary.ID {
+		return
+	}
+
+	votes := 1
+
+	for _, node := range clusterConfig.OtherNodes {
+		if node.ID == primary.ID {
+			continue
+		}
+
+		client, err := rpcClientManager.GetOrCreateRpcClient(&rpc.RpcClientConfig{
+			Address: node.Address,
+		})
+
+		if err != nil {
+			continue
+		}
+
+		r, err := client.CheckNode(&rpc.CheckNodeRequest{NodeId: primary.ID})
+
+		if err != nil {
+			continue
+		}
+
+		// someone has already taken over, and this node will apply the new
+		// config shortly.
+		if r.GetEpoch() > clusterConfig.Epoch {
+			return
+		}
+
+		if r.GetSuspected() {
+			votes++
+		}
+
+		if node.ReplicaOf == primary.ID && r.GetLastLsn() > 0 && (r.GetLastLsn() > lsn || (r.GetLastLsn() == lsn && node.ID < thisNode.ID)) {
+			log.Printf("Leaving replica %s at LSN %d to take over from primary %s", node.ID, r.GetLastLsn(), primary.ID)
+			return
+		}
+	}
+
+	total := len(clusterConfig.OtherNodes) + 1
+
+	if votes <= total/2 {
+		log.Printf("Primary %s is unreachable, but only %d of %d nodes agree", primary.ID, votes, total)
+		return
+	}
+
+	promoted := clusterConfig.Promote(thisNode.ID)
+
+	log.Printf("Taking over from primary %s at LSN %d", primary.ID, lsn)
+
+	// the new config is only committed if the cluster config is still at the
+	// epoch this node saw, so when two replicas both decide to take over, the
+	// second one is refused instead of undoing the first.
+	err := proposer.ProposeClusterConfig(append([]*configuration.NodeConfig{promoted.ThisNode}, promoted.OtherNodes...), promoted.ReplicationMode, promoted.Quorum, clusterConfig.Epoch, true)
+
+	if errors.Is(err, service.ErrEpochChanged) {
+		log.Printf("Dropping the takeover from primary %s, the cluster config has moved on from epoch %d", primary.ID, clusterConfig.Epoch)
+		return
+	}
+
+	if err != nil {
+		log.Printf("Failed to take over from primary %s %v", primary.ID, err)
+	}
+}
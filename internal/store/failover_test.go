@@ -0,0 +1,137 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/ethan-stone/go-key-store/internal/configuration"
+	"github.com/ethan-stone/go-key-store/internal/rpc"
+	"github.com/ethan-stone/go-key-store/internal/service"
+)
+
+// failoverRpcClient answers CheckNode for another node.
+type failoverRpcClient struct {
+	MockRpcClient
+	checked *rpc.CheckNodeResponse
+}
+
+func (c *failoverRpcClient) CheckNode(req *rpc.CheckNodeRequest) (*rpc.CheckNodeResponse, error) {
+	return c.checked, nil
+}
+
+// recordingProposer records the configs it commits. Like the cluster config
+// raft group, it only commits a config made from the one at its epoch.
+type recordingProposer struct {
+	epoch    uint64
+	proposed [][]*configuration.NodeConfig
+}
+
+func (p *recordingProposer) ProposeClusterConfig(nodes []*configuration.NodeConfig, mode configuration.ReplicationMode, quorum configuration.QuorumConfig, epoch uint64, forward bool) error {
+	if epoch != p.epoch {
+		return service.ErrEpochChanged
+	}
+
+	p.proposed = append(p.proposed, nodes)
+	p.epoch++
+
+	return nil
+}
+
+// checkFailedPrimary runs a failover check on a replica that has synced 3
+// entries from its primary, which no node can reach. checked has what each
+// other node says when asked about the primary. The replica's config is at
+// epoch 3.
+func checkFailedPrimary(t *testing.T, checked map[string]*rpc.CheckNodeResponse, proposer *recordingProposer) [][]*configuration.NodeConfig {
+	store := openDurableStore(t, t.TempDir())
+	defer store.Close()
+
+	for _, key := range []string{"a", "b", "c"} {
+		store.Put(key, []byte("1"), nil)
+	}
+
+	store.syncedWith = "primary"
+
+	configManager := configuration.NewBaseConfigurationManager(&configuration.ClusterConfig{
+		ThisNode: &configuration.NodeConfig{ID: "replica1", Address: "localhost:8082", HashSlots: []int{0, 8191}, ReplicaOf: "primary"},
+		OtherNodes: []*configuration.NodeConfig{
+			{ID: "primary", Address: "localhost:8081", HashSlots: []int{0, 8191}},
+			{ID: "replica2", Address: "localhost:8083", HashSlots: []int{0, 8191}, ReplicaOf: "primary"},
+			{ID: "other", Address: "localhost:8084", HashSlots: []int{8192, 16383}},
+		},
+		Epoch: 3,
+	})
+
+	mockRpcClientManager := &MockRpcClientManager{
+		MockGetOrCreateRpcClient: func(config *rpc.RpcClientConfig) (rpc.RpcClient, error) {
+			return &failoverRpcClient{checked: checked[config.Address]}, nil
+		},
+		MockReachable: func(address string) bool {
+			return address != "localhost:8081"
+		},
+	}
+
+	store.checkPrimary(configManager, mockRpcClientManager, proposer)
+
+	return proposer.proposed
+}
+
+func TestReplicaShouldTakeOverOnceAQuorumAgrees(t *testing.T) {
+	proposed := checkFailedPrimary(t, map[string]*rpc.CheckNodeResponse{
+		"localhost:8083": {Suspected: true, LastLsn: 2},
+		"localhost:8084": {Suspected: true},
+	}, &recordingProposer{epoch: 3})
+
+	if len(proposed) != 1 {
+		t.Fatalf("Expected the replica to propose a new config, got %d", len(proposed))
+	}
+
+	clusterConfig := &configuration.ClusterConfig{ThisNode: proposed[0][0], OtherNodes: proposed[0][1:]}
+
+	if len(proposed[0]) != 4 || clusterConfig.FindNode("replica1").IsReplica() {
+		t.Fatalf("Expected the replica to be promoted, got %v", clusterConfig.FindNode("replica1"))
+	}
+
+	if node := clusterConfig.FindNode("replica2"); node.ReplicaOf != "replica1" {
+		t.Errorf("Expected replica2 to follow the new primary, got %q", node.ReplicaOf)
+	}
+
+	if node := clusterConfig.FindNode("primary"); node.ReplicaOf != "replica1" {
+		t.Errorf("Expected the old primary to follow the new primary, got %q", node.ReplicaOf)
+	}
+}
+
+func TestReplicaShouldNotTakeOverWithoutAQuorum(t *testing.T) {
+	proposed := checkFailedPrimary(t, map[string]*rpc.CheckNodeResponse{
+		"localhost:8083": {Suspected: true, LastLsn: 2},
+		"localhost:8084": {Suspected: false},
+	}, &recordingProposer{epoch: 3})
+
+	if len(proposed) != 0 {
+		t.Errorf("Did not expect a replica to take over when only half the nodes agree")
+	}
+}
+
+func TestOnlyTheMostUpToDateReplicaShouldTakeOver(t *testing.T) {
+	proposed := checkFailedPrimary(t, map[string]*rpc.CheckNodeResponse{
+		"localhost:8083": {Suspected: true, LastLsn: 4},
+		"localhost:8084": {Suspected: true},
+	}, &recordingProposer{epoch: 3})
+
+	if len(proposed) != 0 {
+		t.Errorf("Did not expect a replica to take over from one that is further along")
+	}
+}
+
+func TestReplicaShouldNotTakeOverOnceTheConfigHasMovedOn(t *testing.T) {
+	// both replicas decided to take over at epoch 3, and the other one's
+	// config was committed first.
+	proposer := &recordingProposer{epoch: 4}
+
+	proposed := checkFailedPrimary(t, map[string]*rpc.CheckNodeResponse{
+		"localhost:8083": {Suspected: true, LastLsn: 2},
+		"localhost:8084": {Suspected: true},
+	}, proposer)
+
+	if len(proposed) != 0 || proposer.epoch != 4 {
+		t.Errorf("Did not expect a takeover made from an old config to be committed, got %d at epoch %d", len(proposed), proposer.epoch)
+	}
+}
@@ -0,0 +1,103 @@
+package store
+
+import (
+	"slices"
+)
+
+// hash fields are kept as field, value pairs sorted by field.
+func hashFromElements(elements [][]byte) map[string][]byte {
+	fields := make(map[string][]byte, len(elements)/2)
+
+	for i := 0; i+1 < len(elements); i += 2 {
+		fields[string(elements[i])] = elements[i+1]
+	}
+
+	return fields
+}
+
+func hashToElements(fields map[string][]byte) [][]byte {
+	names := make([]string, 0, len(fields))
+
+	for name := range fields {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	elements := make([][]byte, 0, len(fields)*2)
+
+	for _, name := range names {
+		elements = append(elements, []byte(name), fields[name])
+	}
+
+	return elements
+}
+
+func (store *LocalKeyValueStore) HashSet(key string, fields map[string][]byte) (int, error) {
+	added := 0
+
+	err := store.updateCollection(key, hashType, func(elements [][]byte) ([][]byte, bool, error) {
+		hash := hashFromElements(elements)
+
+		for field, val := range fields {
+			if _, ok := hash[field]; !ok {
+				added++
+			}
+
+			hash[field] = val
+		}
+
+		return hashToElements(hash), len(fields) > 0, nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return added, nil
+}
+
+func (store *LocalKeyValueStore) HashGet(key string, field string) ([]byte, bool, error) {
+	elements, err := store.readCollection(key, hashType)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	val, ok := hashFromElements(elements)[field]
+
+	return val, ok, nil
+}
+
+func (store *LocalKeyValueStore) HashDelete(key string, fields []string) (int, error) {
+	deleted := 0
+
+	err := store.updateCollection(key, hashType, func(elements [][]byte) ([][]byte, bool, error) {
+		hash := hashFromElements(elements)
+
+		for _, field := range fields {
+			if _, ok := hash[field]; ok {
+				delete(hash, field)
+				deleted++
+			}
+		}
+
+		return hashToElements(hash), deleted > 0, nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
+}
+
+func (store *LocalKeyValueStore) HashGetAll(key string) (map[string][]byte, error) {
+	elements, err := store.readCollection(key, hashType)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return hashFromElements(elements), nil
+}
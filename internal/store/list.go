@@ -0,0 +1,82 @@
+package store
+
+import (
+	"slices"
+
+	"github.com/ethan-stone/go-key-store/internal/service"
+)
+
+// ListPush pushing a, b, c onto the left leaves the list as c, b, a, the
+// same as pushing them one at a time.
+func (store *LocalKeyValueStore) ListPush(key string, side service.ListSide, vals [][]byte) (int, error) {
+	length := 0
+
+	err := store.updateCollection(key, listType, func(list [][]byte) ([][]byte, bool, error) {
+		if side == service.ListLeft {
+			pushed := slices.Clone(vals)
+			slices.Reverse(pushed)
+			list = append(pushed, list...)
+		} else {
+			list = append(list, vals...)
+		}
+
+		length = len(list)
+
+		return list, len(vals) > 0, nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return length, nil
+}
+
+func (store *LocalKeyValueStore) ListPop(key string, side service.ListSide, count int) ([][]byte, error) {
+	popped := [][]byte{}
+
+	err := store.updateCollection(key, listType, func(list [][]byte) ([][]byte, bool, error) {
+		count := min(count, len(list))
+
+		if side == service.ListLeft {
+			popped = list[:count]
+			list = list[count:]
+		} else {
+			popped = slices.Clone(list[len(list)-count:])
+			slices.Reverse(popped)
+			list = list[:len(list)-count]
+		}
+
+		return list, count > 0, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return popped, nil
+}
+
+func (store *LocalKeyValueStore) ListRange(key string, start int, stop int) ([][]byte, error) {
+	list, err := store.readCollection(key, listType)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if start < 0 {
+		start = max(len(list)+start, 0)
+	}
+
+	if stop < 0 {
+		stop = len(list) + stop
+	}
+
+	stop = min(stop, len(list)-1)
+
+	if start > stop {
+		return [][]byte{}, nil
+	}
+
+	return list[start : stop+1], nil
+}
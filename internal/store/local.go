@@ -1,74 +1,420 @@
 package store
 
 import (
+	"log"
+	"path/filepath"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/ethan-stone/go-key-store/internal/engine"
+	"github.com/ethan-stone/go-key-store/internal/raft"
 	"github.com/ethan-stone/go-key-store/internal/service"
+	"github.com/ethan-stone/go-key-store/internal/wal"
 )
 
 type LocalKeyValueStore struct {
 	sync.RWMutex
-	data map[string]string
+	engine       engine.Engine
+	engineName   string
+	wal          *wal.SegmentedWal // nil when the store is purely in memory
+	dataDir      string
+	snapshotLock sync.Mutex
+	writes       sync.Mutex       // taken before the store lock by anything that changes the engine or the WAL, see lockWrites
+	expires      map[string]int64 // expiry of every key that has one, see expiry.go
+	sortedSets   sortedSetCache   // see sorted_set.go
+	changes      changeFeed       // see watch.go
+	replica      atomic.Bool      // set while following a primary, see replication.go
+	syncedWith   string           // the primary the data was last synced from since starting, guarded by the store lock
+	shard        *raft.Node       // set with raft replication, guarded by the store lock, see shard.go
+	shardBehind  atomic.Bool      // set when the shard has committed entries past the end of the WAL
+	lastVersion  uint64           // only used without a WAL, see nextVersion
+	now          func() time.Time // nil for time.Now, tests swap it out
+}
+
+// lockWrites takes the store lock for a change. Changes hold the write lock
+// too, which is kept while a raft shard commits one and the store lock is let
+// go, so nothing else can change the store in between but reads aren't held
+// up. See commitToShardLocked.
+func (store *LocalKeyValueStore) lockWrites() {
+	store.writes.Lock()
+	store.Lock()
+}
+
+func (store *LocalKeyValueStore) unlockWrites() {
+	store.Unlock()
+	store.writes.Unlock()
 }
 
 func (store *LocalKeyValueStore) Get(key string) (*service.GetResult, error) {
+	err := store.waitForReadIndex()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return store.get(key)
+}
+
+func (store *LocalKeyValueStore) get(key string) (*service.GetResult, error) {
 	store.RLock()
 	defer store.RUnlock()
-	val, ok := store.data[key]
+	val, ok, err := store.lookup(key)
+
+	if err != nil {
+		return nil, err
+	}
 
 	if !ok {
 		return &service.GetResult{
 			Ok:  false,
-			Val: "",
+			Val: nil,
 		}, nil
 	}
 
+	if val.valueType != stringType {
+		return nil, service.ErrWrongType
+	}
+
 	OpLog.AddEntry(&OpLogEntry{
 		OpType: Get,
 		Key:    key,
-		Val:    &val,
+		Val:    val.data,
 	})
 
 	return &service.GetResult{
-		Ok:  true,
-		Val: val,
+		Ok:          true,
+		Val:         val.data,
+		ContentType: val.contentType,
+		Version:     val.version,
 	}, nil
 }
 
-func (store *LocalKeyValueStore) Put(key string, val string) error {
-	store.Lock()
-	defer store.Unlock()
-	store.data[key] = val
+// lookup gets the key from the engine, treating it as missing once it has
+// expired. The caller holds the store lock.
+func (store *LocalKeyValueStore) lookup(key string) (*storedValue, bool, error) {
+	stored, ok, err := store.engine.Get(key)
 
-	defer OpLog.AddEntry(&OpLogEntry{
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	val := decodeValue(stored)
+
+	if val.expired(store.currentTime()) {
+		return nil, false, nil
+	}
+
+	return val, true, nil
+}
+
+func (store *LocalKeyValueStore) Put(key string, val []byte, options *service.PutOptions) (*service.PutResult, error) {
+	if options == nil {
+		options = &service.PutOptions{}
+	}
+
+	value, pending, err := store.put(key, val, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	OpLog.AddEntry(&OpLogEntry{
 		OpType: Put,
 		Key:    key,
-		Val:    &val,
+		Val:    val,
 	})
 
-	return nil
+	err = pending.Wait()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &service.PutResult{Version: value.version}, nil
 }
 
-func (store *LocalKeyValueStore) Delete(key string) error {
+// put checks the condition and logs and applies the put under a single hold of
+// the store lock, so nothing can change the key in between.
+func (store *LocalKeyValueStore) put(key string, val []byte, options *service.PutOptions) (*storedValue, *wal.PendingWrite, error) {
+	store.lockWrites()
+	defer store.unlockWrites()
 
-	store.Lock()
-	defer store.Unlock()
-	defer OpLog.AddEntry(&OpLogEntry{
+	err := store.checkCondition(key, options.Condition)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	value := &storedValue{
+		data:        val,
+		contentType: options.ContentType,
+		version:     store.nextVersion(),
+		expiresAt:   expiryFor(store.currentTime(), options.TTL),
+	}
+
+	pending, err := store.writeLocked(key, value)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return value, pending, nil
+}
+
+// writeLocked logs and applies a put of value, which already has its version.
+// The caller holds the store lock.
+func (store *LocalKeyValueStore) writeLocked(key string, value *storedValue) (*wal.PendingWrite, error) {
+	return store.writeAtLocked(key, value, value.version)
+}
+
+// writeAtLocked is writeLocked for a value whose version isn't the sequence of
+// the change, which is only the case with quorum replication.
+func (store *LocalKeyValueStore) writeAtLocked(key string, value *storedValue, sequence uint64) (*wal.PendingWrite, error) {
+	valueBytes, err := value.encode()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return store.logAndApplyLocked(&wal.WalEntryWrite{
+		OpType:      wal.Put,
+		KeyLength:   int32(len(key)),
+		ValueLength: int32(len(valueBytes)),
+		KeyBytes:    []byte(key),
+		ValueBytes:  &valueBytes,
+	}, func() error {
+		err := store.engine.Put(key, string(valueBytes))
+
+		if err != nil {
+			return err
+		}
+
+		event := &service.WatchEvent{
+			Sequence: sequence,
+			Type:     service.WatchPut,
+			Key:      key,
+			Version:  value.version,
+		}
+
+		if value.valueType == tombstoneType {
+			event = &service.WatchEvent{Sequence: sequence, Type: service.WatchDelete, Key: key}
+		}
+
+		store.trackExpiry(key, sweepAt(value.valueType, value.version, value.expiresAt))
+		store.changes.publish(event)
+
+		return nil
+	})
+}
+
+func (store *LocalKeyValueStore) Delete(key string, options *service.DeleteOptions) error {
+	if options == nil {
+		options = &service.DeleteOptions{}
+	}
+
+	pending, err := store.delete(key, options)
+
+	if err != nil {
+		return err
+	}
+
+	OpLog.AddEntry(&OpLogEntry{
 		OpType: Delete,
 		Key:    key,
 		Val:    nil,
 	})
-	delete(store.data, key)
 
-	return nil
+	return pending.Wait()
+}
+
+func (store *LocalKeyValueStore) delete(key string, options *service.DeleteOptions) (*wal.PendingWrite, error) {
+	store.lockWrites()
+	defer store.unlockWrites()
+
+	err := store.checkCondition(key, options.Condition)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return store.deleteLocked(key)
+}
+
+// deleteLocked logs and applies a delete. The caller holds the store lock.
+func (store *LocalKeyValueStore) deleteLocked(key string) (*wal.PendingWrite, error) {
+	// a delete has no version of its own, but it still takes the next LSN.
+	sequence := store.nextVersion()
+
+	return store.logAndApplyLocked(&wal.WalEntryWrite{
+		OpType:      wal.Del,
+		KeyLength:   int32(len(key)),
+		ValueLength: 0,
+		KeyBytes:    []byte(key),
+		ValueBytes:  nil,
+	}, func() error {
+		err := store.engine.Delete(key)
+
+		if err != nil {
+			return err
+		}
+
+		store.trackExpiry(key, 0)
+		store.dropSortedSet(key)
+		store.changes.publish(&service.WatchEvent{
+			Sequence: sequence,
+			Type:     service.WatchDelete,
+			Key:      key,
+		})
+
+		return nil
+	})
+}
+
+// logAndApply applies the change and gives the entry its place in the WAL while
+// holding the store lock, so the order of the log always matches the order the
+// changes were made in. The caller waits on the returned write after the lock is
+// released, which lets concurrent writers share a group commit. With the batched
+// policy a change can be read before it is durable, but it is never acknowledged
+// to the writer before then.
+func (store *LocalKeyValueStore) logAndApply(entry *wal.WalEntryWrite, apply func() error) (*wal.PendingWrite, error) {
+	store.lockWrites()
+	defer store.unlockWrites()
+
+	return store.logAndApplyLocked(entry, apply)
+}
+
+// logAndApplyLocked is logAndApply for callers that already hold the store
+// lock, because they need to look at the engine before deciding to write.
+func (store *LocalKeyValueStore) logAndApplyLocked(entry *wal.WalEntryWrite, apply func() error) (*wal.PendingWrite, error) {
+	// with raft replication the change is only made once the shard has
+	// committed it, and a replica only changes by applying what its primary
+	// sends.
+	if store.shard != nil {
+		err := store.commitToShardLocked(entry, nil)
+
+		if err != nil {
+			return nil, err
+		}
+	} else if store.replica.Load() {
+		return nil, service.ErrReadOnlyReplica
+	}
+
+	if store.wal == nil {
+		return nil, apply()
+	}
+
+	// the change is made before it is logged, so one that fails isn't
+	// replayed on the next start up. Nothing is acknowledged before the entry
+	// is durable either way.
+	err := apply()
+
+	if err != nil {
+		// the shard has committed the entry at this LSN, so a no-op takes its
+		// place to keep the LSNs lined up.
+		if store.shard != nil {
+			_, _, noopErr := store.wal.Enqueue(&wal.WalEntryWrite{OpType: wal.Noop})
+
+			if noopErr != nil {
+				log.Printf("Failed to log a no-op in place of a failed change %v", noopErr)
+			}
+		}
+
+		return nil, err
+	}
+
+	_, pending, err := store.wal.Enqueue(entry)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pending, nil
 }
 
 var Store *LocalKeyValueStore
 
 func InitializeLocalKeyValueStore() *LocalKeyValueStore {
 	Store = &LocalKeyValueStore{
-		data: make(map[string]string),
+		engine:     engine.NewMemoryEngine(),
+		engineName: engine.Memory,
 	}
 
 	return Store
 }
+
+type DurableLocalKeyValueStoreConfig struct {
+	DataDir           string
+	Engine            string // see engine.Names, defaults to engine.Memory
+	MaxWalSegmentSize int64
+	Wal               *wal.WalWriterConfig // defaults to fsyncing every write
+}
+
+// InitializeDurableLocalKeyValueStore opens the storage engine in the data
+// directory, brings it up to date from the newest snapshot or checkpoint and
+// the WAL, and then logs every new write once it has been applied.
+func InitializeDurableLocalKeyValueStore(config *DurableLocalKeyValueStoreConfig) (*LocalKeyValueStore, error) {
+	engineName := config.Engine
+
+	if engineName == "" {
+		engineName = engine.Memory
+	}
+
+	storageEngine, err := engine.Open(&engine.Config{
+		Name: engineName,
+		Dir:  filepath.Join(config.DataDir, engineName),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	segmentedWal, err := wal.OpenSegmentedWal(&wal.SegmentedWalConfig{
+		Dir:            filepath.Join(config.DataDir, walDirName),
+		MaxSegmentSize: config.MaxWalSegmentSize,
+		Writer:         config.Wal,
+	})
+
+	if err != nil {
+		storageEngine.Close()
+		return nil, err
+	}
+
+	store := &LocalKeyValueStore{
+		engine:     storageEngine,
+		engineName: engineName,
+		wal:        segmentedWal,
+		dataDir:    config.DataDir,
+	}
+
+	err = store.recover()
+
+	if err != nil {
+		segmentedWal.Close()
+		storageEngine.Close()
+		return nil, err
+	}
+
+	store.changes.start(segmentedWal.NextLSN() - 1)
+
+	Store = store
+
+	return Store, nil
+}
+
+// Close stops logging and closes the storage engine. A persistent engine syncs
+// on close, but the WAL still covers anything it hasn't.
+func (store *LocalKeyValueStore) Close() error {
+	store.lockWrites()
+	defer store.unlockWrites()
+
+	if store.wal != nil {
+		err := store.wal.Close()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return store.engine.Close()
+}
@@ -1,15 +1,19 @@
 package store
 
 import (
+	"bytes"
 	"testing"
+
+	"github.com/ethan-stone/go-key-store/internal/engine"
+	"github.com/ethan-stone/go-key-store/internal/service"
 )
 
 func TestPut(t *testing.T) {
 	store := &LocalKeyValueStore{
-		data: make(map[string]string),
+		engine: engine.NewMemoryEngine(),
 	}
 
-	err := store.Put("a", "b")
+	_, err := store.Put("a", []byte("b"), nil)
 
 	if err != nil {
 		t.Fatalf("Did not expect an error when putting into store %v", err)
@@ -18,7 +22,7 @@ func TestPut(t *testing.T) {
 
 func TestGetShouldReturnNotOkWhenKeyNotFound(t *testing.T) {
 	store := &LocalKeyValueStore{
-		data: make(map[string]string),
+		engine: engine.NewMemoryEngine(),
 	}
 
 	r, err := store.Get("a")
@@ -34,10 +38,10 @@ func TestGetShouldReturnNotOkWhenKeyNotFound(t *testing.T) {
 
 func TestShouldReturnOkWhenKeyFound(t *testing.T) {
 	store := &LocalKeyValueStore{
-		data: make(map[string]string),
+		engine: engine.NewMemoryEngine(),
 	}
 
-	err := store.Put("a", "b")
+	_, err := store.Put("a", []byte("b"), nil)
 
 	if err != nil {
 		t.Fatalf("Did not expect an error when putting into store %v", err)
@@ -56,10 +60,10 @@ func TestShouldReturnOkWhenKeyFound(t *testing.T) {
 
 func TestShouldDelete(t *testing.T) {
 	store := &LocalKeyValueStore{
-		data: make(map[string]string),
+		engine: engine.NewMemoryEngine(),
 	}
 
-	err := store.Put("a", "b")
+	_, err := store.Put("a", []byte("b"), nil)
 
 	if err != nil {
 		t.Fatalf("Did not expect an error when putting into store %v", err)
@@ -75,7 +79,7 @@ func TestShouldDelete(t *testing.T) {
 		t.Errorf("Did not expect to not find key %s", "a")
 	}
 
-	err = store.Delete("a")
+	err = store.Delete("a", nil)
 
 	if err != nil {
 		t.Fatalf("Did not expect an error when deleting from store %v", err)
@@ -91,3 +95,31 @@ func TestShouldDelete(t *testing.T) {
 		t.Errorf("Did not expect to find key %s", "a")
 	}
 }
+
+func TestShouldReturnBinaryValueWithContentType(t *testing.T) {
+	store := &LocalKeyValueStore{
+		engine: engine.NewMemoryEngine(),
+	}
+
+	val := []byte{0x00, 0x01, 0xff, 0xfe, '\n', 0x80}
+
+	_, err := store.Put("a", val, &service.PutOptions{ContentType: "image/png"})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when putting into store %v", err)
+	}
+
+	r, err := store.Get("a")
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when getting from store %v", err)
+	}
+
+	if !r.Ok || !bytes.Equal(r.Val, val) {
+		t.Errorf("Expected value %v, got %v", val, r.Val)
+	}
+
+	if r.ContentType != "image/png" {
+		t.Errorf("Expected content type image/png, got %s", r.ContentType)
+	}
+}
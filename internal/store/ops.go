@@ -11,12 +11,13 @@ const (
 	Get OpTypeEnum = iota
 	Put
 	Delete
+	Expire
 )
 
 type OpLogEntry struct {
 	OpType OpTypeEnum
 	Key    string
-	Val    *string // Delete entries will not have the value
+	Val    []byte // Delete and Expire entries will not have the value
 }
 
 type OpLogEntries struct {
@@ -35,14 +36,17 @@ func (opLog *OpLogEntries) AddEntry(entry *OpLogEntry) {
 		opTypeString = "GET"
 	} else if entry.OpType == Put {
 		opTypeString = "PUT"
+	} else if entry.OpType == Expire {
+		opTypeString = "EXPIRE"
 	} else {
 		opTypeString = "DELETE"
 	}
 
-	if entry.OpType == Delete {
+	if entry.OpType == Delete || entry.OpType == Expire {
 		log.Printf("%s %s", opTypeString, entry.Key)
 	} else {
-		log.Printf("%s %s %s", opTypeString, entry.Key, *entry.Val)
+		// values can be binary, so only their size is logged.
+		log.Printf("%s %s (%d bytes)", opTypeString, entry.Key, len(entry.Val))
 	}
 
 }
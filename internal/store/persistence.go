@@ -0,0 +1,326 @@
+package store
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/ethan-stone/go-key-store/internal/engine"
+	"github.com/ethan-stone/go-key-store/internal/wal"
+)
+
+// The data directory holds the segmented WAL in a "wal" subdirectory, a
+// subdirectory per persistent engine, and the snapshots and checkpoint next to
+// them. Snapshots are named by the WAL segment that was active when they were
+// cut, so once one is on disk every older segment can be deleted.
+const (
+	walDirName         = "wal"
+	snapshotFilePrefix = "snapshot-"
+	snapshotFileSuffix = ".bin"
+)
+
+func snapshotFileName(dataDir string, segment uint64) string {
+	return filepath.Join(dataDir, fmt.Sprintf("%s%012d%s", snapshotFilePrefix, segment, snapshotFileSuffix))
+}
+
+// listSnapshots returns the segment numbers of every snapshot in dataDir,
+// oldest first.
+func listSnapshots(dataDir string) ([]uint64, error) {
+	matches, err := filepath.Glob(filepath.Join(dataDir, snapshotFilePrefix+"*"+snapshotFileSuffix))
+
+	if err != nil {
+		return nil, err
+	}
+
+	segments := []uint64{}
+
+	for _, match := range matches {
+		var segment uint64
+
+		_, err := fmt.Sscanf(filepath.Base(match), snapshotFilePrefix+"%012d"+snapshotFileSuffix, &segment)
+
+		if err != nil {
+			continue
+		}
+
+		segments = append(segments, segment)
+	}
+
+	sort.Slice(segments, func(i, j int) bool { return segments[i] < segments[j] })
+
+	return segments, nil
+}
+
+// recover brings the engine up to date. A persistent engine picks up from its
+// last checkpoint, and any other engine is loaded from the newest valid
+// snapshot. Either way the WAL written after that point is replayed on top.
+// The WAL has already had any torn tail cut off when it was opened, so a bad
+// entry here means the middle of the log is damaged.
+func (store *LocalKeyValueStore) recover() error {
+	replayFrom := wal.Position{}
+	restored := false
+
+	if _, ok := store.engine.(engine.Persistent); ok {
+		cp, err := readCheckpoint(store.dataDir)
+
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("Skipping checkpoint: %v", err)
+		}
+
+		if cp != nil && cp.Engine != store.engineName {
+			log.Printf("Skipping checkpoint for the %s engine", cp.Engine)
+		}
+
+		if cp != nil && cp.Engine == store.engineName {
+			log.Printf("Replaying from checkpoint at WAL segment %d offset %d", cp.WalSegment, cp.WalOffset)
+
+			replayFrom = wal.Position{Segment: cp.WalSegment, Offset: cp.WalOffset}
+			restored = true
+		}
+	}
+
+	if !restored {
+		position, err := store.loadSnapshot()
+
+		if err != nil {
+			return err
+		}
+
+		replayFrom = position
+	}
+
+	// the reader would silently skip ahead to the oldest segment, which would
+	// lose every write in the gap. This also catches switching to an engine
+	// that has never seen the segments that have since been deleted.
+	if segments := store.wal.Segments(); max(replayFrom.Segment, 1) < segments[0] {
+		return fmt.Errorf("the %s engine needs WAL segment %d onwards but the oldest segment is %d", store.engineName, max(replayFrom.Segment, 1), segments[0])
+	}
+
+	reader := store.wal.NewReader(replayFrom)
+
+	defer reader.Close()
+
+	replayed := 0
+
+	for {
+		entry, _, err := reader.Next()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		err = store.apply(entry)
+
+		if err != nil {
+			return err
+		}
+
+		replayed++
+	}
+
+	log.Printf("Replayed %d WAL entries", replayed)
+
+	return store.loadExpiries()
+}
+
+// loadSnapshot puts every key in the newest valid snapshot into the engine and
+// returns the WAL position to replay from.
+func (store *LocalKeyValueStore) loadSnapshot() (wal.Position, error) {
+	snapshots, err := listSnapshots(store.dataDir)
+
+	if err != nil {
+		return wal.Position{}, err
+	}
+
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		path := snapshotFileName(store.dataDir, snapshots[i])
+
+		snap, err := readSnapshot(path)
+
+		if err != nil {
+			log.Printf("Skipping snapshot %s: %v", path, err)
+			continue
+		}
+
+		for key, val := range snap.data {
+			err = store.engine.Put(key, val)
+
+			if err != nil {
+				return wal.Position{}, err
+			}
+		}
+
+		log.Printf("Loaded %d keys from snapshot %s", len(snap.data), path)
+
+		return snap.walPosition, nil
+	}
+
+	return wal.Position{}, nil
+}
+
+// apply changes the engine to reflect a WAL entry without logging it again.
+func (store *LocalKeyValueStore) apply(entry *wal.WalEntry) error {
+	key := string(entry.KeyBytes)
+
+	switch entry.OpType {
+	case wal.Put:
+		store.dropSortedSet(key)
+		return store.engine.Put(key, string(*entry.ValueBytes))
+	case wal.Del:
+		store.dropSortedSet(key)
+		return store.engine.Delete(key)
+	case wal.Expire:
+		expiresAt := int64(0)
+
+		if entry.ValueBytes != nil && len(*entry.ValueBytes) == 8 {
+			expiresAt = int64(binary.LittleEndian.Uint64(*entry.ValueBytes))
+		}
+
+		return store.setExpiry(key, expiresAt)
+	case wal.Batch:
+		entries, err := wal.DecodeBatch(entry)
+
+		if err != nil {
+			return err
+		}
+
+		for _, batchEntry := range entries {
+			err = store.apply(batchEntry)
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Snapshot makes everything written so far recoverable without the WAL, then
+// deletes the WAL segments and snapshots that makes redundant. A persistent
+// engine is synced and a checkpoint is written. Any other engine is written
+// out to a snapshot file. Writes are only blocked while the engine takes its
+// own snapshot, not while it is written.
+func (store *LocalKeyValueStore) Snapshot() error {
+	if store.wal == nil {
+		return errors.New("snapshots require a durable store")
+	}
+
+	store.snapshotLock.Lock()
+	defer store.snapshotLock.Unlock()
+
+	return store.snapshotHeld()
+}
+
+// snapshotHeld is Snapshot for callers that already hold snapshotLock.
+func (store *LocalKeyValueStore) snapshotHeld() error {
+	if persistent, ok := store.engine.(engine.Persistent); ok {
+		return store.checkpoint(persistent)
+	}
+
+	store.Lock()
+
+	walPosition := store.wal.End()
+
+	view, err := store.engine.Snapshot()
+
+	store.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	defer view.Close()
+
+	err = writeSnapshot(snapshotFileName(store.dataDir, walPosition.Segment), walPosition, view)
+
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Wrote snapshot covering WAL segment %d up to offset %d", walPosition.Segment, walPosition.Offset)
+
+	return store.removeCovered(walPosition.Segment)
+}
+
+// checkpoint syncs a persistent engine and records how far through the WAL
+// it is durable up to.
+func (store *LocalKeyValueStore) checkpoint(persistent engine.Persistent) error {
+	// every change before the end of the WAL has already been applied, since
+	// changes are applied under the store lock.
+	store.Lock()
+
+	walPosition := store.wal.End()
+
+	store.Unlock()
+
+	err := persistent.Sync()
+
+	if err != nil {
+		return err
+	}
+
+	err = writeCheckpoint(store.dataDir, &checkpoint{
+		Engine:     store.engineName,
+		WalSegment: walPosition.Segment,
+		WalOffset:  walPosition.Offset,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Wrote checkpoint for the %s engine at WAL segment %d offset %d", store.engineName, walPosition.Segment, walPosition.Offset)
+
+	return store.removeCovered(walPosition.Segment)
+}
+
+// removeCovered deletes the WAL segments and snapshots older than segment.
+func (store *LocalKeyValueStore) removeCovered(segment uint64) error {
+	err := store.wal.RemoveSegmentsBefore(segment)
+
+	if err != nil {
+		return err
+	}
+
+	snapshots, err := listSnapshots(store.dataDir)
+
+	if err != nil {
+		return err
+	}
+
+	for _, snapshotSegment := range snapshots {
+		if snapshotSegment < segment {
+			err = os.Remove(snapshotFileName(store.dataDir, snapshotSegment))
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return syncDir(store.dataDir)
+}
+
+// StartSnapshotting cuts a snapshot every interval in the background.
+func (store *LocalKeyValueStore) StartSnapshotting(interval time.Duration) {
+	go func() {
+		for range time.NewTicker(interval).C {
+			err := store.Snapshot()
+
+			if err != nil {
+				log.Printf("Failed to snapshot store %v", err)
+			}
+		}
+	}()
+}
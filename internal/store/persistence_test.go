@@ -0,0 +1,410 @@
+package store
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethan-stone/go-key-store/internal/engine"
+	"github.com/ethan-stone/go-key-store/internal/service"
+	"github.com/ethan-stone/go-key-store/internal/wal"
+)
+
+func TestShouldRecoverFromWal(t *testing.T) {
+	dataDir := t.TempDir()
+
+	store, err := InitializeDurableLocalKeyValueStore(&DurableLocalKeyValueStoreConfig{DataDir: dataDir})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when initializing store %v", err)
+	}
+
+	store.Put("a", []byte("1"), nil)
+	store.Put("b", []byte("2"), nil)
+	store.Put("a", []byte("3"), nil)
+	store.Delete("b", nil)
+	store.wal.Close()
+
+	recovered, err := InitializeDurableLocalKeyValueStore(&DurableLocalKeyValueStoreConfig{DataDir: dataDir})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when recovering store %v", err)
+	}
+
+	r, err := recovered.Get("a")
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when getting from store %v", err)
+	}
+
+	if !r.Ok || string(r.Val) != "3" {
+		t.Errorf("Expected a to be 3, got %v", r)
+	}
+
+	r, err = recovered.Get("b")
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when getting from store %v", err)
+	}
+
+	if r.Ok {
+		t.Errorf("Did not expect to find key %s", "b")
+	}
+}
+
+func TestShouldRecoverContentTypes(t *testing.T) {
+	dataDir := t.TempDir()
+
+	store, err := InitializeDurableLocalKeyValueStore(&DurableLocalKeyValueStoreConfig{DataDir: dataDir})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when initializing store %v", err)
+	}
+
+	store.Put("a", []byte{0xff, 0x00}, &service.PutOptions{ContentType: "application/x-protobuf"})
+
+	err = store.Snapshot()
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when snapshotting store %v", err)
+	}
+
+	store.Put("b", []byte{0x00, 0xfe}, &service.PutOptions{ContentType: "image/png"})
+	store.Close()
+
+	recovered, err := InitializeDurableLocalKeyValueStore(&DurableLocalKeyValueStoreConfig{DataDir: dataDir})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when recovering store %v", err)
+	}
+
+	defer recovered.Close()
+
+	expected := map[string]*service.GetResult{
+		"a": {Ok: true, Val: []byte{0xff, 0x00}, ContentType: "application/x-protobuf"},
+		"b": {Ok: true, Val: []byte{0x00, 0xfe}, ContentType: "image/png"},
+	}
+
+	for key, want := range expected {
+		r, err := recovered.Get(key)
+
+		if err != nil {
+			t.Fatalf("Did not expect an error when getting from store %v", err)
+		}
+
+		if !r.Ok || !bytes.Equal(r.Val, want.Val) || r.ContentType != want.ContentType {
+			t.Errorf("Expected %s to be %v, got %v", key, want, r)
+		}
+	}
+}
+
+func TestShouldTruncateTornTailOnRecovery(t *testing.T) {
+	dataDir := t.TempDir()
+	walFileName := filepath.Join(dataDir, walDirName, "000000000001.wal")
+
+	store, err := InitializeDurableLocalKeyValueStore(&DurableLocalKeyValueStoreConfig{DataDir: dataDir})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when initializing store %v", err)
+	}
+
+	store.Put("a", []byte("1"), nil)
+	store.wal.Close()
+
+	info, err := os.Stat(walFileName)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when getting WAL size %v", err)
+	}
+
+	goodSize := info.Size()
+
+	// simulate a crash in the middle of appending the next entry.
+	file, err := os.OpenFile(walFileName, os.O_APPEND|os.O_WRONLY, 0666)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening WAL %v", err)
+	}
+
+	file.Write([]byte{1, 5, 0, 0})
+	file.Close()
+
+	recovered, err := InitializeDurableLocalKeyValueStore(&DurableLocalKeyValueStoreConfig{DataDir: dataDir})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when recovering store %v", err)
+	}
+
+	info, err = os.Stat(walFileName)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when getting WAL size %v", err)
+	}
+
+	if info.Size() != goodSize {
+		t.Errorf("Expected WAL to be truncated to %d bytes, got %d", goodSize, info.Size())
+	}
+
+	recovered.Put("b", []byte("2"), nil)
+	recovered.wal.Close()
+
+	recovered, err = InitializeDurableLocalKeyValueStore(&DurableLocalKeyValueStoreConfig{DataDir: dataDir})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when recovering store %v", err)
+	}
+
+	for key, val := range map[string]string{"a": "1", "b": "2"} {
+		r, err := recovered.Get(key)
+
+		if err != nil {
+			t.Fatalf("Did not expect an error when getting from store %v", err)
+		}
+
+		if !r.Ok || string(r.Val) != val {
+			t.Errorf("Expected %s to be %s, got %v", key, val, r)
+		}
+	}
+}
+
+func TestShouldRecoverFromSnapshotAndWalSuffix(t *testing.T) {
+	dataDir := t.TempDir()
+
+	store, err := InitializeDurableLocalKeyValueStore(&DurableLocalKeyValueStoreConfig{DataDir: dataDir})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when initializing store %v", err)
+	}
+
+	store.Put("a", []byte("1"), nil)
+	store.Put("b", []byte("2"), nil)
+
+	err = store.Snapshot()
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when snapshotting store %v", err)
+	}
+
+	store.Put("c", []byte("3"), nil)
+	store.Delete("a", nil)
+	store.wal.Close()
+
+	recovered, err := InitializeDurableLocalKeyValueStore(&DurableLocalKeyValueStoreConfig{DataDir: dataDir})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when recovering store %v", err)
+	}
+
+	for key, val := range map[string]string{"b": "2", "c": "3"} {
+		r, err := recovered.Get(key)
+
+		if err != nil {
+			t.Fatalf("Did not expect an error when getting from store %v", err)
+		}
+
+		if !r.Ok || string(r.Val) != val {
+			t.Errorf("Expected %s to be %s, got %v", key, val, r)
+		}
+	}
+
+	r, err := recovered.Get("a")
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when getting from store %v", err)
+	}
+
+	if r.Ok {
+		t.Errorf("Did not expect to find key %s", "a")
+	}
+}
+
+func TestShouldSkipCorruptSnapshot(t *testing.T) {
+	dataDir := t.TempDir()
+
+	for segment, val := range map[uint64]string{1: "1", 2: "2"} {
+		memoryEngine := engine.NewMemoryEngine()
+		memoryEngine.Put("a", val)
+
+		view, _ := memoryEngine.Snapshot()
+
+		err := writeSnapshot(snapshotFileName(dataDir, segment), wal.Position{Segment: 1, Offset: 0}, view)
+
+		if err != nil {
+			t.Fatalf("Did not expect an error when writing snapshot %v", err)
+		}
+	}
+
+	// flip a byte in the newest snapshot so its checksum no longer matches.
+	contents, err := os.ReadFile(snapshotFileName(dataDir, 2))
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when reading snapshot %v", err)
+	}
+
+	contents[len(contents)-6] ^= 0xff
+
+	os.WriteFile(snapshotFileName(dataDir, 2), contents, 0666)
+
+	store, err := InitializeDurableLocalKeyValueStore(&DurableLocalKeyValueStoreConfig{DataDir: dataDir})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when recovering store %v", err)
+	}
+
+	r, err := store.Get("a")
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when getting from store %v", err)
+	}
+
+	if !r.Ok || string(r.Val) != "1" {
+		t.Errorf("Expected a to be 1 from the older snapshot, got %v", r)
+	}
+}
+
+func TestSnapshotShouldRemoveCoveredWalSegments(t *testing.T) {
+	dataDir := t.TempDir()
+
+	store, err := InitializeDurableLocalKeyValueStore(&DurableLocalKeyValueStoreConfig{
+		DataDir:           dataDir,
+		MaxWalSegmentSize: 1,
+	})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when initializing store %v", err)
+	}
+
+	// every write starts a new segment since the max size is 1 byte.
+	store.Put("a", []byte("1"), nil)
+	store.Put("b", []byte("2"), nil)
+	store.Put("c", []byte("3"), nil)
+
+	err = store.Snapshot()
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when snapshotting store %v", err)
+	}
+
+	segments := store.wal.Segments()
+
+	if len(segments) != 1 || segments[0] != 3 {
+		t.Errorf("Expected only segment 3 to be left, got %v", segments)
+	}
+
+	store.Put("d", []byte("4"), nil)
+	store.wal.Close()
+
+	recovered, err := InitializeDurableLocalKeyValueStore(&DurableLocalKeyValueStoreConfig{DataDir: dataDir})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when recovering store %v", err)
+	}
+
+	for key, val := range map[string]string{"a": "1", "b": "2", "c": "3", "d": "4"} {
+		r, err := recovered.Get(key)
+
+		if err != nil {
+			t.Fatalf("Did not expect an error when getting from store %v", err)
+		}
+
+		if !r.Ok || string(r.Val) != val {
+			t.Errorf("Expected %s to be %s, got %v", key, val, r)
+		}
+	}
+}
+
+func TestShouldRecoverPersistentEnginesFromCheckpoint(t *testing.T) {
+	for _, engineName := range []string{engine.Disk, engine.LSM, engine.Bitcask} {
+		t.Run(engineName, func(t *testing.T) {
+			testRecoverFromCheckpoint(t, engineName)
+		})
+	}
+}
+
+func testRecoverFromCheckpoint(t *testing.T, engineName string) {
+	dataDir := t.TempDir()
+	config := &DurableLocalKeyValueStoreConfig{
+		DataDir:           dataDir,
+		Engine:            engineName,
+		MaxWalSegmentSize: 1,
+	}
+
+	store, err := InitializeDurableLocalKeyValueStore(config)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when initializing store %v", err)
+	}
+
+	store.Put("a", []byte("1"), nil)
+	store.Put("b", []byte("2"), nil)
+
+	err = store.Snapshot()
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when checkpointing store %v", err)
+	}
+
+	if snapshots, _ := listSnapshots(dataDir); len(snapshots) != 0 {
+		t.Errorf("Did not expect a persistent engine to write snapshots, got %v", snapshots)
+	}
+
+	if segments := store.wal.Segments(); len(segments) != 1 {
+		t.Errorf("Expected the checkpoint to remove covered WAL segments, got %v", segments)
+	}
+
+	store.Put("c", []byte("3"), nil)
+	store.Delete("a", nil)
+	store.Close()
+
+	recovered, err := InitializeDurableLocalKeyValueStore(config)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when recovering store %v", err)
+	}
+
+	defer recovered.Close()
+
+	for key, val := range map[string]string{"b": "2", "c": "3"} {
+		r, err := recovered.Get(key)
+
+		if err != nil {
+			t.Fatalf("Did not expect an error when getting from store %v", err)
+		}
+
+		if !r.Ok || string(r.Val) != val {
+			t.Errorf("Expected %s to be %s, got %v", key, val, r)
+		}
+	}
+
+	if r, _ := recovered.Get("a"); r.Ok {
+		t.Errorf("Did not expect to find key %s", "a")
+	}
+}
+
+func TestShouldRefuseEngineThatMissedRemovedSegments(t *testing.T) {
+	dataDir := t.TempDir()
+
+	store, err := InitializeDurableLocalKeyValueStore(&DurableLocalKeyValueStoreConfig{
+		DataDir:           dataDir,
+		Engine:            engine.Disk,
+		MaxWalSegmentSize: 1,
+	})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when initializing store %v", err)
+	}
+
+	store.Put("a", []byte("1"), nil)
+	store.Put("b", []byte("2"), nil)
+	store.Snapshot()
+	store.Close()
+
+	// the memory engine has no snapshot to start from, and the WAL segments
+	// with a and b in them are gone.
+	_, err = InitializeDurableLocalKeyValueStore(&DurableLocalKeyValueStoreConfig{DataDir: dataDir})
+
+	if err == nil {
+		t.Errorf("Expected an error when switching to an engine that missed removed WAL segments")
+	}
+}
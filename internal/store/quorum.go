@@ -0,0 +1,560 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethan-stone/go-key-store/internal/configuration"
+	"github.com/ethan-stone/go-key-store/internal/rpc"
+	"github.com/ethan-stone/go-key-store/internal/service"
+	"github.com/ethan-stone/go-key-store/internal/wal"
+)
+
+// With quorum replication every key is kept on the N nodes on its preference
+// list, and whichever node gets a request for it is the coordinator. A write is
+// sent to all of them and acknowledged once W have it, and a read asks all of
+// them and answers with the newest of the first R copies it gets, so a read
+// sees the latest acknowledged write as long as R + W > N. Once every node has
+// answered a read, the ones with an older copy are sent the newest one.
+//
+// A write's version is the time the coordinator made it, in unix nanoseconds,
+// and the copy with the highest version wins, so the last write wins as long as
+// the nodes' clocks agree. A coordinator never goes back past a version it has
+// given or seen. A delete leaves a tombstone with its version, so it wins over
+// the puts before it on a node that missed it. Tombstones are dropped after
+// tombstoneGrace, after which a node that missed the delete can bring the key
+// back.
+//
+// Versions aren't LSNs, so conditions can't be checked against them, and only
+// strings can be replicated this way. Everything else returns
+// service.ErrNotQuorumReplicated.
+const tombstoneGrace = 24 * time.Hour
+
+// lastTimestamp is the highest version this node has given a write or seen.
+var lastTimestamp atomic.Uint64
+
+// nextTimestamp is the version for a write this node coordinates at now.
+func nextTimestamp(now time.Time) uint64 {
+	for {
+		last := lastTimestamp.Load()
+		next := max(uint64(now.UnixNano()), last+1)
+
+		if lastTimestamp.CompareAndSwap(last, next) {
+			return next
+		}
+	}
+}
+
+// observeTimestamp keeps this node's next writes after version, so a node
+// whose clock is behind doesn't lose a write to one it has already seen.
+func observeTimestamp(version uint64) {
+	for {
+		last := lastTimestamp.Load()
+
+		if version <= last || lastTimestamp.CompareAndSwap(last, version) {
+			return
+		}
+	}
+}
+
+// newerThan reports whether a wins over b. Two writes with the same version
+// are settled by a delete winning and then by the values, so every node keeps
+// the same one.
+func newerThan(a *service.VersionedValue, b *service.VersionedValue) bool {
+	if !a.Found || !b.Found {
+		return a.Found
+	}
+
+	if a.Version != b.Version {
+		return a.Version > b.Version
+	}
+
+	if a.Deleted != b.Deleted {
+		return a.Deleted
+	}
+
+	return bytes.Compare(a.Val, b.Val) > 0
+}
+
+// sweepAt is when the sampler should drop a value. A tombstone is kept for
+// tombstoneGrace after the delete, going by its version, which is the time the
+// delete was made. That way restarting a node doesn't put it off again.
+func sweepAt(valueType byte, version uint64, expiresAt int64) int64 {
+	if valueType == tombstoneType {
+		return time.Unix(0, int64(version)).Add(tombstoneGrace).UnixMilli()
+	}
+
+	return expiresAt
+}
+
+// ReadVersioned returns this node's copy of the key, including a tombstone or
+// a value that has expired.
+func (store *LocalKeyValueStore) ReadVersioned(key string) (*service.VersionedValue, error) {
+	store.RLock()
+	defer store.RUnlock()
+
+	val, err := store.readVersionedLocked(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if val.Found && !val.Deleted && val.Val == nil {
+		return nil, service.ErrWrongType
+	}
+
+	return val, nil
+}
+
+// readVersionedLocked is ReadVersioned without checking the type, so a value of
+// another type has no Val. The caller holds the store lock.
+func (store *LocalKeyValueStore) readVersionedLocked(key string) (*service.VersionedValue, error) {
+	stored, ok, err := store.engine.Get(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return &service.VersionedValue{}, nil
+	}
+
+	val := decodeValue(stored)
+
+	switch val.valueType {
+	case tombstoneType:
+		return &service.VersionedValue{Found: true, Deleted: true, Version: val.version}, nil
+	case stringType:
+		// an empty string still has a value.
+		data := val.data
+
+		if data == nil {
+			data = []byte{}
+		}
+
+		return &service.VersionedValue{
+			Found:       true,
+			Val:         data,
+			ContentType: val.contentType,
+			Version:     val.version,
+			ExpiresAt:   val.expiresAt,
+		}, nil
+	}
+
+	return &service.VersionedValue{Found: true, Version: val.version}, nil
+}
+
+// WriteVersioned logs and applies val, unless this node already has a copy of
+// the key that wins over it.
+func (store *LocalKeyValueStore) WriteVersioned(key string, val *service.VersionedValue) (bool, error) {
+	observeTimestamp(val.Version)
+
+	pending, err := store.writeVersioned(key, val)
+
+	if err != nil || pending == nil {
+		return false, err
+	}
+
+	return true, pending.Wait()
+}
+
+func (store *LocalKeyValueStore) writeVersioned(key string, val *service.VersionedValue) (*wal.PendingWrite, error) {
+	store.lockWrites()
+	defer store.unlockWrites()
+
+	current, err := store.readVersionedLocked(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !newerThan(val, current) {
+		return nil, nil
+	}
+
+	value := &storedValue{
+		data:        val.Val,
+		contentType: val.ContentType,
+		version:     val.Version,
+		expiresAt:   val.ExpiresAt,
+	}
+
+	// a tombstone expired as soon as time began.
+	if val.Deleted {
+		value = &storedValue{version: val.Version, expiresAt: 1, valueType: tombstoneType}
+	}
+
+	// the change still gets the next LSN as its sequence, since the version
+	// could be older than the last change a watch has seen.
+	return store.writeAtLocked(key, value, store.nextVersion())
+}
+
+// QuorumKeyValueStore reads and writes a key on the nodes on its preference
+// list, with this node as the coordinator.
+type QuorumKeyValueStore struct {
+	notQuorumReplicated
+	replicas []rpc.QuorumReplica // the preference list
+	quorum   configuration.QuorumConfig
+}
+
+// quorumReplicaResponse is one node's answer to a read.
+type quorumReplicaResponse struct {
+	replica rpc.QuorumReplica
+	val     *service.VersionedValue
+	err     error
+}
+
+// getQuorumStore gets the coordinator for keys in the hash slot.
+func getQuorumStore(hashSlot uint32, clusterConfig *configuration.ClusterConfig, rpcClientManager rpc.RpcClientManager) (*QuorumKeyValueStore, error) {
+	preferenceList := clusterConfig.PreferenceList(hashSlot)
+
+	if len(preferenceList) == 0 {
+		return nil, fmt.Errorf("could not find a preference list for hash slot %d", hashSlot)
+	}
+
+	replicas := []rpc.QuorumReplica{}
+
+	for _, node := range preferenceList {
+		if node.ID == clusterConfig.ThisNode.ID {
+			replicas = append(replicas, Store)
+			continue
+		}
+
+		// a node that can't be reached now counts as a failed read or write.
+		client, err := rpcClientManager.GetOrCreateRpcClient(&rpc.RpcClientConfig{
+			Address: node.Address,
+		})
+
+		if err != nil {
+			replicas = append(replicas, unreachableReplica{err: err})
+			continue
+		}
+
+		replicas = append(replicas, &RemoteKeyValueStore{rpcClient: client})
+	}
+
+	log.Printf("Using quorum of %d nodes", len(replicas))
+
+	return &QuorumKeyValueStore{
+		replicas: replicas,
+		quorum:   clusterConfig.Quorum.Resolved(),
+	}, nil
+}
+
+// needed caps want at the number of nodes on the preference list, which is
+// short of N when the cluster has fewer nodes than that.
+func (store *QuorumKeyValueStore) needed(want int) int {
+	return min(want, len(store.replicas))
+}
+
+func (store *QuorumKeyValueStore) Get(key string) (*service.GetResult, error) {
+	val, err := store.read(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !val.Found || val.Deleted || (val.ExpiresAt != 0 && time.Now().UnixMilli() >= val.ExpiresAt) {
+		return &service.GetResult{Ok: false}, nil
+	}
+
+	return &service.GetResult{
+		Ok:          true,
+		Val:         val.Val,
+		ContentType: val.ContentType,
+		Version:     val.Version,
+	}, nil
+}
+
+func (store *QuorumKeyValueStore) TTL(key string) (*service.TTLResult, error) {
+	val, err := store.read(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	if !val.Found || val.Deleted || (val.ExpiresAt != 0 && now.UnixMilli() >= val.ExpiresAt) {
+		return &service.TTLResult{Ok: false}, nil
+	}
+
+	if val.ExpiresAt == 0 {
+		return &service.TTLResult{Ok: true, Expires: false}, nil
+	}
+
+	return &service.TTLResult{Ok: true, Expires: true, TTL: time.UnixMilli(val.ExpiresAt).Sub(now)}, nil
+}
+
+func (store *QuorumKeyValueStore) Put(key string, val []byte, options *service.PutOptions) (*service.PutResult, error) {
+	if options == nil {
+		options = &service.PutOptions{}
+	}
+
+	if options.Condition != (service.Condition{}) {
+		return nil, service.ErrNotQuorumReplicated
+	}
+
+	if len(options.ContentType) > MaxContentTypeLength {
+		return nil, ErrContentTypeTooLong
+	}
+
+	now := time.Now()
+	version := nextTimestamp(now)
+
+	err := store.write(key, &service.VersionedValue{
+		Found:       true,
+		Val:         val,
+		ContentType: options.ContentType,
+		Version:     version,
+		ExpiresAt:   expiryFor(now, options.TTL),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &service.PutResult{Version: version}, nil
+}
+
+func (store *QuorumKeyValueStore) Delete(key string, options *service.DeleteOptions) error {
+	if options != nil && options.Condition != (service.Condition{}) {
+		return service.ErrNotQuorumReplicated
+	}
+
+	return store.write(key, &service.VersionedValue{
+		Found:   true,
+		Deleted: true,
+		Version: nextTimestamp(time.Now()),
+	})
+}
+
+// read asks every node for its copy of the key, and returns the newest of the
+// first R to answer. The nodes are brought up to date in the background once
+// they have all answered.
+func (store *QuorumKeyValueStore) read(key string) (*service.VersionedValue, error) {
+	responses := make(chan *quorumReplicaResponse, len(store.replicas))
+
+	for _, replica := range store.replicas {
+		go func() {
+			val, err := replica.ReadVersioned(key)
+			responses <- &quorumReplicaResponse{replica: replica, val: val, err: err}
+		}()
+	}
+
+	needed := store.needed(store.quorum.R)
+	answered := []*quorumReplicaResponse{}
+	newest := &service.VersionedValue{}
+
+	received := 0
+
+	var lastErr error
+
+	for range store.replicas {
+		response := <-responses
+		received++
+
+		if response.err != nil {
+			lastErr = response.err
+		} else {
+			answered = append(answered, response)
+
+			if newerThan(response.val, newest) {
+				newest = response.val
+			}
+		}
+
+		if len(answered) == needed {
+			break
+		}
+	}
+
+	if len(answered) < needed {
+		return nil, fmt.Errorf("%w: %d of %d needed for a read, %v", service.ErrNoQuorum, len(answered), needed, lastErr)
+	}
+
+	observeTimestamp(newest.Version)
+
+	go store.repair(key, answered, responses, len(store.replicas)-received)
+
+	return newest, nil
+}
+
+// repair waits for the rest of the responses to a read, and sends every node
+// with an older copy than the newest one it has.
+func (store *QuorumKeyValueStore) repair(key string, answered []*quorumReplicaResponse, responses <-chan *quorumReplicaResponse, remaining int) {
+	for range remaining {
+		response := <-responses
+
+		if response.err == nil {
+			answered = append(answered, response)
+		}
+	}
+
+	newest := &service.VersionedValue{}
+
+	for _, response := range answered {
+		if newerThan(response.val, newest) {
+			newest = response.val
+		}
+	}
+
+	for _, response := range answered {
+		if !newerThan(newest, response.val) {
+			continue
+		}
+
+		_, err := response.replica.WriteVersioned(key, newest)
+
+		if err != nil {
+			log.Printf("Failed to repair key %s %v", key, err)
+		}
+	}
+}
+
+// write sends val to every node, and returns once W of them have it. A node
+// with a newer copy counts, since the key has moved past val there already.
+func (store *QuorumKeyValueStore) write(key string, val *service.VersionedValue) error {
+	errs := make(chan error, len(store.replicas))
+
+	for _, replica := range store.replicas {
+		go func() {
+			_, err := replica.WriteVersioned(key, val)
+			errs <- err
+		}()
+	}
+
+	needed := store.needed(store.quorum.W)
+	acked := 0
+
+	var lastErr error
+
+	for range store.replicas {
+		err := <-errs
+
+		if err != nil {
+			lastErr = err
+		} else {
+			acked++
+		}
+
+		if acked == needed {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %d of %d needed for a write, %v", service.ErrNoQuorum, acked, needed, lastErr)
+}
+
+// unreachableReplica is a node on a preference list that couldn't be connected
+// to.
+type unreachableReplica struct {
+	err error
+}
+
+func (replica unreachableReplica) ReadVersioned(key string) (*service.VersionedValue, error) {
+	return nil, replica.err
+}
+
+func (replica unreachableReplica) WriteVersioned(key string, val *service.VersionedValue) (bool, error) {
+	return false, replica.err
+}
+
+// notQuorumReplicated refuses everything QuorumKeyValueStore doesn't replicate.
+type notQuorumReplicated struct{}
+
+func (notQuorumReplicated) Expire(key string, ttl time.Duration) (bool, error) {
+	return false, service.ErrNotQuorumReplicated
+}
+
+func (notQuorumReplicated) Persist(key string) (bool, error) {
+	return false, service.ErrNotQuorumReplicated
+}
+
+func (notQuorumReplicated) Incr(key string, delta int64) (*service.IncrResult, error) {
+	return nil, service.ErrNotQuorumReplicated
+}
+
+func (notQuorumReplicated) Batch(ops []*service.BatchOp) (*service.BatchResult, error) {
+	return nil, service.ErrNotQuorumReplicated
+}
+
+func (notQuorumReplicated) Scan(options *service.ScanOptions) (*service.ScanResult, error) {
+	return nil, service.ErrNotQuorumReplicated
+}
+
+func (notQuorumReplicated) Watch(ctx context.Context, options *service.WatchOptions, fn func(event *service.WatchEvent) error) error {
+	return service.ErrNotQuorumReplicated
+}
+
+func (notQuorumReplicated) ListPush(key string, side service.ListSide, vals [][]byte) (int, error) {
+	return 0, service.ErrNotQuorumReplicated
+}
+
+func (notQuorumReplicated) ListPop(key string, side service.ListSide, count int) ([][]byte, error) {
+	return nil, service.ErrNotQuorumReplicated
+}
+
+func (notQuorumReplicated) ListRange(key string, start int, stop int) ([][]byte, error) {
+	return nil, service.ErrNotQuorumReplicated
+}
+
+func (notQuorumReplicated) HashSet(key string, fields map[string][]byte) (int, error) {
+	return 0, service.ErrNotQuorumReplicated
+}
+
+func (notQuorumReplicated) HashGet(key string, field string) ([]byte, bool, error) {
+	return nil, false, service.ErrNotQuorumReplicated
+}
+
+func (notQuorumReplicated) HashDelete(key string, fields []string) (int, error) {
+	return 0, service.ErrNotQuorumReplicated
+}
+
+func (notQuorumReplicated) HashGetAll(key string) (map[string][]byte, error) {
+	return nil, service.ErrNotQuorumReplicated
+}
+
+func (notQuorumReplicated) SetAdd(key string, members [][]byte) (int, error) {
+	return 0, service.ErrNotQuorumReplicated
+}
+
+func (notQuorumReplicated) SetRemove(key string, members [][]byte) (int, error) {
+	return 0, service.ErrNotQuorumReplicated
+}
+
+func (notQuorumReplicated) SetMembers(key string) ([][]byte, error) {
+	return nil, service.ErrNotQuorumReplicated
+}
+
+func (notQuorumReplicated) SetIsMember(key string, member []byte) (bool, error) {
+	return false, service.ErrNotQuorumReplicated
+}
+
+func (notQuorumReplicated) SortedSetAdd(key string, members map[string]float64) (int, error) {
+	return 0, service.ErrNotQuorumReplicated
+}
+
+func (notQuorumReplicated) SortedSetRemove(key string, members []string) (int, error) {
+	return 0, service.ErrNotQuorumReplicated
+}
+
+func (notQuorumReplicated) SortedSetIncr(key string, member string, delta float64) (float64, error) {
+	return 0, service.ErrNotQuorumReplicated
+}
+
+func (notQuorumReplicated) SortedSetRangeByRank(key string, start int, stop int) ([]service.ScoredMember, error) {
+	return nil, service.ErrNotQuorumReplicated
+}
+
+func (notQuorumReplicated) SortedSetRangeByScore(key string, min float64, max float64, limit int) ([]service.ScoredMember, error) {
+	return nil, service.ErrNotQuorumReplicated
+}
+
+func (notQuorumReplicated) SortedSetRank(key string, member string) (*service.RankResult, error) {
+	return nil, service.ErrNotQuorumReplicated
+}
@@ -0,0 +1,272 @@
+package store
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethan-stone/go-key-store/internal/configuration"
+	"github.com/ethan-stone/go-key-store/internal/rpc"
+	"github.com/ethan-stone/go-key-store/internal/service"
+)
+
+// quorumRpcClient sends quorum reads and writes to a store in the same process.
+type quorumRpcClient struct {
+	MockRpcClient
+	store *LocalKeyValueStore
+}
+
+func (client *quorumRpcClient) QuorumRead(req *rpc.QuorumReadRequest) (*rpc.VersionedValue, error) {
+	val, err := client.store.ReadVersioned(req.GetKey())
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &rpc.VersionedValue{
+		Found:       val.Found,
+		Deleted:     val.Deleted,
+		Val:         val.Val,
+		ContentType: val.ContentType,
+		Version:     val.Version,
+		ExpiresAt:   val.ExpiresAt,
+	}, nil
+}
+
+func (client *quorumRpcClient) QuorumWrite(req *rpc.QuorumWriteRequest) (*rpc.QuorumWriteResponse, error) {
+	applied, err := client.store.WriteVersioned(req.GetKey(), &service.VersionedValue{
+		Found:       req.GetValue().GetFound(),
+		Deleted:     req.GetValue().GetDeleted(),
+		Val:         req.GetValue().GetVal(),
+		ContentType: req.GetValue().GetContentType(),
+		Version:     req.GetValue().GetVersion(),
+		ExpiresAt:   req.GetValue().GetExpiresAt(),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &rpc.QuorumWriteResponse{Applied: applied}, nil
+}
+
+// openQuorumStores opens three stores, and a coordinator for keys on all of
+// them with the first one as the local node.
+func openQuorumStores(t *testing.T) ([]*LocalKeyValueStore, *QuorumKeyValueStore) {
+	stores := []*LocalKeyValueStore{}
+
+	for range 3 {
+		store := openDurableStore(t, t.TempDir())
+		t.Cleanup(func() { store.Close() })
+
+		stores = append(stores, store)
+	}
+
+	coordinator := &QuorumKeyValueStore{
+		replicas: []rpc.QuorumReplica{
+			stores[0],
+			&RemoteKeyValueStore{rpcClient: &quorumRpcClient{store: stores[1]}},
+			&RemoteKeyValueStore{rpcClient: &quorumRpcClient{store: stores[2]}},
+		},
+		quorum: configuration.DefaultQuorumConfig,
+	}
+
+	return stores, coordinator
+}
+
+func TestQuorumWriteShouldNeedWNodes(t *testing.T) {
+	_, coordinator := openQuorumStores(t)
+
+	coordinator.replicas[2] = unreachableReplica{err: errors.New("down")}
+
+	_, err := coordinator.Put("a", []byte("1"), nil)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when writing with one node down %v", err)
+	}
+
+	r, err := coordinator.Get("a")
+
+	if err != nil || !r.Ok || string(r.Val) != "1" {
+		t.Fatalf("Expected to read the write with one node down, got %v %v", r, err)
+	}
+
+	coordinator.replicas[1] = unreachableReplica{err: errors.New("down")}
+
+	_, err = coordinator.Put("a", []byte("2"), nil)
+
+	if !errors.Is(err, service.ErrNoQuorum) {
+		t.Errorf("Expected a write with two nodes down to fail, got %v", err)
+	}
+
+	_, err = coordinator.Get("a")
+
+	if !errors.Is(err, service.ErrNoQuorum) {
+		t.Errorf("Expected a read with two nodes down to fail, got %v", err)
+	}
+}
+
+func TestQuorumReadShouldReturnTheNewestCopyAndRepairTheRest(t *testing.T) {
+	stores, coordinator := openQuorumStores(t)
+
+	_, err := coordinator.Put("a", []byte("1"), nil)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when writing %v", err)
+	}
+
+	// the last node misses the second write.
+	replicas := coordinator.replicas
+	coordinator.replicas = replicas[:2]
+
+	_, err = coordinator.Put("a", []byte("2"), nil)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when writing %v", err)
+	}
+
+	coordinator.replicas = replicas
+
+	// asking the stale node and one up to date node still gets the newest.
+	coordinator.quorum = configuration.QuorumConfig{N: 3, R: 3, W: 2}
+
+	r, err := coordinator.Get("a")
+
+	if err != nil || !r.Ok || string(r.Val) != "2" {
+		t.Fatalf("Expected to read the newest copy, got %v %v", r, err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for {
+		val, err := stores[2].ReadVersioned("a")
+
+		if err != nil {
+			t.Fatalf("Did not expect an error when reading the stale node %v", err)
+		}
+
+		if string(val.Val) == "2" {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the stale node to be repaired, got %s", val.Val)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestQuorumDeleteShouldWinOverAnOlderPut(t *testing.T) {
+	stores, coordinator := openQuorumStores(t)
+
+	_, err := coordinator.Put("a", []byte("1"), nil)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when writing %v", err)
+	}
+
+	err = coordinator.Delete("a", nil)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when deleting %v", err)
+	}
+
+	r, err := coordinator.Get("a")
+
+	if err != nil || r.Ok {
+		t.Fatalf("Expected the key to be deleted, got %v %v", r, err)
+	}
+
+	deleted, err := stores[0].ReadVersioned("a")
+
+	if err != nil || !deleted.Deleted {
+		t.Fatalf("Expected the delete to leave a tombstone, got %v %v", deleted, err)
+	}
+
+	// a put from before the delete that arrives late is ignored.
+	applied, err := stores[0].WriteVersioned("a", &service.VersionedValue{Found: true, Val: []byte("old"), Version: deleted.Version - 1})
+
+	if err != nil || applied {
+		t.Errorf("Expected an older put not to replace the tombstone, got %v %v", applied, err)
+	}
+
+	// the tombstone is hidden from a plain read, and kept until the grace is up.
+	local, err := stores[0].Get("a")
+
+	if err != nil || local.Ok {
+		t.Errorf("Expected the tombstone to be hidden, got %v %v", local, err)
+	}
+
+	if at := stores[0].expires["a"]; at < time.Now().Add(tombstoneGrace-time.Minute).UnixMilli() {
+		t.Errorf("Expected the tombstone to be kept for the grace period, got %d", at)
+	}
+}
+
+func TestTombstoneGraceShouldNotStartAgainAfterARestart(t *testing.T) {
+	dataDir := t.TempDir()
+	store := openDurableStore(t, dataDir)
+
+	// a delete from two days ago, which is past its grace.
+	deletedAt := time.Now().Add(-48 * time.Hour)
+
+	_, err := store.WriteVersioned("a", &service.VersionedValue{Found: true, Deleted: true, Version: uint64(deletedAt.UnixNano())})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when writing a tombstone %v", err)
+	}
+
+	sweepAt := deletedAt.Add(tombstoneGrace).UnixMilli()
+
+	if at := store.expires["a"]; at != sweepAt {
+		t.Errorf("Expected the tombstone to be swept a grace period after the delete, got %d", at)
+	}
+
+	store.Close()
+
+	recovered := openDurableStore(t, dataDir)
+	defer recovered.Close()
+
+	if at := recovered.expires["a"]; at != sweepAt {
+		t.Errorf("Expected the tombstone to be swept at the same time after a restart, got %d", at)
+	}
+}
+
+func TestNewerThanShouldSettleTiesTheSameWayEverywhere(t *testing.T) {
+	put := &service.VersionedValue{Found: true, Val: []byte("a"), Version: 5}
+	otherPut := &service.VersionedValue{Found: true, Val: []byte("b"), Version: 5}
+	deleted := &service.VersionedValue{Found: true, Deleted: true, Version: 5}
+	later := &service.VersionedValue{Found: true, Val: []byte("a"), Version: 6}
+
+	if !newerThan(later, deleted) || newerThan(deleted, later) {
+		t.Errorf("Expected the higher version to win")
+	}
+
+	if !newerThan(deleted, put) || newerThan(put, deleted) {
+		t.Errorf("Expected a delete to win a tie")
+	}
+
+	if !newerThan(otherPut, put) || newerThan(put, otherPut) {
+		t.Errorf("Expected the bigger value to win a tie")
+	}
+
+	if !newerThan(put, &service.VersionedValue{}) || newerThan(&service.VersionedValue{}, put) {
+		t.Errorf("Expected any copy to win over none")
+	}
+}
+
+func TestQuorumStoreShouldRefuseConditions(t *testing.T) {
+	_, coordinator := openQuorumStores(t)
+
+	_, err := coordinator.Put("a", []byte("1"), &service.PutOptions{Condition: service.Condition{IfAbsent: true}})
+
+	if !errors.Is(err, service.ErrNotQuorumReplicated) {
+		t.Errorf("Expected a conditional put to be refused, got %v", err)
+	}
+
+	_, err = coordinator.Incr("a", 1)
+
+	if !errors.Is(err, service.ErrNotQuorumReplicated) {
+		t.Errorf("Expected an increment to be refused, got %v", err)
+	}
+}
@@ -1,8 +1,10 @@
 package store
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/ethan-stone/go-key-store/internal/configuration"
 	"github.com/ethan-stone/go-key-store/internal/rpc"
@@ -17,41 +19,62 @@ func (store *RemoteKeyValueStore) Get(key string) (*service.GetResult, error) {
 	r, err := store.rpcClient.Get(key)
 
 	if err != nil {
-		return nil, err
+		return nil, rpc.ServiceError(err)
 	}
 
 	if !r.GetOk() {
 		return &service.GetResult{
 			Ok:  false,
-			Val: "",
+			Val: nil,
 		}, nil
 	}
 
 	return &service.GetResult{
-		Ok:  true,
-		Val: r.GetVal(),
+		Ok:          true,
+		Val:         r.GetVal(),
+		ContentType: r.GetContentType(),
+		Version:     r.GetVersion(),
 	}, nil
 }
 
-func (store *RemoteKeyValueStore) Put(key string, val string) error {
-	r, err := store.rpcClient.Put(key, val)
+func (store *RemoteKeyValueStore) Put(key string, val []byte, options *service.PutOptions) (*service.PutResult, error) {
+	req := &rpc.PutRequest{
+		Key: key,
+		Val: val,
+	}
+
+	if options != nil {
+		req.ContentType = options.ContentType
+		req.TtlMs = options.TTL.Milliseconds()
+		req.Condition = toRpcCondition(options.Condition)
+	}
+
+	r, err := store.rpcClient.Put(req)
 
 	if err != nil {
-		return err
+		return nil, rpc.ServiceError(err)
 	}
 
 	if !r.GetOk() {
-		return fmt.Errorf("could not put key \"%s\"", key)
+		return nil, fmt.Errorf("could not put key \"%s\"", key)
 	}
 
-	return nil
+	return &service.PutResult{Version: r.GetVersion()}, nil
 }
 
-func (store *RemoteKeyValueStore) Delete(key string) error {
-	r, err := store.rpcClient.Delete(key)
+func (store *RemoteKeyValueStore) Delete(key string, options *service.DeleteOptions) error {
+	req := &rpc.DeleteRequest{
+		Key: key,
+	}
+
+	if options != nil {
+		req.Condition = toRpcCondition(options.Condition)
+	}
+
+	r, err := store.rpcClient.Delete(req)
 
 	if err != nil {
-		return err
+		return rpc.ServiceError(err)
 	}
 
 	if !r.GetOk() {
@@ -61,6 +84,470 @@ func (store *RemoteKeyValueStore) Delete(key string) error {
 	return nil
 }
 
+func (store *RemoteKeyValueStore) Batch(ops []*service.BatchOp) (*service.BatchResult, error) {
+	req := &rpc.BatchRequest{
+		Ops: make([]*rpc.BatchOp, len(ops)),
+	}
+
+	for i, op := range ops {
+		req.Ops[i] = &rpc.BatchOp{
+			Key:         op.Key,
+			Delete:      op.Delete,
+			Val:         op.Val,
+			ContentType: op.ContentType,
+			TtlMs:       op.TTL.Milliseconds(),
+			Condition:   toRpcCondition(op.Condition),
+		}
+	}
+
+	r, err := store.rpcClient.Batch(req)
+
+	if err != nil {
+		return nil, rpc.ServiceError(err)
+	}
+
+	if !r.GetOk() {
+		return nil, fmt.Errorf("could not apply batch of %d keys", len(ops))
+	}
+
+	return &service.BatchResult{Versions: r.GetVersions()}, nil
+}
+
+func (store *RemoteKeyValueStore) Scan(options *service.ScanOptions) (*service.ScanResult, error) {
+	if options == nil {
+		options = &service.ScanOptions{}
+	}
+
+	req := &rpc.ScanRequest{
+		Prefix: options.Prefix,
+		Start:  options.Start,
+		End:    options.End,
+		After:  options.After,
+		Limit:  uint32(options.Limit),
+	}
+
+	// the remote node hashes with its own hash function, which has to be the
+	// same as this node's to be in the cluster at all.
+	if options.HashSlots != nil {
+		req.HashSlots = &rpc.HashSlotRange{
+			Start: uint32(options.HashSlots[0]),
+			End:   uint32(options.HashSlots[1]),
+		}
+	}
+
+	items, more, err := store.rpcClient.Scan(req)
+
+	if err != nil {
+		return nil, rpc.ServiceError(err)
+	}
+
+	result := &service.ScanResult{
+		Items: make([]*service.ScanItem, len(items)),
+		More:  more,
+	}
+
+	for i, item := range items {
+		valueType := service.ValueType(item.GetType())
+
+		if valueType == "" {
+			valueType = service.StringType
+		}
+
+		result.Items[i] = &service.ScanItem{
+			Key:         item.GetKey(),
+			Type:        valueType,
+			Val:         item.GetVal(),
+			ContentType: item.GetContentType(),
+			Version:     item.GetVersion(),
+		}
+	}
+
+	return result, nil
+}
+
+func (store *RemoteKeyValueStore) Watch(ctx context.Context, options *service.WatchOptions, fn func(event *service.WatchEvent) error) error {
+	if options == nil {
+		options = &service.WatchOptions{}
+	}
+
+	err := store.rpcClient.Watch(ctx, &rpc.WatchRequest{
+		Key:    options.Key,
+		Prefix: options.Prefix,
+		Resume: options.Resume,
+		After:  options.After,
+	}, func(event *rpc.WatchResponse) error {
+		return fn(&service.WatchEvent{
+			Sequence: event.GetSequence(),
+			Type:     service.WatchEventType(event.GetType()),
+			Key:      event.GetKey(),
+			Version:  event.GetVersion(),
+		})
+	})
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if err != nil {
+		return rpc.ServiceError(err)
+	}
+
+	return nil
+}
+
+func toRpcCondition(condition service.Condition) *rpc.Condition {
+	return &rpc.Condition{
+		IfVersion: condition.IfVersion,
+		IfAbsent:  condition.IfAbsent,
+		IfPresent: condition.IfPresent,
+	}
+}
+
+func (store *RemoteKeyValueStore) Expire(key string, ttl time.Duration) (bool, error) {
+	r, err := store.rpcClient.Expire(&rpc.ExpireRequest{
+		Key:   key,
+		TtlMs: ttl.Milliseconds(),
+	})
+
+	if err != nil {
+		return false, rpc.ServiceError(err)
+	}
+
+	return r.GetOk(), nil
+}
+
+func (store *RemoteKeyValueStore) Persist(key string) (bool, error) {
+	r, err := store.rpcClient.Persist(&rpc.PersistRequest{
+		Key: key,
+	})
+
+	if err != nil {
+		return false, rpc.ServiceError(err)
+	}
+
+	return r.GetOk(), nil
+}
+
+func (store *RemoteKeyValueStore) TTL(key string) (*service.TTLResult, error) {
+	r, err := store.rpcClient.Ttl(&rpc.TtlRequest{
+		Key: key,
+	})
+
+	if err != nil {
+		return nil, rpc.ServiceError(err)
+	}
+
+	return &service.TTLResult{
+		Ok:      r.GetOk(),
+		Expires: r.GetExpires(),
+		TTL:     time.Duration(r.GetTtlMs()) * time.Millisecond,
+	}, nil
+}
+
+func (store *RemoteKeyValueStore) Incr(key string, delta int64) (*service.IncrResult, error) {
+	r, err := store.rpcClient.Incr(&rpc.IncrRequest{
+		Key:   key,
+		Delta: delta,
+	})
+
+	if err != nil {
+		return nil, rpc.ServiceError(err)
+	}
+
+	if !r.GetOk() {
+		return nil, fmt.Errorf("could not increment key %s", key)
+	}
+
+	return &service.IncrResult{Val: r.GetVal(), Version: r.GetVersion()}, nil
+}
+
+func (store *RemoteKeyValueStore) ListPush(key string, side service.ListSide, vals [][]byte) (int, error) {
+	r, err := store.rpcClient.ListPush(&rpc.ListPushRequest{
+		Key:  key,
+		Left: side == service.ListLeft,
+		Vals: vals,
+	})
+
+	if err != nil {
+		return 0, rpc.ServiceError(err)
+	}
+
+	return int(r.GetLength()), nil
+}
+
+func (store *RemoteKeyValueStore) ListPop(key string, side service.ListSide, count int) ([][]byte, error) {
+	r, err := store.rpcClient.ListPop(&rpc.ListPopRequest{
+		Key:   key,
+		Left:  side == service.ListLeft,
+		Count: uint32(count),
+	})
+
+	if err != nil {
+		return nil, rpc.ServiceError(err)
+	}
+
+	return r.GetVals(), nil
+}
+
+func (store *RemoteKeyValueStore) ListRange(key string, start int, stop int) ([][]byte, error) {
+	r, err := store.rpcClient.ListRange(&rpc.ListRangeRequest{
+		Key:   key,
+		Start: int64(start),
+		Stop:  int64(stop),
+	})
+
+	if err != nil {
+		return nil, rpc.ServiceError(err)
+	}
+
+	return r.GetVals(), nil
+}
+
+func (store *RemoteKeyValueStore) HashSet(key string, fields map[string][]byte) (int, error) {
+	r, err := store.rpcClient.HashSet(&rpc.HashSetRequest{
+		Key:    key,
+		Fields: fields,
+	})
+
+	if err != nil {
+		return 0, rpc.ServiceError(err)
+	}
+
+	return int(r.GetAdded()), nil
+}
+
+func (store *RemoteKeyValueStore) HashGet(key string, field string) ([]byte, bool, error) {
+	r, err := store.rpcClient.HashGet(&rpc.HashGetRequest{
+		Key:   key,
+		Field: field,
+	})
+
+	if err != nil {
+		return nil, false, rpc.ServiceError(err)
+	}
+
+	return r.GetVal(), r.GetFound(), nil
+}
+
+func (store *RemoteKeyValueStore) HashDelete(key string, fields []string) (int, error) {
+	r, err := store.rpcClient.HashDelete(&rpc.HashDeleteRequest{
+		Key:    key,
+		Fields: fields,
+	})
+
+	if err != nil {
+		return 0, rpc.ServiceError(err)
+	}
+
+	return int(r.GetDeleted()), nil
+}
+
+func (store *RemoteKeyValueStore) HashGetAll(key string) (map[string][]byte, error) {
+	r, err := store.rpcClient.HashGetAll(&rpc.HashGetAllRequest{
+		Key: key,
+	})
+
+	if err != nil {
+		return nil, rpc.ServiceError(err)
+	}
+
+	fields := r.GetFields()
+
+	if fields == nil {
+		fields = map[string][]byte{}
+	}
+
+	return fields, nil
+}
+
+func (store *RemoteKeyValueStore) SetAdd(key string, members [][]byte) (int, error) {
+	r, err := store.rpcClient.SetAdd(&rpc.SetAddRequest{
+		Key:     key,
+		Members: members,
+	})
+
+	if err != nil {
+		return 0, rpc.ServiceError(err)
+	}
+
+	return int(r.GetAdded()), nil
+}
+
+func (store *RemoteKeyValueStore) SetRemove(key string, members [][]byte) (int, error) {
+	r, err := store.rpcClient.SetRemove(&rpc.SetRemoveRequest{
+		Key:     key,
+		Members: members,
+	})
+
+	if err != nil {
+		return 0, rpc.ServiceError(err)
+	}
+
+	return int(r.GetRemoved()), nil
+}
+
+func (store *RemoteKeyValueStore) SetMembers(key string) ([][]byte, error) {
+	r, err := store.rpcClient.SetMembers(&rpc.SetMembersRequest{
+		Key: key,
+	})
+
+	if err != nil {
+		return nil, rpc.ServiceError(err)
+	}
+
+	return r.GetMembers(), nil
+}
+
+func (store *RemoteKeyValueStore) SetIsMember(key string, member []byte) (bool, error) {
+	r, err := store.rpcClient.SetIsMember(&rpc.SetIsMemberRequest{
+		Key:    key,
+		Member: member,
+	})
+
+	if err != nil {
+		return false, rpc.ServiceError(err)
+	}
+
+	return r.GetIsMember(), nil
+}
+
+func (store *RemoteKeyValueStore) SortedSetAdd(key string, members map[string]float64) (int, error) {
+	r, err := store.rpcClient.SortedSetAdd(&rpc.SortedSetAddRequest{
+		Key:     key,
+		Members: members,
+	})
+
+	if err != nil {
+		return 0, rpc.ServiceError(err)
+	}
+
+	return int(r.GetAdded()), nil
+}
+
+func (store *RemoteKeyValueStore) SortedSetRemove(key string, members []string) (int, error) {
+	r, err := store.rpcClient.SortedSetRemove(&rpc.SortedSetRemoveRequest{
+		Key:     key,
+		Members: members,
+	})
+
+	if err != nil {
+		return 0, rpc.ServiceError(err)
+	}
+
+	return int(r.GetRemoved()), nil
+}
+
+func (store *RemoteKeyValueStore) SortedSetIncr(key string, member string, delta float64) (float64, error) {
+	r, err := store.rpcClient.SortedSetIncr(&rpc.SortedSetIncrRequest{
+		Key:    key,
+		Member: member,
+		Delta:  delta,
+	})
+
+	if err != nil {
+		return 0, rpc.ServiceError(err)
+	}
+
+	return r.GetScore(), nil
+}
+
+func fromRpcScoredMembers(scored []*rpc.ScoredMember) []service.ScoredMember {
+	members := make([]service.ScoredMember, len(scored))
+
+	for i, member := range scored {
+		members[i] = service.ScoredMember{Member: member.GetMember(), Score: member.GetScore()}
+	}
+
+	return members
+}
+
+func (store *RemoteKeyValueStore) SortedSetRangeByRank(key string, start int, stop int) ([]service.ScoredMember, error) {
+	r, err := store.rpcClient.SortedSetRangeByRank(&rpc.SortedSetRangeByRankRequest{
+		Key:   key,
+		Start: int64(start),
+		Stop:  int64(stop),
+	})
+
+	if err != nil {
+		return nil, rpc.ServiceError(err)
+	}
+
+	return fromRpcScoredMembers(r.GetMembers()), nil
+}
+
+func (store *RemoteKeyValueStore) SortedSetRangeByScore(key string, min float64, max float64, limit int) ([]service.ScoredMember, error) {
+	r, err := store.rpcClient.SortedSetRangeByScore(&rpc.SortedSetRangeByScoreRequest{
+		Key:   key,
+		Min:   min,
+		Max:   max,
+		Limit: uint32(limit),
+	})
+
+	if err != nil {
+		return nil, rpc.ServiceError(err)
+	}
+
+	return fromRpcScoredMembers(r.GetMembers()), nil
+}
+
+func (store *RemoteKeyValueStore) SortedSetRank(key string, member string) (*service.RankResult, error) {
+	r, err := store.rpcClient.SortedSetRank(&rpc.SortedSetRankRequest{
+		Key:    key,
+		Member: member,
+	})
+
+	if err != nil {
+		return nil, rpc.ServiceError(err)
+	}
+
+	return &service.RankResult{
+		Ok:    r.GetFound(),
+		Rank:  int(r.GetRank()),
+		Score: r.GetScore(),
+	}, nil
+}
+
+// ReadVersioned gets the remote node's copy of a key when it is on the key's
+// preference list with quorum replication.
+func (store *RemoteKeyValueStore) ReadVersioned(key string) (*service.VersionedValue, error) {
+	r, err := store.rpcClient.QuorumRead(&rpc.QuorumReadRequest{
+		Key: key,
+	})
+
+	if err != nil {
+		return nil, rpc.ServiceError(err)
+	}
+
+	return &service.VersionedValue{
+		Found:       r.GetFound(),
+		Deleted:     r.GetDeleted(),
+		Val:         r.GetVal(),
+		ContentType: r.GetContentType(),
+		Version:     r.GetVersion(),
+		ExpiresAt:   r.GetExpiresAt(),
+	}, nil
+}
+
+func (store *RemoteKeyValueStore) WriteVersioned(key string, val *service.VersionedValue) (bool, error) {
+	r, err := store.rpcClient.QuorumWrite(&rpc.QuorumWriteRequest{
+		Key: key,
+		Value: &rpc.VersionedValue{
+			Found:       true,
+			Deleted:     val.Deleted,
+			Val:         val.Val,
+			ContentType: val.ContentType,
+			Version:     val.Version,
+			ExpiresAt:   val.ExpiresAt,
+		},
+	})
+
+	if err != nil {
+		return false, rpc.ServiceError(err)
+	}
+
+	return r.GetApplied(), nil
+}
+
 var remoteKeyValueStores map[string]*RemoteKeyValueStore = make(map[string]*RemoteKeyValueStore)
 
 func InitializeRemoteStores(clusterConfig *configuration.ClusterConfig, rpcClientManager rpc.RpcClientManager) {
@@ -0,0 +1,487 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/ethan-stone/go-key-store/internal/configuration"
+	"github.com/ethan-stone/go-key-store/internal/rpc"
+	"github.com/ethan-stone/go-key-store/internal/wal"
+)
+
+// A primary streams its WAL to each of its replicas, which log and apply every
+// entry with the same LSN, so a replica's WAL is a copy of its primary's. A
+// replica that is new, was following another primary or has fallen behind the
+// WAL the primary still has is sent a snapshot of the engine first. The
+// primary the replica last synced from is only kept in memory, so a replica
+// always starts with a snapshot after it restarts.
+const (
+	replicationChunkSize     = 100
+	replicationPollInterval  = 10 * time.Millisecond
+	replicationRetryInterval = time.Second
+)
+
+// StreamReplication sends the replica everything after req's LSN, and then
+// every new entry as it is written, until ctx is done or send fails.
+func (store *LocalKeyValueStore) StreamReplication(ctx context.Context, primaryID string, req *rpc.ReplicateRequest, send func(r *rpc.ReplicateResponse) error) error {
+	if store.wal == nil {
+		return errors.New("replication requires a durable store")
+	}
+
+	after := req.GetAfterLsn()
+	from := wal.Position{}
+
+	if req.GetPrimaryId() != primaryID || !store.hasEntriesAfter(after) {
+		position, lsn, err := store.sendSnapshot(primaryID, send)
+
+		if err != nil {
+			return err
+		}
+
+		from, after = position, lsn
+	}
+
+	return store.tailWal(ctx, primaryID, from, after, send)
+}
+
+// hasEntriesAfter reports whether every entry after lsn is still in the WAL.
+func (store *LocalKeyValueStore) hasEntriesAfter(lsn uint64) bool {
+	reader := store.wal.NewReader(wal.Position{})
+
+	defer reader.Close()
+
+	oldest := store.wal.NextLSN()
+
+	entry, _, err := reader.NextBefore(store.wal.WrittenEnd())
+
+	if err == nil {
+		oldest = entry.LSN
+	}
+
+	return oldest <= lsn+1 && lsn < store.wal.NextLSN()
+}
+
+// sendSnapshot sends every key in the engine. It returns the WAL position and
+// LSN the snapshot is up to, which is where the replica carries on from.
+func (store *LocalKeyValueStore) sendSnapshot(primaryID string, send func(r *rpc.ReplicateResponse) error) (wal.Position, uint64, error) {
+	store.Lock()
+
+	position := store.wal.End()
+	lsn := store.wal.NextLSN() - 1
+	term := uint64(0)
+
+	if store.shard != nil {
+		term = store.shard.TermAt(lsn)
+	}
+
+	view, err := store.engine.Snapshot()
+
+	store.Unlock()
+
+	if err != nil {
+		return wal.Position{}, 0, err
+	}
+
+	defer view.Close()
+
+	log.Printf("Sending snapshot up to LSN %d to replica", lsn)
+
+	err = send(&rpc.ReplicateResponse{PrimaryId: primaryID, SnapshotStart: true, SnapshotLsn: lsn})
+
+	if err != nil {
+		return wal.Position{}, 0, err
+	}
+
+	items := []*rpc.SnapshotItem{}
+
+	flush := func() error {
+		if len(items) == 0 {
+			return nil
+		}
+
+		err := send(&rpc.ReplicateResponse{PrimaryId: primaryID, SnapshotLsn: lsn, Items: items})
+		items = []*rpc.SnapshotItem{}
+
+		return err
+	}
+
+	var sendErr error
+
+	err = view.Iterate(func(key, val string) bool {
+		items = append(items, &rpc.SnapshotItem{Key: key, Value: []byte(val)})
+
+		if len(items) == replicationChunkSize {
+			sendErr = flush()
+		}
+
+		return sendErr == nil
+	})
+
+	if err == nil {
+		err = sendErr
+	}
+
+	if err == nil {
+		err = flush()
+	}
+
+	if err != nil {
+		return wal.Position{}, 0, err
+	}
+
+	err = send(&rpc.ReplicateResponse{PrimaryId: primaryID, SnapshotEnd: true, SnapshotLsn: lsn, RaftTerm: term})
+
+	return position, lsn, err
+}
+
+// tailWal sends every entry after lsn, reading from position on. It only
+// reads entries that have been written out, so it never sends one that
+// might be lost or torn by a crash before it is.
+func (store *LocalKeyValueStore) tailWal(ctx context.Context, primaryID string, position wal.Position, lsn uint64, send func(r *rpc.ReplicateResponse) error) error {
+	reader := store.wal.NewReader(position)
+
+	defer reader.Close()
+
+	for {
+		entry, _, err := reader.NextBefore(store.wal.WrittenEnd())
+
+		if err == io.EOF {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(replicationPollInterval):
+			}
+
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if entry.LSN <= lsn {
+			continue
+		}
+
+		// the segment the entry was in was removed before it could be read.
+		// The replica starts again with a snapshot when it reconnects.
+		if entry.LSN != lsn+1 {
+			return fmt.Errorf("WAL entry %d is no longer kept", lsn+1)
+		}
+
+		replicationEntry := &rpc.ReplicationEntry{
+			Lsn:    entry.LSN,
+			OpType: uint32(entry.OpType),
+			Key:    string(entry.KeyBytes),
+		}
+
+		if entry.ValueBytes != nil {
+			replicationEntry.Value = *entry.ValueBytes
+		}
+
+		err = send(&rpc.ReplicateResponse{PrimaryId: primaryID, Entry: replicationEntry})
+
+		if err != nil {
+			return err
+		}
+
+		lsn = entry.LSN
+	}
+}
+
+// StartReplicating follows this node's primary in the background whenever the
+// cluster config says this node is a replica, reconnecting when the stream
+// breaks or the node is given another primary.
+func (store *LocalKeyValueStore) StartReplicating(configManager configuration.ConfigurationManager, rpcClientManager rpc.RpcClientManager) {
+	go func() {
+		for {
+			err := store.replicate(configManager, rpcClientManager)
+
+			if err != nil {
+				log.Printf("Stopped replicating %v", err)
+			}
+
+			time.Sleep(replicationRetryInterval)
+		}
+	}()
+}
+
+// replicate follows the primary until the stream breaks or this node's
+// primary changes.
+func (store *LocalKeyValueStore) replicate(configManager configuration.ConfigurationManager, rpcClientManager rpc.RpcClientManager) error {
+	clusterConfig := configManager.GetClusterConfig()
+	thisNode := clusterConfig.ThisNode
+
+	// a raft shard's members are kept up to date by the shard, see shard.go.
+	if clusterConfig.ReplicationMode == configuration.RaftReplication {
+		return nil
+	}
+
+	if !thisNode.IsReplica() {
+		if store.replica.Load() {
+			return store.promote()
+		}
+
+		return nil
+	}
+
+	if store.wal == nil {
+		return errors.New("replication requires a durable store")
+	}
+
+	store.replica.Store(true)
+
+	primary := clusterConfig.FindNode(thisNode.ReplicaOf)
+
+	if primary == nil {
+		return fmt.Errorf("could not find primary %s", thisNode.ReplicaOf)
+	}
+
+	client, err := rpcClientManager.GetOrCreateRpcClient(&rpc.RpcClientConfig{
+		Address: primary.Address,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	defer cancel()
+
+	go func() {
+		ticker := time.NewTicker(replicationRetryInterval)
+
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if configManager.GetClusterConfig().ThisNode.ReplicaOf != primary.ID {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	log.Printf("Replicating from primary %s at %s", primary.ID, primary.Address)
+
+	syncedWith, lsn := store.ReplicatedFrom()
+
+	err = client.Replicate(ctx, &rpc.ReplicateRequest{
+		ReplicaId: thisNode.ID,
+		PrimaryId: syncedWith,
+		AfterLsn:  lsn,
+	}, store.receive)
+
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	return err
+}
+
+// ReplicatedFrom returns the primary the store last synced with since it
+// started, empty if it hasn't, and the LSN of the last entry it has.
+func (store *LocalKeyValueStore) ReplicatedFrom() (string, uint64) {
+	store.RLock()
+	defer store.RUnlock()
+
+	if store.wal == nil {
+		return store.syncedWith, 0
+	}
+
+	return store.syncedWith, store.wal.NextLSN() - 1
+}
+
+// promote starts taking writes after following a primary. Expiries aren't
+// tracked while entries from the primary are applied, so they are found again.
+func (store *LocalKeyValueStore) promote() error {
+	store.lockWrites()
+	defer store.unlockWrites()
+
+	err := store.loadExpiries()
+
+	if err != nil {
+		return err
+	}
+
+	store.syncedWith = ""
+	store.changes.start(store.wal.NextLSN() - 1)
+	store.replica.Store(false)
+
+	log.Printf("Promoted to primary at LSN %d", store.wal.NextLSN()-1)
+
+	return nil
+}
+
+// receive applies one response from the primary.
+func (store *LocalKeyValueStore) receive(r *rpc.ReplicateResponse) error {
+	switch {
+	case r.GetSnapshotStart():
+		return store.startSync(r.GetSnapshotLsn())
+	case r.GetSnapshotEnd():
+		return store.finishSync(r.GetPrimaryId())
+	case r.GetEntry() != nil:
+		return store.applyReplicated(r.GetEntry())
+	}
+
+	store.lockWrites()
+	defer store.unlockWrites()
+
+	for _, item := range r.GetItems() {
+		err := store.engine.Put(item.GetKey(), string(item.GetValue()))
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// startSync throws away everything before a snapshot from the primary is
+// loaded. The WAL starts again after the snapshot's LSN, and an empty
+// snapshot or checkpoint is cut straight away so the store can still be
+// recovered if it stops part way through. Reads see a partly loaded store
+// until the sync finishes.
+func (store *LocalKeyValueStore) startSync(lsn uint64) error {
+	log.Printf("Syncing from a snapshot up to LSN %d", lsn)
+
+	store.snapshotLock.Lock()
+	defer store.snapshotLock.Unlock()
+
+	err := store.clear(lsn)
+
+	if err != nil {
+		return err
+	}
+
+	return store.snapshotHeld()
+}
+
+func (store *LocalKeyValueStore) clear(lsn uint64) error {
+	store.lockWrites()
+	defer store.unlockWrites()
+
+	keys := []string{}
+
+	err := store.engine.Iterate(func(key, _ string) bool {
+		keys = append(keys, key)
+		return true
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		err = store.engine.Delete(key)
+
+		if err != nil {
+			return err
+		}
+
+		store.dropSortedSet(key)
+	}
+
+	store.expires = nil
+	store.syncedWith = ""
+
+	err = store.wal.Reset(lsn + 1)
+
+	if err != nil {
+		return err
+	}
+
+	store.changes.start(lsn)
+
+	return nil
+}
+
+// finishSync makes the loaded snapshot recoverable without the primary.
+func (store *LocalKeyValueStore) finishSync(primaryID string) error {
+	store.lockWrites()
+
+	err := store.loadExpiries()
+
+	if err == nil {
+		store.syncedWith = primaryID
+	}
+
+	store.unlockWrites()
+
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Synced from primary %s", primaryID)
+
+	return store.Snapshot()
+}
+
+// applyReplicated logs and applies an entry from the primary with the LSN the
+// primary gave it.
+func (store *LocalKeyValueStore) applyReplicated(entry *rpc.ReplicationEntry) error {
+	store.lockWrites()
+
+	pending, err := store.applyReplicatedLocked(entry)
+
+	store.unlockWrites()
+
+	if err != nil {
+		return err
+	}
+
+	return pending.Wait()
+}
+
+// applyReplicatedLocked is applyReplicated for callers that already hold the
+// store lock. The caller waits on the returned write after releasing it.
+func (store *LocalKeyValueStore) applyReplicatedLocked(entry *rpc.ReplicationEntry) (*wal.PendingWrite, error) {
+	if next := store.wal.NextLSN(); entry.GetLsn() != next {
+		return nil, fmt.Errorf("expected WAL entry %d from the primary, got %d", next, entry.GetLsn())
+	}
+
+	opType := byte(entry.GetOpType())
+	value := entry.GetValue()
+
+	write := &wal.WalEntryWrite{
+		OpType:      opType,
+		KeyLength:   int32(len(entry.GetKey())),
+		ValueLength: int32(len(value)),
+		KeyBytes:    []byte(entry.GetKey()),
+	}
+
+	if opType != wal.Del && opType != wal.Noop {
+		write.ValueBytes = &value
+	}
+
+	_, pending, err := store.wal.Enqueue(write)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = store.apply(&wal.WalEntry{
+		OpType:      opType,
+		LSN:         entry.GetLsn(),
+		KeyLength:   write.KeyLength,
+		ValueLength: write.ValueLength,
+		KeyBytes:    write.KeyBytes,
+		ValueBytes:  write.ValueBytes,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pending, nil
+}
@@ -0,0 +1,191 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ethan-stone/go-key-store/internal/configuration"
+	"github.com/ethan-stone/go-key-store/internal/rpc"
+	"github.com/ethan-stone/go-key-store/internal/service"
+)
+
+func openDurableStore(t *testing.T, dataDir string) *LocalKeyValueStore {
+	store, err := InitializeDurableLocalKeyValueStore(&DurableLocalKeyValueStoreConfig{DataDir: dataDir})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when initializing store %v", err)
+	}
+
+	return store
+}
+
+// startReplication streams primary to replica in the background, the way the
+// replicate rpc does.
+func startReplication(t *testing.T, primary *LocalKeyValueStore, replica *LocalKeyValueStore, req *rpc.ReplicateRequest) <-chan error {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	replica.replica.Store(true)
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- primary.StreamReplication(ctx, "primary", req, replica.receive)
+	}()
+
+	return done
+}
+
+// waitForValue waits for the key to have val in store.
+func waitForValue(t *testing.T, store *LocalKeyValueStore, key string, val string) {
+	deadline := time.Now().Add(5 * time.Second)
+
+	for time.Now().Before(deadline) {
+		r, _ := store.Get(key)
+
+		if r != nil && r.Ok && string(r.Val) == val {
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("Expected %s to be replicated as %s", key, val)
+}
+
+func TestReplicaShouldSyncFromSnapshotThenFollowWal(t *testing.T) {
+	primary := openDurableStore(t, t.TempDir())
+	defer primary.Close()
+
+	for i := range 250 {
+		primary.Put(fmt.Sprintf("key-%d", i), []byte("old"), nil)
+	}
+
+	replicaDir := t.TempDir()
+	replica := openDurableStore(t, replicaDir)
+
+	replica.Put("stale", []byte("1"), nil)
+
+	startReplication(t, primary, replica, &rpc.ReplicateRequest{})
+
+	waitForValue(t, replica, "key-249", "old")
+
+	primary.Put("key-0", []byte("new"), nil)
+	primary.Delete("key-1", nil)
+	primary.Batch([]*service.BatchOp{{Key: "{b}1", Val: []byte("1")}, {Key: "{b}2", Val: []byte("2")}})
+
+	waitForValue(t, replica, "{b}2", "2")
+
+	if r, _ := replica.Get("key-0"); string(r.Val) != "new" {
+		t.Errorf("Expected key-0 to be new, got %v", r)
+	}
+
+	if r, _ := replica.Get("key-1"); r.Ok {
+		t.Errorf("Did not expect to find key-1 on the replica")
+	}
+
+	if r, _ := replica.Get("stale"); r.Ok {
+		t.Errorf("Did not expect the replica to keep keys from before the sync")
+	}
+
+	if replica.wal.NextLSN() != primary.wal.NextLSN() {
+		t.Errorf("Expected the replica to be at LSN %d, got %d", primary.wal.NextLSN(), replica.wal.NextLSN())
+	}
+
+	_, err := replica.Put("key-0", []byte("x"), nil)
+
+	if !errors.Is(err, service.ErrReadOnlyReplica) {
+		t.Errorf("Expected a write to a replica to fail, got %v", err)
+	}
+
+	replica.Close()
+
+	recovered := openDurableStore(t, replicaDir)
+	defer recovered.Close()
+
+	if r, _ := recovered.Get("{b}1"); !r.Ok || string(r.Val) != "1" {
+		t.Errorf("Expected the replica to recover {b}1, got %v", r)
+	}
+
+	if r, _ := recovered.Get("key-0"); !r.Ok || string(r.Val) != "new" {
+		t.Errorf("Expected the replica to recover key-0, got %v", r)
+	}
+}
+
+func TestPrimaryShouldOnlySendTheTailToACaughtUpReplica(t *testing.T) {
+	primary := openDurableStore(t, t.TempDir())
+	defer primary.Close()
+
+	primary.Put("a", []byte("1"), nil)
+	primary.Put("b", []byte("2"), nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	responses := make(chan *rpc.ReplicateResponse, 10)
+
+	go primary.StreamReplication(ctx, "primary", &rpc.ReplicateRequest{PrimaryId: "primary", AfterLsn: 1}, func(r *rpc.ReplicateResponse) error {
+		responses <- r
+		return nil
+	})
+
+	select {
+	case r := <-responses:
+		if r.GetSnapshotStart() || r.GetEntry().GetLsn() != 2 || r.GetEntry().GetKey() != "b" {
+			t.Errorf("Expected only the entry for b, got %v", r)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected an entry from the primary")
+	}
+
+	other := make(chan *rpc.ReplicateResponse, 10)
+
+	go primary.StreamReplication(ctx, "primary", &rpc.ReplicateRequest{PrimaryId: "another", AfterLsn: 1}, func(r *rpc.ReplicateResponse) error {
+		other <- r
+		return nil
+	})
+
+	select {
+	case r := <-other:
+		if !r.GetSnapshotStart() || r.GetSnapshotLsn() != 2 {
+			t.Errorf("Expected a snapshot up to LSN 2 for a replica of another primary, got %v", r)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected a snapshot from the primary")
+	}
+}
+
+func TestReplicaShouldTakeWritesOncePromoted(t *testing.T) {
+	replica := openDurableStore(t, t.TempDir())
+	defer replica.Close()
+
+	replica.Put("a", []byte("1"), nil)
+	replica.replica.Store(true)
+
+	if _, err := replica.Put("a", []byte("2"), nil); !errors.Is(err, service.ErrReadOnlyReplica) {
+		t.Fatalf("Expected a write to a replica to fail, got %v", err)
+	}
+
+	configManager := configuration.NewBaseConfigurationManager(&configuration.ClusterConfig{
+		ThisNode: &configuration.NodeConfig{ID: "replica", Address: "localhost:8082", HashSlots: []int{0, 16383}},
+	})
+
+	err := replica.replicate(configManager, nil)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when promoting %v", err)
+	}
+
+	put, err := replica.Put("a", []byte("2"), nil)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when writing to a promoted replica %v", err)
+	}
+
+	if put.Version != 2 {
+		t.Errorf("Expected the promoted replica to carry on from LSN 1, got version %d", put.Version)
+	}
+}
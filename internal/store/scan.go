@@ -0,0 +1,212 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/ethan-stone/go-key-store/internal/configuration"
+	"github.com/ethan-stone/go-key-store/internal/rpc"
+	"github.com/ethan-stone/go-key-store/internal/service"
+)
+
+func (store *LocalKeyValueStore) Scan(options *service.ScanOptions) (*service.ScanResult, error) {
+	if options == nil {
+		options = &service.ScanOptions{}
+	}
+
+	err := store.waitForReadIndex()
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the first key that can match is the furthest along of these.
+	start := max(options.Prefix, options.Start, options.After)
+	now := store.currentTime()
+	result := &service.ScanResult{Items: []*service.ScanItem{}}
+
+	err = store.engine.IterateFrom(start, func(key string, stored string) bool {
+		if options.After != "" && key == options.After {
+			return true
+		}
+
+		// keys are in order, so once one is past the end or the prefix none
+		// of the rest can match either.
+		if options.End != "" && key >= options.End {
+			return false
+		}
+
+		if !strings.HasPrefix(key, options.Prefix) {
+			return false
+		}
+
+		if options.HashSlots != nil {
+			hashSlot := options.HashFunction.HashSlot(key)
+
+			if hashSlot < uint32(options.HashSlots[0]) || hashSlot > uint32(options.HashSlots[1]) {
+				return true
+			}
+		}
+
+		val := decodeValue(stored)
+
+		if val.expired(now) {
+			return true
+		}
+
+		if options.Limit > 0 && len(result.Items) == options.Limit {
+			result.More = true
+			return false
+		}
+
+		item := &service.ScanItem{
+			Key:         key,
+			Type:        valueTypes[val.valueType],
+			ContentType: val.contentType,
+			Version:     val.version,
+		}
+
+		if val.valueType == stringType {
+			item.Val = val.data
+		}
+
+		result.Items = append(result.Items, item)
+
+		return true
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// scanCursor is where a cluster scan got up to. Nodes are scanned one at a
+// time in the order of their hash slots, and each node's keys in ascending
+// order.
+type scanCursor struct {
+	Node string `json:"node"` // ID of the node being scanned
+	Slot int    `json:"slot"` // first hash slot of that node
+	Key  string `json:"key"`  // last key returned from the node, empty if none have been yet
+}
+
+func (cursor *scanCursor) encode() string {
+	encoded, _ := json.Marshal(cursor)
+
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+func decodeScanCursor(encoded string) (*scanCursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(encoded)
+
+	if err != nil {
+		return nil, service.ErrInvalidCursor
+	}
+
+	cursor := &scanCursor{}
+
+	err = json.Unmarshal(decoded, cursor)
+
+	if err != nil {
+		return nil, service.ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
+
+type ClusterScanResult struct {
+	Items  []*service.ScanItem
+	Cursor string // pass to the next ScanCluster to carry on, empty once every node has been scanned
+}
+
+// ScanCluster scans every node in the cluster, starting from cursor or from
+// the first node if cursor is empty. options.After and options.HashSlots are
+// set for each node from the cursor, and options.Limit is shared between
+// nodes. If hash slots move between nodes part way through a scan, keys in
+// the slots that moved can be returned twice or missed.
+func ScanCluster(options *service.ScanOptions, cursor string, clusterConfig *configuration.ClusterConfig, rpcClientManager rpc.RpcClientManager) (*ClusterScanResult, error) {
+	nodes := clusterConfig.Primaries()
+
+	// a node that hasn't joined a cluster yet, or one that has only been
+	// added to one, has no primaries to scan.
+	if len(nodes) == 0 {
+		return nil, errors.New("no node in the cluster config owns any hash slots")
+	}
+
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].HashSlots[0] < nodes[j].HashSlots[0]
+	})
+
+	position := &scanCursor{Node: nodes[0].ID, Slot: nodes[0].HashSlots[0]}
+
+	if cursor != "" {
+		decoded, err := decodeScanCursor(cursor)
+
+		if err != nil {
+			return nil, err
+		}
+
+		position = decoded
+	}
+
+	i := sort.Search(len(nodes), func(i int) bool {
+		return nodes[i].HashSlots[1] >= position.Slot
+	})
+
+	if i < len(nodes) && nodes[i].ID != position.Node {
+		log.Printf("Hash slot %d moved from node %s to %s during a scan", position.Slot, position.Node, nodes[i].ID)
+	}
+
+	result := &ClusterScanResult{Items: []*service.ScanItem{}}
+	after := position.Key
+
+	for ; i < len(nodes); i++ {
+		node := nodes[i]
+
+		store, err := getStoreForSlot(uint32(node.HashSlots[0]), clusterConfig, rpcClientManager)
+
+		if err != nil {
+			return nil, err
+		}
+
+		nodeOptions := *options
+		nodeOptions.After = after
+		nodeOptions.HashSlots = node.HashSlots
+		nodeOptions.HashFunction = clusterConfig.HashFunction
+
+		if options.Limit > 0 {
+			nodeOptions.Limit = options.Limit - len(result.Items)
+		}
+
+		nodeResult, err := store.Scan(&nodeOptions)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result.Items = append(result.Items, nodeResult.Items...)
+
+		if nodeResult.More {
+			last := result.Items[len(result.Items)-1].Key
+			result.Cursor = (&scanCursor{Node: node.ID, Slot: node.HashSlots[0], Key: last}).encode()
+
+			return result, nil
+		}
+
+		after = ""
+
+		if options.Limit > 0 && len(result.Items) == options.Limit && i+1 < len(nodes) {
+			next := nodes[i+1]
+			result.Cursor = (&scanCursor{Node: next.ID, Slot: next.HashSlots[0]}).encode()
+
+			return result, nil
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,176 @@
+package store
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ethan-stone/go-key-store/internal/configuration"
+	"github.com/ethan-stone/go-key-store/internal/engine"
+	"github.com/ethan-stone/go-key-store/internal/rpc"
+	"github.com/ethan-stone/go-key-store/internal/service"
+)
+
+func scanKeys(result *service.ScanResult) []string {
+	keys := []string{}
+
+	for _, item := range result.Items {
+		keys = append(keys, item.Key)
+	}
+
+	return keys
+}
+
+func TestScanShouldFilterByPrefixAndRange(t *testing.T) {
+	store, clock := newExpiringStore()
+
+	for _, key := range []string{"a", "b:1", "b:2", "b:3", "c"} {
+		store.Put(key, []byte(key), nil)
+	}
+
+	store.Put("b:0", []byte("gone"), &service.PutOptions{TTL: time.Second})
+	clock.now = clock.now.Add(time.Second)
+
+	result, err := store.Scan(&service.ScanOptions{Prefix: "b:"})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when scanning %v", err)
+	}
+
+	if keys := scanKeys(result); !slices.Equal(keys, []string{"b:1", "b:2", "b:3"}) || result.More {
+		t.Errorf("Expected b:1, b:2, b:3 without the expired b:0, got %v", keys)
+	}
+
+	result, _ = store.Scan(&service.ScanOptions{Start: "b:2", End: "c"})
+
+	if keys := scanKeys(result); !slices.Equal(keys, []string{"b:2", "b:3"}) {
+		t.Errorf("Expected b:2, b:3, got %v", keys)
+	}
+
+	result, _ = store.Scan(&service.ScanOptions{Limit: 2})
+
+	if keys := scanKeys(result); !slices.Equal(keys, []string{"a", "b:1"}) || !result.More {
+		t.Errorf("Expected a, b:1 and more to come, got %v %t", keys, result.More)
+	}
+
+	result, _ = store.Scan(&service.ScanOptions{After: "b:1", Limit: 10})
+
+	if keys := scanKeys(result); !slices.Equal(keys, []string{"b:2", "b:3", "c"}) || result.More {
+		t.Errorf("Expected b:2, b:3, c and nothing more, got %v %t", keys, result.More)
+	}
+}
+
+// storeRpcClient answers scans from a local store, standing in for another
+// node.
+type storeRpcClient struct {
+	MockRpcClient
+	store         *LocalKeyValueStore
+	clusterConfig *configuration.ClusterConfig
+}
+
+func (c *storeRpcClient) Scan(req *rpc.ScanRequest) ([]*rpc.ScanItem, bool, error) {
+	result, err := c.store.Scan(&service.ScanOptions{
+		Prefix:       req.GetPrefix(),
+		After:        req.GetAfter(),
+		Limit:        int(req.GetLimit()),
+		HashSlots:    []int{int(req.GetHashSlots().GetStart()), int(req.GetHashSlots().GetEnd())},
+		HashFunction: c.clusterConfig.HashFunction,
+	})
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	items := []*rpc.ScanItem{}
+
+	for _, item := range result.Items {
+		items = append(items, &rpc.ScanItem{Key: item.Key, Val: item.Val})
+	}
+
+	return items, result.More, nil
+}
+
+func TestScanClusterShouldVisitEveryNodeOnce(t *testing.T) {
+	clusterConfig := &configuration.ClusterConfig{
+		ThisNode:   &configuration.NodeConfig{ID: "this", Address: "localhost:8081", HashSlots: []int{8192, 16383}},
+		OtherNodes: []*configuration.NodeConfig{{ID: "other", Address: "localhost:8083", HashSlots: []int{0, 8191}}},
+	}
+
+	local := InitializeLocalKeyValueStore()
+	remote := &LocalKeyValueStore{engine: engine.NewMemoryEngine()}
+
+	expected := []string{}
+
+	for i := range 20 {
+		key := fmt.Sprintf("user:%d", i)
+		expected = append(expected, key)
+
+		if clusterConfig.HashFunction.HashSlot(key) >= 8192 {
+			local.Put(key, []byte("1"), nil)
+		} else {
+			remote.Put(key, []byte("1"), nil)
+		}
+	}
+
+	remote.Put("other:1", []byte("1"), nil)
+
+	mockRpcClientManager := &MockRpcClientManager{
+		MockGetOrCreateRpcClient: func(
+			config *rpc.RpcClientConfig,
+		) (rpc.RpcClient, error) {
+			return &storeRpcClient{store: remote, clusterConfig: clusterConfig}, nil
+		},
+	}
+
+	keys := []string{}
+	cursor := ""
+
+	for range 20 {
+		result, err := ScanCluster(&service.ScanOptions{Prefix: "user:", Limit: 3}, cursor, clusterConfig, mockRpcClientManager)
+
+		if err != nil {
+			t.Fatalf("Did not expect an error when scanning the cluster %v", err)
+		}
+
+		if len(result.Items) > 3 {
+			t.Errorf("Expected at most 3 items a page, got %d", len(result.Items))
+		}
+
+		for _, item := range result.Items {
+			keys = append(keys, item.Key)
+		}
+
+		cursor = result.Cursor
+
+		if cursor == "" {
+			break
+		}
+	}
+
+	slices.Sort(keys)
+	slices.Sort(expected)
+
+	if !slices.Equal(keys, expected) {
+		t.Errorf("Expected every user key exactly once, got %v", keys)
+	}
+
+	_, err := ScanCluster(&service.ScanOptions{Limit: 3}, "not a cursor", clusterConfig, mockRpcClientManager)
+
+	if err == nil {
+		t.Errorf("Expected an error for an invalid cursor")
+	}
+}
+
+func TestScanClusterShouldFailWithoutPrimaries(t *testing.T) {
+	// a node that was added to a cluster, and is all it knows about so far.
+	clusterConfig := &configuration.ClusterConfig{
+		ThisNode: &configuration.NodeConfig{ID: "this", Address: "localhost:8081", HashSlots: []int{16384, 16383}},
+	}
+
+	_, err := ScanCluster(&service.ScanOptions{Limit: 3}, "", clusterConfig, &MockRpcClientManager{})
+
+	if err == nil {
+		t.Errorf("Expected an error when no node owns any hash slots")
+	}
+}
@@ -0,0 +1,69 @@
+package store
+
+import (
+	"bytes"
+	"slices"
+)
+
+// set members are kept sorted, so looking one up is a binary search.
+func (store *LocalKeyValueStore) SetAdd(key string, members [][]byte) (int, error) {
+	added := 0
+
+	err := store.updateCollection(key, setType, func(set [][]byte) ([][]byte, bool, error) {
+		for _, member := range members {
+			i, found := slices.BinarySearchFunc(set, member, bytes.Compare)
+
+			if !found {
+				set = slices.Insert(set, i, member)
+				added++
+			}
+		}
+
+		return set, added > 0, nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return added, nil
+}
+
+func (store *LocalKeyValueStore) SetRemove(key string, members [][]byte) (int, error) {
+	removed := 0
+
+	err := store.updateCollection(key, setType, func(set [][]byte) ([][]byte, bool, error) {
+		for _, member := range members {
+			i, found := slices.BinarySearchFunc(set, member, bytes.Compare)
+
+			if found {
+				set = slices.Delete(set, i, i+1)
+				removed++
+			}
+		}
+
+		return set, removed > 0, nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return removed, nil
+}
+
+func (store *LocalKeyValueStore) SetMembers(key string) ([][]byte, error) {
+	return store.readCollection(key, setType)
+}
+
+func (store *LocalKeyValueStore) SetIsMember(key string, member []byte) (bool, error) {
+	set, err := store.readCollection(key, setType)
+
+	if err != nil {
+		return false, err
+	}
+
+	_, found := slices.BinarySearchFunc(set, member, bytes.Compare)
+
+	return found, nil
+}
@@ -0,0 +1,536 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/ethan-stone/go-key-store/internal/configuration"
+	"github.com/ethan-stone/go-key-store/internal/raft"
+	"github.com/ethan-stone/go-key-store/internal/rpc"
+	"github.com/ethan-stone/go-key-store/internal/service"
+	"github.com/ethan-stone/go-key-store/internal/wal"
+	"google.golang.org/protobuf/proto"
+)
+
+// With raft replication a primary and its replicas are a raft group, a shard,
+// named after their hash slots. The shard's log is the WAL, every entry's raft
+// index being its LSN. A write is checked on the leader under the store lock
+// as usual, but it is only logged and applied once the shard has committed it,
+// so it is acknowledged once a majority of the shard has it. The other members
+// log and apply every entry as they learn it is committed. Entries that aren't
+// writes, like the one a new leader appends, are logged as no-ops so the LSNs
+// stay lined up. Writes to a shard are committed one at a time, since the
+// write lock is held until they are, but the store lock is let go while the
+// shard commits one so reads aren't held up by it.
+//
+// Reads on the leader wait for everything committed before they started to be
+// applied, once the leader has made sure it still is one. A stale read can be
+// answered by any member from its own copy.
+//
+// The leader of a shard is made the primary in the cluster config, so keys are
+// routed to it like they are to any other primary. The config's primary starts
+// the shard with the data it already has, and a member that is missing entries
+// the leader has dropped, including one that has just joined, loads a
+// snapshot from the leader over the replication stream.
+const (
+	shardCheckInterval = 500 * time.Millisecond
+	// shardLogRetained is how many applied entries a shard keeps in its raft
+	// log, so a member that is a little behind doesn't need a snapshot.
+	shardLogRetained = 1000
+)
+
+// ShardHost hands raft messages to the shard on this node they are for.
+type ShardHost interface {
+	AddGroup(name string, node *raft.Node)
+	RemoveGroup(name string)
+}
+
+type raftShards struct {
+	store            *LocalKeyValueStore
+	configManager    configuration.ConfigurationManager
+	rpcClientManager rpc.RpcClientManager
+	proposer         ConfigProposer
+	host             ShardHost
+	node             *raft.Node // nil while this node isn't in a shard
+	name             string
+	joinedAt         time.Time
+	compacted        uint64
+}
+
+// StartRaftShards keeps this node in the shard for its hash slots in the
+// background while the cluster uses raft replication.
+func (store *LocalKeyValueStore) StartRaftShards(configManager configuration.ConfigurationManager, rpcClientManager rpc.RpcClientManager, proposer ConfigProposer, host ShardHost) {
+	shards := &raftShards{
+		store:            store,
+		configManager:    configManager,
+		rpcClientManager: rpcClientManager,
+		proposer:         proposer,
+		host:             host,
+	}
+
+	go func() {
+		for range time.NewTicker(shardCheckInterval).C {
+			shards.check()
+		}
+	}()
+}
+
+// check joins or leaves a shard when the config changes, and makes sure the
+// config's primary is the shard's leader.
+func (shards *raftShards) check() {
+	store := shards.store
+	clusterConfig := shards.configManager.GetClusterConfig()
+	thisNode := clusterConfig.ThisNode
+
+	if clusterConfig.ReplicationMode != configuration.RaftReplication || clusterConfig.Epoch == 0 || store.wal == nil {
+		shards.leave()
+		return
+	}
+
+	primary := thisNode
+
+	if thisNode.IsReplica() {
+		primary = clusterConfig.FindNode(thisNode.ReplicaOf)
+	}
+
+	// a node added without hash slots has nothing to replicate yet.
+	if primary == nil || !primary.HasHashSlots() {
+		return
+	}
+
+	name := fmt.Sprintf("slots-%d-%d", primary.HashSlots[0], primary.HashSlots[1])
+
+	if shards.node == nil || shards.name != name {
+		shards.leave()
+
+		err := shards.join(name, thisNode)
+
+		if err != nil {
+			log.Printf("Failed to join raft shard %s %v", name, err)
+		}
+
+		return
+	}
+
+	node := shards.node
+	members := shardMembers(clusterConfig, primary)
+
+	if node.LastIndex() == 0 {
+		// an existing leader gets an election timeout to reach this node
+		// before the primary starts the shard.
+		if !thisNode.IsReplica() && time.Since(shards.joinedAt) > raft.DefaultElectionTimeout {
+			shards.start()
+		}
+
+		return
+	}
+
+	shards.compact()
+
+	if !node.IsLeader() {
+		store.replica.Store(true)
+
+		if _, leaderAddress := node.Leader(); store.shardBehind.Load() && leaderAddress != "" {
+			err := shards.installSnapshot(node, leaderAddress)
+
+			if err != nil {
+				log.Printf("Failed to catch up with raft shard %s %v", name, err)
+			}
+		}
+
+		return
+	}
+
+	// a new leader applies everything from before it was elected first.
+	if !store.caughtUpWithShard() {
+		return
+	}
+
+	if store.replica.Load() {
+		err := store.promote()
+
+		if err != nil {
+			log.Printf("Failed to start taking writes for raft shard %s %v", name, err)
+			return
+		}
+	}
+
+	if thisNode.IsReplica() {
+		promoted := clusterConfig.Promote(thisNode.ID)
+
+		log.Printf("Leading raft shard %s, taking over from primary %s", name, primary.ID)
+
+		err := shards.proposer.ProposeClusterConfig(append([]*configuration.NodeConfig{promoted.ThisNode}, promoted.OtherNodes...), promoted.ReplicationMode, promoted.Quorum, clusterConfig.Epoch, true)
+
+		if err != nil {
+			log.Printf("Failed to take over as primary %v", err)
+		}
+
+		return
+	}
+
+	// members are added or removed one at a time.
+	for next := raft.NextMembers(node.Members(), members); next != nil; next = raft.NextMembers(node.Members(), members) {
+		log.Printf("Changing the members of raft shard %s to %v", name, next)
+
+		err := store.changeShardMembers(next)
+
+		if err != nil {
+			log.Printf("Failed to change the members of raft shard %s %v", name, err)
+			return
+		}
+	}
+}
+
+// join opens the shard, which has everything this node has been sent for it
+// if it has been in it before.
+func (shards *raftShards) join(name string, thisNode *configuration.NodeConfig) error {
+	store := shards.store
+
+	var node *raft.Node
+
+	node, err := raft.OpenNode(&raft.NodeConfig{
+		ID:        thisNode.ID,
+		Address:   thisNode.Address,
+		Group:     name,
+		Dir:       filepath.Join(store.dataDir, "raft"),
+		Transport: raft.NewRpcTransport(shards.rpcClientManager),
+		Apply:     store.applyShardEntry,
+		InstallSnapshot: func(leaderAddress string) error {
+			return shards.installSnapshot(node, leaderAddress)
+		},
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// a replica joining the shard for the first time is sent everything by
+	// the leader, starting from the first LSN if the leader has no more.
+	if node.LastIndex() == 0 && thisNode.IsReplica() && store.wal.NextLSN() > 1 {
+		err = store.startSync(0)
+
+		if err != nil {
+			node.Close()
+			return err
+		}
+	}
+
+	store.Lock()
+	store.shard = node
+	store.replica.Store(true)
+	store.Unlock()
+
+	shards.host.AddGroup(name, node)
+	shards.node = node
+	shards.name = name
+	shards.joinedAt = time.Now()
+	shards.compacted = 0
+
+	log.Printf("Joined raft shard %s", name)
+
+	return nil
+}
+
+func (shards *raftShards) leave() {
+	if shards.node == nil {
+		return
+	}
+
+	shards.host.RemoveGroup(shards.name)
+
+	shards.store.Lock()
+	shards.store.shard = nil
+	shards.store.Unlock()
+
+	shards.node.Close()
+
+	log.Printf("Left raft shard %s", shards.name)
+
+	shards.node = nil
+	shards.name = ""
+}
+
+// start makes this node the shard's first leader and only member, carrying on
+// from the last entry in its WAL. The other members are added once it has
+// logged the no-op the shard starts with.
+func (shards *raftShards) start() {
+	store := shards.store
+
+	store.RLock()
+	index := store.wal.NextLSN()
+	store.RUnlock()
+
+	log.Printf("Starting raft shard %s at LSN %d", shards.name, index)
+
+	err := shards.node.Bootstrap(index - 1)
+
+	if err != nil {
+		log.Printf("Failed to start raft shard %s %v", shards.name, err)
+	}
+}
+
+// installSnapshot loads a snapshot from the leader, and carries on from it.
+func (shards *raftShards) installSnapshot(node *raft.Node, leaderAddress string) error {
+	store := shards.store
+
+	client, err := shards.rpcClientManager.GetOrCreateRpcClient(&rpc.RpcClientConfig{
+		Address: leaderAddress,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	defer cancel()
+
+	loaded := false
+
+	var lsn, term uint64
+
+	// without a primary ID the leader always starts with a snapshot.
+	err = client.Replicate(ctx, &rpc.ReplicateRequest{
+		ReplicaId: shards.configManager.GetClusterConfig().ThisNode.ID,
+	}, func(r *rpc.ReplicateResponse) error {
+		if loaded {
+			return nil
+		}
+
+		err := store.receive(r)
+
+		if err != nil {
+			return err
+		}
+
+		if r.GetSnapshotEnd() {
+			loaded, lsn, term = true, r.GetSnapshotLsn(), r.GetRaftTerm()
+			cancel()
+		}
+
+		return nil
+	})
+
+	if !loaded {
+		if err == nil {
+			err = errors.New("the leader stopped before sending a snapshot")
+		}
+
+		return err
+	}
+
+	store.shardBehind.Store(false)
+
+	return node.Restore(lsn, term)
+}
+
+// compact drops the raft log entries the WAL makes redundant, every so often.
+func (shards *raftShards) compact() {
+	store := shards.store
+
+	store.RLock()
+	applied := store.wal.NextLSN() - 1
+	store.RUnlock()
+
+	if applied < shards.compacted+2*shardLogRetained {
+		return
+	}
+
+	err := shards.node.Compact(applied - shardLogRetained)
+
+	if err != nil {
+		log.Printf("Failed to compact raft shard %s %v", shards.name, err)
+		return
+	}
+
+	shards.compacted = applied - shardLogRetained
+}
+
+// shardMembers is the primary and its replicas.
+func shardMembers(clusterConfig *configuration.ClusterConfig, primary *configuration.NodeConfig) []raft.Member {
+	members := []raft.Member{{ID: primary.ID, Address: primary.Address}}
+
+	for _, node := range append([]*configuration.NodeConfig{clusterConfig.ThisNode}, clusterConfig.OtherNodes...) {
+		if node.ReplicaOf == primary.ID {
+			members = append(members, raft.Member{ID: node.ID, Address: node.Address})
+		}
+	}
+
+	return members
+}
+
+// encodeShardEntry is the data of the raft entry for a WAL entry.
+func encodeShardEntry(entry *wal.WalEntryWrite, lsn uint64) ([]byte, error) {
+	replicationEntry := &rpc.ReplicationEntry{
+		Lsn:    lsn,
+		OpType: uint32(entry.OpType),
+		Key:    string(entry.KeyBytes),
+	}
+
+	if entry.ValueBytes != nil {
+		replicationEntry.Value = *entry.ValueBytes
+	}
+
+	return proto.Marshal(replicationEntry)
+}
+
+// commitToShardLocked waits for the shard to commit the entry at the next LSN,
+// with members as the shard's members, or the ones it has if nil. The caller
+// holds the store lock from lockWrites, and logs and applies the entry once it
+// is committed. The store lock is let go while the shard commits the entry,
+// but the write lock isn't, so the engine and the WAL are just as the caller
+// left them when it is taken again.
+func (store *LocalKeyValueStore) commitToShardLocked(entry *wal.WalEntryWrite, members []raft.Member) error {
+	shard := store.shard
+
+	// the shard hasn't been started yet, though it may know who has.
+	if shard.LastIndex() == 0 {
+		_, leaderAddress := shard.Leader()
+		return &service.NotLeaderError{LeaderAddress: leaderAddress}
+	}
+
+	index := store.wal.NextLSN()
+
+	data, err := encodeShardEntry(entry, index)
+
+	if err != nil {
+		return err
+	}
+
+	store.Unlock()
+
+	err = shard.ProposeAt(index, data, members)
+
+	store.Lock()
+
+	return err
+}
+
+// changeShardMembers commits a no-op that changes the shard's members.
+func (store *LocalKeyValueStore) changeShardMembers(members []raft.Member) error {
+	store.lockWrites()
+
+	if store.shard == nil {
+		store.unlockWrites()
+		return errors.New("this node isn't in a raft shard")
+	}
+
+	noop := &wal.WalEntryWrite{OpType: wal.Noop}
+
+	err := store.commitToShardLocked(noop, members)
+
+	var pending *wal.PendingWrite
+
+	if err == nil {
+		_, pending, err = store.wal.Enqueue(noop)
+	}
+
+	store.unlockWrites()
+
+	if err != nil {
+		return err
+	}
+
+	return pending.Wait()
+}
+
+// applyShardEntry logs and applies an entry the shard has committed, unless
+// this node already has it.
+func (store *LocalKeyValueStore) applyShardEntry(entry *raft.Entry) {
+	replicationEntry := &rpc.ReplicationEntry{Lsn: entry.Index, OpType: wal.Noop}
+
+	if len(entry.Data) > 0 {
+		err := proto.Unmarshal(entry.Data, replicationEntry)
+
+		if err != nil {
+			log.Printf("Failed to read raft shard entry %d %v", entry.Index, err)
+			return
+		}
+	}
+
+	store.lockWrites()
+
+	// the leader logs its own writes as soon as they are committed, and a
+	// snapshot can already have the entry.
+	if next := store.wal.NextLSN(); entry.Index != next {
+		if entry.Index > next {
+			log.Printf("Raft shard entry %d is past the end of the WAL at %d, catching up from the leader", entry.Index, next)
+			store.shardBehind.Store(true)
+		}
+
+		store.unlockWrites()
+		return
+	}
+
+	pending, err := store.applyReplicatedLocked(replicationEntry)
+
+	store.unlockWrites()
+
+	if err == nil {
+		err = pending.Wait()
+	}
+
+	if err != nil {
+		log.Printf("Failed to apply raft shard entry %d %v", entry.Index, err)
+	}
+}
+
+// caughtUpWithShard reports whether the WAL has every entry in the shard's
+// raft log.
+func (store *LocalKeyValueStore) caughtUpWithShard() bool {
+	store.RLock()
+	defer store.RUnlock()
+
+	return store.shard != nil && store.shard.LastIndex() == store.wal.NextLSN()-1
+}
+
+// waitForReadIndex makes a read on a shard's leader see every write committed
+// before it started. It does nothing outside of raft replication.
+func (store *LocalKeyValueStore) waitForReadIndex() error {
+	store.RLock()
+	shard := store.shard
+	store.RUnlock()
+
+	if shard == nil {
+		return nil
+	}
+
+	index, err := shard.ReadIndex()
+
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(raft.ProposeTimeout)
+
+	for {
+		store.RLock()
+		applied := store.wal.NextLSN() - 1
+		store.RUnlock()
+
+		if applied >= index {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return raft.ErrReadTimeout
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// staleStore reads this node's own copy, even on a shard member that isn't
+// the leader.
+type staleStore struct {
+	*LocalKeyValueStore
+}
+
+func (store staleStore) Get(key string) (*service.GetResult, error) {
+	return store.get(key)
+}
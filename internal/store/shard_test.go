@@ -0,0 +1,231 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethan-stone/go-key-store/internal/raft"
+	"github.com/ethan-stone/go-key-store/internal/rpc"
+	"github.com/ethan-stone/go-key-store/internal/service"
+)
+
+// shardTransport delivers raft messages between shards in the same process.
+type shardTransport struct {
+	sync.Mutex
+	nodes map[string]*raft.Node
+}
+
+func (transport *shardTransport) node(address string) (*raft.Node, error) {
+	transport.Lock()
+	defer transport.Unlock()
+
+	node, ok := transport.nodes[address]
+
+	if !ok {
+		return nil, fmt.Errorf("can't reach %s", address)
+	}
+
+	return node, nil
+}
+
+func (transport *shardTransport) RequestVote(address string, req *rpc.RaftVoteRequest) (*rpc.RaftVoteResponse, error) {
+	node, err := transport.node(address)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return node.HandleVote(req), nil
+}
+
+func (transport *shardTransport) AppendEntries(address string, req *rpc.RaftAppendRequest) (*rpc.RaftAppendResponse, error) {
+	node, err := transport.node(address)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return node.HandleAppend(req), nil
+}
+
+// joinTestShard puts store in a shard as id, the way joining one does.
+func joinTestShard(t *testing.T, transport *shardTransport, store *LocalKeyValueStore, id string) *raft.Node {
+	node, err := raft.OpenNode(&raft.NodeConfig{
+		ID:                id,
+		Address:           id,
+		Group:             "slots-0-16383",
+		Dir:               t.TempDir(),
+		Transport:         transport,
+		Apply:             store.applyShardEntry,
+		ElectionTimeout:   100 * time.Millisecond,
+		HeartbeatInterval: 20 * time.Millisecond,
+	})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when joining the shard %v", err)
+	}
+
+	t.Cleanup(node.Close)
+
+	store.Lock()
+	store.shard = node
+	store.replica.Store(true)
+	store.Unlock()
+
+	transport.Lock()
+	transport.nodes[id] = node
+	transport.Unlock()
+
+	return node
+}
+
+// startTestShard starts a shard led by leader, with follower as its other
+// member.
+func startTestShard(t *testing.T, transport *shardTransport, leader *LocalKeyValueStore, follower *LocalKeyValueStore) {
+	members := []raft.Member{{ID: "leader", Address: "leader"}, {ID: "follower", Address: "follower"}}
+
+	shards := &raftShards{store: leader, node: joinTestShard(t, transport, leader, "leader"), name: "slots-0-16383"}
+	joinTestShard(t, transport, follower, "follower")
+
+	shards.start()
+
+	if !shards.node.IsLeader() {
+		t.Fatalf("Expected the node that started the shard to lead it")
+	}
+
+	// the leader only takes writes once it has applied everything.
+	for !leader.caughtUpWithShard() {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	err := leader.promote()
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when promoting the leader %v", err)
+	}
+
+	// the shard starts with just the leader, and the follower is added after.
+	if len(shards.node.Members()) != 1 {
+		t.Fatalf("Expected the shard to start with one member, got %v", shards.node.Members())
+	}
+
+	err = leader.changeShardMembers(members)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when adding the follower %v", err)
+	}
+}
+
+func TestShardShouldOnlyTakeWritesOnItsLeader(t *testing.T) {
+	transport := &shardTransport{nodes: make(map[string]*raft.Node)}
+
+	leader := openDurableStore(t, t.TempDir())
+	defer leader.Close()
+
+	follower := openDurableStore(t, t.TempDir())
+	defer follower.Close()
+
+	startTestShard(t, transport, leader, follower)
+
+	_, err := leader.Put("a", []byte("1"), nil)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when writing to the leader %v", err)
+	}
+
+	r, err := leader.Get("a")
+
+	if err != nil || !r.Ok || string(r.Val) != "1" {
+		t.Fatalf("Expected the leader to read its own write, got %v %v", r, err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for !follower.caughtUpWithShard() || follower.wal.NextLSN() != leader.wal.NextLSN() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the follower to apply the write")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	r, err = staleStore{follower}.Get("a")
+
+	if err != nil || !r.Ok || string(r.Val) != "1" {
+		t.Fatalf("Expected a stale read on the follower to see the write, got %v %v", r, err)
+	}
+
+	_, err = follower.Get("a")
+
+	if !errors.Is(err, service.ErrNotLeader) {
+		t.Errorf("Expected a read on the follower to be sent to the leader, got %v", err)
+	}
+
+	_, err = follower.Put("b", []byte("2"), nil)
+
+	if !errors.Is(err, service.ErrNotLeader) {
+		t.Errorf("Expected a write on the follower to be sent to the leader, got %v", err)
+	}
+
+	// an entry past the end of the WAL means the follower has missed some.
+	follower.applyShardEntry(&raft.Entry{Index: follower.wal.NextLSN() + 5})
+
+	if !follower.shardBehind.Load() {
+		t.Errorf("Expected the follower to catch up from the leader after missing entries")
+	}
+}
+
+func TestShardWriteShouldNotHoldUpReads(t *testing.T) {
+	transport := &shardTransport{nodes: make(map[string]*raft.Node)}
+
+	leader := openDurableStore(t, t.TempDir())
+	defer leader.Close()
+
+	follower := openDurableStore(t, t.TempDir())
+	defer follower.Close()
+
+	startTestShard(t, transport, leader, follower)
+
+	_, err := leader.Put("a", []byte("1"), nil)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when writing to the leader %v", err)
+	}
+
+	// without the follower the shard can't commit anything.
+	transport.Lock()
+	delete(transport.nodes, "follower")
+	transport.Unlock()
+
+	written := make(chan error)
+
+	go func() {
+		_, err := leader.Put("a", []byte("2"), nil)
+		written <- err
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	read := make(chan *service.GetResult)
+
+	go func() {
+		r, _ := staleStore{leader}.Get("a")
+		read <- r
+	}()
+
+	select {
+	case r := <-read:
+		if r == nil || string(r.Val) != "1" {
+			t.Errorf("Expected the read to see the last committed write, got %v", r)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Expected a read not to wait for a write the shard is committing")
+	}
+
+	if err := <-written; err == nil {
+		t.Errorf("Expected the write to fail without the follower")
+	}
+}
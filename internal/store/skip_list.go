@@ -0,0 +1,193 @@
+package store
+
+import (
+	"math/rand/v2"
+)
+
+// skipList keeps sorted set members in order of score, then member. Every
+// link also knows how many nodes it skips over, so finding a member's rank or
+// the member at a rank takes O(log n) like any other lookup.
+type skipList struct {
+	head   *skipListNode
+	level  int
+	length int
+}
+
+type skipListNode struct {
+	member string
+	score  float64
+	levels []skipListLevel
+}
+
+type skipListLevel struct {
+	next *skipListNode
+	span int // how many nodes next is past this one
+}
+
+const (
+	skipListMaxLevel = 32
+	skipListP        = 0.25
+)
+
+func newSkipList() *skipList {
+	return &skipList{
+		head:  &skipListNode{levels: make([]skipListLevel, skipListMaxLevel)},
+		level: 1,
+	}
+}
+
+func randomSkipListLevel() int {
+	level := 1
+
+	for level < skipListMaxLevel && rand.Float64() < skipListP {
+		level++
+	}
+
+	return level
+}
+
+// before is true if node comes before the member with the given score.
+func (node *skipListNode) before(member string, score float64) bool {
+	return node.score < score || (node.score == score && node.member < member)
+}
+
+// insert adds a member, which must not already be in the list.
+func (list *skipList) insert(member string, score float64) {
+	var update [skipListMaxLevel]*skipListNode
+	var rank [skipListMaxLevel]int
+
+	node := list.head
+
+	for i := list.level - 1; i >= 0; i-- {
+		if i < list.level-1 {
+			rank[i] = rank[i+1]
+		}
+
+		for node.levels[i].next != nil && node.levels[i].next.before(member, score) {
+			rank[i] += node.levels[i].span
+			node = node.levels[i].next
+		}
+
+		update[i] = node
+	}
+
+	level := randomSkipListLevel()
+
+	for i := list.level; i < level; i++ {
+		update[i] = list.head
+		update[i].levels[i].span = list.length
+	}
+
+	list.level = max(list.level, level)
+
+	inserted := &skipListNode{member: member, score: score, levels: make([]skipListLevel, level)}
+
+	for i := range level {
+		inserted.levels[i].next = update[i].levels[i].next
+		update[i].levels[i].next = inserted
+		inserted.levels[i].span = update[i].levels[i].span - (rank[0] - rank[i])
+		update[i].levels[i].span = rank[0] - rank[i] + 1
+	}
+
+	for i := level; i < list.level; i++ {
+		update[i].levels[i].span++
+	}
+
+	list.length++
+}
+
+// remove takes the member out of the list, and returns false if it wasn't in
+// it with that score.
+func (list *skipList) remove(member string, score float64) bool {
+	var update [skipListMaxLevel]*skipListNode
+
+	node := list.head
+
+	for i := list.level - 1; i >= 0; i-- {
+		for node.levels[i].next != nil && node.levels[i].next.before(member, score) {
+			node = node.levels[i].next
+		}
+
+		update[i] = node
+	}
+
+	node = node.levels[0].next
+
+	if node == nil || node.member != member || node.score != score {
+		return false
+	}
+
+	for i := range list.level {
+		if update[i].levels[i].next == node {
+			update[i].levels[i].span += node.levels[i].span - 1
+			update[i].levels[i].next = node.levels[i].next
+		} else {
+			update[i].levels[i].span--
+		}
+	}
+
+	for list.level > 1 && list.head.levels[list.level-1].next == nil {
+		list.level--
+	}
+
+	list.length--
+
+	return true
+}
+
+// rank returns the member's 0 based rank, or -1 if it isn't in the list with
+// that score.
+func (list *skipList) rank(member string, score float64) int {
+	node := list.head
+	rank := 0
+
+	for i := list.level - 1; i >= 0; i-- {
+		for next := node.levels[i].next; next != nil && (next.before(member, score) || (next.member == member && next.score == score)); next = node.levels[i].next {
+			rank += node.levels[i].span
+			node = node.levels[i].next
+		}
+
+		if node != list.head && node.member == member && node.score == score {
+			return rank - 1
+		}
+	}
+
+	return -1
+}
+
+// at returns the node at a 0 based rank, or nil if the list isn't that long.
+func (list *skipList) at(rank int) *skipListNode {
+	if rank < 0 || rank >= list.length {
+		return nil
+	}
+
+	node := list.head
+	traversed := 0
+
+	for i := list.level - 1; i >= 0; i-- {
+		for node.levels[i].next != nil && traversed+node.levels[i].span <= rank+1 {
+			traversed += node.levels[i].span
+			node = node.levels[i].next
+		}
+
+		if traversed == rank+1 {
+			return node
+		}
+	}
+
+	return nil
+}
+
+// firstFrom returns the first node with a score of at least min, or nil if
+// there isn't one.
+func (list *skipList) firstFrom(min float64) *skipListNode {
+	node := list.head
+
+	for i := list.level - 1; i >= 0; i-- {
+		for node.levels[i].next != nil && node.levels[i].next.score < min {
+			node = node.levels[i].next
+		}
+	}
+
+	return node.levels[0].next
+}
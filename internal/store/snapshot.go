@@ -0,0 +1,229 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/ethan-stone/go-key-store/internal/engine"
+	"github.com/ethan-stone/go-key-store/internal/wal"
+)
+
+var snapshotMagic = [4]byte{'G', 'K', 'S', 'S'}
+
+const snapshotVersion = 1
+
+// snapshotHeader is written at the start of every snapshot file.
+//
+// | Field         | Size (bytes) | Purpose                                                 |
+// | ------------- | ------------ | ------------------------------------------------------- |
+// | Magic         | 4            | "GKSS", so other files are never mistaken for snapshots |
+// | Version       | 4            | Format version of the rest of the file                  |
+// | WalSegment    | 8            | The WAL segment that was active when it was cut         |
+// | WalOffset     | 8            | Offset into that segment the snapshot covers up to      |
+// | EntryCount    | 8            | Number of key value pairs that follow                   |
+//
+// Each entry is a 4 byte key length, a 4 byte value length, then the key and
+// value bytes. The file ends with a CRC32 of every byte before it.
+type snapshotHeader struct {
+	Magic      [4]byte
+	Version    uint32
+	WalSegment uint64
+	WalOffset  int64
+	EntryCount uint64
+}
+
+type snapshot struct {
+	walPosition wal.Position // the first WAL position not covered by the snapshot
+	data        map[string]string
+}
+
+// writeSnapshot writes everything in view to a temporary file and renames it
+// into place, so a crash part way through never leaves a partial snapshot at
+// path. walPosition is the first WAL position the view doesn't cover.
+func writeSnapshot(path string, walPosition wal.Position, view engine.Snapshot) error {
+	entryCount := uint64(0)
+
+	err := view.Iterate(func(key string, val string) bool {
+		entryCount++
+
+		return true
+	})
+
+	if err != nil {
+		return err
+	}
+
+	tmpPath := path + ".tmp"
+
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmpPath)
+
+	hasher := crc32.NewIEEE()
+	buf := bufio.NewWriter(io.MultiWriter(file, hasher))
+
+	header := &snapshotHeader{
+		Magic:      snapshotMagic,
+		Version:    snapshotVersion,
+		WalSegment: walPosition.Segment,
+		WalOffset:  walPosition.Offset,
+		EntryCount: entryCount,
+	}
+
+	err = binary.Write(buf, binary.LittleEndian, header)
+
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	var writeErr error
+
+	err = view.Iterate(func(key string, val string) bool {
+		lengths := [2]uint32{uint32(len(key)), uint32(len(val))}
+
+		writeErr = binary.Write(buf, binary.LittleEndian, lengths)
+
+		if writeErr != nil {
+			return false
+		}
+
+		buf.WriteString(key)
+		buf.WriteString(val)
+
+		return true
+	})
+
+	if err == nil {
+		err = writeErr
+	}
+
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	err = buf.Flush()
+
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	err = binary.Write(file, binary.LittleEndian, hasher.Sum32())
+
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	err = file.Sync()
+
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	err = file.Close()
+
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmpPath, path)
+
+	if err != nil {
+		return err
+	}
+
+	return syncDir(filepath.Dir(path))
+}
+
+// readSnapshot loads and validates the snapshot at path. Any snapshot that
+// is truncated or fails its checksum is rejected as a whole.
+func readSnapshot(path string) (*snapshot, error) {
+	contents, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(contents) < binary.Size(snapshotHeader{})+4 {
+		return nil, fmt.Errorf("snapshot %s is too short", path)
+	}
+
+	body := contents[:len(contents)-4]
+	storedChecksum := binary.LittleEndian.Uint32(contents[len(contents)-4:])
+
+	if crc32.ChecksumIEEE(body) != storedChecksum {
+		return nil, fmt.Errorf("snapshot %s checksum mismatch", path)
+	}
+
+	reader := bytes.NewReader(body)
+
+	var header snapshotHeader
+
+	err = binary.Read(reader, binary.LittleEndian, &header)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if header.Magic != snapshotMagic {
+		return nil, fmt.Errorf("snapshot %s has invalid magic bytes", path)
+	}
+
+	if header.Version != snapshotVersion {
+		return nil, fmt.Errorf("snapshot %s has unsupported version %d", path, header.Version)
+	}
+
+	data := make(map[string]string, header.EntryCount)
+
+	for range header.EntryCount {
+		var lengths [2]uint32
+
+		err = binary.Read(reader, binary.LittleEndian, &lengths)
+
+		if err != nil {
+			return nil, fmt.Errorf("snapshot %s is malformed: %w", path, err)
+		}
+
+		entry := make([]byte, int(lengths[0])+int(lengths[1]))
+
+		_, err = io.ReadFull(reader, entry)
+
+		if err != nil {
+			return nil, fmt.Errorf("snapshot %s is malformed: %w", path, err)
+		}
+
+		data[string(entry[:lengths[0]])] = string(entry[lengths[0]:])
+	}
+
+	return &snapshot{
+		walPosition: wal.Position{Segment: header.WalSegment, Offset: header.WalOffset},
+		data:        data,
+	}, nil
+}
+
+// syncDir makes renames and deletes inside dir durable.
+func syncDir(dir string) error {
+	file, err := os.Open(dir)
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	return file.Sync()
+}
@@ -0,0 +1,386 @@
+package store
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/ethan-stone/go-key-store/internal/service"
+	"github.com/ethan-stone/go-key-store/internal/wal"
+)
+
+// sortedSet is the in memory index of a sorted set. The stored value is the
+// list of elements from collection.go, with each member followed by its score
+// as 8 bytes, in score order. It is only read into an index the first time
+// the set is used, and the index is kept for as long as the stored value's
+// version hasn't changed.
+type sortedSet struct {
+	version uint64
+	scores  map[string]float64
+	list    *skipList
+}
+
+func newSortedSet() *sortedSet {
+	return &sortedSet{
+		scores: make(map[string]float64),
+		list:   newSkipList(),
+	}
+}
+
+func (set *sortedSet) add(member string, score float64) bool {
+	current, exists := set.scores[member]
+
+	if exists {
+		set.list.remove(member, current)
+	}
+
+	set.scores[member] = score
+	set.list.insert(member, score)
+
+	return !exists
+}
+
+func (set *sortedSet) remove(member string) bool {
+	score, exists := set.scores[member]
+
+	if !exists {
+		return false
+	}
+
+	delete(set.scores, member)
+	set.list.remove(member, score)
+
+	return true
+}
+
+func (set *sortedSet) encode() []byte {
+	elements := make([][]byte, 0, set.list.length*2)
+
+	for node := set.list.head.levels[0].next; node != nil; node = node.levels[0].next {
+		elements = append(elements, []byte(node.member), binary.LittleEndian.AppendUint64(nil, math.Float64bits(node.score)))
+	}
+
+	return encodeElements(elements)
+}
+
+func decodeSortedSet(data []byte, version uint64) (*sortedSet, error) {
+	elements, err := decodeElements(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(elements)%2 != 0 {
+		return nil, fmt.Errorf("sorted set has a member without a score")
+	}
+
+	set := newSortedSet()
+	set.version = version
+
+	for i := 0; i < len(elements); i += 2 {
+		if len(elements[i+1]) != 8 {
+			return nil, fmt.Errorf("sorted set has a score that isn't 8 bytes")
+		}
+
+		set.add(string(elements[i]), math.Float64frombits(binary.LittleEndian.Uint64(elements[i+1])))
+	}
+
+	return set, nil
+}
+
+// sortedSetCache holds the index of every sorted set that has been used. It
+// has its own lock since readers fill it in while only holding the store's
+// read lock.
+type sortedSetCache struct {
+	sync.Mutex
+	sets map[string]*sortedSet
+}
+
+func (store *LocalKeyValueStore) dropSortedSet(key string) {
+	store.sortedSets.Lock()
+	defer store.sortedSets.Unlock()
+
+	delete(store.sortedSets.sets, key)
+}
+
+func (store *LocalKeyValueStore) cacheSortedSet(key string, set *sortedSet) {
+	store.sortedSets.Lock()
+	defer store.sortedSets.Unlock()
+
+	if store.sortedSets.sets == nil {
+		store.sortedSets.sets = make(map[string]*sortedSet)
+	}
+
+	store.sortedSets.sets[key] = set
+}
+
+// sortedSetLocked returns the sorted set at key and its expiry, or an empty
+// one if the key doesn't exist. The caller holds the store lock, at least for
+// reading, which keeps writers from changing the index underneath it.
+func (store *LocalKeyValueStore) sortedSetLocked(key string) (*sortedSet, int64, bool, error) {
+	stored, ok, err := store.engine.Get(key)
+
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	expiresAt := expiryOf(stored)
+
+	if !ok || (expiresAt != 0 && store.currentTime().UnixMilli() >= expiresAt) {
+		return newSortedSet(), 0, false, nil
+	}
+
+	valueType, version := typeAndVersionOf(stored)
+
+	if valueType != sortedSetType {
+		return nil, 0, false, service.ErrWrongType
+	}
+
+	store.sortedSets.Lock()
+	cached := store.sortedSets.sets[key]
+	store.sortedSets.Unlock()
+
+	if cached != nil && cached.version == version {
+		return cached, expiresAt, true, nil
+	}
+
+	set, err := decodeSortedSet(decodeValue(stored).data, version)
+
+	if err != nil {
+		return nil, 0, false, fmt.Errorf("could not read sorted set %s: %w", key, err)
+	}
+
+	store.cacheSortedSet(key, set)
+
+	return set, expiresAt, true, nil
+}
+
+func (store *LocalKeyValueStore) readSortedSet(key string, read func(set *sortedSet)) error {
+	err := store.waitForReadIndex()
+
+	if err != nil {
+		return err
+	}
+
+	store.RLock()
+	defer store.RUnlock()
+
+	set, _, _, err := store.sortedSetLocked(key)
+
+	if err != nil {
+		return err
+	}
+
+	read(set)
+
+	return nil
+}
+
+// updateSortedSet lets update change the sorted set at key in place, and logs
+// the whole set as a put if it says it changed. update has to check
+// everything it needs to before it changes anything. A set left empty is
+// deleted.
+func (store *LocalKeyValueStore) updateSortedSet(key string, update func(set *sortedSet) (bool, error)) error {
+	pending, entry, err := store.updateSortedSetLocked(key, update)
+
+	if err != nil || entry == nil {
+		return err
+	}
+
+	OpLog.AddEntry(entry)
+
+	return pending.Wait()
+}
+
+func (store *LocalKeyValueStore) updateSortedSetLocked(key string, update func(set *sortedSet) (bool, error)) (*wal.PendingWrite, *OpLogEntry, error) {
+	store.lockWrites()
+	defer store.unlockWrites()
+
+	set, expiresAt, found, err := store.sortedSetLocked(key)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	changed, err := update(set)
+
+	if err != nil || !changed {
+		return nil, nil, err
+	}
+
+	if set.list.length == 0 {
+		if !found {
+			return nil, nil, nil
+		}
+
+		pending, err := store.deleteLocked(key)
+
+		return pending, &OpLogEntry{OpType: Delete, Key: key}, err
+	}
+
+	value := &storedValue{
+		data:      set.encode(),
+		version:   store.nextVersion(),
+		expiresAt: expiresAt,
+		valueType: sortedSetType,
+	}
+
+	pending, err := store.writeLocked(key, value)
+
+	if err != nil {
+		// the index was changed but the value wasn't, so read it again next time.
+		store.dropSortedSet(key)
+		return nil, nil, err
+	}
+
+	set.version = value.version
+	store.cacheSortedSet(key, set)
+
+	return pending, &OpLogEntry{OpType: Put, Key: key, Val: value.data}, nil
+}
+
+func (store *LocalKeyValueStore) SortedSetAdd(key string, members map[string]float64) (int, error) {
+	added := 0
+
+	err := store.updateSortedSet(key, func(set *sortedSet) (bool, error) {
+		for _, score := range members {
+			if math.IsNaN(score) {
+				return false, service.ErrInvalidScore
+			}
+		}
+
+		for member, score := range members {
+			if set.add(member, score) {
+				added++
+			}
+		}
+
+		return len(members) > 0, nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return added, nil
+}
+
+func (store *LocalKeyValueStore) SortedSetRemove(key string, members []string) (int, error) {
+	removed := 0
+
+	err := store.updateSortedSet(key, func(set *sortedSet) (bool, error) {
+		for _, member := range members {
+			if set.remove(member) {
+				removed++
+			}
+		}
+
+		return removed > 0, nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return removed, nil
+}
+
+func (store *LocalKeyValueStore) SortedSetIncr(key string, member string, delta float64) (float64, error) {
+	score := 0.0
+
+	err := store.updateSortedSet(key, func(set *sortedSet) (bool, error) {
+		score = set.scores[member] + delta
+
+		if math.IsNaN(score) {
+			return false, service.ErrInvalidScore
+		}
+
+		set.add(member, score)
+
+		return true, nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return score, nil
+}
+
+func (store *LocalKeyValueStore) SortedSetRangeByRank(key string, start int, stop int) ([]service.ScoredMember, error) {
+	members := []service.ScoredMember{}
+
+	err := store.readSortedSet(key, func(set *sortedSet) {
+		length := set.list.length
+
+		if start < 0 {
+			start = max(length+start, 0)
+		}
+
+		if stop < 0 {
+			stop = length + stop
+		}
+
+		stop = min(stop, length-1)
+
+		if start > stop {
+			return
+		}
+
+		node := set.list.at(start)
+
+		for rank := start; rank <= stop && node != nil; rank++ {
+			members = append(members, service.ScoredMember{Member: node.member, Score: node.score})
+			node = node.levels[0].next
+		}
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+func (store *LocalKeyValueStore) SortedSetRangeByScore(key string, min float64, max float64, limit int) ([]service.ScoredMember, error) {
+	members := []service.ScoredMember{}
+
+	err := store.readSortedSet(key, func(set *sortedSet) {
+		for node := set.list.firstFrom(min); node != nil && node.score <= max; node = node.levels[0].next {
+			if limit > 0 && len(members) == limit {
+				return
+			}
+
+			members = append(members, service.ScoredMember{Member: node.member, Score: node.score})
+		}
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+func (store *LocalKeyValueStore) SortedSetRank(key string, member string) (*service.RankResult, error) {
+	result := &service.RankResult{}
+
+	err := store.readSortedSet(key, func(set *sortedSet) {
+		score, ok := set.scores[member]
+
+		if !ok {
+			return
+		}
+
+		result.Ok = true
+		result.Rank = set.list.rank(member, score)
+		result.Score = score
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
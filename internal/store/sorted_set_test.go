@@ -0,0 +1,198 @@
+package store
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/ethan-stone/go-key-store/internal/engine"
+	"github.com/ethan-stone/go-key-store/internal/service"
+)
+
+func membersOf(scored []service.ScoredMember) []string {
+	out := make([]string, len(scored))
+
+	for i, member := range scored {
+		out[i] = member.Member
+	}
+
+	return out
+}
+
+func TestSkipListShouldKeepRanksInOrder(t *testing.T) {
+	list := newSkipList()
+	scores := map[string]float64{}
+
+	for i := range 500 {
+		member := fmt.Sprintf("m-%d", rand.Intn(200))
+
+		if score, ok := scores[member]; ok && i%3 == 0 {
+			list.remove(member, score)
+			delete(scores, member)
+			continue
+		}
+
+		if score, ok := scores[member]; ok {
+			list.remove(member, score)
+		}
+
+		scores[member] = float64(rand.Intn(50))
+		list.insert(member, scores[member])
+	}
+
+	expected := []service.ScoredMember{}
+
+	for member, score := range scores {
+		expected = append(expected, service.ScoredMember{Member: member, Score: score})
+	}
+
+	slices.SortFunc(expected, func(a, b service.ScoredMember) int {
+		return cmp.Or(cmp.Compare(a.Score, b.Score), cmp.Compare(a.Member, b.Member))
+	})
+
+	if list.length != len(expected) {
+		t.Fatalf("Expected a length of %d, got %d", len(expected), list.length)
+	}
+
+	for rank, member := range expected {
+		if got := list.rank(member.Member, member.Score); got != rank {
+			t.Errorf("Expected %s to have rank %d, got %d", member.Member, rank, got)
+		}
+
+		if node := list.at(rank); node == nil || node.member != member.Member {
+			t.Errorf("Expected %s at rank %d, got %v", member.Member, rank, node)
+		}
+	}
+
+	if list.rank("missing", 1) != -1 {
+		t.Errorf("Did not expect a rank for a missing member")
+	}
+}
+
+func TestShouldAddRankAndRangeSortedSets(t *testing.T) {
+	store := &LocalKeyValueStore{
+		engine: engine.NewMemoryEngine(),
+	}
+
+	added, err := store.SortedSetAdd("z", map[string]float64{"c": 3, "a": 1, "b": 2, "d": 2})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when adding to a sorted set %v", err)
+	}
+
+	added, _ = store.SortedSetAdd("z", map[string]float64{"a": 5, "e": 4})
+
+	if added != 1 {
+		t.Errorf("Expected only e to be added, got %d", added)
+	}
+
+	members, err := store.SortedSetRangeByRank("z", 0, -1)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when getting a range %v", err)
+	}
+
+	if got := membersOf(members); !slices.Equal(got, []string{"b", "d", "c", "e", "a"}) {
+		t.Errorf("Expected b, d, c, e, a, got %v", got)
+	}
+
+	if got, _ := store.SortedSetRangeByRank("z", -2, -1); !slices.Equal(membersOf(got), []string{"e", "a"}) {
+		t.Errorf("Expected e, a, got %v", membersOf(got))
+	}
+
+	if got, _ := store.SortedSetRangeByScore("z", 2, 4, 0); !slices.Equal(membersOf(got), []string{"b", "d", "c", "e"}) {
+		t.Errorf("Expected b, d, c, e, got %v", membersOf(got))
+	}
+
+	if got, _ := store.SortedSetRangeByScore("z", 2.5, math.Inf(1), 2); !slices.Equal(membersOf(got), []string{"c", "e"}) {
+		t.Errorf("Expected c, e, got %v", membersOf(got))
+	}
+
+	r, err := store.SortedSetRank("z", "c")
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when getting a rank %v", err)
+	}
+
+	if !r.Ok || r.Rank != 2 || r.Score != 3 {
+		t.Errorf("Expected c to be rank 2 with score 3, got %v", r)
+	}
+
+	score, err := store.SortedSetIncr("z", "b", 10)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when incrementing a member %v", err)
+	}
+
+	if r, _ := store.SortedSetRank("z", "b"); score != 12 || r.Rank != 4 {
+		t.Errorf("Expected b to move to rank 4 with score 12, got %v %f", r, score)
+	}
+
+	if _, err := store.SortedSetAdd("z", map[string]float64{"f": math.NaN()}); !errors.Is(err, service.ErrInvalidScore) {
+		t.Errorf("Expected an invalid score error, got %v", err)
+	}
+
+	removed, _ := store.SortedSetRemove("z", []string{"a", "b", "c", "d", "e", "missing"})
+
+	if removed != 5 {
+		t.Errorf("Expected 5 members to be removed, got %d", removed)
+	}
+
+	if r, _ := store.Get("z"); r.Ok {
+		t.Errorf("Expected an empty sorted set to be deleted")
+	}
+
+	if r, _ := store.SortedSetRank("z", "a"); r.Ok {
+		t.Errorf("Did not expect a rank in a deleted sorted set, got %v", r)
+	}
+
+	store.Put("str", []byte("1"), nil)
+
+	if _, err := store.SortedSetAdd("str", map[string]float64{"a": 1}); !errors.Is(err, service.ErrWrongType) {
+		t.Errorf("Expected a wrong type error adding to a string, got %v", err)
+	}
+}
+
+func TestShouldRecoverSortedSetsFromSnapshotAndWal(t *testing.T) {
+	dataDir := t.TempDir()
+
+	store, err := InitializeDurableLocalKeyValueStore(&DurableLocalKeyValueStoreConfig{DataDir: dataDir})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when initializing store %v", err)
+	}
+
+	store.SortedSetAdd("z", map[string]float64{"a": 1, "b": 2})
+
+	err = store.Snapshot()
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when snapshotting store %v", err)
+	}
+
+	store.SortedSetIncr("z", "a", 5)
+	store.SortedSetAdd("z", map[string]float64{"c": -1})
+	store.Close()
+
+	recovered, err := InitializeDurableLocalKeyValueStore(&DurableLocalKeyValueStoreConfig{DataDir: dataDir})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when recovering store %v", err)
+	}
+
+	defer recovered.Close()
+
+	members, err := recovered.SortedSetRangeByRank("z", 0, -1)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when getting a range %v", err)
+	}
+
+	if got := membersOf(members); !slices.Equal(got, []string{"c", "b", "a"}) || members[2].Score != 6 {
+		t.Errorf("Expected c, b, a with a at 6, got %v", members)
+	}
+}
@@ -0,0 +1,151 @@
+package store
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/ethan-stone/go-key-store/internal/service"
+)
+
+// Values are handed to the engine, the WAL and snapshots with their metadata
+// in front of them.
+//
+// | marker (1) = 0 | format (1) = 1 | version (8) | expires at (8) | type (1) | content type length (2) | content type | data |
+//
+// Version is the key's version, see nextVersion. Expires at is in unix
+// milliseconds, 0 if the value never expires. Type is one of the value types
+// below, and lists, hashes and sets lay out their data as described in
+// collection.go. Values written before any of this existed were JSON strings,
+// so they don't start with a NUL byte and are read back as raw data with no
+// content type.
+const (
+	valueMarker          byte = 0
+	valueFormat          byte = 1
+	valueHeaderSize           = 21
+	MaxContentTypeLength      = math.MaxUint16
+)
+
+// the types a value can be.
+const (
+	stringType byte = iota
+	listType
+	hashType
+	setType
+	sortedSetType
+	// tombstoneType is what a delete leaves with quorum replication, see
+	// quorum.go. A tombstone has always expired, so reads never see it.
+	tombstoneType
+)
+
+var valueTypes = map[byte]service.ValueType{
+	stringType:    service.StringType,
+	listType:      service.ListType,
+	hashType:      service.HashType,
+	setType:       service.SetType,
+	sortedSetType: service.SortedSetType,
+}
+
+var ErrContentTypeTooLong = errors.New("content type is too long")
+
+type storedValue struct {
+	data        []byte
+	contentType string
+	version     uint64
+	expiresAt   int64
+	valueType   byte
+}
+
+func (val *storedValue) encode() ([]byte, error) {
+	if len(val.contentType) > MaxContentTypeLength {
+		return nil, ErrContentTypeTooLong
+	}
+
+	encoded := make([]byte, valueHeaderSize+len(val.contentType)+len(val.data))
+	encoded[0] = valueMarker
+	encoded[1] = valueFormat
+	binary.LittleEndian.PutUint64(encoded[2:10], val.version)
+	binary.LittleEndian.PutUint64(encoded[10:18], uint64(val.expiresAt))
+	encoded[18] = val.valueType
+	binary.LittleEndian.PutUint16(encoded[19:21], uint16(len(val.contentType)))
+	copy(encoded[valueHeaderSize:], val.contentType)
+	copy(encoded[valueHeaderSize+len(val.contentType):], val.data)
+
+	return encoded, nil
+}
+
+// expired is true once now has reached the expiry. A value that has expired is
+// treated as missing even before it is deleted.
+func (val *storedValue) expired(now time.Time) bool {
+	return val.expiresAt != 0 && now.UnixMilli() >= val.expiresAt
+}
+
+// valueHeader returns the size of the stored value's header, or false if it
+// doesn't have one.
+func valueHeader(stored string) (int, bool) {
+	if len(stored) < 2 || stored[0] != valueMarker {
+		return 0, false
+	}
+
+	if stored[1] != valueFormat || len(stored) < valueHeaderSize {
+		return 0, false
+	}
+
+	contentTypeLength := int(binary.LittleEndian.Uint16([]byte(stored[valueHeaderSize-2 : valueHeaderSize])))
+
+	if valueHeaderSize+contentTypeLength > len(stored) {
+		return 0, false
+	}
+
+	return valueHeaderSize, true
+}
+
+func decodeValue(stored string) *storedValue {
+	headerSize, ok := valueHeader(stored)
+
+	if !ok {
+		return &storedValue{data: []byte(stored)}
+	}
+
+	val := &storedValue{
+		version:   binary.LittleEndian.Uint64([]byte(stored[2:10])),
+		expiresAt: int64(binary.LittleEndian.Uint64([]byte(stored[10:18]))),
+		valueType: stored[18],
+	}
+
+	contentTypeLength := int(binary.LittleEndian.Uint16([]byte(stored[headerSize-2 : headerSize])))
+
+	val.contentType = stored[headerSize : headerSize+contentTypeLength]
+	val.data = []byte(stored[headerSize+contentTypeLength:])
+
+	return val
+}
+
+// typeAndVersionOf reads just the type and version of a stored value, without
+// copying it.
+func typeAndVersionOf(stored string) (byte, uint64) {
+	if _, ok := valueHeader(stored); !ok {
+		return stringType, 0
+	}
+
+	return stored[18], binary.LittleEndian.Uint64([]byte(stored[2:10]))
+}
+
+// expiryOf reads just the expiry of a stored value, without copying it.
+func expiryOf(stored string) int64 {
+	if _, ok := valueHeader(stored); !ok {
+		return 0
+	}
+
+	return int64(binary.LittleEndian.Uint64([]byte(stored[10:18])))
+}
+
+// expiryFor turns a TTL into the time it runs out, 0 for no TTL.
+func expiryFor(now time.Time, ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+
+	return now.Add(ttl).UnixMilli()
+}
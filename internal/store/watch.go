@@ -0,0 +1,271 @@
+package store
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/ethan-stone/go-key-store/internal/configuration"
+	"github.com/ethan-stone/go-key-store/internal/rpc"
+	"github.com/ethan-stone/go-key-store/internal/service"
+)
+
+// maxChangesKept is how many of the most recent changes a watch can resume
+// from.
+const maxChangesKept = 4096
+
+// changeFeed keeps the most recent changes in memory for watches. Changes are
+// published while the store lock is held, so they are in the same order as the
+// WAL. The sequence of a change is the version or LSN it was written with, so
+// sequences keep going up across restarts even though the changes before a
+// restart are gone.
+type changeFeed struct {
+	sync.Mutex
+	events  []*service.WatchEvent // oldest first
+	floor   uint64                // changes up to and including this sequence are no longer kept
+	last    uint64                // sequence of the newest change
+	changed chan struct{}         // closed and replaced every time changes are published
+}
+
+// start sets the sequence the feed starts after, once the store has
+// recovered.
+func (feed *changeFeed) start(sequence uint64) {
+	feed.Lock()
+	defer feed.Unlock()
+
+	feed.floor = sequence
+	feed.last = sequence
+}
+
+func (feed *changeFeed) publish(events ...*service.WatchEvent) {
+	feed.Lock()
+	defer feed.Unlock()
+
+	feed.events = append(feed.events, events...)
+	feed.last = events[len(events)-1].Sequence
+
+	// changes in a batch share a sequence, so they're dropped together.
+	for len(feed.events) > maxChangesKept {
+		feed.floor = feed.events[0].Sequence
+
+		for len(feed.events) > 0 && feed.events[0].Sequence == feed.floor {
+			feed.events = feed.events[1:]
+		}
+	}
+
+	if feed.changed != nil {
+		close(feed.changed)
+		feed.changed = nil
+	}
+}
+
+// since returns the changes after sequence, and a channel that is closed once
+// there are more.
+func (feed *changeFeed) since(sequence uint64) ([]*service.WatchEvent, <-chan struct{}, error) {
+	feed.Lock()
+	defer feed.Unlock()
+
+	if sequence < feed.floor {
+		return nil, nil, service.ErrSequenceExpired
+	}
+
+	if feed.changed == nil {
+		feed.changed = make(chan struct{})
+	}
+
+	first := len(feed.events)
+
+	for first > 0 && feed.events[first-1].Sequence > sequence {
+		first--
+	}
+
+	return feed.events[first:], feed.changed, nil
+}
+
+func (feed *changeFeed) lastSequence() uint64 {
+	feed.Lock()
+	defer feed.Unlock()
+
+	return feed.last
+}
+
+func watching(options *service.WatchOptions, key string) bool {
+	if options.Key != "" {
+		return key == options.Key
+	}
+
+	return strings.HasPrefix(key, options.Prefix)
+}
+
+// Watch only sees changes made on this node. A watch that falls too far behind
+// gets ErrSequenceExpired rather than holding up writers.
+func (store *LocalKeyValueStore) Watch(ctx context.Context, options *service.WatchOptions, fn func(event *service.WatchEvent) error) error {
+	if options == nil {
+		options = &service.WatchOptions{}
+	}
+
+	after := options.After
+
+	if !options.Resume {
+		after = store.changes.lastSequence()
+	}
+
+	events, changed, err := store.changes.since(after)
+
+	if err != nil {
+		return err
+	}
+
+	err = fn(&service.WatchEvent{Sequence: after, Type: service.WatchStart})
+
+	if err != nil {
+		return err
+	}
+
+	for {
+		for _, event := range events {
+			after = event.Sequence
+
+			if !watching(options, event.Key) {
+				continue
+			}
+
+			err := fn(event)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+
+		events, changed, err = store.changes.since(after)
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// watchCursor is the last sequence seen from each node, by node ID. Every
+// node has its own sequences, so a cluster watch resumes each node from its
+// own.
+type watchCursor map[string]uint64
+
+func (cursor watchCursor) encode() string {
+	encoded, _ := json.Marshal(cursor)
+
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+func decodeWatchCursor(encoded string) (watchCursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(encoded)
+
+	if err != nil {
+		return nil, service.ErrInvalidCursor
+	}
+
+	cursor := watchCursor{}
+
+	err = json.Unmarshal(decoded, &cursor)
+
+	if err != nil {
+		return nil, service.ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
+
+type nodeWatchEvent struct {
+	node  string
+	event *service.WatchEvent
+}
+
+// WatchCluster watches the node that owns options.Key, or every node for a
+// prefix, resuming each from cursor if it isn't empty. fn gets every event
+// along with the cursor to resume after it, including each node's start
+// event. The watch ends when any node's watch does. options.After and
+// options.Resume are set for each node from the cursor.
+func WatchCluster(ctx context.Context, options *service.WatchOptions, cursor string, clusterConfig *configuration.ClusterConfig, rpcClientManager rpc.RpcClientManager, fn func(event *service.WatchEvent, cursor string) error) error {
+	position := watchCursor{}
+
+	if cursor != "" {
+		decoded, err := decodeWatchCursor(cursor)
+
+		if err != nil {
+			return err
+		}
+
+		position = decoded
+	}
+
+	nodes := clusterConfig.Primaries()
+
+	if options.Key != "" {
+		hashSlot := int(clusterConfig.HashFunction.HashSlot(options.Key))
+
+		nodes = slices.DeleteFunc(nodes, func(node *configuration.NodeConfig) bool {
+			return hashSlot < node.HashSlots[0] || hashSlot > node.HashSlots[1]
+		})
+
+		if len(nodes) == 0 {
+			return fmt.Errorf("could not find the node for hash slot %d", hashSlot)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	defer cancel()
+
+	events := make(chan nodeWatchEvent)
+	done := make(chan error, len(nodes))
+
+	for _, node := range nodes {
+		store, err := getStoreForSlot(uint32(node.HashSlots[0]), clusterConfig, rpcClientManager)
+
+		if err != nil {
+			return err
+		}
+
+		nodeOptions := *options
+		nodeOptions.After, nodeOptions.Resume = position[node.ID]
+
+		go func() {
+			done <- store.Watch(ctx, &nodeOptions, func(event *service.WatchEvent) error {
+				select {
+				case events <- nodeWatchEvent{node: node.ID, event: event}:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			})
+		}()
+	}
+
+	for {
+		select {
+		case nodeEvent := <-events:
+			position[nodeEvent.node] = nodeEvent.event.Sequence
+
+			err := fn(nodeEvent.event, position.encode())
+
+			if err != nil {
+				return err
+			}
+		case err := <-done:
+			if err == nil {
+				err = fmt.Errorf("a node stopped watching")
+			}
+
+			return err
+		}
+	}
+}
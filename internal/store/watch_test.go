@@ -0,0 +1,165 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ethan-stone/go-key-store/internal/engine"
+	"github.com/ethan-stone/go-key-store/internal/service"
+)
+
+// startWatch watches the store in the background, and returns the events it
+// gets on a channel once the watch has started.
+func startWatch(t *testing.T, store *LocalKeyValueStore, options *service.WatchOptions) (<-chan *service.WatchEvent, <-chan error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	events := make(chan *service.WatchEvent, 100)
+	done := make(chan error, 1)
+
+	go func() {
+		done <- store.Watch(ctx, options, func(event *service.WatchEvent) error {
+			events <- event
+			return nil
+		})
+	}()
+
+	select {
+	case event := <-events:
+		if event.Type != service.WatchStart {
+			t.Fatalf("Expected the watch to start with a start event, got %v", event)
+		}
+	case err := <-done:
+		t.Fatalf("Did not expect an error when starting a watch %v", err)
+	}
+
+	return events, done
+}
+
+func nextEvent(t *testing.T, events <-chan *service.WatchEvent) *service.WatchEvent {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		t.Fatalf("Expected a watch event")
+		return nil
+	}
+}
+
+func TestWatchShouldGetPutsAndDeletesInOrder(t *testing.T) {
+	store := &LocalKeyValueStore{
+		engine: engine.NewMemoryEngine(),
+	}
+
+	events, _ := startWatch(t, store, &service.WatchOptions{Prefix: "user:"})
+
+	put, _ := store.Put("user:1", []byte("1"), nil)
+	store.Put("other", []byte("1"), nil)
+	store.SetAdd("user:2", bytesOf("a"))
+	store.Delete("user:1", nil)
+	store.Batch([]*service.BatchOp{{Key: "user:3", Val: []byte("3")}, {Key: "user:2", Delete: true}})
+
+	expected := []struct {
+		eventType service.WatchEventType
+		key       string
+	}{
+		{service.WatchPut, "user:1"},
+		{service.WatchPut, "user:2"},
+		{service.WatchDelete, "user:1"},
+		{service.WatchPut, "user:3"},
+		{service.WatchDelete, "user:2"},
+	}
+
+	var last uint64
+
+	for i, want := range expected {
+		event := nextEvent(t, events)
+
+		if event.Type != want.eventType || event.Key != want.key {
+			t.Errorf("Expected event %d to be a %s of %s, got %v", i, want.eventType, want.key, event)
+		}
+
+		if event.Sequence < last {
+			t.Errorf("Expected sequences to keep going up, got %d after %d", event.Sequence, last)
+		}
+
+		last = event.Sequence
+	}
+
+	if first := put.Version; last <= first {
+		t.Errorf("Expected the batch to come after version %d, got %d", first, last)
+	}
+}
+
+func TestWatchShouldResumeAfterASequence(t *testing.T) {
+	store := &LocalKeyValueStore{
+		engine: engine.NewMemoryEngine(),
+	}
+
+	first, _ := store.Put("a", []byte("1"), nil)
+	store.Put("b", []byte("2"), nil)
+	store.Delete("a", nil)
+
+	events, _ := startWatch(t, store, &service.WatchOptions{Resume: true, After: first.Version})
+
+	if event := nextEvent(t, events); event.Key != "b" || event.Type != service.WatchPut {
+		t.Errorf("Expected the put of b, got %v", event)
+	}
+
+	if event := nextEvent(t, events); event.Key != "a" || event.Type != service.WatchDelete {
+		t.Errorf("Expected the delete of a, got %v", event)
+	}
+
+	for i := range maxChangesKept {
+		store.Put(fmt.Sprintf("key-%d", i), []byte("1"), nil)
+	}
+
+	err := store.Watch(context.Background(), &service.WatchOptions{Resume: true, After: first.Version}, func(event *service.WatchEvent) error {
+		return nil
+	})
+
+	if !errors.Is(err, service.ErrSequenceExpired) {
+		t.Errorf("Expected an expired sequence once the changes are dropped, got %v", err)
+	}
+}
+
+func TestWatchShouldNotResumeFromBeforeARestart(t *testing.T) {
+	dataDir := t.TempDir()
+
+	store, err := InitializeDurableLocalKeyValueStore(&DurableLocalKeyValueStoreConfig{DataDir: dataDir})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when initializing store %v", err)
+	}
+
+	first, _ := store.Put("a", []byte("1"), nil)
+	store.Put("a", []byte("2"), nil)
+	store.Close()
+
+	recovered, err := InitializeDurableLocalKeyValueStore(&DurableLocalKeyValueStoreConfig{DataDir: dataDir})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when recovering store %v", err)
+	}
+
+	defer recovered.Close()
+
+	err = recovered.Watch(context.Background(), &service.WatchOptions{Resume: true, After: first.Version}, func(event *service.WatchEvent) error {
+		return nil
+	})
+
+	if !errors.Is(err, service.ErrSequenceExpired) {
+		t.Errorf("Expected an expired sequence after a restart, got %v", err)
+	}
+
+	events, _ := startWatch(t, recovered, &service.WatchOptions{Key: "a"})
+
+	put, _ := recovered.Put("a", []byte("3"), nil)
+
+	if event := nextEvent(t, events); event.Sequence != put.Version || event.Version != put.Version {
+		t.Errorf("Expected the put at version %d, got %v", put.Version, event)
+	}
+}
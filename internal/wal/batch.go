@@ -0,0 +1,104 @@
+package wal
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// EncodeBatch lays out the value of a Batch entry. The batch is one entry in
+// the log, so it is either replayed whole or not at all. Only puts and deletes
+// can go in a batch, and they have no LSN, timestamp or checksum of their own.
+//
+// | Field        | Size (bytes) |
+// | ------------ | ------------ |
+// | Count        | 4            |
+//
+// then for every entry
+//
+// | Field        | Size (bytes) |
+// | ------------ | ------------ |
+// | Op Type      | 1            |
+// | Key Length   | 4            |
+// | Value Length | 4            |
+// | Key Bytes    | variable     |
+// | Value Bytes  | variable     |
+func EncodeBatch(entries []*WalEntryWrite) ([]byte, error) {
+	buf := binary.LittleEndian.AppendUint32(nil, uint32(len(entries)))
+
+	for _, entry := range entries {
+		if entry.OpType != Put && entry.OpType != Del {
+			return nil, fmt.Errorf("op type %d can't go in a batch", entry.OpType)
+		}
+
+		value := []byte{}
+
+		if entry.OpType == Put {
+			if entry.ValueBytes == nil {
+				return nil, fmt.Errorf("ValueBytes must not be nil for op type %d", entry.OpType)
+			}
+
+			value = *entry.ValueBytes
+		}
+
+		buf = append(buf, entry.OpType)
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(entry.KeyBytes)))
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(value)))
+		buf = append(buf, entry.KeyBytes...)
+		buf = append(buf, value...)
+	}
+
+	return buf, nil
+}
+
+// DecodeBatch reads the entries back out of a Batch entry's value. They are
+// given the batch entry's LSN and timestamp.
+func DecodeBatch(batch *WalEntry) ([]*WalEntry, error) {
+	if batch.ValueBytes == nil || len(*batch.ValueBytes) < 4 {
+		return nil, fmt.Errorf("batch at LSN %d is too short", batch.LSN)
+	}
+
+	buf := *batch.ValueBytes
+	count := binary.LittleEndian.Uint32(buf)
+	offset := 4
+	entries := []*WalEntry{}
+
+	for range count {
+		if len(buf)-offset < 9 {
+			return nil, fmt.Errorf("batch at LSN %d is cut off", batch.LSN)
+		}
+
+		opType := buf[offset]
+		keyLength := int(binary.LittleEndian.Uint32(buf[offset+1:]))
+		valueLength := int(binary.LittleEndian.Uint32(buf[offset+5:]))
+		offset += 9
+
+		if opType != Put && opType != Del {
+			return nil, fmt.Errorf("batch at LSN %d holds op type %d", batch.LSN, opType)
+		}
+
+		if keyLength < 0 || valueLength < 0 || len(buf)-offset < keyLength+valueLength {
+			return nil, fmt.Errorf("batch at LSN %d is cut off", batch.LSN)
+		}
+
+		entry := &WalEntry{
+			OpType:      opType,
+			LSN:         batch.LSN,
+			Timestamp:   batch.Timestamp,
+			KeyLength:   int32(keyLength),
+			ValueLength: int32(valueLength),
+			KeyBytes:    buf[offset : offset+keyLength],
+		}
+
+		offset += keyLength
+
+		if opType == Put {
+			value := buf[offset : offset+valueLength]
+			entry.ValueBytes = &value
+		}
+
+		offset += valueLength
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
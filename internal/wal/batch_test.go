@@ -0,0 +1,90 @@
+package wal
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestShouldWriteAndReadBackBatch(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "test_batch.bin")
+
+	writer, err := NewWalWriter(fileName)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening: %v", err)
+	}
+
+	value, err := EncodeBatch([]*WalEntryWrite{
+		putEntry("a", "1"),
+		{OpType: Del, KeyLength: 1, KeyBytes: []byte("b")},
+		putEntry("c", ""),
+	})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when encoding batch: %v", err)
+	}
+
+	err = writer.Write(&WalEntryWrite{
+		OpType:      Batch,
+		ValueLength: int32(len(value)),
+		ValueBytes:  &value,
+	})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when writing: %v", err)
+	}
+
+	writer.Close()
+
+	reader, err := NewWalReader(fileName)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening: %v", err)
+	}
+
+	defer reader.Close()
+
+	read, err := reader.Read(reader.Start())
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when reading: %v", err)
+	}
+
+	entries, err := DecodeBatch(read.Entry())
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when decoding batch: %v", err)
+	}
+
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(entries))
+	}
+
+	expected := []struct {
+		opType byte
+		key    string
+		val    string
+	}{{Put, "a", "1"}, {Del, "b", ""}, {Put, "c", ""}}
+
+	for i, entry := range entries {
+		if entry.OpType != expected[i].opType || string(entry.KeyBytes) != expected[i].key {
+			t.Errorf("Expected entry %d to be op %d on %s, got op %d on %s", i, expected[i].opType, expected[i].key, entry.OpType, entry.KeyBytes)
+		}
+
+		if entry.LSN != 1 {
+			t.Errorf("Expected entry %d to have the batch's LSN, got %d", i, entry.LSN)
+		}
+
+		if entry.OpType == Put && string(*entry.ValueBytes) != expected[i].val {
+			t.Errorf("Expected entry %d to have value %s, got %s", i, expected[i].val, *entry.ValueBytes)
+		}
+	}
+
+	short := (*read.Entry().ValueBytes)[:10]
+
+	_, err = DecodeBatch(&WalEntry{OpType: Batch, ValueBytes: &short})
+
+	if err == nil {
+		t.Errorf("Expected an error when decoding a cut off batch")
+	}
+}
@@ -0,0 +1,63 @@
+package wal
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinels for errors.Is. The concrete errors returned by the package carry
+// the details, such as the offset of a bad entry.
+var (
+	ErrCorruptEntry = errors.New("corrupt WAL entry")
+	ErrTornWrite    = errors.New("torn WAL write")
+	ErrIO           = errors.New("WAL I/O error")
+	ErrClosed       = errors.New("WAL writer is closed")
+)
+
+// CorruptEntryError is an entry that is complete but can't be trusted, like a
+// checksum mismatch or an unknown op type.
+type CorruptEntryError struct {
+	Offset int64
+	Reason string
+}
+
+func (e *CorruptEntryError) Error() string {
+	return fmt.Sprintf("corrupt WAL entry at offset %d: %s", e.Offset, e.Reason)
+}
+
+func (e *CorruptEntryError) Is(target error) bool {
+	return target == ErrCorruptEntry
+}
+
+// TornWriteError is an entry that runs past the end of the file, which is what
+// a crash in the middle of an append leaves behind.
+type TornWriteError struct {
+	Offset int64
+}
+
+func (e *TornWriteError) Error() string {
+	return fmt.Sprintf("torn WAL write at offset %d", e.Offset)
+}
+
+func (e *TornWriteError) Is(target error) bool {
+	return target == ErrTornWrite
+}
+
+// IOError wraps a failure from the file system, like a full disk.
+type IOError struct {
+	Op   string
+	Path string
+	Err  error
+}
+
+func (e *IOError) Error() string {
+	return fmt.Sprintf("WAL %s %s: %v", e.Op, e.Path, e.Err)
+}
+
+func (e *IOError) Is(target error) bool {
+	return target == ErrIO
+}
+
+func (e *IOError) Unwrap() error {
+	return e.Err
+}
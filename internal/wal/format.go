@@ -0,0 +1,180 @@
+package wal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// Op types. New ops are added to the end of this list and to knownOpTypes, so
+// an older reader rejects an entry it doesn't understand as corrupt rather
+// than applying it wrong.
+const (
+	Put    = 1
+	Del    = 2
+	Batch  = 3 // the value holds several put and delete entries that apply atomically
+	Expire = 4 // the value holds the key's new expiry, or nothing to remove it
+	Noop   = 5 // changes nothing, but takes up an LSN
+)
+
+var knownOpTypes = map[byte]bool{
+	Put:    true,
+	Del:    true,
+	Batch:  true,
+	Expire: true,
+	Noop:   true,
+}
+
+// hasValue reports whether entries of an op type carry value bytes.
+func hasValue(opType byte) bool {
+	return opType != Del && opType != Noop
+}
+
+const (
+	FormatV1 = 1 // no file header, entries have no LSN or timestamp
+	FormatV2 = 2
+
+	CurrentFormat = FormatV2
+)
+
+var fileMagic = [4]byte{'G', 'K', 'S', 'W'}
+
+// fileHeader starts every v2 WAL file. v1 files have no header, which is how
+// the two are told apart, since a v1 file starts with an op type byte.
+//
+// | Field    | Size (bytes) | Purpose                                            |
+// | -------- | ------------ | -------------------------------------------------- |
+// | Magic    | 4            | "GKSW"                                             |
+// | Version  | 2            | Format version of the entries in the file          |
+// | Reserved | 2            | Always 0                                           |
+// | Base LSN | 8            | LSN of the first entry written to the file         |
+type fileHeader struct {
+	Magic    [4]byte
+	Version  uint16
+	Reserved uint16
+	BaseLSN  uint64
+}
+
+const fileHeaderSize = 16
+
+// entryHeaderSize is the fixed part of an entry before the key, for each format.
+var entryHeaderSize = map[uint16]int64{
+	FormatV1: 9,  // op type, key length, value length
+	FormatV2: 25, // op type, LSN, timestamp, key length, value length
+}
+
+const checksumSize = 4
+
+func encodeFileHeader(baseLSN uint64) []byte {
+	buf := new(bytes.Buffer)
+
+	binary.Write(buf, binary.LittleEndian, &fileHeader{
+		Magic:   fileMagic,
+		Version: CurrentFormat,
+		BaseLSN: baseLSN,
+	})
+
+	return buf.Bytes()
+}
+
+// readFileHeader works out the format of the file. A file that is empty, or
+// that starts with part of the magic bytes because a crash tore the header,
+// is treated as an empty v2 file.
+func readFileHeader(file *os.File) (*fileHeader, error) {
+	buf := make([]byte, fileHeaderSize)
+
+	n, err := file.ReadAt(buf, 0)
+
+	if err != nil && err != io.EOF {
+		return nil, &IOError{Op: "read", Path: file.Name(), Err: err}
+	}
+
+	if n < fileHeaderSize {
+		if bytes.HasPrefix(fileMagic[:], buf[:min(n, len(fileMagic))]) {
+			return nil, nil
+		}
+
+		return &fileHeader{Version: FormatV1}, nil
+	}
+
+	if !bytes.Equal(buf[:4], fileMagic[:]) {
+		return &fileHeader{Version: FormatV1}, nil
+	}
+
+	header := &fileHeader{}
+
+	binary.Read(bytes.NewReader(buf), binary.LittleEndian, header)
+
+	if header.Version != FormatV2 {
+		return nil, &CorruptEntryError{Offset: 0, Reason: fmt.Sprintf("unsupported WAL format version %d", header.Version)}
+	}
+
+	return header, nil
+}
+
+// encodeEntry lays out an entry in the current format.
+//
+// | Field        | Size (bytes) |
+// | ------------ | ------------ |
+// | Op Type      | 1            |
+// | LSN          | 8            |
+// | Timestamp    | 8            |
+// | Key Length   | 4            |
+// | Value Length | 4            |
+// | Key Bytes    | variable     |
+// | Value Bytes  | variable     |
+// | CRC          | 4            |
+func encodeEntry(entry *WalEntryWrite, lsn uint64, timestamp int64) ([]byte, error) {
+	if !knownOpTypes[entry.OpType] {
+		return nil, fmt.Errorf("unknown op type %d", entry.OpType)
+	}
+
+	valueLength := int32(0)
+
+	if hasValue(entry.OpType) {
+		if entry.ValueBytes == nil {
+			return nil, fmt.Errorf("ValueBytes must not be nil for op type %d", entry.OpType)
+		}
+
+		valueLength = int32(len(*entry.ValueBytes))
+	}
+
+	buf := new(bytes.Buffer)
+
+	buf.WriteByte(entry.OpType)
+	binary.Write(buf, binary.LittleEndian, lsn)
+	binary.Write(buf, binary.LittleEndian, timestamp)
+	binary.Write(buf, binary.LittleEndian, int32(len(entry.KeyBytes)))
+	binary.Write(buf, binary.LittleEndian, valueLength)
+	buf.Write(entry.KeyBytes)
+
+	if hasValue(entry.OpType) {
+		buf.Write(*entry.ValueBytes)
+	}
+
+	checksum := crc32.ChecksumIEEE(buf.Bytes())
+
+	binary.Write(buf, binary.LittleEndian, checksum)
+
+	return buf.Bytes(), nil
+}
+
+// decodeEntryHeader reads the fixed part of an entry. lsn and timestamp are
+// always zero for v1 entries.
+func decodeEntryHeader(buf []byte, version uint16) (opType byte, lsn uint64, timestamp int64, keyLength uint32, valueLength uint32) {
+	opType = buf[0]
+
+	if version == FormatV1 {
+		return opType, 0, 0, binary.LittleEndian.Uint32(buf[1:5]), binary.LittleEndian.Uint32(buf[5:9])
+	}
+
+	lsn = binary.LittleEndian.Uint64(buf[1:9])
+	timestamp = int64(binary.LittleEndian.Uint64(buf[9:17]))
+	keyLength = binary.LittleEndian.Uint32(buf[17:21])
+	valueLength = binary.LittleEndian.Uint32(buf[21:25])
+
+	return opType, lsn, timestamp, keyLength, valueLength
+}
@@ -0,0 +1,730 @@
+package wal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Position addresses an entry in a segmented log. Offset is relative to the
+// start of the segment file.
+type Position struct {
+	Segment uint64
+	Offset  int64
+}
+
+// Before reports whether p comes strictly before other in the log.
+func (p Position) Before(other Position) bool {
+	if p.Segment != other.Segment {
+		return p.Segment < other.Segment
+	}
+
+	return p.Offset < other.Offset
+}
+
+const (
+	manifestFileName = "MANIFEST"
+	segmentSuffix    = ".wal"
+
+	DefaultMaxSegmentSize = 64 * 1024 * 1024
+)
+
+// manifest is the source of truth for which segments are live. Segment files
+// in the directory that are not in the manifest are leftovers from a crash in
+// the middle of a rotation or removal and are deleted on open.
+type manifest struct {
+	Segments []uint64 `json:"segments"` // oldest first, the last one is being appended to
+}
+
+type SegmentedWalConfig struct {
+	Dir            string
+	MaxSegmentSize int64            // a new segment is started once the active one reaches this size
+	Writer         *WalWriterConfig // how each segment is written, defaults to SyncAlways
+}
+
+// SegmentedWal is a log split across numbered segment files in a directory, so
+// old entries can be dropped a whole segment at a time.
+//
+// Appends only take the read lock so concurrent writers can share a group
+// commit on the active segment. Anything that changes which segments exist
+// takes the write lock, which waits for in flight appends.
+type SegmentedWal struct {
+	sync.RWMutex
+	dir            string
+	maxSegmentSize int64
+	writerConfig   WalWriterConfig
+	manifest       *manifest
+	active         *WalWriter
+}
+
+func segmentFileName(dir string, segment uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("%012d%s", segment, segmentSuffix))
+}
+
+// OpenSegmentedWal opens the log in config.Dir, creating it if needed. A torn
+// or corrupt tail on the active segment is cut off, so the log is always well
+// formed once it is open.
+func OpenSegmentedWal(config *SegmentedWalConfig) (*SegmentedWal, error) {
+	err := os.MkdirAll(config.Dir, 0755)
+
+	if err != nil {
+		return nil, &IOError{Op: "mkdir", Path: config.Dir, Err: err}
+	}
+
+	maxSegmentSize := config.MaxSegmentSize
+
+	if maxSegmentSize <= 0 {
+		maxSegmentSize = DefaultMaxSegmentSize
+	}
+
+	writerConfig := WalWriterConfig{Durability: SyncAlways}
+
+	if config.Writer != nil {
+		writerConfig = *config.Writer
+	}
+
+	segmentedWal := &SegmentedWal{
+		dir:            config.Dir,
+		maxSegmentSize: maxSegmentSize,
+		writerConfig:   writerConfig,
+	}
+
+	m, err := readManifest(config.Dir)
+
+	if errors.Is(err, os.ErrNotExist) {
+		m = &manifest{Segments: []uint64{1}}
+
+		err = writeManifest(config.Dir, m)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	segmentedWal.manifest = m
+
+	err = segmentedWal.removeOrphanedSegments()
+
+	if err != nil {
+		return nil, err
+	}
+
+	activeSegment := m.Segments[len(m.Segments)-1]
+
+	err = repairSegment(segmentFileName(config.Dir, activeSegment))
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = segmentedWal.upgradeSegments()
+
+	if err != nil {
+		return nil, err
+	}
+
+	// an active segment with no header yet, say after a crash part way through
+	// creating it, carries on numbering from the segment before it.
+	activeConfig := segmentedWal.writerConfig
+
+	if info, err := os.Stat(segmentFileName(config.Dir, activeSegment)); len(m.Segments) > 1 && (err != nil || info.Size() < fileHeaderSize) {
+		activeConfig.StartLSN, err = NextLSN(segmentFileName(config.Dir, m.Segments[len(m.Segments)-2]), 1)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	segmentedWal.active, err = NewWalWriterWithConfig(segmentFileName(config.Dir, activeSegment), &activeConfig)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return segmentedWal, nil
+}
+
+func readManifest(dir string) (*manifest, error) {
+	contents, err := os.ReadFile(filepath.Join(dir, manifestFileName))
+
+	if err != nil {
+		return nil, err
+	}
+
+	var m manifest
+
+	err = json.Unmarshal(contents, &m)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid WAL manifest: %w", err)
+	}
+
+	if len(m.Segments) == 0 {
+		return nil, fmt.Errorf("WAL manifest has no segments")
+	}
+
+	return &m, nil
+}
+
+// writeManifest replaces the manifest with a rename so readers only ever see
+// the old or the new list of segments.
+func writeManifest(dir string, m *manifest) error {
+	contents, err := json.Marshal(m)
+
+	if err != nil {
+		return err
+	}
+
+	tmpPath := filepath.Join(dir, manifestFileName+".tmp")
+
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+
+	if err != nil {
+		return &IOError{Op: "open", Path: tmpPath, Err: err}
+	}
+
+	_, err = file.Write(contents)
+
+	if err == nil {
+		err = file.Sync()
+	}
+
+	closeErr := file.Close()
+
+	if err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return &IOError{Op: "write", Path: tmpPath, Err: err}
+	}
+
+	err = os.Rename(tmpPath, filepath.Join(dir, manifestFileName))
+
+	if err != nil {
+		return &IOError{Op: "rename", Path: tmpPath, Err: err}
+	}
+
+	return syncDir(dir)
+}
+
+func (segmentedWal *SegmentedWal) removeOrphanedSegments() error {
+	matches, err := filepath.Glob(filepath.Join(segmentedWal.dir, "*"+segmentSuffix))
+
+	if err != nil {
+		return err
+	}
+
+	live := make(map[string]bool)
+
+	for _, segment := range segmentedWal.manifest.Segments {
+		live[segmentFileName(segmentedWal.dir, segment)] = true
+	}
+
+	for _, match := range matches {
+		if live[match] {
+			continue
+		}
+
+		log.Printf("Removing WAL segment %s that is not in the manifest", match)
+
+		err = os.Remove(match)
+
+		if err != nil {
+			return &IOError{Op: "remove", Path: match, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// upgradeSegments rewrites any v1 segments in the current format, numbering
+// their entries on from the segment before. Once one segment has been
+// upgraded, the LSNs have to carry on through every segment after it.
+func (segmentedWal *SegmentedWal) upgradeSegments() error {
+	var nextLSN uint64 = 1
+	upgrading := false
+
+	for i, segment := range segmentedWal.manifest.Segments {
+		fileName := segmentFileName(segmentedWal.dir, segment)
+
+		if !upgrading {
+			version, err := segmentVersion(fileName)
+
+			if err != nil {
+				return err
+			}
+
+			if version != FormatV1 {
+				continue
+			}
+
+			upgrading = true
+
+			if i > 0 {
+				nextLSN, err = NextLSN(segmentFileName(segmentedWal.dir, segmentedWal.manifest.Segments[i-1]), 1)
+
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		var err error
+
+		nextLSN, err = Upgrade(fileName, nextLSN)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// segmentVersion returns the format of a segment file, or 0 if it doesn't exist.
+func segmentVersion(fileName string) (uint16, error) {
+	reader, err := NewWalReader(fileName)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer reader.Close()
+
+	return reader.Version(), nil
+}
+
+// repairSegment truncates a segment after its last complete, valid entry.
+func repairSegment(fileName string) error {
+	info, err := os.Stat(fileName)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return &IOError{Op: "stat", Path: fileName, Err: err}
+	}
+
+	reader, err := NewWalReader(fileName)
+
+	if err != nil {
+		return err
+	}
+
+	defer reader.Close()
+
+	offset := reader.Start()
+
+	for {
+		entryRead, err := reader.Read(offset)
+
+		if errors.Is(err, ErrIO) {
+			return err
+		}
+
+		if err != nil {
+			if err != io.EOF {
+				log.Printf("WAL segment %s has a bad entry at offset %d: %v", fileName, offset, err)
+			}
+
+			break
+		}
+
+		offset += entryRead.Size()
+	}
+
+	if offset < info.Size() {
+		log.Printf("Truncating WAL segment %s from %d to %d bytes", fileName, info.Size(), offset)
+
+		return Truncate(fileName, offset)
+	}
+
+	return nil
+}
+
+// Write appends an entry to the active segment, starting a new segment first
+// if the active one is full. It returns the position the entry was written at
+// once the entry is durable.
+func (segmentedWal *SegmentedWal) Write(entry *WalEntryWrite) (Position, error) {
+	position, pending, err := segmentedWal.Enqueue(entry)
+
+	if err != nil {
+		return Position{}, err
+	}
+
+	return position, pending.Wait()
+}
+
+// Enqueue gives an entry its place in the log without waiting for it to be
+// durable. See WalWriter.Enqueue.
+func (segmentedWal *SegmentedWal) Enqueue(entry *WalEntryWrite) (Position, *PendingWrite, error) {
+	segmentedWal.RLock()
+
+	for segmentedWal.full() {
+		segmentedWal.RUnlock()
+		segmentedWal.Lock()
+
+		// another writer may have rotated while we waited for the lock.
+		if segmentedWal.full() {
+			err := segmentedWal.rotate()
+
+			if err != nil {
+				segmentedWal.Unlock()
+				return Position{}, nil, err
+			}
+		}
+
+		segmentedWal.Unlock()
+		segmentedWal.RLock()
+	}
+
+	defer segmentedWal.RUnlock()
+
+	pending, err := segmentedWal.active.Enqueue(entry)
+
+	if err != nil {
+		return Position{}, nil, err
+	}
+
+	return Position{Segment: segmentedWal.activeSegment(), Offset: pending.Offset}, pending, nil
+}
+
+// full reports whether the active segment should be rotated before the next
+// append. A segment always takes at least one entry, however small the
+// maximum size, so rotating can never loop.
+func (segmentedWal *SegmentedWal) full() bool {
+	size := segmentedWal.active.Size()
+
+	return size >= segmentedWal.maxSegmentSize && size > fileHeaderSize
+}
+
+// End is the position the next entry will be written at.
+func (segmentedWal *SegmentedWal) End() Position {
+	segmentedWal.RLock()
+	defer segmentedWal.RUnlock()
+
+	return segmentedWal.end()
+}
+
+// WrittenEnd is the position after the last entry that has been written out,
+// see WalWriter.Written.
+func (segmentedWal *SegmentedWal) WrittenEnd() Position {
+	segmentedWal.RLock()
+	defer segmentedWal.RUnlock()
+
+	return Position{
+		Segment: segmentedWal.activeSegment(),
+		Offset:  segmentedWal.active.Written(),
+	}
+}
+
+// NextLSN is the LSN the next entry will be given. Rotating doesn't change it.
+func (segmentedWal *SegmentedWal) NextLSN() uint64 {
+	segmentedWal.RLock()
+	defer segmentedWal.RUnlock()
+
+	return segmentedWal.active.NextLSN()
+}
+
+func (segmentedWal *SegmentedWal) end() Position {
+	return Position{
+		Segment: segmentedWal.activeSegment(),
+		Offset:  segmentedWal.active.Size(),
+	}
+}
+
+func (segmentedWal *SegmentedWal) activeSegment() uint64 {
+	return segmentedWal.manifest.Segments[len(segmentedWal.manifest.Segments)-1]
+}
+
+// Rotate starts a new segment even if the active one is not full.
+func (segmentedWal *SegmentedWal) Rotate() error {
+	segmentedWal.Lock()
+	defer segmentedWal.Unlock()
+
+	return segmentedWal.rotate()
+}
+
+func (segmentedWal *SegmentedWal) rotate() error {
+	next := segmentedWal.activeSegment() + 1
+
+	newManifest := &manifest{
+		Segments: append(append([]uint64{}, segmentedWal.manifest.Segments...), next),
+	}
+
+	// nothing else can enqueue while we hold the write lock, so this is the
+	// LSN of the first entry in the new segment.
+	config := segmentedWal.writerConfig
+	config.StartLSN = segmentedWal.active.NextLSN()
+
+	// the file is created before it is added to the manifest so readers never
+	// find a live segment with no header. If the manifest write fails it is
+	// an orphan and is removed the next time the log is opened.
+	active, err := NewWalWriterWithConfig(segmentFileName(segmentedWal.dir, next), &config)
+
+	if err != nil {
+		return err
+	}
+
+	err = writeManifest(segmentedWal.dir, newManifest)
+
+	if err != nil {
+		active.Close()
+		return err
+	}
+
+	err = segmentedWal.active.Close()
+
+	if err != nil {
+		active.Close()
+		return err
+	}
+
+	segmentedWal.manifest = newManifest
+	segmentedWal.active = active
+
+	return nil
+}
+
+// RemoveSegmentsBefore drops every segment older than segment. The active
+// segment is never removed.
+func (segmentedWal *SegmentedWal) RemoveSegmentsBefore(segment uint64) error {
+	segmentedWal.Lock()
+	defer segmentedWal.Unlock()
+
+	kept := []uint64{}
+	removed := []uint64{}
+
+	for i, s := range segmentedWal.manifest.Segments {
+		if s < segment && i < len(segmentedWal.manifest.Segments)-1 {
+			removed = append(removed, s)
+		} else {
+			kept = append(kept, s)
+		}
+	}
+
+	if len(removed) == 0 {
+		return nil
+	}
+
+	// the manifest is updated first so a crash part way through deleting
+	// files only leaves orphans behind, never a manifest pointing at nothing.
+	err := writeManifest(segmentedWal.dir, &manifest{Segments: kept})
+
+	if err != nil {
+		return err
+	}
+
+	segmentedWal.manifest = &manifest{Segments: kept}
+
+	for _, s := range removed {
+		err = os.Remove(segmentFileName(segmentedWal.dir, s))
+
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return &IOError{Op: "remove", Path: segmentFileName(segmentedWal.dir, s), Err: err}
+		}
+	}
+
+	return syncDir(segmentedWal.dir)
+}
+
+// Reset drops every entry and starts a new, empty segment whose first entry
+// will be given nextLSN. It is used when the log is replaced wholesale, like
+// when a replica loads a snapshot from its primary.
+func (segmentedWal *SegmentedWal) Reset(nextLSN uint64) error {
+	segmentedWal.Lock()
+	defer segmentedWal.Unlock()
+
+	old := segmentedWal.manifest.Segments
+	next := segmentedWal.activeSegment() + 1
+
+	config := segmentedWal.writerConfig
+	config.StartLSN = nextLSN
+
+	active, err := NewWalWriterWithConfig(segmentFileName(segmentedWal.dir, next), &config)
+
+	if err != nil {
+		return err
+	}
+
+	err = writeManifest(segmentedWal.dir, &manifest{Segments: []uint64{next}})
+
+	if err != nil {
+		active.Close()
+		return err
+	}
+
+	err = segmentedWal.active.Close()
+
+	if err != nil {
+		active.Close()
+		return err
+	}
+
+	segmentedWal.manifest = &manifest{Segments: []uint64{next}}
+	segmentedWal.active = active
+
+	for _, s := range old {
+		err = os.Remove(segmentFileName(segmentedWal.dir, s))
+
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return &IOError{Op: "remove", Path: segmentFileName(segmentedWal.dir, s), Err: err}
+		}
+	}
+
+	return syncDir(segmentedWal.dir)
+}
+
+// Segments returns the live segments, oldest first.
+func (segmentedWal *SegmentedWal) Segments() []uint64 {
+	segmentedWal.RLock()
+	defer segmentedWal.RUnlock()
+
+	return append([]uint64{}, segmentedWal.manifest.Segments...)
+}
+
+func (segmentedWal *SegmentedWal) Close() error {
+	segmentedWal.Lock()
+	defer segmentedWal.Unlock()
+
+	return segmentedWal.active.Close()
+}
+
+// SegmentedWalReader reads entries in order starting from a position, moving
+// across segment boundaries as it reaches the end of each segment.
+type SegmentedWalReader struct {
+	segmentedWal *SegmentedWal
+	position     Position
+	reader       *WalReader
+}
+
+// NewReader returns a reader positioned at from. If from is in a segment that
+// has already been removed, reading starts at the oldest live segment.
+func (segmentedWal *SegmentedWal) NewReader(from Position) *SegmentedWalReader {
+	return &SegmentedWalReader{
+		segmentedWal: segmentedWal,
+		position:     from,
+	}
+}
+
+// Next returns the next entry and the position it was read from. io.EOF is
+// returned once the reader has caught up with the end of the active segment.
+func (reader *SegmentedWalReader) Next() (*WalEntry, Position, error) {
+	return reader.next(nil)
+}
+
+// NextBefore is Next for a log that is still being written to. It returns
+// io.EOF at end rather than reading on, so with end from WrittenEnd it never
+// reads an entry that is only part way written.
+func (reader *SegmentedWalReader) NextBefore(end Position) (*WalEntry, Position, error) {
+	return reader.next(&end)
+}
+
+func (reader *SegmentedWalReader) next(end *Position) (*WalEntry, Position, error) {
+	for {
+		if reader.reader == nil {
+			segment, ok := reader.nextLiveSegment(reader.position.Segment)
+
+			if !ok {
+				return nil, reader.position, io.EOF
+			}
+
+			if segment != reader.position.Segment {
+				reader.position = Position{Segment: segment}
+			}
+
+			walReader, err := NewWalReader(segmentFileName(reader.segmentedWal.dir, segment))
+
+			if err != nil {
+				return nil, reader.position, err
+			}
+
+			reader.reader = walReader
+			reader.position.Offset = max(reader.position.Offset, walReader.Start())
+		}
+
+		if end != nil && !reader.position.Before(*end) {
+			return nil, reader.position, io.EOF
+		}
+
+		entryRead, err := reader.reader.Read(reader.position.Offset)
+
+		if err == io.EOF {
+			segment, ok := reader.nextLiveSegment(reader.position.Segment + 1)
+
+			if !ok {
+				return nil, reader.position, io.EOF
+			}
+
+			walReader, err := NewWalReader(segmentFileName(reader.segmentedWal.dir, segment))
+
+			if err != nil {
+				return nil, reader.position, err
+			}
+
+			reader.reader.Close()
+			reader.reader = walReader
+			reader.position = Position{Segment: segment, Offset: walReader.Start()}
+
+			continue
+		}
+
+		if err != nil {
+			return nil, reader.position, fmt.Errorf("WAL segment %d offset %d: %w", reader.position.Segment, reader.position.Offset, err)
+		}
+
+		position := reader.position
+		reader.position.Offset += entryRead.Size()
+
+		return entryRead.Entry(), position, nil
+	}
+}
+
+// nextLiveSegment finds the oldest live segment that is at least segment.
+func (reader *SegmentedWalReader) nextLiveSegment(segment uint64) (uint64, bool) {
+	for _, s := range reader.segmentedWal.Segments() {
+		if s >= segment {
+			return s, true
+		}
+	}
+
+	return 0, false
+}
+
+func (reader *SegmentedWalReader) Close() error {
+	if reader.reader == nil {
+		return nil
+	}
+
+	return reader.reader.Close()
+}
+
+// syncDir makes renames and deletes inside dir durable.
+func syncDir(dir string) error {
+	file, err := os.Open(dir)
+
+	if err != nil {
+		return &IOError{Op: "open", Path: dir, Err: err}
+	}
+
+	defer file.Close()
+
+	err = file.Sync()
+
+	if err != nil {
+		return &IOError{Op: "sync", Path: dir, Err: err}
+	}
+
+	return nil
+}
@@ -0,0 +1,286 @@
+package wal
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func putEntry(key string, val string) *WalEntryWrite {
+	valueBytes := []byte(val)
+
+	return &WalEntryWrite{
+		OpType:      Put,
+		KeyLength:   int32(len(key)),
+		ValueLength: int32(len(valueBytes)),
+		KeyBytes:    []byte(key),
+		ValueBytes:  &valueBytes,
+	}
+}
+
+func TestSegmentedWalShouldRotateAndReadAcrossSegments(t *testing.T) {
+	dir := t.TempDir()
+
+	segmentedWal, err := OpenSegmentedWal(&SegmentedWalConfig{Dir: dir, MaxSegmentSize: 100})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening WAL %v", err)
+	}
+
+	defer segmentedWal.Close()
+
+	keys := []string{"a", "b", "c", "d", "e"}
+	positions := []Position{}
+
+	for _, key := range keys {
+		position, err := segmentedWal.Write(putEntry(key, "value"))
+
+		if err != nil {
+			t.Fatalf("Did not expect an error when writing %v", err)
+		}
+
+		positions = append(positions, position)
+	}
+
+	// each entry is 35 bytes after a 16 byte header, so a segment fills up after 3 entries.
+	if segments := segmentedWal.Segments(); len(segments) != 2 {
+		t.Fatalf("Expected 2 segments, got %v", segments)
+	}
+
+	reader := segmentedWal.NewReader(Position{})
+
+	defer reader.Close()
+
+	for i, key := range keys {
+		entry, position, err := reader.Next()
+
+		if err != nil {
+			t.Fatalf("Did not expect an error when reading entry %d: %v", i, err)
+		}
+
+		if string(entry.KeyBytes) != key {
+			t.Errorf("Expected key %s, got %s", key, string(entry.KeyBytes))
+		}
+
+		if position != positions[i] {
+			t.Errorf("Expected position %v, got %v", positions[i], position)
+		}
+	}
+
+	_, _, err = reader.Next()
+
+	if err != io.EOF {
+		t.Errorf("Expected EOF, got %v", err)
+	}
+}
+
+func TestSegmentedWalReaderShouldStartFromPosition(t *testing.T) {
+	segmentedWal, err := OpenSegmentedWal(&SegmentedWalConfig{Dir: t.TempDir(), MaxSegmentSize: 100})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening WAL %v", err)
+	}
+
+	defer segmentedWal.Close()
+
+	segmentedWal.Write(putEntry("a", "value"))
+	segmentedWal.Write(putEntry("b", "value"))
+
+	from := segmentedWal.End()
+
+	segmentedWal.Write(putEntry("c", "value"))
+	segmentedWal.Write(putEntry("d", "value"))
+
+	reader := segmentedWal.NewReader(from)
+
+	defer reader.Close()
+
+	for _, key := range []string{"c", "d"} {
+		entry, _, err := reader.Next()
+
+		if err != nil {
+			t.Fatalf("Did not expect an error when reading %v", err)
+		}
+
+		if string(entry.KeyBytes) != key {
+			t.Errorf("Expected key %s, got %s", key, string(entry.KeyBytes))
+		}
+	}
+}
+
+func TestSegmentedWalShouldRemoveOldSegments(t *testing.T) {
+	dir := t.TempDir()
+
+	segmentedWal, err := OpenSegmentedWal(&SegmentedWalConfig{Dir: dir, MaxSegmentSize: 1})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening WAL %v", err)
+	}
+
+	for _, key := range []string{"a", "b", "c"} {
+		segmentedWal.Write(putEntry(key, "value"))
+	}
+
+	err = segmentedWal.RemoveSegmentsBefore(3)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when removing segments %v", err)
+	}
+
+	for segment, shouldExist := range map[uint64]bool{1: false, 2: false, 3: true} {
+		_, err := os.Stat(segmentFileName(dir, segment))
+
+		if exists := err == nil; exists != shouldExist {
+			t.Errorf("Expected segment %d exists = %t, got %t", segment, shouldExist, exists)
+		}
+	}
+
+	segmentedWal.Close()
+
+	// an orphaned segment from a crash during rotation is cleaned up on open.
+	os.WriteFile(segmentFileName(dir, 9), []byte{}, 0666)
+
+	reopened, err := OpenSegmentedWal(&SegmentedWalConfig{Dir: dir})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when reopening WAL %v", err)
+	}
+
+	defer reopened.Close()
+
+	if segments := reopened.Segments(); len(segments) != 1 || segments[0] != 3 {
+		t.Errorf("Expected only segment 3, got %v", segments)
+	}
+
+	if _, err := os.Stat(segmentFileName(dir, 9)); !os.IsNotExist(err) {
+		t.Errorf("Expected orphaned segment to be removed, got %v", err)
+	}
+}
+
+func TestOpenSegmentedWalShouldTruncateTornTail(t *testing.T) {
+	dir := t.TempDir()
+
+	segmentedWal, err := OpenSegmentedWal(&SegmentedWalConfig{Dir: dir})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening WAL %v", err)
+	}
+
+	segmentedWal.Write(putEntry("a", "value"))
+
+	end := segmentedWal.End()
+
+	segmentedWal.Close()
+
+	file, _ := os.OpenFile(filepath.Join(dir, "000000000001.wal"), os.O_APPEND|os.O_WRONLY, 0666)
+	file.Write([]byte{Put, 1, 0})
+	file.Close()
+
+	reopened, err := OpenSegmentedWal(&SegmentedWalConfig{Dir: dir})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when reopening WAL %v", err)
+	}
+
+	defer reopened.Close()
+
+	if reopened.End() != end {
+		t.Errorf("Expected end to be %v, got %v", end, reopened.End())
+	}
+}
+
+func TestSegmentedWalShouldContinueLSNsAcrossRotationAndReopen(t *testing.T) {
+	dir := t.TempDir()
+
+	segmentedWal, err := OpenSegmentedWal(&SegmentedWalConfig{Dir: dir, MaxSegmentSize: 100})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening WAL %v", err)
+	}
+
+	for _, key := range []string{"a", "b", "c", "d"} {
+		segmentedWal.Write(putEntry(key, "value"))
+	}
+
+	segmentedWal.Close()
+
+	reopened, err := OpenSegmentedWal(&SegmentedWalConfig{Dir: dir, MaxSegmentSize: 100})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when reopening WAL %v", err)
+	}
+
+	defer reopened.Close()
+
+	reopened.Write(putEntry("e", "value"))
+
+	reader := reopened.NewReader(Position{})
+
+	defer reader.Close()
+
+	for expectedLSN := uint64(1); expectedLSN <= 5; expectedLSN++ {
+		entry, _, err := reader.Next()
+
+		if err != nil {
+			t.Fatalf("Did not expect an error when reading %v", err)
+		}
+
+		if entry.LSN != expectedLSN {
+			t.Errorf("Expected LSN %d, got %d", expectedLSN, entry.LSN)
+		}
+	}
+}
+
+func TestSegmentedWalShouldStartOverAfterReset(t *testing.T) {
+	dir := t.TempDir()
+
+	segmentedWal, err := OpenSegmentedWal(&SegmentedWalConfig{Dir: dir, MaxSegmentSize: 100})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening WAL %v", err)
+	}
+
+	for _, key := range []string{"a", "b", "c", "d"} {
+		segmentedWal.Write(putEntry(key, "value"))
+	}
+
+	err = segmentedWal.Reset(42)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when resetting %v", err)
+	}
+
+	if segments := segmentedWal.Segments(); len(segments) != 1 || segments[0] != 3 {
+		t.Errorf("Expected only a new segment 3, got %v", segments)
+	}
+
+	segmentedWal.Write(putEntry("e", "value"))
+	segmentedWal.Close()
+
+	reopened, err := OpenSegmentedWal(&SegmentedWalConfig{Dir: dir, MaxSegmentSize: 100})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when reopening WAL %v", err)
+	}
+
+	defer reopened.Close()
+
+	if reopened.NextLSN() != 43 {
+		t.Errorf("Expected the next LSN to be 43, got %d", reopened.NextLSN())
+	}
+
+	reader := reopened.NewReader(Position{})
+
+	defer reader.Close()
+
+	entry, _, err := reader.NextBefore(reopened.WrittenEnd())
+
+	if err != nil || string(entry.KeyBytes) != "e" || entry.LSN != 42 {
+		t.Fatalf("Expected only e at LSN 42, got %v %v", entry, err)
+	}
+
+	if _, _, err = reader.NextBefore(reopened.WrittenEnd()); err != io.EOF {
+		t.Errorf("Expected to reach the end, got %v", err)
+	}
+}
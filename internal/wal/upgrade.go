@@ -0,0 +1,140 @@
+package wal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+)
+
+// Upgrade rewrites a v1 WAL file in the current format, giving its entries
+// LSNs that start at firstLSN and a timestamp of 0 since v1 never recorded
+// one. The new file replaces the old one with a rename, so a crash part way
+// through leaves the v1 file untouched. Anything after the last valid v1 entry
+// is dropped.
+//
+// It returns the LSN the next entry after the file should get. Files that are
+// already in the current format are left alone.
+func Upgrade(fileName string, firstLSN uint64) (uint64, error) {
+	reader, err := NewWalReader(fileName)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer reader.Close()
+
+	if reader.Version() != FormatV1 {
+		return reader.nextLSN(firstLSN)
+	}
+
+	tmpPath := fileName + ".upgrade"
+
+	os.Remove(tmpPath)
+
+	writer, err := NewWalWriterWithConfig(tmpPath, &WalWriterConfig{Durability: SyncNone, StartLSN: firstLSN})
+
+	if err != nil {
+		return 0, err
+	}
+
+	offset := reader.Start()
+	upgraded := 0
+
+	for {
+		entryRead, err := reader.Read(offset)
+
+		if err == io.EOF {
+			break
+		}
+
+		if errors.Is(err, ErrIO) {
+			writer.Close()
+			return 0, err
+		}
+
+		if err != nil {
+			log.Printf("Dropping the rest of v1 WAL %s after offset %d: %v", fileName, offset, err)
+			break
+		}
+
+		entry := entryRead.Entry()
+
+		err = writer.Write(&WalEntryWrite{
+			OpType:      entry.OpType,
+			KeyLength:   entry.KeyLength,
+			ValueLength: entry.ValueLength,
+			KeyBytes:    entry.KeyBytes,
+			ValueBytes:  entry.ValueBytes,
+		})
+
+		if err != nil {
+			writer.Close()
+			return 0, err
+		}
+
+		offset += entryRead.Size()
+		upgraded++
+	}
+
+	nextLSN := writer.NextLSN()
+
+	// closing syncs the file, so it is durable before it replaces the original.
+	err = writer.Close()
+
+	if err != nil {
+		return 0, err
+	}
+
+	err = os.Rename(tmpPath, fileName)
+
+	if err != nil {
+		return 0, &IOError{Op: "rename", Path: tmpPath, Err: err}
+	}
+
+	log.Printf("Upgraded %d entries in %s from WAL format v1 to v%d", upgraded, fileName, CurrentFormat)
+
+	return nextLSN, nil
+}
+
+// NextLSN returns the LSN the entry after the last one in fileName should get.
+// A missing or empty file has no entries, so it returns ifEmpty.
+func NextLSN(fileName string, ifEmpty uint64) (uint64, error) {
+	_, err := os.Stat(fileName)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return ifEmpty, nil
+	}
+
+	reader, err := NewWalReader(fileName)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer reader.Close()
+
+	if reader.Version() == FormatV1 {
+		return 0, &CorruptEntryError{Offset: 0, Reason: fmt.Sprintf("%s is a v1 WAL, which has no LSNs", fileName)}
+	}
+
+	return reader.nextLSN(ifEmpty)
+}
+
+// nextLSN scans a current format file for its last LSN.
+func (reader *WalReader) nextLSN(ifEmpty uint64) (uint64, error) {
+	header, err := readFileHeader(reader.file)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if header == nil {
+		return ifEmpty, nil
+	}
+
+	nextLSN, _, err := reader.scanToEnd(header.BaseLSN)
+
+	return nextLSN, err
+}
@@ -0,0 +1,138 @@
+package wal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeV1Entries writes entries the way the v1 writer did, with no file header
+// and no LSN or timestamp.
+func writeV1Entries(t *testing.T, fileName string, keys ...string) {
+	buf := new(bytes.Buffer)
+
+	for _, key := range keys {
+		entry := new(bytes.Buffer)
+
+		entry.WriteByte(Put)
+		binary.Write(entry, binary.LittleEndian, int32(len(key)))
+		binary.Write(entry, binary.LittleEndian, int32(len("value")))
+		entry.WriteString(key)
+		entry.WriteString("value")
+		binary.Write(entry, binary.LittleEndian, crc32.ChecksumIEEE(entry.Bytes()))
+
+		buf.Write(entry.Bytes())
+	}
+
+	err := os.WriteFile(fileName, buf.Bytes(), 0666)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when writing v1 WAL: %v", err)
+	}
+}
+
+func TestShouldReadAndUpgradeV1Wal(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "test_v1.bin")
+
+	writeV1Entries(t, fileName, "a", "b")
+
+	reader, err := NewWalReader(fileName)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening: %v", err)
+	}
+
+	if reader.Version() != FormatV1 || reader.Start() != 0 {
+		t.Errorf("Expected a v1 WAL starting at 0, got v%d starting at %d", reader.Version(), reader.Start())
+	}
+
+	readEntry, err := reader.Read(0)
+
+	if err != nil || string(readEntry.entry.KeyBytes) != "a" || readEntry.entry.LSN != 0 {
+		t.Errorf("Expected to read key a with no LSN, got %v %v", readEntry, err)
+	}
+
+	reader.Close()
+
+	_, err = NewWalWriter(fileName)
+
+	if err == nil {
+		t.Errorf("Expected an error when appending to a v1 WAL")
+	}
+
+	nextLSN, err := Upgrade(fileName, 10)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when upgrading: %v", err)
+	}
+
+	if nextLSN != 12 {
+		t.Errorf("Expected next LSN 12, got %d", nextLSN)
+	}
+
+	reader, err = NewWalReader(fileName)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening: %v", err)
+	}
+
+	defer reader.Close()
+
+	offset := reader.Start()
+
+	for i, key := range []string{"a", "b"} {
+		readEntry, err := reader.Read(offset)
+
+		if err != nil {
+			t.Fatalf("Did not expect an error when reading: %v", err)
+		}
+
+		if string(readEntry.entry.KeyBytes) != key || readEntry.entry.LSN != uint64(10+i) {
+			t.Errorf("Expected key %s with LSN %d, got %s with LSN %d", key, 10+i, string(readEntry.entry.KeyBytes), readEntry.entry.LSN)
+		}
+
+		offset += readEntry.size
+	}
+
+	if _, err := reader.Read(offset); err != io.EOF {
+		t.Errorf("Expected EOF, got %v", err)
+	}
+}
+
+func TestOpenSegmentedWalShouldUpgradeV1Segments(t *testing.T) {
+	dir := t.TempDir()
+
+	os.WriteFile(filepath.Join(dir, manifestFileName), []byte(`{"segments":[1,2]}`), 0666)
+	writeV1Entries(t, segmentFileName(dir, 1), "a", "b")
+	writeV1Entries(t, segmentFileName(dir, 2), "c")
+
+	segmentedWal, err := OpenSegmentedWal(&SegmentedWalConfig{Dir: dir})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening WAL %v", err)
+	}
+
+	defer segmentedWal.Close()
+
+	segmentedWal.Write(putEntry("d", "value"))
+
+	reader := segmentedWal.NewReader(Position{})
+
+	defer reader.Close()
+
+	for i, key := range []string{"a", "b", "c", "d"} {
+		entry, _, err := reader.Next()
+
+		if err != nil {
+			t.Fatalf("Did not expect an error when reading %v", err)
+		}
+
+		if string(entry.KeyBytes) != key || entry.LSN != uint64(i+1) {
+			t.Errorf("Expected key %s with LSN %d, got %s with LSN %d", key, i+1, string(entry.KeyBytes), entry.LSN)
+		}
+	}
+}
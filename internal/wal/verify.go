@@ -0,0 +1,142 @@
+package wal
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// CorruptRange is a run of bytes in a log that could not be read as entries.
+// End is exclusive, and is where the next valid entry starts or the end of
+// the file.
+type CorruptRange struct {
+	Start int64
+	End   int64
+	Err   error // what went wrong reading the entry at Start
+}
+
+type VerifyReport struct {
+	Size          int64
+	ValidEntries  int
+	CorruptRanges []CorruptRange
+}
+
+// Verify scans the whole log at fileName and reports every corrupt range in
+// it. After a bad entry it moves forward a byte at a time until it finds the
+// next entry that passes its checksum, so entries after damage in the middle
+// of a log are still found.
+//
+// If salvageTo is not empty, every valid entry is copied into a new log at
+// that path.
+func Verify(fileName string, salvageTo string) (*VerifyReport, error) {
+	info, err := os.Stat(fileName)
+
+	if err != nil {
+		return nil, &IOError{Op: "stat", Path: fileName, Err: err}
+	}
+
+	reader, err := NewWalReader(fileName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer reader.Close()
+
+	var salvage *os.File
+
+	if salvageTo != "" {
+		salvage, err = os.OpenFile(salvageTo, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0666)
+
+		if err != nil {
+			return nil, &IOError{Op: "open", Path: salvageTo, Err: err}
+		}
+
+		defer salvage.Close()
+	}
+
+	report := &VerifyReport{Size: info.Size()}
+
+	// the salvaged log keeps the original file header, so its entries keep
+	// their LSNs.
+	if salvage != nil && reader.Start() > 0 {
+		header := make([]byte, reader.Start())
+
+		_, err = reader.file.ReadAt(header, 0)
+
+		if err != nil {
+			return nil, &IOError{Op: "read", Path: fileName, Err: err}
+		}
+
+		_, err = salvage.Write(header)
+
+		if err != nil {
+			return nil, &IOError{Op: "write", Path: salvageTo, Err: err}
+		}
+	}
+
+	var corrupt *CorruptRange
+
+	for offset := reader.Start(); offset < info.Size(); {
+		entryRead, err := reader.Read(offset)
+
+		if errors.Is(err, ErrIO) {
+			return nil, err
+		}
+
+		if err != nil && err != io.EOF {
+			if corrupt == nil {
+				corrupt = &CorruptRange{Start: offset, Err: err}
+			}
+
+			offset++
+
+			continue
+		}
+
+		if corrupt != nil {
+			corrupt.End = offset
+			report.CorruptRanges = append(report.CorruptRanges, *corrupt)
+			corrupt = nil
+		}
+
+		if err == io.EOF {
+			break
+		}
+
+		report.ValidEntries++
+
+		if salvage != nil {
+			buf := make([]byte, entryRead.Size())
+
+			_, err = reader.file.ReadAt(buf, offset)
+
+			if err != nil {
+				return nil, &IOError{Op: "read", Path: fileName, Err: err}
+			}
+
+			_, err = salvage.Write(buf)
+
+			if err != nil {
+				return nil, &IOError{Op: "write", Path: salvageTo, Err: err}
+			}
+		}
+
+		offset += entryRead.Size()
+	}
+
+	if corrupt != nil {
+		corrupt.End = info.Size()
+		report.CorruptRanges = append(report.CorruptRanges, *corrupt)
+	}
+
+	if salvage != nil {
+		err = salvage.Sync()
+
+		if err != nil {
+			return nil, &IOError{Op: "sync", Path: salvageTo, Err: err}
+		}
+	}
+
+	return report, nil
+}
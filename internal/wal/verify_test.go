@@ -0,0 +1,147 @@
+package wal
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeEntries(t *testing.T, fileName string, keys ...string) []int64 {
+	writer, err := NewWalWriter(fileName)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening: %v", err)
+	}
+
+	defer writer.Close()
+
+	offsets := []int64{}
+
+	for _, key := range keys {
+		offsets = append(offsets, writer.Size())
+
+		err = writer.Write(putEntry(key, "value"))
+
+		if err != nil {
+			t.Fatalf("Did not expect an error when writing: %v", err)
+		}
+	}
+
+	return offsets
+}
+
+func TestReadShouldReturnTypedErrors(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "test_errors.bin")
+
+	offsets := writeEntries(t, fileName, "a", "b")
+
+	contents, _ := os.ReadFile(fileName)
+
+	// flip a byte in the value of the first entry and cut the second in half.
+	contents[offsets[0]+26] ^= 0xff
+	contents = contents[:offsets[1]+5]
+
+	os.WriteFile(fileName, contents, 0666)
+
+	reader, err := NewWalReader(fileName)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening: %v", err)
+	}
+
+	defer reader.Close()
+
+	_, err = reader.Read(offsets[0])
+
+	var corrupt *CorruptEntryError
+
+	if !errors.Is(err, ErrCorruptEntry) || !errors.As(err, &corrupt) || corrupt.Offset != offsets[0] {
+		t.Errorf("Expected a corrupt entry at offset %d, got %v", offsets[0], err)
+	}
+
+	_, err = reader.Read(offsets[1])
+
+	if !errors.Is(err, ErrTornWrite) {
+		t.Errorf("Expected a torn write, got %v", err)
+	}
+
+	_, err = NewWalReader(filepath.Join(t.TempDir(), "missing.bin"))
+
+	if !errors.Is(err, ErrIO) || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected an I/O error wrapping not exist, got %v", err)
+	}
+}
+
+func TestVerifyShouldReportCorruptRangesAndSalvage(t *testing.T) {
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "test_verify.bin")
+
+	offsets := writeEntries(t, fileName, "a", "b", "c", "d")
+
+	contents, _ := os.ReadFile(fileName)
+
+	// damage the checksum of b, and leave half an entry at the end.
+	contents[offsets[2]-1] ^= 0xff
+	contents = append(contents, Put, 1, 0)
+
+	os.WriteFile(fileName, contents, 0666)
+
+	salvageTo := filepath.Join(dir, "salvaged.bin")
+
+	report, err := Verify(fileName, salvageTo)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when verifying: %v", err)
+	}
+
+	if report.ValidEntries != 3 {
+		t.Errorf("Expected 3 valid entries, got %d", report.ValidEntries)
+	}
+
+	expectedRanges := []CorruptRange{
+		{Start: offsets[1], End: offsets[2]},
+		{Start: int64(len(contents)) - 3, End: int64(len(contents))},
+	}
+
+	if len(report.CorruptRanges) != len(expectedRanges) {
+		t.Fatalf("Expected %d corrupt ranges, got %v", len(expectedRanges), report.CorruptRanges)
+	}
+
+	for i, expected := range expectedRanges {
+		actual := report.CorruptRanges[i]
+
+		if actual.Start != expected.Start || actual.End != expected.End {
+			t.Errorf("Expected corrupt range %d to %d, got %d to %d", expected.Start, expected.End, actual.Start, actual.End)
+		}
+	}
+
+	reader, err := NewWalReader(salvageTo)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening salvaged WAL: %v", err)
+	}
+
+	defer reader.Close()
+
+	offset := reader.Start()
+
+	for _, key := range []string{"a", "c", "d"} {
+		readEntry, err := reader.Read(offset)
+
+		if err != nil {
+			t.Fatalf("Did not expect an error when reading salvaged WAL: %v", err)
+		}
+
+		if string(readEntry.entry.KeyBytes) != key {
+			t.Errorf("Expected key %s, got %s", key, string(readEntry.entry.KeyBytes))
+		}
+
+		offset += readEntry.size
+	}
+
+	if _, err := reader.Read(offset); err != io.EOF {
+		t.Errorf("Expected EOF, got %v", err)
+	}
+}
@@ -1,25 +1,98 @@
 package wal
 
 import (
-	"bytes"
 	"encoding/binary"
 	"fmt"
 	"hash/crc32"
 	"io"
 	"os"
+	"sync"
+	"time"
 )
 
-type WalWriter struct {
-	file *os.File
+// DurabilityPolicy decides when an entry counts as written.
+type DurabilityPolicy int
+
+const (
+	// SyncAlways fsyncs every entry before Write returns.
+	SyncAlways DurabilityPolicy = iota
+	// SyncBatched queues entries from concurrent writers and has a single
+	// goroutine write and fsync them together. Entries that arrive while a
+	// batch is being synced form the next batch, and a batch waits at most
+	// MaxBatchDelay for more entries to join it.
+	SyncBatched
+	// SyncNone writes entries to the file and leaves flushing to the OS, so
+	// a machine crash can lose recent entries.
+	SyncNone
+)
+
+func ParseDurabilityPolicy(s string) (DurabilityPolicy, error) {
+	switch s {
+	case "always":
+		return SyncAlways, nil
+	case "batched":
+		return SyncBatched, nil
+	case "none":
+		return SyncNone, nil
+	}
+
+	return SyncAlways, fmt.Errorf("unknown durability policy %q, expected always, batched or none", s)
 }
 
 const (
-	Put = 1
-	Del = 2
+	// DefaultMaxBatchDelay of zero means a batch is whatever queued up while
+	// the previous batch was being synced, without waiting for more.
+	DefaultMaxBatchDelay = 0
+	DefaultMaxBatchSize  = 1024
 )
 
+type WalWriterConfig struct {
+	Durability    DurabilityPolicy
+	MaxBatchDelay time.Duration // only used by SyncBatched. Trades latency for bigger batches.
+	MaxBatchSize  int           // only used by SyncBatched
+	StartLSN      uint64        // LSN of the first entry when the file is new, defaults to 1
+}
+
+type WalWriter struct {
+	sync.Mutex
+	file    *os.File
+	size    int64
+	written int64 // bytes that have been written out, behind size while entries are queued
+	nextLSN uint64
+	err     error // set after a failed write, since the file no longer ends on an entry boundary
+	config  WalWriterConfig
+	closing bool               // set by Close, after which nothing can be enqueued
+	queue   chan *PendingWrite // only used by SyncBatched
+	sending sync.Mutex         // keeps entries going into the queue in LSN order
+	closed  chan struct{}      // closed once the committer has drained the queue
+}
+
+// PendingWrite is an entry that has been given its place in the log but might
+// not be durable yet.
+type PendingWrite struct {
+	Offset int64
+	LSN    uint64
+	buf    []byte
+	done   chan struct{}
+	err    error
+}
+
+// Wait blocks until the entry is durable according to the writer's policy. A
+// nil PendingWrite has nothing to wait for.
+func (pending *PendingWrite) Wait() error {
+	if pending == nil {
+		return nil
+	}
+
+	<-pending.done
+
+	return pending.err
+}
+
 type WalEntry struct {
-	OpType      byte    // 1 for PUT. 2 for DEL.
+	OpType      byte    // see the op type constants
+	LSN         uint64  // 8 bytes. Increases by one with every entry, 0 for v1 entries.
+	Timestamp   int64   // 8 bytes. Unix nanoseconds when the entry was appended, 0 for v1 entries.
 	KeyLength   int32   // 4 bytes
 	ValueLength int32   // 4 bytes
 	KeyBytes    []byte  // variable
@@ -27,84 +100,391 @@ type WalEntry struct {
 	CheckSum    uint32  // 4 bytes
 }
 
+// WalEntryWrite is an entry to append. The writer assigns its LSN and timestamp.
 type WalEntryWrite struct {
-	OpType      byte    // 1 for PUT. 2 for DEL.
+	OpType      byte    // see the op type constants
 	KeyLength   int32   // 4 bytes
 	ValueLength int32   // 4 bytes
 	KeyBytes    []byte  // variable
 	ValueBytes  *[]byte // variable
 }
 
-func NewWalWriter(fileName string) *WalWriter {
-	file, err := os.OpenFile(fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+func NewWalWriter(fileName string) (*WalWriter, error) {
+	return NewWalWriterWithConfig(fileName, &WalWriterConfig{Durability: SyncAlways})
+}
+
+// NewWalWriterWithConfig opens fileName for appending, writing a file header
+// first if the file is new. Appending to a v1 file is refused, it has to be
+// upgraded with Upgrade first.
+func NewWalWriterWithConfig(fileName string, config *WalWriterConfig) (*WalWriter, error) {
+	file, err := os.OpenFile(fileName, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0666)
+
+	if err != nil {
+		return nil, &IOError{Op: "open", Path: fileName, Err: err}
+	}
+
+	writer := &WalWriter{
+		file:   file,
+		config: *config,
+	}
+
+	if writer.config.StartLSN == 0 {
+		writer.config.StartLSN = 1
+	}
+
+	err = writer.init()
+
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if writer.config.Durability == SyncBatched {
+		if writer.config.MaxBatchSize <= 0 {
+			writer.config.MaxBatchSize = DefaultMaxBatchSize
+		}
+
+		writer.queue = make(chan *PendingWrite, writer.config.MaxBatchSize)
+		writer.closed = make(chan struct{})
+
+		go writer.commit()
+	}
+
+	return writer, nil
+}
+
+// init finds where the writer picks up from. A new file gets a header, and an
+// existing one is scanned for the last LSN written to it.
+func (writer *WalWriter) init() error {
+	header, err := readFileHeader(writer.file)
 
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	return &WalWriter{
-		file: file,
+	if header != nil && header.Version == FormatV1 {
+		return &CorruptEntryError{Offset: 0, Reason: fmt.Sprintf("%s is a v1 WAL and has to be upgraded before it can be appended to", writer.file.Name())}
+	}
+
+	if header == nil {
+		err = writer.file.Truncate(0)
+
+		if err != nil {
+			return &IOError{Op: "truncate", Path: writer.file.Name(), Err: err}
+		}
+
+		_, err = writer.file.Write(encodeFileHeader(writer.config.StartLSN))
+
+		if err == nil {
+			err = writer.file.Sync()
+		}
+
+		if err != nil {
+			return &IOError{Op: "write", Path: writer.file.Name(), Err: err}
+		}
+
+		writer.size = fileHeaderSize
+		writer.written = fileHeaderSize
+		writer.nextLSN = writer.config.StartLSN
+
+		return nil
 	}
+
+	reader := &WalReader{file: writer.file, version: header.Version, start: fileHeaderSize}
+
+	writer.nextLSN, writer.size, err = reader.scanToEnd(header.BaseLSN)
+	writer.written = writer.size
+
+	return err
 }
 
+// Write appends an entry and waits until it is durable.
 func (writer *WalWriter) Write(entry *WalEntryWrite) error {
+	pending, err := writer.Enqueue(entry)
 
-	buf := new(bytes.Buffer)
+	if err != nil {
+		return err
+	}
 
-	err := binary.Write(buf, binary.LittleEndian, entry.OpType)
+	return pending.Wait()
+}
+
+// Enqueue gives an entry its place in the log without waiting for it to be
+// durable. Entries are written in the order they are enqueued, so callers can
+// hold a lock across Enqueue to keep the log in step with their own state and
+// release it before calling Wait.
+func (writer *WalWriter) Enqueue(entry *WalEntryWrite) (*PendingWrite, error) {
+	if writer.config.Durability == SyncBatched {
+		return writer.enqueueBatched(entry)
+	}
+
+	writer.Lock()
+	defer writer.Unlock()
+
+	pending, err := writer.reserveLocked(entry)
 
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	err = binary.Write(buf, binary.LittleEndian, entry.KeyLength)
+	_, err = writer.file.Write(pending.buf)
 
 	if err != nil {
-		panic(err)
+		writer.err = &IOError{Op: "write", Path: writer.file.Name(), Err: err}
+		return nil, writer.err
 	}
 
-	err = binary.Write(buf, binary.LittleEndian, entry.ValueLength)
+	if writer.config.Durability == SyncAlways {
+		err = writer.file.Sync()
+
+		if err != nil {
+			writer.err = &IOError{Op: "sync", Path: writer.file.Name(), Err: err}
+			return nil, writer.err
+		}
+	}
+
+	writer.written = writer.size
+
+	close(pending.done)
+
+	return pending, nil
+}
+
+// enqueueBatched hands the entry to the committer. The queue is sent to
+// without holding the writer lock, since the committer needs the lock to take
+// entries off a full queue.
+func (writer *WalWriter) enqueueBatched(entry *WalEntryWrite) (*PendingWrite, error) {
+	writer.sending.Lock()
+	defer writer.sending.Unlock()
+
+	writer.Lock()
+	pending, err := writer.reserveLocked(entry)
+	writer.Unlock()
 
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+
+	writer.queue <- pending
+
+	return pending, nil
+}
+
+// reserveLocked encodes the entry and gives it the next LSN and offset. The
+// caller holds the writer lock.
+func (writer *WalWriter) reserveLocked(entry *WalEntryWrite) (*PendingWrite, error) {
+	if writer.closing {
+		return nil, ErrClosed
 	}
 
-	err = binary.Write(buf, binary.LittleEndian, entry.KeyBytes)
+	if writer.err != nil {
+		return nil, writer.err
+	}
+
+	buf, err := encodeEntry(entry, writer.nextLSN, time.Now().UnixNano())
 
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	if entry.OpType == Put {
-		if entry.ValueBytes == nil {
-			return fmt.Errorf("ValueBytes must not be nil for Put operation")
+	pending := &PendingWrite{
+		Offset: writer.size,
+		LSN:    writer.nextLSN,
+		buf:    buf,
+		done:   make(chan struct{}),
+	}
+
+	writer.nextLSN++
+	writer.size += int64(len(buf))
+
+	return pending, nil
+}
+
+// commit is the group commit loop. Each batch is written with one write and
+// one fsync, then every waiter in it is woken up.
+func (writer *WalWriter) commit() {
+	defer close(writer.closed)
+
+	for first := range writer.queue {
+		batch, open := writer.gather(first)
+
+		buf := []byte{}
+
+		for _, pending := range batch {
+			buf = append(buf, pending.buf...)
 		}
 
-		err = binary.Write(buf, binary.LittleEndian, *entry.ValueBytes)
+		err := writer.writeBatch(buf)
 
-		if err != nil {
-			panic(err)
+		for _, pending := range batch {
+			pending.err = err
+			close(pending.done)
+		}
+
+		if !open {
+			return
+		}
+	}
+}
+
+func (writer *WalWriter) writeBatch(buf []byte) error {
+	writer.Lock()
+	failed := writer.err
+	writer.Unlock()
+
+	// once a batch has failed nothing after it can be written, or the log
+	// would have a hole where that batch should be.
+	if failed != nil {
+		return failed
+	}
+
+	_, err := writer.file.Write(buf)
+
+	if err != nil {
+		err = &IOError{Op: "write", Path: writer.file.Name(), Err: err}
+	} else if err = writer.file.Sync(); err != nil {
+		err = &IOError{Op: "sync", Path: writer.file.Name(), Err: err}
+	}
+
+	writer.Lock()
+
+	if err != nil {
+		writer.err = err
+	} else {
+		writer.written += int64(len(buf))
+	}
+
+	writer.Unlock()
+
+	return err
+}
+
+// gather builds a batch from the first entry and everything that queued up
+// behind it while the previous batch was being synced. If that is less than a
+// full batch it waits up to MaxBatchDelay for more. The returned bool is false
+// once the queue has been closed.
+func (writer *WalWriter) gather(first *PendingWrite) ([]*PendingWrite, bool) {
+	batch := []*PendingWrite{first}
+
+	var deadline <-chan time.Time
+
+	if writer.config.MaxBatchDelay > 0 {
+		timer := time.NewTimer(writer.config.MaxBatchDelay)
+		defer timer.Stop()
+
+		deadline = timer.C
+	}
+
+	for len(batch) < writer.config.MaxBatchSize {
+		select {
+		case pending, ok := <-writer.queue:
+			if !ok {
+				return batch, false
+			}
+
+			batch = append(batch, pending)
+		case <-deadline:
+			return batch, true
+		default:
+			if deadline == nil {
+				return batch, true
+			}
+
+			select {
+			case pending, ok := <-writer.queue:
+				if !ok {
+					return batch, false
+				}
+
+				batch = append(batch, pending)
+			case <-deadline:
+				return batch, true
+			}
 		}
 	}
 
-	checksum := crc32.ChecksumIEEE(buf.Bytes())
+	return batch, true
+}
+
+// Size is the number of bytes in the log, including entries that are still
+// queued. It is also the offset the next entry will be written at.
+func (writer *WalWriter) Size() int64 {
+	writer.Lock()
+	defer writer.Unlock()
+
+	return writer.size
+}
+
+// Written is the number of bytes in the log that have been written out, and
+// synced unless the policy is SyncNone. Entries before it can be read without
+// running into one that is only part way written.
+func (writer *WalWriter) Written() int64 {
+	writer.Lock()
+	defer writer.Unlock()
+
+	return writer.written
+}
+
+// NextLSN is the LSN the next entry will be given.
+func (writer *WalWriter) NextLSN() uint64 {
+	writer.Lock()
+	defer writer.Unlock()
+
+	return writer.nextLSN
+}
+
+// Sync fsyncs every entry written so far. It is only needed with SyncNone,
+// since the other policies sync an entry before it is acknowledged.
+func (writer *WalWriter) Sync() error {
+	writer.Lock()
+	defer writer.Unlock()
 
-	err = binary.Write(buf, binary.LittleEndian, checksum)
+	err := writer.file.Sync()
 
 	if err != nil {
-		panic(err)
+		return &IOError{Op: "sync", Path: writer.file.Name(), Err: err}
 	}
 
-	_, err = writer.file.Write(buf.Bytes())
+	return nil
+}
+
+// Close waits for queued entries to be committed, then syncs and closes the
+// file.
+func (writer *WalWriter) Close() error {
+	if writer.queue != nil {
+		// the committer takes the writer lock to finish each batch, so it
+		// can't be held while the queue drains.
+		writer.sending.Lock()
+		writer.Lock()
+		closing := writer.closing
+		writer.closing = true
+		writer.Unlock()
+
+		if !closing {
+			close(writer.queue)
+		}
+
+		writer.sending.Unlock()
+
+		<-writer.closed
+	}
+
+	writer.Lock()
+	defer writer.Unlock()
+
+	writer.closing = true
+
+	err := writer.file.Sync()
 
 	if err != nil {
-		panic(err)
+		writer.file.Close()
+		return &IOError{Op: "sync", Path: writer.file.Name(), Err: err}
 	}
 
-	err = writer.file.Sync()
+	err = writer.file.Close()
 
 	if err != nil {
-		panic(err)
+		return &IOError{Op: "close", Path: writer.file.Name(), Err: err}
 	}
 
 	return nil
@@ -115,95 +495,181 @@ type WalEntryRead struct {
 	size  int64
 }
 
+func (entryRead *WalEntryRead) Entry() *WalEntry {
+	return entryRead.entry
+}
+
+// Size is the number of bytes the entry takes up in the log, so the next entry
+// starts at the current offset plus Size.
+func (entryRead *WalEntryRead) Size() int64 {
+	return entryRead.size
+}
+
 type WalReader struct {
-	file *os.File
+	file    *os.File
+	version uint16
+	start   int64 // offset of the first entry, after the file header
 }
 
-func NewWalReader(fileName string) *WalReader {
+func NewWalReader(fileName string) (*WalReader, error) {
 	file, err := os.OpenFile(fileName, os.O_RDONLY, 0666)
 
 	if err != nil {
-		panic(err)
+		return nil, &IOError{Op: "open", Path: fileName, Err: err}
+	}
+
+	header, err := readFileHeader(file)
+
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	reader := &WalReader{
+		file:    file,
+		version: CurrentFormat,
+		start:   fileHeaderSize,
 	}
 
-	return &WalReader{
-		file: file,
+	if header != nil && header.Version == FormatV1 {
+		reader.version = FormatV1
+		reader.start = 0
 	}
+
+	return reader, nil
+}
+
+// Start is the offset of the first entry in the file.
+func (reader *WalReader) Start() int64 {
+	return reader.start
+}
+
+// Version is the format of the file being read.
+func (reader *WalReader) Version() uint16 {
+	return reader.version
 }
 
+// maxEntrySize guards against allocating for a length field that was
+// corrupted into something huge.
+const maxEntrySize = 1 << 30
+
+// Read returns the entry at offset, where the first entry is at Start. io.EOF
+// means offset is exactly the end of the log. Otherwise a bad entry is reported
+// as a *TornWriteError if it runs past the end of the file, or a
+// *CorruptEntryError if it is complete but invalid.
 func (reader *WalReader) Read(offset int64) (*WalEntryRead, error) {
-	headerSize := int64(9)
-	checksumSize := int64(4)
+	headerSize := entryHeaderSize[reader.version]
 	headerBuffer := make([]byte, headerSize)
 
-	_, err := reader.file.ReadAt(headerBuffer, offset)
+	n, err := reader.file.ReadAt(headerBuffer, offset)
 
 	if err != nil {
 		if err == io.EOF {
-			return nil, io.EOF
+			// nothing at all past the offset is a clean end of the log, anything
+			// less than a full header is a write that was torn by a crash.
+			if n == 0 {
+				return nil, io.EOF
+			}
+
+			return nil, &TornWriteError{Offset: offset}
 		}
 
-		panic(err)
+		return nil, &IOError{Op: "read", Path: reader.file.Name(), Err: err}
 	}
 
-	opType := headerBuffer[0]
-	keyLength := binary.LittleEndian.Uint32(headerBuffer[1:5])
-	valueLength := binary.LittleEndian.Uint32(headerBuffer[5:9])
+	opType, lsn, timestamp, keyLength, valueLength := decodeEntryHeader(headerBuffer, reader.version)
+
+	if !knownOpTypes[opType] || (reader.version == FormatV1 && opType != Put && opType != Del) {
+		return nil, &CorruptEntryError{Offset: offset, Reason: fmt.Sprintf("invalid op type %d", opType)}
+	}
 
-	if opType != Put && opType != Del {
-		return nil, fmt.Errorf("invalid op type: %d", opType)
+	if int64(keyLength)+int64(valueLength) > maxEntrySize {
+		return nil, &CorruptEntryError{Offset: offset, Reason: fmt.Sprintf("entry length %d is too large", int64(keyLength)+int64(valueLength))}
 	}
 
-	dataBuffer := make([]byte, keyLength+valueLength)
+	bodySize := headerSize + int64(keyLength) + int64(valueLength)
+	entryBuf := make([]byte, bodySize+checksumSize)
 
-	_, err = reader.file.ReadAt(dataBuffer, offset+headerSize)
+	_, err = reader.file.ReadAt(entryBuf, offset)
 
 	if err != nil {
 		if err == io.EOF {
-			return nil, io.EOF
+			return nil, &TornWriteError{Offset: offset}
 		}
 
-		panic(err)
+		return nil, &IOError{Op: "read", Path: reader.file.Name(), Err: err}
 	}
 
-	keyBytes := dataBuffer[0:keyLength]
+	storedChecksum := binary.LittleEndian.Uint32(entryBuf[bodySize:])
+	computedChecksum := crc32.ChecksumIEEE(entryBuf[:bodySize])
+
+	if storedChecksum != computedChecksum {
+		return nil, &CorruptEntryError{Offset: offset, Reason: "checksum mismatch"}
+	}
+
+	keyBytes := entryBuf[headerSize : headerSize+int64(keyLength)]
 
 	var valueBytes []byte = nil
 
 	if valueLength > 0 {
-		valueBytes = dataBuffer[keyLength : keyLength+valueLength]
+		valueBytes = entryBuf[headerSize+int64(keyLength) : bodySize]
 	}
 
-	checksumBuf := make([]byte, checksumSize)
+	return &WalEntryRead{
+		entry: &WalEntry{OpType: opType, LSN: lsn, Timestamp: timestamp, KeyLength: int32(keyLength), ValueLength: int32(valueLength), KeyBytes: keyBytes, ValueBytes: &valueBytes, CheckSum: storedChecksum},
+		size:  bodySize + checksumSize,
+	}, nil
+}
+
+// scanToEnd reads every entry in the file. It returns the LSN after the last
+// entry, or baseLSN if there are none, and the offset the file ends at.
+func (reader *WalReader) scanToEnd(baseLSN uint64) (uint64, int64, error) {
+	offset := reader.Start()
+	nextLSN := baseLSN
 
-	_, err = reader.file.ReadAt(checksumBuf, offset+headerSize+int64(keyLength)+int64(valueLength))
+	for {
+		entryRead, err := reader.Read(offset)
 
-	if err != nil {
 		if err == io.EOF {
-			return nil, io.EOF
+			return nextLSN, offset, nil
+		}
+
+		if err != nil {
+			return 0, 0, err
 		}
-		panic(err)
+
+		nextLSN = entryRead.entry.LSN + 1
+		offset += entryRead.size
 	}
+}
 
-	entryBuf := make([]byte, headerSize+int64(keyLength)+int64(valueLength))
-	_, err = reader.file.ReadAt(entryBuf, offset)
+func (reader *WalReader) Close() error {
+	return reader.file.Close()
+}
+
+// Truncate cuts the log at fileName down to size bytes. It is used after a
+// crash to drop a torn or corrupt tail so new entries are appended directly
+// after the last good one.
+func Truncate(fileName string, size int64) error {
+	file, err := os.OpenFile(fileName, os.O_WRONLY, 0666)
 
 	if err != nil {
-		if err == io.EOF {
-			return nil, io.EOF
-		}
-		panic(err)
+		return &IOError{Op: "open", Path: fileName, Err: err}
 	}
 
-	storedChecksum := binary.LittleEndian.Uint32(checksumBuf)
-	computedChecksum := crc32.ChecksumIEEE(entryBuf)
+	defer file.Close()
 
-	if storedChecksum != computedChecksum {
-		return nil, fmt.Errorf("checksum mismatch")
+	err = file.Truncate(size)
+
+	if err != nil {
+		return &IOError{Op: "truncate", Path: fileName, Err: err}
 	}
 
-	return &WalEntryRead{
-		entry: &WalEntry{OpType: opType, KeyLength: int32(keyLength), ValueLength: int32(valueLength), KeyBytes: keyBytes, ValueBytes: &valueBytes, CheckSum: storedChecksum},
-		size:  headerSize + int64(keyLength) + int64(valueLength) + checksumSize,
-	}, nil
+	err = file.Sync()
+
+	if err != nil {
+		return &IOError{Op: "sync", Path: fileName, Err: err}
+	}
+
+	return nil
 }
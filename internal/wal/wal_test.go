@@ -2,13 +2,22 @@ package wal
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestWrite(t *testing.T) {
-	wal := NewWalWriter("test_write.bin")
+	wal, err := NewWalWriter("test_write.bin")
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening: %v", err)
+	}
 
 	t.Cleanup(func() {
 		os.Remove("test_write.bin")
@@ -24,7 +33,7 @@ func TestWrite(t *testing.T) {
 		ValueBytes:  &val,
 	}
 
-	err := wal.Write(walEntry)
+	err = wal.Write(walEntry)
 
 	if err != nil {
 		t.Fatalf("Did not expect an error when writing")
@@ -33,7 +42,11 @@ func TestWrite(t *testing.T) {
 }
 
 func TestRead(t *testing.T) {
-	wal := NewWalWriter("test_read.bin")
+	wal, err := NewWalWriter("test_read.bin")
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening: %v", err)
+	}
 
 	t.Cleanup(func() {
 		os.Remove("test_read.bin")
@@ -60,12 +73,12 @@ func TestRead(t *testing.T) {
 
 	expectedWalEntries := []*WalEntryRead{
 		{
-			entry: &WalEntry{OpType: Put, KeyLength: 2, ValueLength: 3, KeyBytes: []byte("ab"), ValueBytes: &val},
-			size:  18,
+			entry: &WalEntry{OpType: Put, LSN: 1, KeyLength: 2, ValueLength: 3, KeyBytes: []byte("ab"), ValueBytes: &val},
+			size:  34,
 		},
 		{
-			entry: &WalEntry{OpType: Del, KeyLength: 5, ValueLength: 0, KeyBytes: []byte("abcde"), ValueBytes: nil},
-			size:  18,
+			entry: &WalEntry{OpType: Del, LSN: 2, KeyLength: 5, ValueLength: 0, KeyBytes: []byte("abcde"), ValueBytes: nil},
+			size:  34,
 		},
 	}
 
@@ -76,9 +89,13 @@ func TestRead(t *testing.T) {
 		}
 	}
 
-	reader := NewWalReader("test_read.bin")
+	reader, err := NewWalReader("test_read.bin")
 
-	offset := int64(0)
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening: %v", err)
+	}
+
+	offset := reader.Start()
 
 	for _, expectedWalEntry := range expectedWalEntries {
 		readEntry, err := reader.Read(offset)
@@ -90,6 +107,12 @@ func TestRead(t *testing.T) {
 		if readEntry.entry.OpType != expectedWalEntry.entry.OpType {
 			t.Errorf("Expected op type to be %d, got %d", expectedWalEntry.entry.OpType, readEntry.entry.OpType)
 		}
+		if readEntry.entry.LSN != expectedWalEntry.entry.LSN {
+			t.Errorf("Expected LSN to be %d, got %d", expectedWalEntry.entry.LSN, readEntry.entry.LSN)
+		}
+		if readEntry.entry.Timestamp == 0 {
+			t.Errorf("Expected a timestamp to be set")
+		}
 		if readEntry.entry.KeyLength != expectedWalEntry.entry.KeyLength {
 			t.Errorf("Expected key length to be %d, got %d", expectedWalEntry.entry.KeyLength, readEntry.entry.KeyLength)
 		}
@@ -112,7 +135,11 @@ func TestRead(t *testing.T) {
 }
 
 func TestShouldGetEOFWhenReadingPastEnd(t *testing.T) {
-	wal := NewWalWriter("test_eof.bin")
+	wal, err := NewWalWriter("test_eof.bin")
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening: %v", err)
+	}
 
 	t.Cleanup(func() {
 		os.Remove("test_eof.bin")
@@ -127,21 +154,25 @@ func TestShouldGetEOFWhenReadingPastEnd(t *testing.T) {
 		KeyBytes:    []byte("ab"),
 		ValueBytes:  &val,
 	}
-	err := wal.Write(walEntry)
+	err = wal.Write(walEntry)
 
 	if err != nil {
 		t.Fatalf("Did not expect an error when writing: %v", err)
 	}
 
-	reader := NewWalReader("test_eof.bin")
+	reader, err := NewWalReader("test_eof.bin")
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening: %v", err)
+	}
 
-	readEntry, err := reader.Read(0)
+	readEntry, err := reader.Read(reader.Start())
 
 	if err != nil {
 		t.Fatalf("Did not expect an error when reading: %v", err)
 	}
 
-	finalReadEntry, err := reader.Read(readEntry.size)
+	finalReadEntry, err := reader.Read(reader.Start() + readEntry.size)
 
 	if err != io.EOF {
 		t.Errorf("Expected EOF, got %v", err)
@@ -152,3 +183,268 @@ func TestShouldGetEOFWhenReadingPastEnd(t *testing.T) {
 	}
 
 }
+
+func TestBatchedWritesShouldAllBeReadable(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "test_batched.bin")
+
+	writer, err := NewWalWriterWithConfig(fileName, &WalWriterConfig{
+		Durability:    SyncBatched,
+		MaxBatchDelay: time.Millisecond,
+	})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening: %v", err)
+	}
+
+	var wg sync.WaitGroup
+
+	for i := range 50 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			err := writer.Write(putEntry(fmt.Sprintf("key-%d", i), "value"))
+
+			if err != nil {
+				t.Errorf("Did not expect an error when writing: %v", err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	err = writer.Close()
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when closing: %v", err)
+	}
+
+	reader, err := NewWalReader(fileName)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening: %v", err)
+	}
+
+	defer reader.Close()
+
+	offset := reader.Start()
+	seen := make(map[string]bool)
+
+	for {
+		readEntry, err := reader.Read(offset)
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatalf("Did not expect an error when reading: %v", err)
+		}
+
+		seen[string(readEntry.entry.KeyBytes)] = true
+		offset += readEntry.size
+	}
+
+	if len(seen) != 50 {
+		t.Errorf("Expected 50 entries, got %d", len(seen))
+	}
+}
+
+func TestEnqueueShouldReturnOffsetsInOrder(t *testing.T) {
+	writer, err := NewWalWriterWithConfig(filepath.Join(t.TempDir(), "test_enqueue.bin"), &WalWriterConfig{
+		Durability: SyncBatched,
+	})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening: %v", err)
+	}
+
+	defer writer.Close()
+
+	first, err := writer.Enqueue(putEntry("ab", "111"))
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when enqueueing: %v", err)
+	}
+
+	second, err := writer.Enqueue(putEntry("ab", "111"))
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when enqueueing: %v", err)
+	}
+
+	if first.Offset != 16 || second.Offset != 50 {
+		t.Errorf("Expected offsets 16 and 50, got %d and %d", first.Offset, second.Offset)
+	}
+
+	if first.LSN != 1 || second.LSN != 2 {
+		t.Errorf("Expected LSNs 1 and 2, got %d and %d", first.LSN, second.LSN)
+	}
+
+	if err := first.Wait(); err != nil {
+		t.Errorf("Did not expect an error when waiting: %v", err)
+	}
+
+	if err := second.Wait(); err != nil {
+		t.Errorf("Did not expect an error when waiting: %v", err)
+	}
+}
+
+// finishesWithin fails the test if fn hasn't returned after timeout, which is
+// how a deadlock in the writer shows up.
+func finishesWithin(t *testing.T, timeout time.Duration, what string, fn func()) {
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		fn()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		t.Fatalf("Expected %s to finish within %v", what, timeout)
+	}
+}
+
+func TestCloseShouldCommitEntriesStillQueued(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "test_close_queued.bin")
+
+	writer, err := NewWalWriterWithConfig(fileName, &WalWriterConfig{
+		Durability:    SyncBatched,
+		MaxBatchDelay: 10 * time.Millisecond,
+	})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening: %v", err)
+	}
+
+	pendings := []*PendingWrite{}
+
+	for i := range 200 {
+		pending, err := writer.Enqueue(putEntry(fmt.Sprintf("key-%d", i), "value"))
+
+		if err != nil {
+			t.Fatalf("Did not expect an error when enqueueing: %v", err)
+		}
+
+		pendings = append(pendings, pending)
+	}
+
+	finishesWithin(t, 10*time.Second, "closing with entries queued", func() {
+		err = writer.Close()
+	})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when closing: %v", err)
+	}
+
+	for _, pending := range pendings {
+		if err := pending.Wait(); err != nil {
+			t.Fatalf("Did not expect an error when waiting: %v", err)
+		}
+	}
+
+	_, err = writer.Enqueue(putEntry("late", "value"))
+
+	if !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected enqueueing after closing to fail, got %v", err)
+	}
+
+	reader, err := NewWalReader(fileName)
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening: %v", err)
+	}
+
+	defer reader.Close()
+
+	_, end, err := reader.scanToEnd(1)
+
+	if err != nil || end != pendings[len(pendings)-1].Offset+int64(len(pendings[len(pendings)-1].buf)) {
+		t.Errorf("Expected every queued entry to be in the file, got end %d %v", end, err)
+	}
+}
+
+func TestMoreWritersThanTheQueueHoldsShouldNotHang(t *testing.T) {
+	writer, err := NewWalWriterWithConfig(filepath.Join(t.TempDir(), "test_burst.bin"), &WalWriterConfig{
+		Durability:   SyncBatched,
+		MaxBatchSize: 2,
+	})
+
+	if err != nil {
+		t.Fatalf("Did not expect an error when opening: %v", err)
+	}
+
+	defer writer.Close()
+
+	finishesWithin(t, 10*time.Second, "a burst of writes", func() {
+		var wg sync.WaitGroup
+
+		for i := range 100 {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				err := writer.Write(putEntry(fmt.Sprintf("key-%d", i), "value"))
+
+				if err != nil {
+					t.Errorf("Did not expect an error when writing: %v", err)
+				}
+			}()
+		}
+
+		wg.Wait()
+	})
+
+	if writer.NextLSN() != 101 || writer.Written() != writer.Size() {
+		t.Errorf("Expected all 100 writes to be written, next LSN is %d", writer.NextLSN())
+	}
+}
+
+func benchmarkWrite(b *testing.B, config *WalWriterConfig) {
+	// enough concurrent writers for group commit to have something to group.
+	b.SetParallelism(8)
+
+	writer, err := NewWalWriterWithConfig(filepath.Join(b.TempDir(), "bench.bin"), config)
+
+	if err != nil {
+		b.Fatalf("Did not expect an error when opening: %v", err)
+	}
+
+	defer writer.Close()
+
+	entry := putEntry("benchmark-key", "benchmark-value")
+
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			err := writer.Write(entry)
+
+			// Fatal can't be called from the RunParallel goroutines.
+			if err != nil {
+				b.Errorf("Did not expect an error when writing: %v", err)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkWriteSyncAlways(b *testing.B) {
+	benchmarkWrite(b, &WalWriterConfig{Durability: SyncAlways})
+}
+
+func BenchmarkWriteSyncBatched(b *testing.B) {
+	benchmarkWrite(b, &WalWriterConfig{Durability: SyncBatched})
+}
+
+func BenchmarkWriteSyncBatchedWithDelay(b *testing.B) {
+	benchmarkWrite(b, &WalWriterConfig{Durability: SyncBatched, MaxBatchDelay: 100 * time.Microsecond})
+}
+
+func BenchmarkWriteSyncNone(b *testing.B) {
+	benchmarkWrite(b, &WalWriterConfig{Durability: SyncNone})
+}